@@ -1,8 +1,11 @@
 package api
 
 import (
+	"time"
+
 	"github.com/gin-gonic/gin"
 
+	"github.com/kiranraoboinapally/student/backend/internal/config"
 	controllers "github.com/kiranraoboinapally/student/backend/internal/controllers"
 	middleware "github.com/kiranraoboinapally/student/backend/internal/middleware"
 )
@@ -10,123 +13,227 @@ import (
 // RegisterAPIRoutes registers all API routes onto the provided gin Engine.
 func RegisterAPIRoutes(r *gin.Engine) {
 	api := r.Group("/api")
+	api.Use(middleware.RequestID(), middleware.AuditTrail())
 
 	// ================= AUTH =================
 	auth := api.Group("/auth")
+	auth.Use(middleware.RateLimit("auth", config.AuthRateLimitPerMinute, time.Minute))
 	{
 		auth.POST("/register", controllers.Register)
 		auth.POST("/login", controllers.Login)
-		auth.POST("/forgot-password", controllers.ForgotPassword)
+		auth.POST("/forgot-password", middleware.RateLimit("forgot-password", 5, time.Hour), controllers.ForgotPassword)
 		auth.POST("/reset-password", controllers.ResetPassword)
 		auth.POST("/change-password", middleware.AuthRoleMiddleware(), controllers.ChangePassword)
+		auth.POST("/refresh", controllers.Refresh)
+		auth.POST("/logout", middleware.AuthRoleMiddleware(), controllers.Logout)
+		auth.POST("/logout-all", middleware.AuthRoleMiddleware(), controllers.LogoutAll)
+
+		// Two-factor auth
+		auth.POST("/mfa/verify", controllers.VerifyMFA)
+		auth.GET("/mfa/status", middleware.AuthRoleMiddleware(), controllers.GetMFAStatus)
+		auth.POST("/mfa/enroll", middleware.AuthRoleMiddleware(), controllers.EnrollMFA)
+		auth.POST("/mfa/confirm", middleware.AuthRoleMiddleware(), controllers.ConfirmMFA)
+		auth.POST("/mfa/disable", middleware.AuthRoleMiddleware(), controllers.DisableMFA)
+		auth.POST("/mfa/recovery-codes", middleware.AuthRoleMiddleware(), controllers.RegenerateRecoveryCodes)
 	}
 
-	// ================= UNIVERSITY ADMIN (Role 1) =================
+	// ================= WEBHOOKS (public, signature-verified) =================
+	api.POST("/webhooks/razorpay", controllers.RazorpayWebhook)
+
+	// ================= PUBLIC VERIFICATION =================
+	api.GET("/verify/receipt/:code", middleware.RateLimit("verify", config.AuthRateLimitPerMinute, time.Minute), controllers.VerifyReceipt)
+	api.GET("/verify/hall-ticket/:code", middleware.RateLimit("verify", config.AuthRateLimitPerMinute, time.Minute), controllers.VerifyHallTicket)
+
+	// ================= UNIVERSITY ADMIN (Role 1 + custom roles, per-permission) =================
 	admin := api.Group("/admin")
-	admin.Use(middleware.AuthRoleMiddleware(middleware.RoleUniversityAdmin))
+	admin.Use(middleware.AuthRoleMiddleware())
 	{
 		// 🔹 DASHBOARD
-		admin.GET("/stats", controllers.GetAdminStats)
-		admin.GET("/pending-approval-counts", controllers.GetPendingApprovalCounts)
+		admin.GET("/stats", middleware.RequirePermission("dashboard.view"), controllers.GetAdminStats)
+		admin.GET("/pending-approval-counts", middleware.RequirePermission("dashboard.view"), controllers.GetPendingApprovalCounts)
 
 		// 🔹 APPROVALS (NEW)
-		admin.GET("/pending-approvals", controllers.GetPendingApprovals)
-		admin.POST("/approve-faculty", controllers.ApproveFaculty)
-		admin.POST("/approve-course-stream", controllers.ApproveCourseStream)
-
-		// 🔹 MARKS MANAGEMENT (Lock/Publish only - entry moved to Faculty)
-		admin.GET("/internal-marks", controllers.GetAllInternalMarks)
-		admin.POST("/marks/lock", controllers.LockMarks)
-		admin.POST("/marks/publish", controllers.PublishResults)
+		admin.GET("/pending-approvals", middleware.RequirePermission("dashboard.view"), controllers.GetPendingApprovals)
+		admin.POST("/approve-faculty", middleware.RequirePermission("faculty.approve"), controllers.ApproveFaculty)
+		admin.POST("/approve-course-stream", middleware.RequirePermission("courses.approve"), controllers.ApproveCourseStream)
+
+		// 🔹 MARKS MANAGEMENT (Lock only - entry moved to Faculty; results publish through result batches)
+		admin.GET("/internal-marks", middleware.RequirePermission("marks.view"), controllers.GetAllInternalMarks)
+		admin.POST("/marks/lock", middleware.RequirePermission("marks.lock"), controllers.LockMarks)
+		admin.POST("/results/compute", middleware.RequirePermission("results.compute"), controllers.ComputeResults)
+		admin.GET("/assessment-schemes", middleware.RequirePermission("academics.manage"), controllers.GetAssessmentSchemes)
+		admin.PUT("/assessment-schemes/:subject_code", middleware.RequirePermission("academics.manage"), controllers.SaveAssessmentScheme)
+		admin.POST("/external-marks", middleware.RequirePermission("results.compute"), controllers.UploadExternalMarks)
+		admin.POST("/results/process", middleware.RequirePermission("results.compute"), controllers.ProcessResults)
+		admin.GET("/results/batches", middleware.RequirePermission("marks.view"), controllers.GetResultBatches)
+		admin.GET("/results/batches/:id", middleware.RequirePermission("marks.view"), controllers.GetResultBatch)
+		admin.POST("/results/batches/:id/publish", middleware.RequirePermission("marks.publish"), controllers.PublishResultBatch)
+		admin.GET("/revaluations", middleware.RequirePermission("results.revaluation"), controllers.GetRevaluationRequests)
+		admin.POST("/revaluations/:id/assign", middleware.RequirePermission("results.revaluation"), controllers.AssignRevaluation)
+		admin.POST("/revaluations/:id/review", middleware.RequirePermission("results.revaluation"), controllers.ReviewRevaluation)
+
+		// 🔹 EXAM SESSIONS & REAPPEAR (BACKLOG) REGISTRATIONS
+		admin.GET("/exam-sessions", middleware.RequirePermission("exams.manage"), controllers.GetExamSessions)
+		admin.POST("/exam-sessions", middleware.RequirePermission("exams.manage"), controllers.CreateExamSession)
+		admin.PUT("/exam-sessions/:id", middleware.RequirePermission("exams.manage"), controllers.UpdateExamSession)
+		admin.GET("/exam-sessions/:id/reappear", middleware.RequirePermission("exams.manage"), controllers.GetReappearRegistrations)
+		admin.POST("/exam-sessions/:id/reappear/lock", middleware.RequirePermission("exams.manage"), controllers.LockReappearRegistrations)
+		admin.POST("/exam-sessions/:id/reappear/results", middleware.RequirePermission("results.compute"), controllers.RecordReappearResults)
+
+		// 🔹 DATE SHEETS, HALL TICKETS & SEATING PLANS
+		admin.GET("/exam-sessions/:id/date-sheet", middleware.RequirePermission("exams.manage"), controllers.GetDateSheet)
+		admin.POST("/exam-sessions/:id/date-sheet", middleware.RequirePermission("exams.manage"), controllers.SaveDateSheet)
+		admin.DELETE("/exam-sessions/:id/date-sheet/:entry_id", middleware.RequirePermission("exams.manage"), controllers.DeleteDateSheetEntry)
+		admin.GET("/exam-sessions/:id/hall-tickets", middleware.RequirePermission("exams.manage"), controllers.GetHallTickets)
+		admin.POST("/exam-sessions/:id/hall-tickets", middleware.RequirePermission("exams.manage"), controllers.IssueHallTickets)
+		admin.GET("/exam-sessions/:id/hall-tickets/:enrollment", middleware.RequirePermission("exams.manage"), controllers.DownloadHallTicket)
+		admin.POST("/students/:enrollment/photo", middleware.RequirePermission("exams.manage"), controllers.UploadStudentPhoto)
+		admin.GET("/exam-rooms", middleware.RequirePermission("exams.manage"), controllers.GetExamRooms)
+		admin.POST("/exam-rooms", middleware.RequirePermission("exams.manage"), controllers.CreateExamRoom)
+		admin.PUT("/exam-rooms/:id", middleware.RequirePermission("exams.manage"), controllers.UpdateExamRoom)
+		admin.GET("/exam-sessions/:id/seating", middleware.RequirePermission("exams.manage"), controllers.GetSeatingPlan)
+		admin.POST("/exam-sessions/:id/seating", middleware.RequirePermission("exams.manage"), controllers.GenerateSeatingPlan)
+		admin.GET("/attendance-eligibility", middleware.RequirePermission("exams.manage"), controllers.GetEligibilityList)
 
 		// 🔹 MASTER FEE TYPES (NEW)
-		admin.GET("/fee-types", controllers.GetMasterFeeTypes)
-		admin.POST("/fee-types", controllers.CreateMasterFeeType)
+		admin.GET("/fee-types", middleware.RequirePermission("fees.manage"), controllers.GetMasterFeeTypes)
+		admin.POST("/fee-types", middleware.RequirePermission("fees.manage"), controllers.CreateMasterFeeType)
 
 		// 🔹 USERS
-		admin.POST("/users/create", controllers.CreateUserByAdmin)
-		admin.GET("/users", controllers.GetAllUsers)
+		admin.POST("/users/create", middleware.RequirePermission("users.manage"), controllers.CreateUserByAdmin)
+		admin.GET("/users", middleware.RequirePermission("users.manage"), controllers.GetAllUsers)
+		admin.POST("/users/:id/mfa/reset", middleware.RequirePermission("users.manage"), controllers.ResetUserMFA)
+
+		// 🔹 MFA POLICY
+		admin.GET("/mfa/roles", middleware.RequirePermission("roles.manage"), controllers.GetRoleMFAPolicies)
+		admin.PUT("/mfa/roles/:id", middleware.RequirePermission("roles.manage"), controllers.SetRoleMFAPolicy)
+
+		// 🔹 ROLES & PERMISSIONS
+		admin.GET("/permissions", middleware.RequirePermission("roles.manage"), controllers.GetPermissions)
+		admin.GET("/roles", middleware.RequirePermission("roles.manage"), controllers.GetRoles)
+		admin.POST("/roles", middleware.RequirePermission("roles.manage"), controllers.CreateRole)
+		admin.PUT("/roles/:id", middleware.RequirePermission("roles.manage"), controllers.UpdateRole)
+		admin.DELETE("/roles/:id", middleware.RequirePermission("roles.manage"), controllers.DeleteRole)
+		admin.PUT("/users/:id/role", middleware.RequirePermission("users.manage"), controllers.AssignUserRole)
+
+		// 🔹 AUDIT LOG
+		admin.GET("/audit-log", middleware.RequirePermission("audit.view"), controllers.GetAuditLog)
+		admin.GET("/audit-log/export", middleware.RequirePermission("audit.view"), controllers.ExportAuditLog)
 
 		// 🔹 REGISTRATIONS
-		admin.GET("/pending-registrations", controllers.GetPendingRegistrations)
-		admin.POST("/approve-registration", controllers.ApproveRegistration)
+		admin.GET("/pending-registrations", middleware.RequirePermission("students.approve"), controllers.GetPendingRegistrations)
+		admin.POST("/approve-registration", middleware.RequirePermission("students.approve"), controllers.ApproveRegistration)
 
 		// 🔹 FEES (Structure & Verification)
-		admin.GET("/fees/payments", controllers.GetAllFeePaymentHistory)
-		admin.POST("/fees/verify", controllers.VerifyPayment)
-		admin.POST("/fee-structure", controllers.CreateFeeStructure)
-		admin.POST("/fees/due", controllers.CreateFeeDue)
+		admin.GET("/fees/payments", middleware.RequirePermission("fees.view"), controllers.GetAllFeePaymentHistory)
+		admin.POST("/fees/verify", middleware.RequirePermission("fees.verify"), controllers.VerifyPayment)
+		admin.GET("/fees/payments/:id/proof", middleware.RequirePermission("fees.verify"), controllers.GetPaymentProof)
+		admin.POST("/fees/refund", middleware.RequirePermission("fees.refund"), controllers.RefundPayment)
+		admin.POST("/fee-structure", middleware.RequirePermission("fees.manage"), controllers.CreateFeeStructure)
+		admin.GET("/fee-structure/:id/installment-plans", middleware.RequirePermission("fees.manage"), controllers.GetInstallmentPlans)
+		admin.POST("/fee-structure/:id/installment-plans", middleware.RequirePermission("fees.manage"), controllers.CreateInstallmentPlan)
+		admin.DELETE("/installment-plans/:plan_id", middleware.RequirePermission("fees.manage"), controllers.DeactivateInstallmentPlan)
+		admin.POST("/fees/due", middleware.RequirePermission("fees.manage"), controllers.CreateFeeDue)
+		admin.GET("/fees/late-fee-rules", middleware.RequirePermission("fees.manage"), controllers.GetLateFeeRules)
+		admin.PUT("/fees/late-fee-rules/:fee_type_id", middleware.RequirePermission("fees.manage"), controllers.SaveLateFeeRule)
+		admin.POST("/fees/late-fees/run", middleware.RequirePermission("fees.manage"), controllers.RunLateFees)
+		admin.POST("/fees/late-fees/waive", middleware.RequirePermission("fees.waive"), controllers.WaiveLateFee)
+		admin.GET("/fees/late-fees/waivers", middleware.RequirePermission("fees.view"), controllers.GetLateFeeWaivers)
+		admin.GET("/fees/receipts/:receipt_id", middleware.RequirePermission("fees.view"), controllers.DownloadReceipt)
+
+		// 🔹 PAYMENT RECONCILIATION
+		admin.POST("/fees/reconciliation/import", middleware.RequirePermission("fees.reconcile"), controllers.ImportBankStatement)
+		admin.GET("/fees/reconciliation/imports", middleware.RequirePermission("fees.reconcile"), controllers.GetStatementImports)
+		admin.GET("/fees/reconciliation/unmatched", middleware.RequirePermission("fees.reconcile"), controllers.GetUnmatchedReconciliation)
+		admin.POST("/fees/reconciliation/lines/:id/resolve", middleware.RequirePermission("fees.reconcile"), controllers.ResolveStatementLine)
+		admin.GET("/fees/reconciliation/summary", middleware.RequirePermission("fees.reconcile"), controllers.GetReconciliationSummary)
+
+		// 🔹 FEE REPORTS (?format=csv|xlsx to export)
+		admin.GET("/fees/reports/aging", middleware.RequirePermission("fees.view"), controllers.GetFeeAgingReport)
+		admin.GET("/fees/reports/defaulters", middleware.RequirePermission("fees.view"), controllers.GetFeeDefaulters)
+		admin.GET("/fees/reports/collections", middleware.RequirePermission("fees.view"), controllers.GetFeeCollectionTrend)
+		admin.GET("/fees/reports/projection", middleware.RequirePermission("fees.view"), controllers.GetFeeProjectionReport)
+
+		// 🔹 SCHOLARSHIPS & CONCESSIONS
+		admin.GET("/scholarships/schemes", middleware.RequirePermission("scholarships.manage"), controllers.GetScholarshipSchemes)
+		admin.POST("/scholarships/schemes", middleware.RequirePermission("scholarships.manage"), controllers.CreateScholarshipScheme)
+		admin.PUT("/scholarships/schemes/:id", middleware.RequirePermission("scholarships.manage"), controllers.UpdateScholarshipScheme)
+		admin.GET("/scholarships/grants", middleware.RequirePermission("scholarships.manage"), controllers.GetScholarshipGrants)
+		admin.POST("/scholarships/grants", middleware.RequirePermission("scholarships.manage"), controllers.CreateScholarshipGrants)
+		admin.POST("/scholarships/grants/:id/review", middleware.RequirePermission("scholarships.approve"), controllers.ReviewScholarshipGrant)
+		admin.GET("/scholarships/disbursements", middleware.RequirePermission("fees.view"), controllers.GetScholarshipDisbursementReport)
 
 		// 🔹 ATTENDANCE (View summary only - marking moved to Faculty)
-		admin.GET("/attendance/summary", controllers.GetAttendanceSummary)
+		admin.GET("/attendance/summary", middleware.RequirePermission("attendance.view"), controllers.GetAttendanceSummary)
 
 		// 🔹 MASTER DATA - INSTITUTES
-		admin.GET("/institutes", controllers.GetInstitutes)
-		admin.POST("/institutes", controllers.CreateInstitute)
-		admin.POST("/institutes/users", controllers.CreateInstituteUser)
-		admin.PUT("/institutes/:id", controllers.UpdateInstitute)
-		admin.DELETE("/institutes/:id", controllers.DeleteInstitute)
-		admin.GET("/institutes/:id/courses", controllers.GetCoursesByInstitute)
+		admin.GET("/institutes", middleware.RequirePermission("institutes.manage"), controllers.GetInstitutes)
+		admin.POST("/institutes", middleware.RequirePermission("institutes.manage"), controllers.CreateInstitute)
+		admin.POST("/institutes/users", middleware.RequirePermission("institutes.manage"), controllers.CreateInstituteUser)
+		admin.PUT("/institutes/:id", middleware.RequirePermission("institutes.manage"), controllers.UpdateInstitute)
+		admin.DELETE("/institutes/:id", middleware.RequirePermission("institutes.manage"), controllers.DeleteInstitute)
+		admin.GET("/institutes/:id/courses", middleware.RequirePermission("institutes.manage"), controllers.GetCoursesByInstitute)
 
 		// 🔹 GRADING SYSTEM
-		admin.GET("/grading-rules", controllers.GetGradingRules)
-		admin.POST("/grading-rules", controllers.CreateGradingRule)
-		admin.PUT("/grading-rules/:id", controllers.UpdateGradingRule)
-		admin.DELETE("/grading-rules/:id", controllers.DeleteGradingRule)
+		admin.GET("/grading-rules", middleware.RequirePermission("academics.manage"), controllers.GetGradingRules)
+		admin.POST("/grading-rules", middleware.RequirePermission("academics.manage"), controllers.CreateGradingRule)
+		admin.PUT("/grading-rules/:id", middleware.RequirePermission("academics.manage"), controllers.UpdateGradingRule)
+		admin.DELETE("/grading-rules/:id", middleware.RequirePermission("academics.manage"), controllers.DeleteGradingRule)
 
 		// 🔹 ACADEMIC RULES
-		admin.GET("/academic-rules", controllers.GetAcademicRules)
-		admin.PUT("/academic-rules", controllers.UpdateAcademicRules)
+		admin.GET("/academic-rules", middleware.RequirePermission("academics.manage"), controllers.GetAcademicRules)
+		admin.PUT("/academic-rules", middleware.RequirePermission("academics.manage"), controllers.UpdateAcademicRules)
+		admin.GET("/academic-rules/versions", middleware.RequirePermission("academics.manage"), controllers.GetAcademicRuleVersions)
 
 		// 🔹 FEE MANAGEMENT (NEW)
-		admin.GET("/fees/active-courses", controllers.GetActiveCoursesByInstitute)
-		admin.POST("/fees/create-for-active", controllers.CreateFeesForActiveStudents)
+		admin.GET("/fees/active-courses", middleware.RequirePermission("fees.manage"), controllers.GetActiveCoursesByInstitute)
+		admin.POST("/fees/dues-run", middleware.RequirePermission("fees.manage"), controllers.RunFeeDues)
 
 		// 🔹 USER MANAGEMENT
-		admin.GET("/users/all", controllers.GetAllUsers)
+		admin.GET("/users/all", middleware.RequirePermission("users.manage"), controllers.GetAllUsers)
 
 		// 🔹 COURSES
-		admin.GET("/courses", controllers.GetCourses)
-		admin.POST("/courses", controllers.CreateCourse)
-		admin.PUT("/courses/:id", controllers.UpdateCourse)
-		admin.DELETE("/courses/:id", controllers.DeleteCourse)
+		admin.GET("/courses", middleware.RequirePermission("academics.manage"), controllers.GetCourses)
+		admin.POST("/courses", middleware.RequirePermission("academics.manage"), controllers.CreateCourse)
+		admin.PUT("/courses/:id", middleware.RequirePermission("academics.manage"), controllers.UpdateCourse)
+		admin.DELETE("/courses/:id", middleware.RequirePermission("academics.manage"), controllers.DeleteCourse)
 
 		// 🔹 SUBJECTS
-		admin.GET("/subjects", controllers.GetSubjects)
-		admin.POST("/subjects", controllers.CreateSubject)
-		admin.PUT("/subjects/:id", controllers.UpdateSubject)
-		admin.DELETE("/subjects/:id", controllers.DeleteSubject)
+		admin.GET("/subjects", middleware.RequirePermission("academics.manage"), controllers.GetSubjects)
+		admin.POST("/subjects", middleware.RequirePermission("academics.manage"), controllers.CreateSubject)
+		admin.PUT("/subjects/:id", middleware.RequirePermission("academics.manage"), controllers.UpdateSubject)
+		admin.DELETE("/subjects/:id", middleware.RequirePermission("academics.manage"), controllers.DeleteSubject)
 
 		// 🔹 FACULTY (View/Manage from university level)
-		admin.GET("/faculty", controllers.GetFaculties)
-		admin.POST("/faculty", controllers.CreateFaculty)
-		admin.PUT("/faculty/:id", controllers.UpdateFaculty)
-		admin.DELETE("/faculty/:id", controllers.DeleteFaculty)
+		admin.GET("/faculty", middleware.RequirePermission("faculty.manage"), controllers.GetFaculties)
+		admin.POST("/faculty", middleware.RequirePermission("faculty.manage"), controllers.CreateFaculty)
+		admin.PUT("/faculty/:id", middleware.RequirePermission("faculty.manage"), controllers.UpdateFaculty)
+		admin.DELETE("/faculty/:id", middleware.RequirePermission("faculty.manage"), controllers.DeleteFaculty)
 
 		// 🔹 STUDENTS (View only)
-		admin.GET("/students", controllers.GetStudents)
+		admin.GET("/students", middleware.RequirePermission("students.view"), controllers.GetStudents)
 
 		// 🔹 NOTICES
-		admin.GET("/notices", controllers.GetNotices)
-		admin.POST("/notices", controllers.CreateNotice)
-		admin.PUT("/notices/:id", controllers.UpdateNotice)
-		admin.DELETE("/notices/:id", controllers.DeleteNotice)
+		admin.GET("/notices", middleware.RequirePermission("notices.manage"), controllers.GetNotices)
+		admin.POST("/notices", middleware.RequirePermission("notices.manage"), controllers.CreateNotice)
+		admin.PUT("/notices/:id", middleware.RequirePermission("notices.manage"), controllers.UpdateNotice)
+		admin.DELETE("/notices/:id", middleware.RequirePermission("notices.manage"), controllers.DeleteNotice)
 
 		// 🔹 DEPARTMENTS
-		admin.GET("/departments", controllers.GetDepartments)
-		admin.POST("/departments", controllers.CreateDepartment)
-		admin.PUT("/departments/:id", controllers.UpdateDepartment)
-		admin.DELETE("/departments/:id", controllers.DeleteDepartment)
+		admin.GET("/departments", middleware.RequirePermission("academics.manage"), controllers.GetDepartments)
+		admin.POST("/departments", middleware.RequirePermission("academics.manage"), controllers.CreateDepartment)
+		admin.PUT("/departments/:id", middleware.RequirePermission("academics.manage"), controllers.UpdateDepartment)
+		admin.DELETE("/departments/:id", middleware.RequirePermission("academics.manage"), controllers.DeleteDepartment)
 
 		// 🔹 STUDENT REGISTRATION APPROVALS
-		admin.GET("/pending-student-registrations", controllers.GetPendingStudentRegistrations)
-		admin.POST("/approve-student", controllers.ApproveStudentRegistration)
-		admin.POST("/approve-students-bulk", controllers.BulkApproveStudentRegistrations)
+		admin.GET("/pending-student-registrations", middleware.RequirePermission("students.approve"), controllers.GetPendingStudentRegistrations)
+		admin.POST("/approve-student", middleware.RequirePermission("students.approve"), controllers.ApproveStudentRegistration)
+		admin.POST("/approve-students-bulk", middleware.RequirePermission("students.approve"), controllers.BulkApproveStudentRegistrations)
 	}
 
 	// ================= FACULTY (Role 2) =================
 	faculty := api.Group("/faculty")
-	faculty.Use(middleware.AuthRoleMiddleware(middleware.RoleFaculty))
+	faculty.Use(middleware.AuthRoleMiddleware(), middleware.RequirePermission("portal.faculty"))
 	{
 		// 🔹 ATTENDANCE (NEW - Faculty marks attendance)
 		faculty.POST("/attendance/mark", controllers.FacultyMarkAttendance)
@@ -138,6 +245,10 @@ func RegisterAPIRoutes(r *gin.Engine) {
 		faculty.POST("/internal-marks/submit", controllers.FacultySubmitMarks)
 		faculty.GET("/internal-marks", controllers.FacultyGetInternalMarks)
 
+		// 🔹 REVALUATION (Requests assigned to this faculty as evaluator)
+		faculty.GET("/revaluations", controllers.FacultyGetRevaluations)
+		faculty.POST("/revaluations/:id/evaluate", controllers.FacultyEvaluateRevaluation)
+
 		// 🔹 STUDENTS (View students in their assigned courses)
 		faculty.GET("/students", controllers.FacultyGetStudents)
 
@@ -154,7 +265,8 @@ func RegisterAPIRoutes(r *gin.Engine) {
 	// ================= INSTITUTE ADMIN (Role 3) =================
 	institute := api.Group("/institute")
 	institute.Use(
-		middleware.AuthRoleMiddleware(middleware.RoleInstituteAdmin),
+		middleware.AuthRoleMiddleware(),
+		middleware.RequirePermission("portal.institute"),
 		middleware.RequireInstitute(),
 		middleware.CollegeBelongsToUser(),
 	)
@@ -176,10 +288,16 @@ func RegisterAPIRoutes(r *gin.Engine) {
 
 		// 🔹 FEES (View only - collection)
 		institute.GET("/fees", controllers.GetInstituteStudentFees)
+		institute.POST("/fees/offline-payment", controllers.InstituteRecordOfflinePayment)
 
 		// 🔹 ATTENDANCE (View summary only)
 		institute.GET("/attendance", controllers.GetInstituteAttendanceSummary)
 
+		// 🔹 ATTENDANCE ELIGIBILITY & CONDONATION
+		institute.GET("/attendance-eligibility", controllers.GetInstituteEligibilityList)
+		institute.GET("/condonations", controllers.GetInstituteCondonations)
+		institute.POST("/condonations/:id/review", controllers.ReviewCondonation)
+
 		// 🔹 INTERNAL MARKS (View only)
 		institute.GET("/internal-marks", controllers.GetInstituteInternalMarks)
 
@@ -197,16 +315,19 @@ func RegisterAPIRoutes(r *gin.Engine) {
 
 	// ================= STUDENT (Role 5) =================
 	student := api.Group("/student")
-	student.Use(middleware.AuthRoleMiddleware(middleware.RoleStudent))
+	student.Use(middleware.AuthRoleMiddleware(), middleware.RequirePermission("portal.student"))
 	{
 		student.GET("/profile", controllers.GetStudentProfile)
 		student.GET("/dashboard", controllers.GetStudentDashboard)
 
 		student.GET("/fees/summary", controllers.GetStudentFeeSummary)
 		student.GET("/fees", controllers.GetStudentFees)
+		student.GET("/fees/receipts/:receipt_id", controllers.DownloadStudentReceipt)
+		student.GET("/scholarships", controllers.GetStudentScholarships)
 		student.POST("/fees/request-payment", controllers.RequestPayment)
 		student.POST("/fees/verify-payment", controllers.VerifyPaymentAndRecord)
-		student.POST("/fees/pay", controllers.PayFee)
+		student.POST("/fees/mock-checkout/:order_id", controllers.MockCheckout) // PAYMENT_GATEWAY=mock only
+		student.POST("/fees/pay", controllers.PayFee) // offline payment with proof, verified by an admin
 
 		student.GET("/semester/current", controllers.GetCurrentSemester)
 		student.GET("/subjects/current", controllers.GetCurrentSemesterSubjects)
@@ -215,6 +336,15 @@ func RegisterAPIRoutes(r *gin.Engine) {
 
 		student.GET("/attendance", controllers.GetStudentAttendance)
 		student.GET("/results/semester", controllers.GetSemesterResults)
+		student.GET("/revaluations", controllers.GetMyRevaluations)
+		student.POST("/revaluations", controllers.ApplyForRevaluation)
+		student.GET("/backlogs", controllers.GetMyBacklogs)
+		student.POST("/reappear", controllers.RegisterReappear)
+		student.GET("/exams", controllers.GetMyExams)
+		student.GET("/exams/:session_id/hall-ticket", controllers.DownloadMyHallTicket)
+		student.POST("/photo", controllers.UploadMyPhoto)
+		student.GET("/eligibility", controllers.GetMyEligibility)
+		student.POST("/condonations", controllers.ApplyForCondonation)
 
 		student.GET("/notices", controllers.GetNotices)
 		student.POST("/leaves/apply", controllers.ApplyLeave)
@@ -231,6 +361,7 @@ func RegisterAPIRoutes(r *gin.Engine) {
 	profile.Use(middleware.AuthRoleMiddleware())
 	{
 		profile.GET("/me", controllers.GetMyProfile)
+		profile.GET("/permissions", controllers.GetMyPermissions)
 		profile.GET("/:id", controllers.GetStudentByID)
 	}
 }
@@ -5,6 +5,7 @@ import (
 	"log"
 	"os"
 	"strconv"
+	"strings"
 
 	"github.com/joho/godotenv"
 	"github.com/kiranraoboinapally/student/backend/internal/models"
@@ -16,8 +17,60 @@ import (
 var DB *gorm.DB
 var JwtSecret string
 var JwtExpiresHours int
+var JwtAccessMinutes int
 var ServerPort string
 
+// Brute-force protection
+var AuthMaxFailedAttempts int
+var AuthFailureWindowMinutes int
+var AuthLockoutMinutes int
+var AuthRateLimitPerMinute int
+var RateLimitStore string
+
+// Outgoing mail
+var MailDriver string
+var SMTPHost string
+var SMTPPort string
+var SMTPUser string
+var SMTPPass string
+var MailFrom string
+var MailOutboxDir string
+var AppBaseURL string
+
+// Issuer name shown in authenticator apps
+var MFAIssuer string
+
+// Payment gateway: razorpay, mock (in-memory, for local development) or disabled
+var PaymentGateway string
+var RazorpayKeyID string
+var RazorpaySecret string
+var RazorpayWebhookSecret string
+
+// Minutes before an unverified payment order is reconciled against the gateway
+var PaymentOrderTTLMinutes int
+
+// Hours between late fee assessments (0 disables the scheduled job)
+var LateFeeIntervalHours int
+
+// Days between reminders about the same overdue charge (0 disables reminders)
+var FeeReminderIntervalDays int
+
+// Days a statement line's date may differ from the payment date and still match
+var ReconciliationDateToleranceDays int
+
+// Directory for uploaded files such as offline payment proofs
+var UploadDir string
+
+// Days after results are published during which students may apply for revaluation
+var RevaluationWindowDays int
+
+// Revaluation and re-totalling fees per subject, in rupees
+var RevaluationFee int
+var RetotallingFee int
+
+// Which reappear attempt counts towards results: "best" (highest mark) or "latest"
+var ReappearResultPolicy string
+
 func Init() {
 	// load .env
 	if err := godotenv.Load(); err != nil {
@@ -41,6 +94,43 @@ func Init() {
 		v, _ := strconv.Atoi(jh)
 		JwtExpiresHours = v
 	}
+	// Access tokens are short-lived; JWT_EXPIRES_HOURS now bounds the refresh session
+	am := os.Getenv("JWT_ACCESS_MINUTES")
+	if am == "" {
+		JwtAccessMinutes = 15
+	} else {
+		v, _ := strconv.Atoi(am)
+		JwtAccessMinutes = v
+	}
+
+	AuthMaxFailedAttempts = envPositiveInt("AUTH_MAX_FAILED_ATTEMPTS", 5)
+	AuthFailureWindowMinutes = envPositiveInt("AUTH_FAILURE_WINDOW_MINUTES", 15)
+	AuthLockoutMinutes = envPositiveInt("AUTH_LOCKOUT_MINUTES", 5)
+	AuthRateLimitPerMinute = envPositiveInt("AUTH_RATE_LIMIT_PER_MINUTE", 20)
+	RateLimitStore = os.Getenv("RATE_LIMIT_STORE") // "memory" (default) or "db"
+
+	MailDriver = envString("MAIL_DRIVER", "log") // smtp, file or log
+	SMTPHost = os.Getenv("SMTP_HOST")
+	SMTPPort = envString("SMTP_PORT", "587")
+	SMTPUser = os.Getenv("SMTP_USER")
+	SMTPPass = os.Getenv("SMTP_PASS")
+	MailFrom = envString("MAIL_FROM", "no-reply@university.local")
+	MailOutboxDir = envString("MAIL_OUTBOX_DIR", "mail_outbox")
+	AppBaseURL = strings.TrimRight(envString("APP_BASE_URL", "http://localhost:5173"), "/")
+	MFAIssuer = envString("MFA_ISSUER", "University Portal")
+	PaymentGateway = envString("PAYMENT_GATEWAY", "razorpay")
+	RazorpayKeyID = os.Getenv("RAZORPAY_KEY_ID")
+	RazorpaySecret = os.Getenv("RAZORPAY_SECRET")
+	RazorpayWebhookSecret = os.Getenv("RAZORPAY_WEBHOOK_SECRET")
+	PaymentOrderTTLMinutes = envPositiveInt("PAYMENT_ORDER_TTL_MINUTES", 30)
+	LateFeeIntervalHours = envInt("LATE_FEE_INTERVAL_HOURS", 24)
+	FeeReminderIntervalDays = envInt("FEE_REMINDER_INTERVAL_DAYS", 7)
+	ReconciliationDateToleranceDays = envInt("RECONCILIATION_DATE_TOLERANCE_DAYS", 3)
+	UploadDir = envString("UPLOAD_DIR", "uploads")
+	RevaluationWindowDays = envInt("REVALUATION_WINDOW_DAYS", 15)
+	RevaluationFee = envInt("REVALUATION_FEE", 1000)
+	RetotallingFee = envInt("RETOTALLING_FEE", 300)
+	ReappearResultPolicy = envString("REAPPEAR_RESULT_POLICY", "best")
 
 	ServerPort = os.Getenv("SERVER_PORT")
 	if ServerPort == "" {
@@ -79,8 +169,175 @@ func Init() {
 		log.Printf("Warning: FacultyCourseAssignment migration error: %v", err)
 	}
 	
+	// Session tables for refresh tokens and revoked access tokens
+	if err := DB.AutoMigrate(&models.RefreshToken{}, &models.RevokedToken{}); err != nil {
+		log.Printf("Warning: session tables migration error: %v", err)
+	}
+	// Outgoing mail queue
+	if err := DB.AutoMigrate(&models.EmailOutbox{}); err != nil {
+		log.Printf("Warning: email outbox migration error: %v", err)
+	}
+
+	// Brute-force protection tables
+	if err := DB.AutoMigrate(&models.AuthThrottle{}, &models.RateLimitBucket{}); err != nil {
+		log.Printf("Warning: auth throttle migration error: %v", err)
+	}
+	if !DB.Migrator().HasColumn(&models.User{}, "TokenVersion") {
+		if err := DB.Migrator().AddColumn(&models.User{}, "TokenVersion"); err != nil {
+			log.Printf("Warning: users.token_version migration error: %v", err)
+		}
+	}
+
+	// Two-factor auth columns and recovery codes
+	for _, field := range []string{"MFAEnabled", "MFASecret", "MFALastStep"} {
+		if !DB.Migrator().HasColumn(&models.User{}, field) {
+			if err := DB.Migrator().AddColumn(&models.User{}, field); err != nil {
+				log.Printf("Warning: users.%s migration error: %v", field, err)
+			}
+		}
+	}
+	if !DB.Migrator().HasColumn(&models.Role{}, "MFARequired") {
+		if err := DB.Migrator().AddColumn(&models.Role{}, "MFARequired"); err != nil {
+			log.Printf("Warning: roles.mfa_required migration error: %v", err)
+		}
+	}
+	if err := DB.AutoMigrate(&models.MFARecoveryCode{}); err != nil {
+		log.Printf("Warning: mfa recovery codes migration error: %v", err)
+	}
+
+	// Permission catalogue and role grants (seeded by middleware.InitPermissions)
+	if err := DB.AutoMigrate(&models.Permission{}, &models.RolePermission{}); err != nil {
+		log.Printf("Warning: permissions migration error: %v", err)
+	}
+
+	// Append-only audit trail
+	if err := DB.AutoMigrate(&models.AuditLog{}); err != nil {
+		log.Printf("Warning: audit log migration error: %v", err)
+	}
+
+	// Gateway payments (idempotent by payment id) and webhook deliveries
+	if err := DB.AutoMigrate(&models.GatewayPayment{}, &models.WebhookEvent{}); err != nil {
+		log.Printf("Warning: gateway payments migration error: %v", err)
+	}
+	// Server-side payment orders (amount/enrollment are never taken from the client)
+	if err := DB.AutoMigrate(&models.PaymentOrder{}); err != nil {
+		log.Printf("Warning: payment orders migration error: %v", err)
+	}
+
+	// Unified fee ledger (backfilled from the legacy fee tables by migrations/011_fee_ledger.sql)
+	if err := DB.AutoMigrate(&models.FeeLedgerEntry{}); err != nil {
+		log.Printf("Warning: fee ledger migration error: %v", err)
+	}
+
+	// Late fee rules per fee type
+	if err := DB.AutoMigrate(&models.LateFeeRule{}); err != nil {
+		log.Printf("Warning: late fee rules migration error: %v", err)
+	}
+
+	// Installment plans on fee structures, and overdue reminder tracking
+	if err := DB.AutoMigrate(&models.InstallmentPlan{}, &models.InstallmentPlanItem{}, &models.FeeReminder{}); err != nil {
+		log.Printf("Warning: installment plans migration error: %v", err)
+	}
+
+	// Scholarship / concession schemes and the grants awarding them to students
+	if err := DB.AutoMigrate(&models.ScholarshipScheme{}, &models.ScholarshipGrant{}); err != nil {
+		log.Printf("Warning: scholarships migration error: %v", err)
+	}
+
+	// Numbered fee receipts and their per-institute, per-financial-year counters
+	if err := DB.AutoMigrate(&models.FeeReceipt{}, &models.ReceiptSequence{}); err != nil {
+		log.Printf("Warning: fee receipts migration error: %v", err)
+	}
+
+	// Bank / gateway statement imports, their lines, and the payments they reconcile
+	if err := DB.AutoMigrate(&models.BankStatementImport{}, &models.BankStatementLine{}, &models.PaymentReconciliation{}); err != nil {
+		log.Printf("Warning: reconciliation migration error: %v", err)
+	}
+
+	// Proof documents attached to offline payments
+	if err := DB.AutoMigrate(&models.PaymentProof{}); err != nil {
+		log.Printf("Warning: payment proofs migration error: %v", err)
+	}
+
+	// Assessment schemes, external exam marks and staged result batches
+	if err := DB.AutoMigrate(&models.AssessmentScheme{}, &models.AssessmentComponent{}, &models.ExternalMark{},
+		&models.ResultBatch{}, &models.ProcessedMark{}); err != nil {
+		log.Printf("Warning: result processing migration error: %v", err)
+	}
+	// One batch per institute and semester gave way to numbered rounds
+	if DB.Migrator().HasIndex(&models.ResultBatch{}, "idx_result_batch") {
+		if err := DB.Migrator().DropIndex(&models.ResultBatch{}, "idx_result_batch"); err != nil {
+			log.Printf("Warning: result_batches index migration error: %v", err)
+		}
+	}
+
+	// Revaluation / re-totalling requests on published marks
+	if err := DB.AutoMigrate(&models.RevaluationRequest{}); err != nil {
+		log.Printf("Warning: revaluation migration error: %v", err)
+	}
+
+	// Exam sessions, and the registration / result columns on legacy reappear_students
+	if err := DB.AutoMigrate(&models.ExamSession{}); err != nil {
+		log.Printf("Warning: exam sessions migration error: %v", err)
+	}
+	for _, field := range []string{"ExamSessionID", "Semester", "SubjectCode", "Status", "FeeChargeID",
+		"MarksObtained", "Absent", "ResultAt", "LockedAt", "LockedBy", "CreatedAt"} {
+		if !DB.Migrator().HasColumn(&models.ReappearStudent{}, field) {
+			if err := DB.Migrator().AddColumn(&models.ReappearStudent{}, field); err != nil {
+				log.Printf("Warning: reappear_students.%s migration error: %v", field, err)
+			}
+		}
+	}
+
+	// Date sheets, hall tickets, exam rooms and seating plans
+	if err := DB.AutoMigrate(&models.DateSheetEntry{}, &models.HallTicket{}, &models.ExamRoom{}, &models.SeatAllocation{}); err != nil {
+		log.Printf("Warning: exam scheduling migration error: %v", err)
+	}
+
+	// Structured, versioned columns on the legacy academic_rules table, and condonation requests
+	if !DB.Migrator().HasTable(&models.AcademicRuleSet{}) {
+		if err := DB.Migrator().CreateTable(&models.AcademicRuleSet{}); err != nil {
+			log.Printf("Warning: academic rules migration error: %v", err)
+		}
+	}
+	for _, field := range []string{"Version", "MinAttendancePercent", "CondonationMinPercent", "InternalWeightage",
+		"MaxReappearSubjects", "EffectiveFrom"} {
+		if !DB.Migrator().HasColumn(&models.AcademicRuleSet{}, field) {
+			if err := DB.Migrator().AddColumn(&models.AcademicRuleSet{}, field); err != nil {
+				log.Printf("Warning: academic_rules.%s migration error: %v", field, err)
+			}
+		}
+	}
+	if err := DB.AutoMigrate(&models.AttendanceCondonation{}); err != nil {
+		log.Printf("Warning: attendance condonations migration error: %v", err)
+	}
+
 	DB.Exec("SET FOREIGN_KEY_CHECKS = 1")
 	log.Println("Auto-migration completed")
 }
 
+// envInt reads a non-negative integer env var, falling back to def when unset or invalid.
+// 0 is a valid setting; several intervals use it to disable their job.
+func envInt(key string, def int) int {
+	v, err := strconv.Atoi(os.Getenv(key))
+	if err != nil || v < 0 {
+		return def
+	}
+	return v
+}
+
+// envPositiveInt is envInt for limits where 0 makes no sense and would lock users out
+func envPositiveInt(key string, def int) int {
+	if v := envInt(key, def); v > 0 {
+		return v
+	}
+	return def
+}
 
+// envString reads an env var, falling back to def when unset
+func envString(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
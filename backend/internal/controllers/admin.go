@@ -1,19 +1,19 @@
 package controllers
 
 import (
-	"database/sql"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
-	"sort"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"gorm.io/gorm/clause"
+	"gorm.io/gorm"
 
 	"github.com/kiranraoboinapally/student/backend/internal/config"
+	"github.com/kiranraoboinapally/student/backend/internal/middleware"
 	"github.com/kiranraoboinapally/student/backend/internal/models"
 	"github.com/kiranraoboinapally/student/backend/internal/utils"
 )
@@ -28,8 +28,6 @@ func GetAdminStats(c *gin.Context) {
 		totalActiveStudents         int64
 		totalCourses                int64
 		passedCount                 int64
-		regPaid, examPaid, miscPaid float64
-		totalExpected, totalPaidExp float64
 	)
 
 	// Total Institutes
@@ -55,16 +53,8 @@ func GetAdminStats(c *gin.Context) {
 		Where("LOWER(student_status) IN (?)", []string{"passed", "completed", "graduated", "passed out"}).
 		Count(&passedCount)
 
-	// Fees Paid
-	db.Raw("SELECT COALESCE(SUM(fee_amount),0) FROM registration_fees WHERE payment_status = ?", "Paid").Scan(&regPaid)
-	db.Raw("SELECT COALESCE(SUM(fee_amount),0) FROM examination_fees WHERE payment_status = ?", "Paid").Scan(&examPaid)
-	db.Raw("SELECT COALESCE(SUM(fee_amount),0) FROM miscellaneous_fees WHERE payment_status = ?", "Paid").Scan(&miscPaid)
-	totalFeesPaid := regPaid + examPaid + miscPaid
-
-	// Expected vs Pending Fees
-	db.Raw("SELECT COALESCE(SUM(total_expected_fee),0) FROM expected_fee_collections").Scan(&totalExpected)
-	db.Raw("SELECT COALESCE(SUM(total_paid),0) FROM expected_fee_collections").Scan(&totalPaidExp)
-	totalPending := totalExpected - totalPaidExp
+	// Fees charged / paid / outstanding, from the ledger
+	fees := sumLedger(db, "")
 
 	c.JSON(http.StatusOK, gin.H{
 		"total_institutes":      totalInstitutes,
@@ -72,9 +62,10 @@ func GetAdminStats(c *gin.Context) {
 		"total_active_students": totalActiveStudents,
 		"total_courses":         totalCourses,
 		"passed_students_count": passedCount,
-		"total_fees_paid":       totalFeesPaid,
-		"total_expected_fees":   totalExpected,
-		"total_pending_fees":    totalPending,
+		"total_fees_paid":       fees.Paid,
+		"total_expected_fees":   fees.Charged,
+		"total_waived_fees":     fees.Waived,
+		"total_pending_fees":    fees.Balance,
 	})
 }
 
@@ -248,7 +239,7 @@ func GetCoursesByInstitute(c *gin.Context) {
 type AdminCreateStudentUserRequest struct {
 	EnrollmentNumber string `json:"enrollment_number" binding:"required"`
 	Email            string `json:"email" binding:"required,email"`
-	TempPassword     string `json:"temp_password" binding:"required,min=6"`
+	TempPassword     string `json:"temp_password" binding:"omitempty,min=6"` // generated when omitted; emailed, never returned
 }
 
 func CreateUserByAdmin(c *gin.Context) {
@@ -272,7 +263,12 @@ func CreateUserByAdmin(c *gin.Context) {
 		return
 	}
 
-	hashed, _ := utils.HashPassword(req.TempPassword)
+	tempPassword, err := tempPasswordOrGenerate(req.TempPassword)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate password"})
+		return
+	}
+	hashed, _ := utils.HashPassword(tempPassword)
 	user := models.User{
 		Username:       req.EnrollmentNumber,
 		Email:          req.Email,
@@ -283,13 +279,22 @@ func CreateUserByAdmin(c *gin.Context) {
 		IsTempPassword: true,
 	}
 
-	if err := db.Create(&user).Error; err != nil {
+	tx := db.Begin()
+	if err := tx.Create(&user).Error; err != nil {
+		tx.Rollback()
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create user"})
 		return
 	}
+	subject, body := temporaryPasswordEmail(user.FullName, user.Username, tempPassword)
+	if err := QueueEmail(tx, user.Email, subject, body); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to send credentials email"})
+		return
+	}
+	tx.Commit()
 
 	c.JSON(http.StatusCreated, gin.H{
-		"message":  "student login created",
+		"message":  "student login created, credentials sent by email",
 		"user_id":  user.UserID,
 		"username": user.Username,
 	})
@@ -302,7 +307,7 @@ type AdminCreateInstituteUserRequest struct {
 	Email        string `json:"email" binding:"required,email"`
 	FullName     string `json:"full_name" binding:"required"`
 	RoleID       int    `json:"role_id" binding:"required"` // 3=Institute Admin, 2=Faculty
-	TempPassword string `json:"temp_password" binding:"required,min=6"`
+	TempPassword string `json:"temp_password" binding:"omitempty,min=6"` // generated when omitted; emailed, never returned
 }
 
 func CreateInstituteUser(c *gin.Context) {
@@ -334,7 +339,12 @@ func CreateInstituteUser(c *gin.Context) {
 		return
 	}
 
-	hashed, _ := utils.HashPassword(req.TempPassword)
+	tempPassword, err := tempPasswordOrGenerate(req.TempPassword)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate password"})
+		return
+	}
+	hashed, _ := utils.HashPassword(tempPassword)
 	user := models.User{
 		Username:       req.Username,
 		Email:          req.Email,
@@ -346,10 +356,19 @@ func CreateInstituteUser(c *gin.Context) {
 		IsTempPassword: true,
 	}
 
-	if err := db.Create(&user).Error; err != nil {
+	tx := db.Begin()
+	if err := tx.Create(&user).Error; err != nil {
+		tx.Rollback()
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user"})
 		return
 	}
+	subject, body := temporaryPasswordEmail(user.FullName, user.Username, tempPassword)
+	if err := QueueEmail(tx, user.Email, subject, body); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send credentials email"})
+		return
+	}
+	tx.Commit()
 
 	c.JSON(http.StatusCreated, gin.H{
 		"message":   "Institute user created successfully",
@@ -401,210 +420,131 @@ type UnifiedPayment struct {
 	InstituteName   *string  `json:"institute_name"`
 	CourseName      *string  `json:"course_name"`
 	ProgramPattern  *string  `json:"program_pattern"`
+	PaymentMethod   *string  `json:"payment_method"`
+	ProofID         *int64   `json:"proof_id"`    // offline payments: proof at /admin/fees/payments/:id/proof
+	RecordedBy      *int64   `json:"recorded_by"` // user who recorded an offline payment
 }
 
+// GetAllFeePaymentHistory lists ledger payments with filtering, ordering and pagination done in SQL
 func GetAllFeePaymentHistory(c *gin.Context) {
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 500 {
+		limit = 20
+	}
 	offset := (page - 1) * limit
 
 	db := config.DB
+	query := db.Table("fee_ledger").
+		Joins("JOIN master_fee_types ON master_fee_types.fee_type_id = fee_ledger.fee_type_id").
+		Joins("LEFT JOIN master_students ON master_students.enrollment_number = fee_ledger.enrollment_number").
+		Where("fee_ledger.entry_type = ?", LedgerPayment)
 
-	// Filters
-	enrollmentFilter := c.Query("enrollment_number")
-	instituteFilter := strings.TrimSpace(c.Query("institute_name"))
-	statusFilter := strings.TrimSpace(c.Query("status"))
-	sourceFilter := strings.TrimSpace(c.Query("source"))
-
-	var enNum int64
-	hasEnrollmentFilter := enrollmentFilter != ""
-	if hasEnrollmentFilter {
-		val, err := strconv.ParseInt(enrollmentFilter, 10, 64)
+	if v := c.Query("enrollment_number"); v != "" {
+		enNum, err := strconv.ParseInt(v, 10, 64)
 		if err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid enrollment number"})
 			return
 		}
-		enNum = val
+		query = query.Where("fee_ledger.enrollment_number = ?", enNum)
 	}
-
-	var results []UnifiedPayment
-	seenPayments := make(map[string]struct{}) // for uniqueness
-
-	fetchFrom := func(table, idCol, nameCol, amountCol, txnCol, dateCol, statusCol, source, instCol, courseCol string) error {
-		if sourceFilter != "" && sourceFilter != source {
-			return nil
-		}
-
-		rows, err := db.Table(table).
-			Select(idCol + " AS payment_id, enrollment_number, " + nameCol + " AS student_name, " +
-				amountCol + " AS fee_amount, " + txnCol + " AS transaction_number, " +
-				dateCol + " AS transaction_date, " + statusCol + " AS status, " +
-				instCol + " AS institute_name, " + courseCol + " AS course_name").
-			Rows()
-		if err != nil {
-			return err
-		}
-		defer rows.Close()
-
-		for rows.Next() {
-			var up UnifiedPayment
-			var instName, courseName sql.NullString
-			var status sql.NullString
-
-			if err := rows.Scan(&up.PaymentID, &up.EnrollmentNo, &up.StudentName, &up.FeeAmount,
-				&up.TransactionNo, &up.TransactionDate, &status, &instName, &courseName); err != nil {
-				continue
-			}
-
-			up.Source = source
-
-			// Save status exactly as-is (even if nil)
-			if status.Valid {
-				up.Status = &status.String
-			} else {
-				up.Status = nil
-			}
-
-			if instName.Valid {
-				up.InstituteName = &instName.String
-			}
-			if courseName.Valid {
-				up.CourseName = &courseName.String
-			}
-
-			// Skip duplicates (payment_id + source)
-			key := fmt.Sprintf("%d_%s", up.PaymentID, up.Source)
-			if _, exists := seenPayments[key]; exists {
-				continue
-			}
-			seenPayments[key] = struct{}{}
-
-			// Apply filters
-			if hasEnrollmentFilter && (up.EnrollmentNo == nil || *up.EnrollmentNo != enNum) {
-				continue
-			}
-			if statusFilter != "" {
-				if up.Status == nil || strings.ToLower(*up.Status) != strings.ToLower(statusFilter) {
-					continue
-				}
-			}
-
-			results = append(results, up)
-		}
-		return nil
+	if v := strings.TrimSpace(c.Query("institute_name")); v != "" {
+		query = query.Where("LOWER(master_students.institute_name) LIKE ?", "%"+strings.ToLower(v)+"%")
 	}
-
-	tables := []struct {
-		table, idCol, nameCol, amountCol, txnCol, dateCol, statusCol, source, instCol, courseCol string
-	}{
-		{"registration_fees", "regn_fee_id", "student_name", "COALESCE(fee_amount,0)", "transaction_number", "transaction_date", "payment_status", "registration", "institute_name", "course_name"},
-		{"examination_fees", "exam_fee_id", "student_name", "COALESCE(fee_amount,0)", "transaction_number", "transaction_date", "payment_status", "examination", "institute_name", "course_name"},
-		{"miscellaneous_fees", "misc_fee_id", "student_name", "COALESCE(fee_amount,0)", "transaction_number", "transaction_date", "payment_status", "miscellaneous", "institute_name", "course_name"},
+	if v := strings.TrimSpace(c.Query("status")); v != "" {
+		query = query.Where("LOWER(fee_ledger.status) = ?", strings.ToLower(v))
 	}
-
-	for _, t := range tables {
-		if err := fetchFrom(t.table, t.idCol, t.nameCol, t.amountCol, t.txnCol, t.dateCol, t.statusCol, t.source, t.instCol, t.courseCol); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch payments"})
-			return
-		}
+	if v := strings.TrimSpace(c.Query("source")); v != "" {
+		query = query.Where("LOWER(master_fee_types.fee_type_name) = ?", strings.ToLower(v))
 	}
-
-	// Enrich with MasterStudent only if institute/course is missing
-	if len(results) > 0 {
-		enrollSet := make(map[int64]struct{})
-		for _, r := range results {
-			if r.EnrollmentNo != nil {
-				enrollSet[*r.EnrollmentNo] = struct{}{}
-			}
-		}
-
-		if len(enrollSet) > 0 {
-			ids := make([]int64, 0, len(enrollSet))
-			for id := range enrollSet {
-				ids = append(ids, id)
-			}
-
-			var masters []models.MasterStudent
-			db.Where("enrollment_number IN ?", ids).Find(&masters)
-
-			masterMap := make(map[int64]models.MasterStudent)
-			for _, m := range masters {
-				masterMap[m.EnrollmentNumber] = m
-			}
-
-			for i := range results {
-				if results[i].EnrollmentNo != nil {
-					if m, ok := masterMap[*results[i].EnrollmentNo]; ok {
-						if results[i].InstituteName == nil {
-							results[i].InstituteName = m.InstituteName
-						}
-						if results[i].CourseName == nil {
-							results[i].CourseName = m.CourseName
-						}
-						results[i].ProgramPattern = m.ProgramPattern
-					}
-				}
-			}
-		}
+	if v := c.Query("fee_type_id"); v != "" {
+		query = query.Where("fee_ledger.fee_type_id = ?", v)
 	}
 
-	// Apply institute filter (case-insensitive)
-	if instituteFilter != "" {
-		needle := strings.ToLower(strings.TrimSpace(instituteFilter))
-		filtered := results[:0]
-		for _, r := range results {
-			if r.InstituteName != nil && strings.Contains(strings.ToLower(*r.InstituteName), needle) {
-				filtered = append(filtered, r)
-			}
-		}
-		results = filtered
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch payments"})
+		return
 	}
 
-	// Sort by date descending
-	sort.SliceStable(results, func(i, j int) bool {
-		di := parseDateString(results[i].TransactionDate)
-		dj := parseDateString(results[j].TransactionDate)
-		if !di.IsZero() && !dj.IsZero() {
-			return di.After(dj)
-		}
-		si, sj := "", ""
-		if results[i].TransactionDate != nil {
-			si = *results[i].TransactionDate
-		}
-		if results[j].TransactionDate != nil {
-			sj = *results[j].TransactionDate
-		}
-		return si > sj
-	})
-
-	// Pagination
-	total := len(results)
-	start := offset
-	if start > total {
-		start = total
+	var rows []struct {
+		EntryID           int64
+		EnrollmentNumber  int64
+		StudentName       *string
+		Credit            float64
+		TransactionNumber *string
+		TransactionDate   *time.Time
+		Status            string
+		PaymentMethod     *string
+		FeeTypeName       string
+		InstituteName     *string
+		CourseName        *string
+		ProgramPattern    *string
+		ProofID           *int64
+		CreatedBy         *int64
+	}
+	err := query.Select(`fee_ledger.entry_id, fee_ledger.enrollment_number, master_students.student_name,
+			fee_ledger.credit, fee_ledger.transaction_number, fee_ledger.transaction_date, fee_ledger.status,
+			fee_ledger.payment_method, master_fee_types.fee_type_name, master_students.institute_name,
+			master_students.course_name, master_students.program_pattern, payment_proofs.proof_id, fee_ledger.created_by`).
+		Joins("LEFT JOIN payment_proofs ON payment_proofs.payment_entry_id = fee_ledger.entry_id").
+		Order("fee_ledger.transaction_date DESC, fee_ledger.entry_id DESC").
+		Limit(limit).Offset(offset).
+		Scan(&rows).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch payments"})
+		return
 	}
-	end := start + limit
-	if end > total {
-		end = total
+
+	results := make([]UnifiedPayment, 0, len(rows))
+	for _, r := range rows {
+		enrollment, amount, status := r.EnrollmentNumber, r.Credit, r.Status
+		results = append(results, UnifiedPayment{
+			PaymentID:       r.EntryID,
+			EnrollmentNo:    &enrollment,
+			StudentName:     r.StudentName,
+			FeeAmount:       &amount,
+			TransactionNo:   r.TransactionNumber,
+			TransactionDate: optTimeString(r.TransactionDate, "2006-01-02 15:04:05"),
+			Status:          &status,
+			Source:          strings.ToLower(r.FeeTypeName),
+			InstituteName:   r.InstituteName,
+			CourseName:      r.CourseName,
+			ProgramPattern:  r.ProgramPattern,
+			PaymentMethod:   r.PaymentMethod,
+			ProofID:         r.ProofID,
+			RecordedBy:      r.CreatedBy,
+		})
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"total_records": total,
-		"payments":      results[start:end],
+		"payments":      results,
 		"pagination": gin.H{
 			"page":        page,
 			"limit":       limit,
 			"total":       total,
-			"total_pages": (total + limit - 1) / limit,
+			"total_pages": (total + int64(limit) - 1) / int64(limit),
 		},
 	})
 }
 
+// errPaymentStatusChanged is returned when another reviewer changed a payment's status first
+var errPaymentStatusChanged = errors.New("payment status was changed by someone else; reload and try again")
+
+// VerifyPayment marks a ledger payment verified or rejected; a rejected payment no longer counts
+// towards the student's balance. Pending offline payments start counting once verified, and the
+// verifier must not be the person who recorded them. Verified payments get a numbered receipt.
+// "source" is accepted for older clients and ignored.
 func VerifyPayment(c *gin.Context) {
 	var req struct {
 		PaymentID int64  `json:"payment_id" binding:"required"`
-		Source    string `json:"source" binding:"required"`
+		Source    string `json:"source"`
 		Action    string `json:"action" binding:"required"`
+		Remarks   string `json:"remarks"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -616,30 +556,101 @@ func VerifyPayment(c *gin.Context) {
 		return
 	}
 
-	newStatus := "Verified"
+	newStatus := PaymentStatusVerified
 	if req.Action == "reject" {
-		newStatus = "Rejected"
-	}
-
-	var table, idCol string
-	switch req.Source {
-	case "registration":
-		table, idCol = "registration_fees", "regn_fee_id"
-	case "examination":
-		table, idCol = "examination_fees", "exam_fee_id"
-	case "miscellaneous":
-		table, idCol = "miscellaneous_fees", "misc_fee_id"
-	default:
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid source"})
-		return
+		newStatus = PaymentStatusRejected
 	}
 
 	db := config.DB
-	if err := db.Table(table).Where(idCol+" = ?", req.PaymentID).
-		Update("payment_status", newStatus).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update payment status"})
+	var entry models.FeeLedgerEntry
+	if err := db.Where("entry_id = ? AND entry_type = ?", req.PaymentID, LedgerPayment).First(&entry).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "payment not found"})
 		return
 	}
+	previousStatus := entry.Status
+	if previousStatus == newStatus || (previousStatus != PaymentStatusPending && previousStatus != PaymentStatusPaid && previousStatus != PaymentStatusVerified) {
+		c.JSON(http.StatusConflict, gin.H{"error": "payment is already " + previousStatus})
+		return
+	}
+	// Maker-checker: whoever recorded an offline payment cannot also approve or reject it
+	if uid := c.GetInt64("user_id"); entry.CreatedBy != nil && *entry.CreatedBy == uid {
+		c.JSON(http.StatusForbidden, gin.H{"error": "a payment must be verified by someone other than the person who recorded it"})
+		return
+	}
+
+	var receipt *models.FeeReceipt
+	if req.Action == "verify" {
+		err := db.Transaction(func(tx *gorm.DB) error {
+			// Conditional on the status read above, so two reviewers cannot both act on the payment
+			res := tx.Model(&entry).Where("status = ?", previousStatus).
+				Updates(map[string]interface{}{"status": newStatus, "updated_at": time.Now()})
+			if res.Error != nil {
+				return res.Error
+			}
+			if res.RowsAffected == 0 {
+				return errPaymentStatusChanged
+			}
+			var err error
+			receipt, err = issueReceipt(tx, entry)
+			if err != nil || previousStatus != PaymentStatusPending {
+				return err
+			}
+			var user models.User
+			if tx.Where("username = ?", strconv.FormatInt(entry.EnrollmentNumber, 10)).Limit(1).Find(&user).RowsAffected > 0 && user.Email != "" {
+				subject, body := paymentVerifiedEmail(user.FullName, entry.Credit, safeString(entry.TransactionNumber), receipt.ReceiptNumber)
+				return QueueEmail(tx, user.Email, subject, body)
+			}
+			return nil
+		})
+		if err == errPaymentStatusChanged {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update payment status"})
+			return
+		}
+	} else {
+		if db.Where("fee_record_id = ?", entry.EntryID).Limit(1).Find(&models.GatewayPayment{}).RowsAffected > 0 {
+			c.JSON(http.StatusConflict, gin.H{"error": "online payments cannot be rejected; refund them instead"})
+			return
+		}
+		// A rejection reverses the payment like a refund, so balances and the audit trail agree
+		var createdBy *int64
+		if uid := c.GetInt64("user_id"); uid != 0 {
+			createdBy = &uid
+		}
+		reason := req.Remarks
+		if reason == "" {
+			reason = "Payment rejected"
+		}
+		var reversal *models.FeeLedgerEntry
+		err := db.Transaction(func(tx *gorm.DB) error {
+			var err error
+			reversal, err = reversePayment(tx, entry.EntryID, paymentReversal{
+				EntryType: LedgerReversal,
+				Reference: ptrString("reject:" + strconv.FormatInt(entry.EntryID, 10)),
+				Reason:    reason,
+				CreatedBy: createdBy,
+			})
+			return err
+		})
+		if err != nil {
+			if err == errNothingToReverse {
+				c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update payment status"})
+			return
+		}
+		if reversal != nil {
+			notifyPaymentReversal(reversal)
+		}
+	}
+
+	middleware.Audit(c, "fees."+req.Action, "fee_ledger", strconv.FormatInt(req.PaymentID, 10),
+		gin.H{"payment_status": previousStatus},
+		gin.H{"payment_status": newStatus, "remarks": req.Remarks})
 
 	SendAdminNotification("payment_status_updated", gin.H{
 		"payment_id": req.PaymentID,
@@ -647,29 +658,28 @@ func VerifyPayment(c *gin.Context) {
 		"status":     newStatus,
 	})
 
-	c.JSON(http.StatusOK, gin.H{
+	resp := gin.H{
 		"message":    "payment status updated",
 		"payment_id": req.PaymentID,
 		"status":     newStatus,
-	})
-}
-
-func parseDateString(s *string) time.Time {
-	if s == nil || strings.TrimSpace(*s) == "" {
-		return time.Time{}
 	}
-	layouts := []string{
-		"2006-01-02 15:04:05",
-		"2006-01-02",
-		time.RFC3339,
-		"2006-01-02 15:04",
+	if receipt != nil {
+		resp["receipt_id"] = receipt.ReceiptID
+		resp["receipt_number"] = receipt.ReceiptNumber
 	}
-	for _, layout := range layouts {
-		if t, err := time.Parse(layout, strings.TrimSpace(*s)); err == nil {
-			return t
-		}
-	}
-	return time.Time{}
+	c.JSON(http.StatusOK, resp)
+}
+
+// paymentVerifiedEmail tells a student that an offline payment was verified and receipted
+func paymentVerifiedEmail(fullName string, amount float64, transactionNo, receiptNumber string) (string, string) {
+	return "Your fee payment has been verified", fmt.Sprintf(`Hello %s,
+
+Your fee payment of Rs. %.2f (reference %s) has been verified and credited to your fee account.
+
+Receipt number: %s
+
+You can download the receipt from your fee history at %s.
+`, fullName, amount, transactionNo, receiptNumber, config.AppBaseURL)
 }
 
 // ======================== MARKS UPLOAD ========================
@@ -714,10 +724,7 @@ func UploadStudentMarks(c *gin.Context) {
 		})
 	}
 
-	if err := db.Clauses(clause.OnConflict{
-		Columns:   []clause.Column{{Name: "enrollment_number"}, {Name: "semester"}, {Name: "subject_code"}},
-		DoUpdates: clause.AssignmentColumns([]string{"marks_obtained", "grade", "status"}),
-	}).Create(&records).Error; err != nil {
+	if err := upsertStudentMarks(db, records); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -798,13 +805,22 @@ func ApproveRegistration(c *gin.Context) {
 	}
 
 	db := config.DB
-	if err := db.Model(&models.User{}).
+	result := db.Model(&models.User{}).
 		Where("user_id = ? AND status = ?", req.UserID, "inactive").
-		Update("status", newStatus).Error; err != nil {
+		Update("status", newStatus)
+	if result.Error != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update status"})
 		return
 	}
 
+	if result.RowsAffected > 0 {
+		var user models.User
+		if err := db.Where("user_id = ?", req.UserID).First(&user).Error; err == nil {
+			subject, body := registrationStatusEmail(user.FullName, newStatus)
+			QueueEmail(db, user.Email, subject, body)
+		}
+	}
+
 	SendAdminNotification("registration_status_changed", gin.H{
 		"user_id": req.UserID,
 		"status":  newStatus,
@@ -851,32 +867,24 @@ func GetInstituteDetail(c *gin.Context) {
 		Group("course_name").
 		Scan(&branches)
 
-	// Fees paid
-	var regPaid, examPaid, miscPaid float64
-	db.Raw("SELECT COALESCE(SUM(fee_amount),0) FROM registration_fees WHERE payment_status = ? AND enrollment_number IN (SELECT enrollment_number FROM master_students WHERE institute_name = ?)", "Paid", instName).Scan(&regPaid)
-	db.Raw("SELECT COALESCE(SUM(fee_amount),0) FROM examination_fees WHERE payment_status = ? AND enrollment_number IN (SELECT enrollment_number FROM master_students WHERE institute_name = ?)", "Paid", instName).Scan(&examPaid)
-	db.Raw("SELECT COALESCE(SUM(fee_amount),0) FROM miscellaneous_fees WHERE payment_status = ? AND enrollment_number IN (SELECT enrollment_number FROM master_students WHERE institute_name = ?)", "Paid", instName).Scan(&miscPaid)
-	totalPaid := regPaid + examPaid + miscPaid
-
-	// Expected & pending
-	var totalExpected, totalPaidExp float64
-	db.Raw("SELECT COALESCE(SUM(total_expected_fee),0) FROM expected_fee_collections WHERE enrollment_number IN (SELECT enrollment_number FROM master_students WHERE institute_name = ?)", instName).Scan(&totalExpected)
-	db.Raw("SELECT COALESCE(SUM(total_paid),0) FROM expected_fee_collections WHERE enrollment_number IN (SELECT enrollment_number FROM master_students WHERE institute_name = ?)", instName).Scan(&totalPaidExp)
-	pending := totalExpected - totalPaidExp
+	// Fees charged / paid / outstanding for the institute's students
+	fees := sumLedger(db, "fee_ledger.enrollment_number IN (SELECT enrollment_number FROM master_students WHERE institute_name = ?)", instName)
 
 	c.JSON(http.StatusOK, gin.H{
 		"institute":      inst,
 		"total_students": totalStudents,
 		"branches":       branches,
-		"fees_paid":      totalPaid,
-		"expected_fees":  totalExpected,
-		"pending_fees":   pending,
+		"fees_paid":      fees.Paid,
+		"expected_fees":  fees.Charged,
+		"waived_fees":    fees.Waived,
+		"pending_fees":   fees.Balance,
 	})
 }
 
 // ======================== FEE STRUCTURE & DUE ========================
 func CreateFeeStructure(c *gin.Context) {
 	var payload struct {
+		FeeTypeID      int     `json:"fee_type_id"`
 		CourseName     *string `json:"course_name"`
 		Session        *string `json:"session"`
 		Batch          *string `json:"batch"`
@@ -891,6 +899,7 @@ func CreateFeeStructure(c *gin.Context) {
 	}
 
 	fs := models.FeeStructure{
+		FeeTypeID:      payload.FeeTypeID,
 		CourseName:     payload.CourseName,
 		Session:        payload.Session,
 		Batch:          payload.Batch,
@@ -898,6 +907,24 @@ func CreateFeeStructure(c *gin.Context) {
 		FeeAmount:      payload.FeeAmount,
 		Status:         "active",
 	}
+	for _, d := range []struct {
+		in  *string
+		out **time.Time
+	}{{payload.EffectiveFrom, &fs.EffectiveFrom}, {payload.EffectiveTo, &fs.EffectiveTo}} {
+		if d.in == nil || *d.in == "" {
+			continue
+		}
+		t, err := time.Parse("2006-01-02", *d.in)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "effective dates must be YYYY-MM-DD"})
+			return
+		}
+		*d.out = &t
+	}
+	if fs.EffectiveFrom != nil && fs.EffectiveTo != nil && fs.EffectiveTo.Before(*fs.EffectiveFrom) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "effective_to is before effective_from"})
+		return
+	}
 
 	db := config.DB
 	if err := db.Create(&fs).Error; err != nil {
@@ -916,41 +943,109 @@ func CreateFeeStructure(c *gin.Context) {
 	})
 }
 
+// CreateFeeDue charges a student for a fee head, optionally with a due date
 func CreateFeeDue(c *gin.Context) {
 	var payload struct {
 		EnrollmentNumber int64   `json:"enrollment_number" binding:"required"`
 		FeeHead          string  `json:"fee_head" binding:"required"`
-		OriginalAmount   float64 `json:"original_amount" binding:"required"`
+		OriginalAmount   float64 `json:"original_amount" binding:"required,gt=0"`
 		DueDate          string  `json:"due_date"`
+		Semester         *int    `json:"semester"`
+		Description      string  `json:"description"`
+		InstallmentPlan  *int    `json:"installment_plan_id"` // split into the plan's dated installments
 	}
 	if err := c.ShouldBindJSON(&payload); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	fd := models.FeeDue{
-		StudentID:      int(payload.EnrollmentNumber),
-		FeeHead:        payload.FeeHead,
-		OriginalAmount: payload.OriginalAmount,
-		AmountPaid:     0,
-		Status:         "Pending",
-		CreatedAt:      time.Now(),
+	db := config.DB
+	feeType, err := resolveFeeType(db, payload.FeeHead)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unknown fee head"})
+		return
 	}
 
-	db := config.DB
-	if err := db.Create(&fd).Error; err != nil {
+	entry := models.FeeLedgerEntry{
+		EnrollmentNumber: payload.EnrollmentNumber,
+		FeeTypeID:        feeType.FeeTypeID,
+		EntryType:        LedgerCharge,
+		Debit:            payload.OriginalAmount,
+		Semester:         payload.Semester,
+	}
+	if payload.DueDate != "" {
+		due, err := time.Parse("2006-01-02", payload.DueDate)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "due_date must be YYYY-MM-DD"})
+			return
+		}
+		entry.DueDate = &due
+	}
+	if payload.Description != "" {
+		entry.Description = &payload.Description
+	}
+	if uid := c.GetInt64("user_id"); uid != 0 {
+		entry.CreatedBy = &uid
+	}
+
+	if payload.InstallmentPlan != nil {
+		plan, err := loadInstallmentPlan(db, *payload.InstallmentPlan)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "installment plan not found"})
+			return
+		}
+		var installments []models.FeeLedgerEntry
+		err = db.Transaction(func(tx *gorm.DB) error {
+			var err error
+			installments, err = postInstallmentCharges(tx, entry, payload.OriginalAmount, plan, "")
+			return err
+		})
+		if err == nil && len(installments) == 0 {
+			err = fmt.Errorf("no installments posted")
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create fee due"})
+			return
+		}
+		ids := make([]int64, 0, len(installments))
+		for _, inst := range installments {
+			ids = append(ids, inst.EntryID)
+		}
+		middleware.Audit(c, "fees.charge", "fee_ledger", strconv.FormatInt(ids[0], 10), nil,
+			gin.H{"enrollment_number": entry.EnrollmentNumber, "fee_type_id": entry.FeeTypeID, "amount": payload.OriginalAmount,
+				"installment_plan_id": plan.PlanID, "installment_ids": ids})
+		SendAdminNotification("fee_due_created", gin.H{
+			"fee_due_id": ids[0],
+			"enrollment": payload.EnrollmentNumber,
+		})
+		c.JSON(http.StatusCreated, gin.H{
+			"message":      "fee due created",
+			"fee_due_id":   ids[0],
+			"installments": installments,
+		})
+		return
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		_, err := postCharge(tx, &entry)
+		return err
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create fee due"})
 		return
 	}
 
+	middleware.Audit(c, "fees.charge", "fee_ledger", strconv.FormatInt(entry.EntryID, 10), nil,
+		gin.H{"enrollment_number": entry.EnrollmentNumber, "fee_type_id": entry.FeeTypeID, "amount": entry.Debit})
+
 	SendAdminNotification("fee_due_created", gin.H{
-		"fee_due_id": fd.FeeDueID,
+		"fee_due_id": entry.EntryID,
 		"enrollment": payload.EnrollmentNumber,
 	})
 
 	c.JSON(http.StatusCreated, gin.H{
 		"message":    "fee due created",
-		"fee_due_id": fd.FeeDueID,
+		"fee_due_id": entry.EntryID,
 	})
 }
 
@@ -7,6 +7,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/kiranraoboinapally/student/backend/internal/config"
+	"github.com/kiranraoboinapally/student/backend/internal/middleware"
 	"github.com/kiranraoboinapally/student/backend/internal/models"
 )
 
@@ -149,13 +150,21 @@ func ApproveFaculty(c *gin.Context) {
 		return
 	}
 
+	var faculty models.Faculty
+	db.Preload("User").Where("faculty_id = ?", req.FacultyID).First(&faculty)
+
 	// If approved, also activate the user account
 	if newStatus == "approved" {
-		var faculty models.Faculty
-		db.Where("faculty_id = ?", req.FacultyID).First(&faculty)
 		db.Model(&models.User{}).Where("user_id = ?", faculty.UserID).Update("status", "active")
 	}
 
+	middleware.Audit(c, "faculty.approve", "faculty", strconv.FormatInt(req.FacultyID, 10),
+		gin.H{"approval_status": "pending"},
+		gin.H{"approval_status": newStatus, "remarks": req.Remarks})
+
+	subject, body := facultyStatusEmail(faculty.User.FullName, newStatus, req.Remarks)
+	QueueEmail(db, faculty.User.Email, subject, body)
+
 	SendAdminNotification("faculty_approval_status", gin.H{
 		"faculty_id": req.FacultyID,
 		"status":     newStatus,
@@ -283,6 +292,12 @@ func LockMarks(c *gin.Context) {
 		return
 	}
 
+	middleware.Audit(c, "marks.lock", "internal_marks", "", nil, gin.H{
+		"filters":      req,
+		"status":       "locked",
+		"locked_count": result.RowsAffected,
+	})
+
 	SendAdminNotification("marks_locked", gin.H{
 		"locked_count": result.RowsAffected,
 		"locked_by":    adminUserID,
@@ -294,62 +309,6 @@ func LockMarks(c *gin.Context) {
 	})
 }
 
-// PublishResultsRequest for publishing locked marks
-type PublishResultsRequest struct {
-	InstituteID *int    `json:"institute_id"`
-	Semester    *int    `json:"semester"`
-	SubjectCode string  `json:"subject_code"`
-	MarkIDs     []int64 `json:"mark_ids"`
-}
-
-// PublishResults changes locked marks to published (visible to students)
-func PublishResults(c *gin.Context) {
-	var req PublishResultsRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-
-	db := config.DB
-	now := time.Now()
-
-	query := db.Model(&models.InternalMark{}).Where("status = ?", "locked")
-
-	if len(req.MarkIDs) > 0 {
-		query = query.Where("internal_mark_id IN ?", req.MarkIDs)
-	} else {
-		if req.InstituteID != nil {
-			query = query.Where("institute_id = ?", *req.InstituteID)
-		}
-		if req.Semester != nil {
-			query = query.Where("semester = ?", *req.Semester)
-		}
-		if req.SubjectCode != "" {
-			query = query.Where("subject_code = ?", req.SubjectCode)
-		}
-	}
-
-	result := query.Updates(map[string]interface{}{
-		"status":       "published",
-		"published_at": now,
-		"updated_at":   now,
-	})
-
-	if result.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to publish results"})
-		return
-	}
-
-	SendAdminNotification("results_published", gin.H{
-		"published_count": result.RowsAffected,
-	})
-
-	c.JSON(http.StatusOK, gin.H{
-		"message":         "results published successfully",
-		"published_count": result.RowsAffected,
-	})
-}
-
 // GetAllInternalMarks retrieves all internal marks for university admin
 func GetAllInternalMarks(c *gin.Context) {
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
@@ -551,6 +510,13 @@ func ApproveStudentRegistration(c *gin.Context) {
 		return
 	}
 
+	middleware.Audit(c, "student_registration."+req.Action, "user", strconv.FormatInt(req.UserID, 10),
+		gin.H{"status": user.Status},
+		gin.H{"status": newStatus, "remarks": req.Remarks})
+
+	subject, body := registrationStatusEmail(user.FullName, newStatus)
+	QueueEmail(db, user.Email, subject, body)
+
 	SendAdminNotification("student_approval_status", gin.H{
 		"user_id": req.UserID,
 		"status":  newStatus,
@@ -594,6 +560,9 @@ func BulkApproveStudentRegistrations(c *gin.Context) {
 		newStatus = "rejected"
 	}
 
+	var users []models.User
+	db.Where("user_id IN ? AND role_id = ? AND status = ?", req.UserIDs, 5, "inactive").Find(&users)
+
 	result := db.Model(&models.User{}).
 		Where("user_id IN ? AND role_id = ? AND status = ?", req.UserIDs, 5, "inactive").
 		Update("status", newStatus)
@@ -603,6 +572,18 @@ func BulkApproveStudentRegistrations(c *gin.Context) {
 		return
 	}
 
+	approvedIDs := make([]int64, 0, len(users))
+	for _, u := range users {
+		approvedIDs = append(approvedIDs, u.UserID)
+	}
+	middleware.Audit(c, "student_registration.bulk_"+req.Action, "user", "", gin.H{"status": "inactive"},
+		gin.H{"status": newStatus, "user_ids": approvedIDs})
+
+	for _, u := range users {
+		subject, body := registrationStatusEmail(u.FullName, newStatus)
+		QueueEmail(db, u.Email, subject, body)
+	}
+
 	SendAdminNotification("bulk_student_approval", gin.H{
 		"count":  result.RowsAffected,
 		"status": newStatus,
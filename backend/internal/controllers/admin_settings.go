@@ -1,12 +1,17 @@
 package controllers
 
 import (
-	"database/sql"
+	"errors"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 	"github.com/kiranraoboinapally/student/backend/internal/config"
+	"github.com/kiranraoboinapally/student/backend/internal/grading"
+	"github.com/kiranraoboinapally/student/backend/internal/middleware"
 	"github.com/kiranraoboinapally/student/backend/internal/models"
 )
 
@@ -40,6 +45,10 @@ func CreateGradingRule(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "marks_percent, grade, and grade_points are required"})
 		return
 	}
+	if err := validateGradeBand(input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
 	// Check for duplicate grade
 	var existing models.GradeMapping
@@ -82,6 +91,13 @@ func UpdateGradingRule(c *gin.Context) {
 		}
 	}
 
+	if err := validateGradeBand(input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	before := existing
+
 	// Update fields
 	if input.MarksPercent != "" {
 		existing.MarksPercent = input.MarksPercent
@@ -101,9 +117,27 @@ func UpdateGradingRule(c *gin.Context) {
 		return
 	}
 
+	middleware.Audit(c, "grading_rule.update", "grade_mapping", id, before, existing)
+
 	c.JSON(http.StatusOK, existing)
 }
 
+// validateGradeBand checks that a grade mapping's range and points can be read by the result
+// computation; empty fields (not being updated) are skipped
+func validateGradeBand(g models.GradeMapping) error {
+	if g.MarksPercent != "" {
+		if _, _, err := grading.ParseBand(g.MarksPercent); err != nil {
+			return err
+		}
+	}
+	if g.GradePoints != "" {
+		if _, err := strconv.ParseFloat(strings.TrimSpace(g.GradePoints), 64); err != nil {
+			return errors.New("grade_points must be a number")
+		}
+	}
+	return nil
+}
+
 // DeleteGradingRule deletes a grade mapping
 func DeleteGradingRule(c *gin.Context) {
 	db := config.DB
@@ -125,73 +159,106 @@ func DeleteGradingRule(c *gin.Context) {
 
 // ======================== ACADEMIC RULES ========================
 
-// GetAcademicRules retrieves academic rules
-func GetAcademicRules(c *gin.Context) {
-	db := config.DB
-	
-	var rules struct {
-		ID        int       `json:"id"`
-		Rules     string    `json:"rules"`
-		UpdatedAt time.Time `json:"updated_at"`
-		UpdatedBy int       `json:"updated_by"`
-	}
+// defaultAcademicRules apply until a version of the rules has been saved
+func defaultAcademicRules() models.AcademicRuleSet {
+	return models.AcademicRuleSet{MinAttendancePercent: 75, CondonationMinPercent: 65, InternalWeightage: 30}
+}
 
-	// Get the first (and should be only) record
-	err := db.Raw("SELECT id, rules, updated_at, updated_by FROM academic_rules ORDER BY id DESC LIMIT 1").Scan(&rules).Error
-	
-	if err == sql.ErrNoRows || rules.ID == 0 {
-		c.JSON(http.StatusOK, gin.H{"rules": ""})
-		return
+// currentAcademicRules returns the latest version of the academic rules already in effect
+func currentAcademicRules(db *gorm.DB) models.AcademicRuleSet {
+	var rules models.AcademicRuleSet
+	if db.Where("effective_from IS NULL OR effective_from <= ?", time.Now().Format("2006-01-02")).
+		Order("version DESC, id DESC").Limit(1).Find(&rules).RowsAffected == 0 {
+		return defaultAcademicRules()
 	}
+	return rules
+}
 
-	if err != nil {
+// GetAcademicRules returns the academic rules in effect
+func GetAcademicRules(c *gin.Context) {
+	c.JSON(http.StatusOK, currentAcademicRules(config.DB))
+}
+
+// GetAcademicRuleVersions lists every version of the academic rules, newest first
+func GetAcademicRuleVersions(c *gin.Context) {
+	versions := []models.AcademicRuleSet{}
+	if err := config.DB.Order("version DESC, id DESC").Find(&versions).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch academic rules"})
 		return
 	}
-
-	c.JSON(http.StatusOK, rules)
+	c.JSON(http.StatusOK, gin.H{"versions": versions})
 }
 
-// UpdateAcademicRules updates academic rules
+// UpdateAcademicRules saves a new version of the academic rules. Fields left out keep their value
+// from the latest version; effective_from defers the new version to a later date.
 func UpdateAcademicRules(c *gin.Context) {
-	db := config.DB
-
 	var input struct {
-		Rules string `json:"rules" binding:"required"`
+		Rules                 *string  `json:"rules"`
+		MinAttendancePercent  *float64 `json:"min_attendance_percent"`
+		CondonationMinPercent *float64 `json:"condonation_min_percent"`
+		InternalWeightage     *float64 `json:"internal_weightage"`
+		MaxReappearSubjects   *int     `json:"max_reappear_subjects"`
+		EffectiveFrom         string   `json:"effective_from"` // YYYY-MM-DD
 	}
-
 	if err := c.ShouldBindJSON(&input); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	// Get user ID from context (set by auth middleware)
-	userID, exists := c.Get("user_id")
-	if !exists {
-		userID = 1 // Default admin user
+	db := config.DB
+	previous := defaultAcademicRules()
+	db.Order("version DESC, id DESC").Limit(1).Find(&previous)
+	next := previous
+	next.ID = 0
+	next.Version = previous.Version + 1
+	if input.Rules != nil {
+		next.Rules = strings.TrimSpace(*input.Rules)
+	}
+	if input.MinAttendancePercent != nil {
+		next.MinAttendancePercent = *input.MinAttendancePercent
+	}
+	if input.CondonationMinPercent != nil {
+		next.CondonationMinPercent = *input.CondonationMinPercent
+	}
+	if input.InternalWeightage != nil {
+		next.InternalWeightage = *input.InternalWeightage
+	}
+	if input.MaxReappearSubjects != nil {
+		next.MaxReappearSubjects = *input.MaxReappearSubjects
+	}
+	effective, err := optDate("effective_from", input.EffectiveFrom)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	next.EffectiveFrom = effective
+
+	switch {
+	case next.MinAttendancePercent < 0 || next.MinAttendancePercent > 100:
+		err = errors.New("min_attendance_percent must be between 0 and 100")
+	case next.CondonationMinPercent < 0 || next.CondonationMinPercent > next.MinAttendancePercent:
+		err = errors.New("condonation_min_percent must be between 0 and min_attendance_percent")
+	case next.InternalWeightage < 0 || next.InternalWeightage > 100:
+		err = errors.New("internal_weightage must be between 0 and 100")
+	case next.MaxReappearSubjects < 0:
+		err = errors.New("max_reappear_subjects cannot be negative")
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 
-	// Check if record exists
-	var count int64
-	db.Raw("SELECT COUNT(*) FROM academic_rules").Scan(&count)
-
-	if count == 0 {
-		// Insert new record
-		err := db.Exec("INSERT INTO academic_rules (rules, updated_by, updated_at) VALUES (?, ?, ?)",
-			input.Rules, userID, time.Now()).Error
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create academic rules"})
-			return
-		}
-	} else {
-		// Update existing record
-		err := db.Exec("UPDATE academic_rules SET rules = ?, updated_by = ?, updated_at = ? WHERE id = (SELECT MIN(id) FROM academic_rules)",
-			input.Rules, userID, time.Now()).Error
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update academic rules"})
-			return
-		}
+	next.UpdatedAt = time.Now()
+	next.UpdatedBy = nil
+	if uid := c.GetInt64("user_id"); uid != 0 {
+		next.UpdatedBy = &uid
 	}
+	if err := db.Create(&next).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update academic rules"})
+		return
+	}
+
+	middleware.Audit(c, "academic_rules.update", "academic_rules", strconv.Itoa(next.Version), previous, next)
 
-	c.JSON(http.StatusOK, gin.H{"message": "Academic rules updated successfully", "rules": input.Rules})
+	c.JSON(http.StatusOK, gin.H{"message": "Academic rules updated successfully", "rules": next.Rules, "academic_rules": next})
 }
@@ -0,0 +1,550 @@
+package controllers
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/kiranraoboinapally/student/backend/internal/config"
+	"github.com/kiranraoboinapally/student/backend/internal/middleware"
+	"github.com/kiranraoboinapally/student/backend/internal/models"
+)
+
+// ======================== ATTENDANCE ELIGIBILITY & CONDONATION ========================
+
+// Attendance standing of a student in a subject
+const (
+	EligibilityEligible   = "eligible"
+	EligibilityCondonable = "condonable" // short, but within the condonation band
+	EligibilityCondoned   = "condoned"   // short, excused by an approved condonation
+	EligibilityDetained   = "detained"
+)
+
+// Condonation request statuses
+const (
+	CondonationPending  = "pending"
+	CondonationApproved = "approved"
+	CondonationRejected = "rejected"
+)
+
+// subjectEligibility is a student's attendance standing in one subject
+type subjectEligibility struct {
+	SubjectCode       string   `json:"subject_code"`
+	SubjectName       string   `json:"subject_name"`
+	Held              int64    `json:"classes_held"`
+	Attended          int64    `json:"classes_attended"`
+	Percent           *float64 `json:"attendance_percent"` // nil when no attendance has been recorded
+	Status            string   `json:"status"`
+	CondonationID     *int64   `json:"condonation_id,omitempty"`
+	CondonationStatus string   `json:"condonation_status,omitempty"`
+}
+
+// percentLabel formats a percentage without trailing zeros, e.g. "75%" or "62.5%"
+func percentLabel(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64) + "%"
+}
+
+// classifyAttendance applies the rules to a subject's attendance. Subjects with nothing recorded
+// are not held against the student; a rejected condonation leaves the student detained.
+func classifyAttendance(rules models.AcademicRuleSet, pct *float64, condonation *models.AttendanceCondonation) string {
+	switch {
+	case pct == nil || *pct >= rules.MinAttendancePercent:
+		return EligibilityEligible
+	case condonation != nil && condonation.Status == CondonationApproved:
+		return EligibilityCondoned
+	case condonation != nil && condonation.Status == CondonationRejected:
+		return EligibilityDetained
+	case *pct >= rules.CondonationMinPercent:
+		return EligibilityCondonable
+	}
+	return EligibilityDetained
+}
+
+// attendanceEligibility works out each student's standing in every subject of a course semester
+// from subject-wise attendance. Students whose attendance is only recorded without a subject are
+// judged on that overall figure in every subject.
+func attendanceEligibility(db *gorm.DB, rules models.AcademicRuleSet, course string, semester int, enrollments []int64) (map[int64][]subjectEligibility, error) {
+	out := map[int64][]subjectEligibility{}
+	if len(enrollments) == 0 {
+		return out, nil
+	}
+	var subjects []models.SubjectMaster
+	if err := db.Where("course_name = ? AND semester = ?", course, semester).Order("subject_code").Find(&subjects).Error; err != nil {
+		return nil, err
+	}
+	codes := make([]string, len(subjects))
+	for i, s := range subjects {
+		codes[i] = s.SubjectCode
+	}
+
+	var rows []struct {
+		EnrollmentNumber int64
+		SubjectCode      string
+		Held             int64
+		Attended         int64
+	}
+	if err := db.Model(&models.Attendance{}).
+		Select("enrollment_number, COALESCE(subject_code, '') AS subject_code, COUNT(*) AS held, "+
+			"COALESCE(SUM(CASE WHEN present THEN 1 ELSE 0 END), 0) AS attended").
+		Where("enrollment_number IN ? AND (subject_code IN ? OR subject_code IS NULL OR subject_code = '')", enrollments, codes).
+		Group("enrollment_number, COALESCE(subject_code, '')").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	type tally struct{ held, attended int64 }
+	tallies := map[int64]map[string]tally{}
+	for _, r := range rows {
+		if tallies[r.EnrollmentNumber] == nil {
+			tallies[r.EnrollmentNumber] = map[string]tally{}
+		}
+		tallies[r.EnrollmentNumber][r.SubjectCode] = tally{r.Held, r.Attended}
+	}
+
+	var condonations []models.AttendanceCondonation
+	if err := db.Where("enrollment_number IN ? AND semester = ?", enrollments, semester).Find(&condonations).Error; err != nil {
+		return nil, err
+	}
+	condonationOf := map[string]*models.AttendanceCondonation{}
+	for i := range condonations {
+		cd := &condonations[i]
+		condonationOf[strconv.FormatInt(cd.EnrollmentNumber, 10)+"\x00"+cd.SubjectCode] = cd
+	}
+
+	for _, e := range enrollments {
+		mine := tallies[e]
+		subjectWise := false
+		for code := range mine {
+			if code != "" {
+				subjectWise = true
+			}
+		}
+		list := make([]subjectEligibility, 0, len(subjects))
+		for _, s := range subjects {
+			t := mine[s.SubjectCode]
+			if !subjectWise {
+				t = mine[""]
+			}
+			se := subjectEligibility{SubjectCode: s.SubjectCode, SubjectName: s.SubjectName, Held: t.held, Attended: t.attended}
+			if t.held > 0 {
+				pct := math.Round(float64(t.attended)*10000/float64(t.held)) / 100
+				se.Percent = &pct
+			}
+			cd := condonationOf[strconv.FormatInt(e, 10)+"\x00"+s.SubjectCode]
+			if cd != nil {
+				se.CondonationID = &cd.CondonationID
+				se.CondonationStatus = cd.Status
+			}
+			se.Status = classifyAttendance(rules, se.Percent, cd)
+			list = append(list, se)
+		}
+		out[e] = list
+	}
+	return out, nil
+}
+
+// eligibilitySummary rolls a student's subjects up: detained if any subject is short beyond
+// condonation, condonable if a shortfall can still be condoned, otherwise eligible
+func eligibilitySummary(subjects []subjectEligibility) string {
+	status := EligibilityEligible
+	for _, s := range subjects {
+		if s.Status == EligibilityDetained {
+			return EligibilityDetained
+		}
+		if s.Status == EligibilityCondonable {
+			status = EligibilityCondonable
+		}
+	}
+	return status
+}
+
+// GetMyEligibility shows the caller's attendance standing in each subject of their current
+// semester under the academic rules in effect, with their condonation requests
+func GetMyEligibility(c *gin.Context) {
+	enrollment, err := getEnrollmentOrError(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	db := config.DB
+	var student models.MasterStudent
+	if err := db.Where("enrollment_number = ?", enrollment).First(&student).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "student not found"})
+		return
+	}
+	rules := currentAcademicRules(db)
+	semester := regularSemesters(db, []int64{enrollment})[enrollment]
+	standing, err := attendanceEligibility(db, rules, safeString(student.CourseName), semester, []int64{enrollment})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load attendance"})
+		return
+	}
+	condonations := []models.AttendanceCondonation{}
+	db.Where("enrollment_number = ?", enrollment).Order("condonation_id DESC").Find(&condonations)
+	c.JSON(http.StatusOK, gin.H{
+		"semester":     semester,
+		"status":       eligibilitySummary(standing[enrollment]),
+		"subjects":     standing[enrollment],
+		"rules":        rules,
+		"condonations": condonations,
+	})
+}
+
+// ApplyForCondonation asks the institute to condone a shortfall in a subject of the caller's
+// current semester. Only attendance within the condonation band qualifies, once per subject.
+func ApplyForCondonation(c *gin.Context) {
+	enrollment, err := getEnrollmentOrError(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	var req struct {
+		SubjectCode string `json:"subject_code" binding:"required"`
+		Reason      string `json:"reason" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	db := config.DB
+	var student models.MasterStudent
+	if err := db.Where("enrollment_number = ?", enrollment).First(&student).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "student not found"})
+		return
+	}
+	rules := currentAcademicRules(db)
+	course := safeString(student.CourseName)
+	semester := regularSemesters(db, []int64{enrollment})[enrollment]
+	standing, err := attendanceEligibility(db, rules, course, semester, []int64{enrollment})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load attendance"})
+		return
+	}
+	var subject *subjectEligibility
+	for i, s := range standing[enrollment] {
+		if s.SubjectCode == req.SubjectCode {
+			subject = &standing[enrollment][i]
+		}
+	}
+	switch {
+	case subject == nil:
+		c.JSON(http.StatusBadRequest, gin.H{"error": req.SubjectCode + " is not a subject of your current semester"})
+		return
+	case subject.CondonationID != nil:
+		c.JSON(http.StatusConflict, gin.H{"error": "a condonation request for " + req.SubjectCode + " has already been made"})
+		return
+	case subject.Status == EligibilityEligible:
+		c.JSON(http.StatusConflict, gin.H{"error": "your attendance in " + req.SubjectCode + " meets the requirement"})
+		return
+	case subject.Status == EligibilityDetained:
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("attendance below %s cannot be condoned", percentLabel(rules.CondonationMinPercent))})
+		return
+	}
+
+	var uid *int64
+	if id := c.GetInt64("user_id"); id != 0 {
+		uid = &id
+	}
+	request := models.AttendanceCondonation{
+		EnrollmentNumber:  enrollment,
+		Semester:          semester,
+		SubjectCode:       req.SubjectCode,
+		InstituteID:       studentInstitute(db, enrollment).InstituteID,
+		CourseName:        course,
+		AttendancePercent: *subject.Percent,
+		RulesVersion:      rules.Version,
+		Reason:            strings.TrimSpace(req.Reason),
+		Status:            CondonationPending,
+		RequestedBy:       uid,
+		CreatedAt:         time.Now(),
+	}
+	if err := db.Create(&request).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to submit condonation request"})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "condonation request submitted", "condonation": request})
+}
+
+// condonationView is a condonation request with the student's name
+type condonationView struct {
+	models.AttendanceCondonation
+	StudentName string `json:"student_name"`
+}
+
+// GetInstituteCondonations lists the condonation requests of the caller's institute
+func GetInstituteCondonations(c *gin.Context) {
+	instituteID, _ := c.Get("institute_id")
+	instID := instituteID.(int)
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 500 {
+		limit = 50
+	}
+
+	db := config.DB
+	q := db.Model(&models.AttendanceCondonation{}).Where("institute_id = ?", instID)
+	if status := c.Query("status"); status != "" {
+		q = q.Where("status = ?", status)
+	}
+	if semester := c.Query("semester"); semester != "" {
+		q = q.Where("semester = ?", semester)
+	}
+	var total int64
+	q.Count(&total)
+	var requests []models.AttendanceCondonation
+	if err := q.Order("condonation_id DESC").Limit(limit).Offset((page - 1) * limit).Find(&requests).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load condonation requests"})
+		return
+	}
+
+	names := map[int64]string{}
+	if len(requests) > 0 {
+		enrollments := make([]int64, len(requests))
+		for i, r := range requests {
+			enrollments[i] = r.EnrollmentNumber
+		}
+		var students []models.MasterStudent
+		db.Select("enrollment_number, student_name").Where("enrollment_number IN ?", enrollments).Find(&students)
+		for _, s := range students {
+			names[s.EnrollmentNumber] = s.StudentName
+		}
+	}
+	out := make([]condonationView, len(requests))
+	for i, r := range requests {
+		out[i] = condonationView{AttendanceCondonation: r, StudentName: names[r.EnrollmentNumber]}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"condonations": out,
+		"pagination": gin.H{
+			"page":        page,
+			"limit":       limit,
+			"total":       total,
+			"total_pages": (total + int64(limit) - 1) / int64(limit),
+		},
+	})
+}
+
+// ReviewCondonation approves or rejects a pending condonation request of the caller's institute
+func ReviewCondonation(c *gin.Context) {
+	instituteID, _ := c.Get("institute_id")
+	instID := instituteID.(int)
+
+	var req struct {
+		Approve *bool  `json:"approve" binding:"required"`
+		Remarks string `json:"remarks"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	db := config.DB
+	var request models.AttendanceCondonation
+	if err := db.Where("condonation_id = ? AND institute_id = ?", c.Param("id"), instID).First(&request).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "condonation request not found"})
+		return
+	}
+	if request.Status != CondonationPending {
+		c.JSON(http.StatusConflict, gin.H{"error": "this request has already been " + request.Status})
+		return
+	}
+	if !*req.Approve && strings.TrimSpace(req.Remarks) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "remarks are required when rejecting"})
+		return
+	}
+
+	before := request
+	now := time.Now()
+	request.Status = CondonationRejected
+	if *req.Approve {
+		request.Status = CondonationApproved
+	}
+	request.ReviewedAt = &now
+	if uid := c.GetInt64("user_id"); uid != 0 {
+		request.ReviewedBy = &uid
+	}
+	request.ReviewRemarks = nil
+	if remarks := strings.TrimSpace(req.Remarks); remarks != "" {
+		request.ReviewRemarks = &remarks
+	}
+
+	var student models.MasterStudent
+	db.Where("enrollment_number = ?", request.EnrollmentNumber).Limit(1).Find(&student)
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&request).Error; err != nil {
+			return err
+		}
+		if email := safeString(student.StudentEmailID); email != "" {
+			subject, body := condonationOutcomeEmail(student.StudentName, request.SubjectCode, request.Semester, *req.Approve, safeString(request.ReviewRemarks))
+			return QueueEmail(tx, email, subject, body)
+		}
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save review"})
+		return
+	}
+	middleware.Audit(c, "attendance.condonation.review", "attendance_condonations", strconv.FormatInt(request.CondonationID, 10), before, request)
+	c.JSON(http.StatusOK, gin.H{"message": "condonation request " + request.Status, "condonation": request})
+}
+
+// eligibilityRow is one student's standing over the subjects of a semester
+type eligibilityRow struct {
+	EnrollmentNumber int64                `json:"enrollment_number"`
+	StudentName      string               `json:"student_name"`
+	InstituteName    string               `json:"institute_name"`
+	Status           string               `json:"status"`
+	Subjects         []subjectEligibility `json:"subjects"`
+}
+
+// sendEligibilityList lists the attendance standing of the students of a course in a semester,
+// optionally for one institute and one overall status, as JSON or exported with format=csv|xlsx
+func sendEligibilityList(c *gin.Context, instituteID *int) {
+	course := strings.TrimSpace(c.Query("course_name"))
+	semester, err := strconv.Atoi(c.Query("semester"))
+	if course == "" || err != nil || semester < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "course_name and semester are required"})
+		return
+	}
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "csv" && format != "xlsx" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be json, csv or xlsx"})
+		return
+	}
+
+	db := config.DB
+	q := db.Where("course_name = ?", course)
+	if instituteID != nil {
+		q = q.Where("institute_id = ?", *instituteID)
+	}
+	var students []models.MasterStudent
+	if err := q.Order("enrollment_number").Find(&students).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load students"})
+		return
+	}
+	enrollments := make([]int64, len(students))
+	for i, s := range students {
+		enrollments[i] = s.EnrollmentNumber
+	}
+	current := regularSemesters(db, enrollments)
+	var inSemester []int64
+	for _, e := range enrollments {
+		if current[e] == semester {
+			inSemester = append(inSemester, e)
+		}
+	}
+	rules := currentAcademicRules(db)
+	standing, err := attendanceEligibility(db, rules, course, semester, inSemester)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load attendance"})
+		return
+	}
+
+	summary := map[string]int{EligibilityEligible: 0, EligibilityCondonable: 0, EligibilityDetained: 0}
+	want := c.Query("status")
+	rows := []eligibilityRow{}
+	for _, s := range students {
+		subjects, ok := standing[s.EnrollmentNumber]
+		if !ok {
+			continue
+		}
+		status := eligibilitySummary(subjects)
+		summary[status]++
+		if want != "" && status != want {
+			continue
+		}
+		rows = append(rows, eligibilityRow{
+			EnrollmentNumber: s.EnrollmentNumber, StudentName: s.StudentName,
+			InstituteName: safeString(s.InstituteName), Status: status, Subjects: subjects,
+		})
+	}
+
+	if format != "json" {
+		var out [][]interface{}
+		for _, r := range rows {
+			for _, s := range r.Subjects {
+				pct := ""
+				if s.Percent != nil {
+					pct = strconv.FormatFloat(*s.Percent, 'f', 2, 64)
+				}
+				out = append(out, []interface{}{r.EnrollmentNumber, r.StudentName, r.InstituteName, s.SubjectCode, s.SubjectName,
+					s.Held, s.Attended, pct, s.Status, s.CondonationStatus, r.Status})
+			}
+		}
+		sendReport(c, format, "attendance-eligibility", []string{"Enrollment Number", "Student Name", "Institute", "Subject Code",
+			"Subject Name", "Classes Held", "Classes Attended", "Attendance %", "Subject Status", "Condonation", "Student Status"}, out)
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 500 {
+		limit = 50
+	}
+	total := len(rows)
+	start, end := (page-1)*limit, page*limit
+	if start > total {
+		start = total
+	}
+	if end > total {
+		end = total
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"rules":    rules,
+		"summary":  summary,
+		"students": rows[start:end],
+		"pagination": gin.H{
+			"page":        page,
+			"limit":       limit,
+			"total":       total,
+			"total_pages": (total + limit - 1) / limit,
+		},
+	})
+}
+
+// GetEligibilityList is the exam cell's attendance eligibility list across institutes
+func GetEligibilityList(c *gin.Context) {
+	var instituteID *int
+	if v := c.Query("institute_id"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid institute_id"})
+			return
+		}
+		instituteID = &id
+	}
+	sendEligibilityList(c, instituteID)
+}
+
+// GetInstituteEligibilityList is the attendance eligibility list of the caller's institute
+func GetInstituteEligibilityList(c *gin.Context) {
+	instituteID, _ := c.Get("institute_id")
+	instID := instituteID.(int)
+	sendEligibilityList(c, &instID)
+}
+
+// condonationOutcomeEmail tells a student whether an attendance condonation was approved
+func condonationOutcomeEmail(fullName, subjectCode string, semester int, approved bool, remarks string) (string, string) {
+	body := fmt.Sprintf("Hello %s,\n\nYour request to condone the attendance shortfall in %s (semester %d) has been ", fullName, subjectCode, semester)
+	if approved {
+		body += "approved. You are eligible to sit the examination in this subject.\n"
+	} else {
+		body += "rejected. You are not eligible to sit the examination in this subject.\n"
+	}
+	if remarks != "" {
+		body += "\nRemarks: " + remarks + "\n"
+	}
+	body += fmt.Sprintf("\nYou can see your attendance standing at %s.\n", config.AppBaseURL)
+	return "Attendance condonation for " + subjectCode, body
+}
@@ -0,0 +1,188 @@
+package controllers
+
+import (
+	"encoding/csv"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/kiranraoboinapally/student/backend/internal/config"
+	"github.com/kiranraoboinapally/student/backend/internal/models"
+)
+
+// ======================== AUDIT LOG ========================
+
+// maxAuditExportRows bounds a single CSV export
+const maxAuditExportRows = 100000
+
+// auditLogQuery applies the filters shared by the list and export endpoints
+func auditLogQuery(c *gin.Context) (*gorm.DB, bool) {
+	query := config.DB.Model(&models.AuditLog{})
+
+	if v := c.Query("actor_user_id"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid actor_user_id"})
+			return nil, false
+		}
+		query = query.Where("actor_user_id = ?", id)
+	}
+	if v := c.Query("institute_id"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid institute_id"})
+			return nil, false
+		}
+		query = query.Where("institute_id = ?", id)
+	}
+	if v := c.Query("role_id"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid role_id"})
+			return nil, false
+		}
+		query = query.Where("role_id = ?", id)
+	}
+	if v := c.Query("action"); v != "" {
+		query = query.Where("action = ?", v)
+	}
+	if v := c.Query("entity_type"); v != "" {
+		query = query.Where("entity_type = ?", v)
+	}
+	if v := c.Query("entity_id"); v != "" {
+		query = query.Where("entity_id = ?", v)
+	}
+	if v := c.Query("request_id"); v != "" {
+		query = query.Where("request_id = ?", v)
+	}
+	if v := c.Query("from"); v != "" {
+		t, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "from must be YYYY-MM-DD"})
+			return nil, false
+		}
+		query = query.Where("created_at >= ?", t)
+	}
+	if v := c.Query("to"); v != "" {
+		t, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "to must be YYYY-MM-DD"})
+			return nil, false
+		}
+		query = query.Where("created_at < ?", t.AddDate(0, 0, 1))
+	}
+	return query, true
+}
+
+// GetAuditLog lists audit entries, newest first, with filters and pagination
+func GetAuditLog(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 500 {
+		limit = 50
+	}
+
+	query, ok := auditLogQuery(c)
+	if !ok {
+		return
+	}
+
+	var total int64
+	query.Count(&total)
+
+	entries := []models.AuditLog{}
+	if err := query.Order("audit_id DESC").Limit(limit).Offset((page - 1) * limit).Find(&entries).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch audit log"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"entries": entries,
+		"pagination": gin.H{
+			"page":        page,
+			"limit":       limit,
+			"total":       total,
+			"total_pages": (total + int64(limit) - 1) / int64(limit),
+		},
+	})
+}
+
+// ExportAuditLog streams the filtered audit log as CSV
+func ExportAuditLog(c *gin.Context) {
+	query, ok := auditLogQuery(c)
+	if !ok {
+		return
+	}
+
+	rows, err := query.Order("audit_id ASC").Limit(maxAuditExportRows).Rows()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to export audit log"})
+		return
+	}
+	defer rows.Close()
+
+	filename := "audit-log-" + time.Now().Format("20060102-150405") + ".csv"
+	c.Header("Content-Type", "text/csv")
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+
+	w := csv.NewWriter(c.Writer)
+	w.Write([]string{
+		"audit_id", "created_at", "actor_user_id", "actor_username", "role_id", "institute_id",
+		"action", "entity_type", "entity_id", "changes", "before", "after",
+		"method", "path", "status_code", "client_ip", "request_id",
+	})
+
+	for rows.Next() {
+		var e models.AuditLog
+		if err := config.DB.ScanRows(rows, &e); err != nil {
+			break
+		}
+		w.Write([]string{
+			strconv.FormatInt(e.AuditID, 10),
+			e.CreatedAt.Format(time.RFC3339),
+			optInt64(e.ActorUserID),
+			e.ActorUsername,
+			optInt(e.RoleID),
+			optInt(e.InstituteID),
+			e.Action,
+			e.EntityType,
+			e.EntityID,
+			optString(e.Changes),
+			optString(e.Before),
+			optString(e.After),
+			e.Method,
+			e.Path,
+			strconv.Itoa(e.StatusCode),
+			e.ClientIP,
+			e.RequestID,
+		})
+	}
+	w.Flush()
+}
+
+func optInt64(v *int64) string {
+	if v == nil {
+		return ""
+	}
+	return strconv.FormatInt(*v, 10)
+}
+
+func optInt(v *int) string {
+	if v == nil {
+		return ""
+	}
+	return strconv.Itoa(*v)
+}
+
+func optString(v *string) string {
+	if v == nil {
+		return ""
+	}
+	return *v
+}
@@ -1,14 +1,11 @@
 package controllers
 
 import (
-	"crypto/rand"
-	"encoding/hex"
 	"net/http"
 	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
 
 	"github.com/kiranraoboinapally/student/backend/internal/config"
 	"github.com/kiranraoboinapally/student/backend/internal/models"
@@ -92,9 +89,21 @@ func Login(c *gin.Context) {
 	}
 
 	db := config.DB
-	var user models.User
+	ip := c.ClientIP()
+
+	if wait, locked := throttleLockedFor(db, throttleLoginUser, req.Username); locked {
+		respondLocked(c, wait)
+		return
+	}
+	if wait, locked := throttleLockedFor(db, throttleLoginIP, ip); locked {
+		respondLocked(c, wait)
+		return
+	}
 
+	var user models.User
 	if err := db.Where("username = ?", req.Username).First(&user).Error; err != nil {
+		throttleFailure(c, db, throttleLoginUser, req.Username)
+		throttleFailure(c, db, throttleLoginIP, ip)
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid credentials"})
 		return
 	}
@@ -105,18 +114,28 @@ func Login(c *gin.Context) {
 	}
 
 	if !utils.CheckPasswordHash(user.PasswordHash, req.Password) {
+		throttleFailure(c, db, throttleLoginUser, req.Username)
+		throttleFailure(c, db, throttleLoginIP, ip)
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid credentials"})
 		return
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"user_id":  user.UserID,
-		"username": user.Username,
-		"role_id":  user.RoleID,
-		"exp":      time.Now().Add(time.Hour * time.Duration(config.JwtExpiresHours)).Unix(),
-	})
+	// Only the username counter is cleared; the IP keeps its history so one valid
+	// account cannot be used to reset an attacker's budget
+	throttleReset(db, throttleLoginUser, req.Username)
 
-	tokenStr, err := token.SignedString([]byte(config.JwtSecret))
+	// Accounts with a second factor only get an MFA-pending token here
+	challenge, err := mfaChallenge(db, &user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "token creation failed"})
+		return
+	}
+	if challenge != nil {
+		c.JSON(http.StatusOK, challenge)
+		return
+	}
+
+	session, _, err := issueSession(c, db, user)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "token creation failed"})
 		return
@@ -124,12 +143,102 @@ func Login(c *gin.Context) {
 
 	db.Model(&user).Update("last_login", time.Now())
 
-	c.JSON(http.StatusOK, gin.H{
-		"token":                 tokenStr,
-		"expires_in_hours":      config.JwtExpiresHours,
-		"force_password_change": user.IsTempPassword,
-		"role_id":               user.RoleID,
-	})
+	session["force_password_change"] = user.IsTempPassword
+	session["role_id"] = user.RoleID
+	c.JSON(http.StatusOK, session)
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// Refresh rotates a refresh token and returns a new access/refresh pair
+func Refresh(c *gin.Context) {
+	var req RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	db := config.DB
+	var current models.RefreshToken
+	if err := db.Where("token_hash = ?", utils.HashToken(req.RefreshToken)).First(&current).Error; err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid refresh token"})
+		return
+	}
+
+	// A rotated token being presented again means it leaked: kill every session
+	if current.RevokedAt != nil {
+		if current.ReplacedBy != nil {
+			RevokeUserSessions(db, current.UserID)
+		}
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "refresh token revoked"})
+		return
+	}
+	if time.Now().After(current.ExpiresAt) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "refresh token expired"})
+		return
+	}
+
+	var user models.User
+	if err := db.Where("user_id = ?", current.UserID).First(&user).Error; err != nil || user.Status != "active" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "account not active"})
+		return
+	}
+
+	tx := db.Begin()
+	session, next, err := issueSession(c, tx, user)
+	if err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "token creation failed"})
+		return
+	}
+	// Guard against two concurrent refreshes of the same token
+	result := tx.Model(&models.RefreshToken{}).
+		Where("id = ? AND revoked_at IS NULL", current.ID).
+		Updates(map[string]interface{}{"revoked_at": time.Now(), "replaced_by": next.ID})
+	if result.Error != nil || result.RowsAffected == 0 {
+		tx.Rollback()
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "refresh token revoked"})
+		return
+	}
+	tx.Commit()
+
+	session["force_password_change"] = user.IsTempPassword
+	session["role_id"] = user.RoleID
+	c.JSON(http.StatusOK, session)
+}
+
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// Logout revokes the presented refresh token and the access token used for the call
+func Logout(c *gin.Context) {
+	var req LogoutRequest
+	_ = c.ShouldBindJSON(&req)
+
+	userID := c.GetInt64("user_id")
+	db := config.DB
+
+	if req.RefreshToken != "" {
+		db.Model(&models.RefreshToken{}).
+			Where("token_hash = ? AND user_id = ? AND revoked_at IS NULL", utils.HashToken(req.RefreshToken), userID).
+			Update("revoked_at", time.Now())
+	}
+	revokeAccessToken(c, db, userID)
+
+	c.JSON(http.StatusOK, gin.H{"message": "logged out"})
+}
+
+// LogoutAll revokes every session of the current user on every device
+func LogoutAll(c *gin.Context) {
+	userID := c.GetInt64("user_id")
+	if err := RevokeUserSessions(config.DB, userID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to revoke sessions"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "all sessions revoked"})
 }
 
 type ChangePasswordRequest struct {
@@ -169,14 +278,36 @@ func ChangePassword(c *gin.Context) {
 		return
 	}
 
-	if err := config.DB.Model(&models.User{}).
+	db := config.DB
+	tx := db.Begin()
+	if err := tx.Model(&models.User{}).
 		Where("user_id = ?", userID).
 		Updates(map[string]interface{}{"password_hash": hashed, "is_temp_password": false}).Error; err != nil {
+		tx.Rollback()
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "password update failed"})
 		return
 	}
+	if err := RevokeUserSessions(tx, userID); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "password update failed"})
+		return
+	}
+	tx.Commit()
 
-	c.JSON(http.StatusOK, gin.H{"message": "password changed successfully"})
+	// Every other session is gone; hand the caller a fresh one so they stay signed in
+	var user models.User
+	if err := db.Where("user_id = ?", userID).First(&user).Error; err != nil {
+		c.JSON(http.StatusOK, gin.H{"message": "password changed successfully"})
+		return
+	}
+	session, _, err := issueSession(c, db, user)
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{"message": "password changed successfully"})
+		return
+	}
+	session["message"] = "password changed successfully"
+	session["role_id"] = user.RoleID
+	c.JSON(http.StatusOK, session)
 }
 
 type ForgotPasswordRequest struct {
@@ -197,15 +328,26 @@ func ForgotPassword(c *gin.Context) {
 		return
 	}
 
-	tokenBytes := make([]byte, 32)
-	rand.Read(tokenBytes)
-	token := hex.EncodeToString(tokenBytes)
+	token, err := utils.RandomToken(32)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create reset token"})
+		return
+	}
 	expiresAt := time.Now().Add(1 * time.Hour)
 
-	db.Exec("UPDATE password_reset_tokens SET used = TRUE WHERE user_id = ?", user.UserID)
-	db.Table("password_reset_tokens").Create(map[string]interface{}{"user_id": user.UserID, "email": req.Email, "token": token, "expires_at": expiresAt, "used": false})
+	// The token only ever leaves the server inside the email
+	subject, body := passwordResetEmail(user.FullName, token)
+	tx := db.Begin()
+	tx.Exec("UPDATE password_reset_tokens SET used = TRUE WHERE user_id = ?", user.UserID)
+	tx.Table("password_reset_tokens").Create(map[string]interface{}{"user_id": user.UserID, "email": req.Email, "token": token, "expires_at": expiresAt, "used": false})
+	if err := QueueEmail(tx, user.Email, subject, body); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to send reset link"})
+		return
+	}
+	tx.Commit()
 
-	c.JSON(http.StatusOK, gin.H{"message": "if email exists, reset link will be sent", "token": token})
+	c.JSON(http.StatusOK, gin.H{"message": "if email exists, reset link will be sent"})
 }
 
 type ResetPasswordRequest struct {
@@ -221,6 +363,12 @@ func ResetPassword(c *gin.Context) {
 	}
 
 	db := config.DB
+	ip := c.ClientIP()
+	if wait, locked := throttleLockedFor(db, throttleResetIP, ip); locked {
+		respondLocked(c, wait)
+		return
+	}
+
 	var reset struct {
 		ID        int
 		UserID    int64
@@ -228,6 +376,7 @@ func ResetPassword(c *gin.Context) {
 		Used      bool
 	}
 	if err := db.Table("password_reset_tokens").Where("token = ? AND used = FALSE", req.Token).First(&reset).Error; err != nil {
+		throttleFailure(c, db, throttleResetIP, ip)
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid or expired token"})
 		return
 	}
@@ -240,6 +389,11 @@ func ResetPassword(c *gin.Context) {
 	tx := db.Begin()
 	tx.Model(&models.User{}).Where("user_id = ?", reset.UserID).Update("password_hash", hashed)
 	tx.Table("password_reset_tokens").Where("id = ?", reset.ID).Update("used", true)
+	if err := RevokeUserSessions(tx, reset.UserID); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "password reset failed"})
+		return
+	}
 	tx.Commit()
 
 	c.JSON(http.StatusOK, gin.H{"message": "password reset successful"})
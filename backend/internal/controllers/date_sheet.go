@@ -0,0 +1,323 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/kiranraoboinapally/student/backend/internal/config"
+	"github.com/kiranraoboinapally/student/backend/internal/middleware"
+	"github.com/kiranraoboinapally/student/backend/internal/models"
+)
+
+// ======================== DATE SHEET ========================
+
+// Clash kinds
+const (
+	ClashCourse  = "course"  // two papers of one course and semester overlap
+	ClashBacklog = "backlog" // a backlog student's reappear paper overlaps another of their papers
+)
+
+// clockMinutes parses HH:MM into minutes after midnight
+func clockMinutes(s string) (int, bool) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, false
+	}
+	return t.Hour()*60 + t.Minute(), true
+}
+
+// papersOverlap reports whether two papers are on the same day with overlapping times
+func papersOverlap(a, b models.DateSheetEntry) bool {
+	if !a.ExamDate.Equal(b.ExamDate) {
+		return false
+	}
+	as, _ := clockMinutes(a.StartTime)
+	ae, _ := clockMinutes(a.EndTime)
+	bs, _ := clockMinutes(b.StartTime)
+	be, _ := clockMinutes(b.EndTime)
+	return as < be && bs < ae
+}
+
+// examClash is two overlapping papers and who would have to sit both
+type examClash struct {
+	Kind             string `json:"kind"`
+	EnrollmentNumber int64  `json:"enrollment_number,omitempty"`
+	CourseName       string `json:"course_name"`
+	Semester         int    `json:"semester,omitempty"`
+	SubjectA         string `json:"subject_a"`
+	SubjectB         string `json:"subject_b"`
+	ExamDate         string `json:"exam_date"`
+}
+
+// regularSemesters is the semester each student sits regular papers for: the one after their latest
+// semester result (or published marks), or 1 for students with neither
+func regularSemesters(db *gorm.DB, enrollments []int64) map[int64]int {
+	out := map[int64]int{}
+	if len(enrollments) == 0 {
+		return out
+	}
+	var rows []struct {
+		EnrollmentNumber int64
+		Semester         int
+	}
+	db.Raw(`SELECT enrollment_number, MAX(semester) AS semester FROM (
+			SELECT enrollment_number, semester FROM semester_results WHERE enrollment_number IN ?
+			UNION ALL
+			SELECT enrollment_number, semester FROM student_marks WHERE enrollment_number IN ?
+		) s GROUP BY enrollment_number`, enrollments, enrollments).Scan(&rows)
+	for _, e := range enrollments {
+		out[e] = 1
+	}
+	for _, r := range rows {
+		out[r.EnrollmentNumber] = r.Semester + 1
+	}
+	return out
+}
+
+// backlogCandidate is a student with reappear papers in a session
+type backlogCandidate struct {
+	EnrollmentNumber int64
+	CourseName       string
+	Subjects         []string
+}
+
+// sessionBacklogCandidates loads the students registered (locked or not) for reappear papers
+func sessionBacklogCandidates(db *gorm.DB, sessionID int) ([]backlogCandidate, error) {
+	var rows []struct {
+		EnrollmentNo int64
+		SubjectCode  string
+		CourseName   string
+	}
+	if err := db.Table("reappear_students r").
+		Select("r.enrollment_no, r.subject_code, COALESCE(s.course_name, '') AS course_name").
+		Joins("LEFT JOIN master_students s ON s.enrollment_number = r.enrollment_no").
+		Where("r.exam_session_id = ?", sessionID).Order("r.enrollment_no, r.subject_code").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	var out []backlogCandidate
+	for _, r := range rows {
+		if n := len(out); n > 0 && out[n-1].EnrollmentNumber == r.EnrollmentNo {
+			out[n-1].Subjects = append(out[n-1].Subjects, r.SubjectCode)
+			continue
+		}
+		out = append(out, backlogCandidate{EnrollmentNumber: r.EnrollmentNo, CourseName: r.CourseName, Subjects: []string{r.SubjectCode}})
+	}
+	return out, nil
+}
+
+// dateSheetClashes checks a session's date sheet: papers of one course and semester must not
+// overlap, and no backlog student may have a reappear paper overlapping their regular papers or
+// another reappear paper. It also lists reappear subjects with no paper scheduled yet.
+func dateSheetClashes(db *gorm.DB, sessionID int, entries []models.DateSheetEntry) ([]examClash, []string, error) {
+	clashes := []examClash{}
+	byCourseSem := map[string][]models.DateSheetEntry{}
+	byCourseSubject := map[string]models.DateSheetEntry{}
+	for _, e := range entries {
+		key := e.CourseName + "\x00" + strconv.Itoa(e.Semester)
+		byCourseSem[key] = append(byCourseSem[key], e)
+		byCourseSubject[e.CourseName+"\x00"+e.SubjectCode] = e
+	}
+	for _, list := range byCourseSem {
+		for i := range list {
+			for j := i + 1; j < len(list); j++ {
+				if papersOverlap(list[i], list[j]) {
+					clashes = append(clashes, examClash{
+						Kind: ClashCourse, CourseName: list[i].CourseName, Semester: list[i].Semester,
+						SubjectA: list[i].SubjectCode, SubjectB: list[j].SubjectCode, ExamDate: list[i].ExamDate.Format("2006-01-02"),
+					})
+				}
+			}
+		}
+	}
+
+	candidates, err := sessionBacklogCandidates(db, sessionID)
+	if err != nil {
+		return nil, nil, err
+	}
+	enrollments := make([]int64, len(candidates))
+	for i, c := range candidates {
+		enrollments[i] = c.EnrollmentNumber
+	}
+	regular := regularSemesters(db, enrollments)
+	unscheduled := map[string]bool{}
+	for _, cand := range candidates {
+		var backlog []models.DateSheetEntry
+		for _, code := range cand.Subjects {
+			e, ok := byCourseSubject[cand.CourseName+"\x00"+code]
+			if !ok {
+				unscheduled[code] = true
+				continue
+			}
+			backlog = append(backlog, e)
+		}
+		papers := byCourseSem[cand.CourseName+"\x00"+strconv.Itoa(regular[cand.EnrollmentNumber])]
+		for i, b := range backlog {
+			for _, others := range [][]models.DateSheetEntry{papers, backlog[i+1:]} {
+				for _, o := range others {
+					if o.SubjectCode != b.SubjectCode && papersOverlap(b, o) {
+						clashes = append(clashes, examClash{
+							Kind: ClashBacklog, EnrollmentNumber: cand.EnrollmentNumber, CourseName: cand.CourseName,
+							SubjectA: b.SubjectCode, SubjectB: o.SubjectCode, ExamDate: b.ExamDate.Format("2006-01-02"),
+						})
+					}
+				}
+			}
+		}
+	}
+	missing := make([]string, 0, len(unscheduled))
+	for code := range unscheduled {
+		missing = append(missing, code)
+	}
+	sort.Strings(missing)
+	sort.SliceStable(clashes, func(i, j int) bool { return clashes[i].ExamDate < clashes[j].ExamDate })
+	return clashes, missing, nil
+}
+
+// GetDateSheet returns a session's date sheet, optionally for one course and semester, with the
+// clash report for the whole session
+func GetDateSheet(c *gin.Context) {
+	sessionID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid session id"})
+		return
+	}
+	db := config.DB
+	var all []models.DateSheetEntry
+	if err := db.Where("exam_session_id = ?", sessionID).Order("exam_date, start_time, course_name, subject_code").Find(&all).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load date sheet"})
+		return
+	}
+	clashes, unscheduled, err := dateSheetClashes(db, sessionID, all)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to check clashes"})
+		return
+	}
+	entries := []models.DateSheetEntry{}
+	course, semester := c.Query("course_name"), c.Query("semester")
+	for _, e := range all {
+		if (course == "" || e.CourseName == course) && (semester == "" || strconv.Itoa(e.Semester) == semester) {
+			entries = append(entries, e)
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"entries": entries, "clashes": clashes, "unscheduled_reappear": unscheduled})
+}
+
+// SaveDateSheet adds or reschedules papers. Overlapping papers within a course and semester are
+// always refused; clashes for backlog students are refused unless force is set.
+func SaveDateSheet(c *gin.Context) {
+	var req struct {
+		Entries []struct {
+			CourseName  string `json:"course_name" binding:"required"`
+			Semester    int    `json:"semester" binding:"required,min=1"`
+			SubjectCode string `json:"subject_code" binding:"required"`
+			ExamDate    string `json:"exam_date" binding:"required"` // YYYY-MM-DD
+			StartTime   string `json:"start_time" binding:"required"`
+			EndTime     string `json:"end_time" binding:"required"`
+		} `json:"entries" binding:"required,min=1,dive"`
+		Force bool `json:"force"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	db := config.DB
+	var session models.ExamSession
+	if err := db.Where("exam_session_id = ?", c.Param("id")).First(&session).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "exam session not found"})
+		return
+	}
+	var existing []models.DateSheetEntry
+	db.Where("exam_session_id = ?", session.ExamSessionID).Find(&existing)
+	merged := map[string]models.DateSheetEntry{}
+	for _, e := range existing {
+		merged[e.CourseName+"\x00"+e.SubjectCode] = e
+	}
+
+	now := time.Now()
+	records := make([]models.DateSheetEntry, 0, len(req.Entries))
+	for _, in := range req.Entries {
+		date, err := time.Parse("2006-01-02", in.ExamDate)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "exam_date must be YYYY-MM-DD"})
+			return
+		}
+		if (session.StartDate != nil && date.Before(*session.StartDate)) || (session.EndDate != nil && date.After(*session.EndDate)) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": in.SubjectCode + " is scheduled outside the session dates"})
+			return
+		}
+		start, ok1 := clockMinutes(in.StartTime)
+		end, ok2 := clockMinutes(in.EndTime)
+		if !ok1 || !ok2 || end <= start {
+			c.JSON(http.StatusBadRequest, gin.H{"error": in.SubjectCode + ": start_time and end_time must be HH:MM, start before end"})
+			return
+		}
+		var subject models.SubjectMaster
+		if db.Where("subject_code = ? AND course_name = ?", in.SubjectCode, in.CourseName).Limit(1).Find(&subject).RowsAffected == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": in.SubjectCode + " is not a subject of " + in.CourseName})
+			return
+		}
+		e := models.DateSheetEntry{
+			ExamSessionID: session.ExamSessionID, CourseName: in.CourseName, SubjectCode: in.SubjectCode,
+			SubjectName: subject.SubjectName, Semester: in.Semester, ExamDate: date,
+			StartTime: fmt.Sprintf("%02d:%02d", start/60, start%60), EndTime: fmt.Sprintf("%02d:%02d", end/60, end%60),
+			CreatedAt: now, UpdatedAt: now,
+		}
+		records = append(records, e)
+		merged[e.CourseName+"\x00"+e.SubjectCode] = e
+	}
+
+	all := make([]models.DateSheetEntry, 0, len(merged))
+	for _, e := range merged {
+		all = append(all, e)
+	}
+	clashes, unscheduled, err := dateSheetClashes(db, session.ExamSessionID, all)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to check clashes"})
+		return
+	}
+	var blocking []examClash
+	for _, cl := range clashes {
+		if cl.Kind == ClashCourse || !req.Force {
+			blocking = append(blocking, cl)
+		}
+	}
+	if len(blocking) > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "the date sheet has clashes", "clashes": blocking})
+		return
+	}
+
+	if err := db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "exam_session_id"}, {Name: "course_name"}, {Name: "subject_code"}},
+		DoUpdates: clause.AssignmentColumns([]string{"subject_name", "semester", "exam_date", "start_time", "end_time", "updated_at"}),
+	}).Create(&records).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save date sheet"})
+		return
+	}
+	middleware.Audit(c, "exams.date_sheet.save", "exam_date_sheet", strconv.Itoa(session.ExamSessionID), nil,
+		gin.H{"entries": len(records), "forced_clashes": len(clashes)})
+	c.JSON(http.StatusOK, gin.H{"message": "date sheet saved", "entries": len(records), "clashes": clashes, "unscheduled_reappear": unscheduled})
+}
+
+// DeleteDateSheetEntry removes a paper from the date sheet
+func DeleteDateSheetEntry(c *gin.Context) {
+	var entry models.DateSheetEntry
+	if err := config.DB.Where("entry_id = ? AND exam_session_id = ?", c.Param("entry_id"), c.Param("id")).First(&entry).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "date sheet entry not found"})
+		return
+	}
+	if err := config.DB.Delete(&entry).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete entry"})
+		return
+	}
+	middleware.Audit(c, "exams.date_sheet.delete", "exam_date_sheet", strconv.FormatInt(entry.EntryID, 10), entry, nil)
+	c.JSON(http.StatusOK, gin.H{"message": "entry deleted"})
+}
@@ -0,0 +1,321 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/kiranraoboinapally/student/backend/internal/config"
+	"github.com/kiranraoboinapally/student/backend/internal/middleware"
+	"github.com/kiranraoboinapally/student/backend/internal/models"
+)
+
+// ======================== DUES RUN ========================
+
+// errDryRun rolls back a dues run that was only a preview
+var errDryRun = errors.New("dry run")
+
+// structureMatches reports whether a fee structure applies to a student and how specific it is.
+// Empty course / session / batch / program pattern on the structure match every student.
+func structureMatches(fs models.FeeStructure, s models.MasterStudent) (bool, int) {
+	score := 0
+	for _, f := range []struct{ want, have *string }{
+		{fs.CourseName, s.CourseName},
+		{fs.Session, s.Session},
+		{fs.Batch, s.Batch},
+		{fs.ProgramPattern, s.ProgramPattern},
+	} {
+		if f.want == nil || strings.TrimSpace(*f.want) == "" {
+			continue
+		}
+		if f.have == nil || !strings.EqualFold(strings.TrimSpace(*f.want), strings.TrimSpace(*f.have)) {
+			return false, 0
+		}
+		score++
+	}
+	return true, score
+}
+
+// effectiveStructures loads the active fee structures in force on a date
+func effectiveStructures(db *gorm.DB, asOf time.Time) ([]models.FeeStructure, error) {
+	var structures []models.FeeStructure
+	err := db.Where("LOWER(status) = 'active' AND fee_type_id > 0").
+		Where("effective_from IS NULL OR effective_from <= ?", asOf).
+		Where("effective_to IS NULL OR effective_to >= ?", asOf).
+		Order("fee_structure_id").Find(&structures).Error
+	return structures, err
+}
+
+// resolveStructures picks, per fee type, the most specific structure that applies to the student.
+// Ties go to the most recently effective, then the newest, structure.
+func resolveStructures(structures []models.FeeStructure, s models.MasterStudent) []models.FeeStructure {
+	best := map[int]int{}
+	score := map[int]int{}
+	var order []int
+	for i, fs := range structures {
+		ok, sc := structureMatches(fs, s)
+		if !ok {
+			continue
+		}
+		j, seen := best[fs.FeeTypeID]
+		if !seen {
+			order = append(order, fs.FeeTypeID)
+		} else if sc < score[fs.FeeTypeID] || (sc == score[fs.FeeTypeID] && !laterStructure(fs, structures[j])) {
+			continue
+		}
+		best[fs.FeeTypeID] = i
+		score[fs.FeeTypeID] = sc
+	}
+	resolved := make([]models.FeeStructure, 0, len(order))
+	for _, feeTypeID := range order {
+		resolved = append(resolved, structures[best[feeTypeID]])
+	}
+	return resolved
+}
+
+func laterStructure(a, b models.FeeStructure) bool {
+	switch {
+	case a.EffectiveFrom != nil && b.EffectiveFrom == nil:
+		return true
+	case a.EffectiveFrom == nil && b.EffectiveFrom != nil:
+		return false
+	case a.EffectiveFrom != nil && !a.EffectiveFrom.Equal(*b.EffectiveFrom):
+		return a.EffectiveFrom.After(*b.EffectiveFrom)
+	}
+	return a.FeeStructureID > b.FeeStructureID
+}
+
+// duesReference identifies the dues a run posts for one student and fee type in a term; installments
+// append their sequence. A student is charged at most once per fee type per term.
+func duesReference(term string, enrollment int64, feeTypeID int) string {
+	return fmt.Sprintf("dues:%s:%d:%d", term, enrollment, feeTypeID)
+}
+
+func duesAlreadyPosted(tx *gorm.DB, ref string) bool {
+	var n int64
+	tx.Model(&models.FeeLedgerEntry{}).
+		Where("entry_type = ? AND (reference = ? OR reference LIKE ?)", LedgerCharge, ref, ref+":%").
+		Count(&n)
+	return n > 0
+}
+
+type duesLine struct {
+	EnrollmentNumber int64   `json:"enrollment_number"`
+	StudentName      string  `json:"student_name"`
+	FeeTypeID        int     `json:"fee_type_id"`
+	FeeType          string  `json:"fee_type"`
+	FeeStructureID   int     `json:"fee_structure_id"`
+	Amount           float64 `json:"amount"`
+	Concession       float64 `json:"concession"`
+	Installments     int     `json:"installments"`
+}
+
+type duesRunResult struct {
+	Term           string     `json:"term"`
+	DryRun         bool       `json:"dry_run"`
+	Students       int        `json:"students"`
+	Charged        int        `json:"charged"`
+	AlreadyCharged int        `json:"already_charged"`
+	Unmatched      []int64    `json:"unmatched"` // active students no structure applies to
+	Amount         float64    `json:"amount"`
+	Concessions    float64    `json:"concessions"`
+	Lines          []duesLine `json:"lines"`
+}
+
+// RunFeeDues generates dues for active students from the fee structures in force, in one
+// transaction. Each student is charged once per fee type per term, so re-running the same term only
+// picks up students or structures added since. Structures with an active installment plan are
+// split into its installments. With dry_run the postings are rolled back and returned as a preview.
+func RunFeeDues(c *gin.Context) {
+	var req struct {
+		Term        string `json:"term" binding:"required"` // e.g. 2026-ODD
+		InstituteID *int   `json:"institute_id"`
+		CourseName  string `json:"course_name"`
+		AsOf        string `json:"as_of"`    // YYYY-MM-DD, selects structures in force; defaults to today
+		DueDate     string `json:"due_date"` // YYYY-MM-DD, for dues not split into installments
+		Semester    *int   `json:"semester"`
+		DryRun      bool   `json:"dry_run"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.Term = strings.TrimSpace(req.Term)
+	if req.Term == "" || len(req.Term) > 40 || strings.Contains(req.Term, ":") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "term must be 1-40 characters without ':'"})
+		return
+	}
+	asOf := time.Now()
+	if req.AsOf != "" {
+		t, err := time.Parse("2006-01-02", req.AsOf)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "as_of must be YYYY-MM-DD"})
+			return
+		}
+		asOf = t
+	}
+	var dueDate *time.Time
+	if req.DueDate != "" {
+		t, err := time.Parse("2006-01-02", req.DueDate)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "due_date must be YYYY-MM-DD"})
+			return
+		}
+		dueDate = &t
+	}
+
+	db := config.DB
+	structures, err := effectiveStructures(db, asOf)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load fee structures"})
+		return
+	}
+	if len(structures) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "no fee structures are in force on " + asOf.Format("2006-01-02")})
+		return
+	}
+
+	q := db.Where("LOWER(student_status) = 'active'")
+	if req.InstituteID != nil {
+		q = q.Where("institute_id = ?", *req.InstituteID)
+	}
+	if req.CourseName != "" {
+		q = q.Where("course_name = ?", req.CourseName)
+	}
+	var students []models.MasterStudent
+	if err := q.Order("enrollment_number").Find(&students).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load students"})
+		return
+	}
+	if len(students) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "no active students match"})
+		return
+	}
+
+	feeTypeNames := map[int]string{}
+	var feeTypes []models.MasterFeeType
+	db.Find(&feeTypes)
+	for _, ft := range feeTypes {
+		feeTypeNames[ft.FeeTypeID] = ft.FeeTypeName
+	}
+	plans := map[int]*models.InstallmentPlan{}
+	for _, fs := range structures {
+		var plan models.InstallmentPlan
+		if db.Preload("Items", func(q *gorm.DB) *gorm.DB { return q.Order("sequence") }).
+			Where("fee_structure_id = ? AND is_active = ?", fs.FeeStructureID, true).
+			Order("plan_id DESC").Limit(1).Find(&plan).RowsAffected > 0 && len(plan.Items) > 0 {
+			plans[fs.FeeStructureID] = &plan
+		}
+	}
+
+	var createdBy *int64
+	if uid := c.GetInt64("user_id"); uid != 0 {
+		createdBy = &uid
+	}
+
+	result := duesRunResult{Term: req.Term, DryRun: req.DryRun, Students: len(students), Unmatched: []int64{}, Lines: []duesLine{}}
+	err = db.Transaction(func(tx *gorm.DB) error {
+		for _, s := range students {
+			applicable := resolveStructures(structures, s)
+			if len(applicable) == 0 {
+				result.Unmatched = append(result.Unmatched, s.EnrollmentNumber)
+				continue
+			}
+			for _, fs := range applicable {
+				amount := roundMoney(fs.FeeAmount)
+				if amount <= 0 {
+					continue
+				}
+				ref := duesReference(req.Term, s.EnrollmentNumber, fs.FeeTypeID)
+				if duesAlreadyPosted(tx, ref) {
+					result.AlreadyCharged++
+					continue
+				}
+
+				template := models.FeeLedgerEntry{
+					EnrollmentNumber: s.EnrollmentNumber,
+					FeeTypeID:        fs.FeeTypeID,
+					EntryType:        LedgerCharge,
+					Debit:            amount,
+					DueDate:          dueDate,
+					Semester:         req.Semester,
+					Description:      ptrString(truncate(feeHeadLabel(feeTypeNames[fs.FeeTypeID])+" - "+req.Term, 255)),
+					CreatedBy:        createdBy,
+				}
+				var posted []models.FeeLedgerEntry
+				if plan := plans[fs.FeeStructureID]; plan != nil {
+					posted, err = postInstallmentCharges(tx, template, amount, *plan, ref)
+					if err != nil {
+						return err
+					}
+				} else {
+					entry := template
+					entry.Reference = ptrString(ref)
+					inserted, err := postCharge(tx, &entry)
+					if err != nil {
+						return err
+					}
+					if inserted {
+						posted = append(posted, entry)
+					}
+				}
+				if len(posted) == 0 {
+					continue
+				}
+
+				var concession float64
+				tx.Model(&models.FeeLedgerEntry{}).Select("COALESCE(SUM(credit - debit),0)").
+					Where("entry_type = ? AND parent_entry_id IN ? AND reference LIKE 'scholarship:%'", LedgerWaiver, entryIDs(posted)).
+					Scan(&concession)
+				result.Charged++
+				result.Amount += amount
+				result.Concessions += concession
+				result.Lines = append(result.Lines, duesLine{
+					EnrollmentNumber: s.EnrollmentNumber,
+					StudentName:      s.StudentName,
+					FeeTypeID:        fs.FeeTypeID,
+					FeeType:          feeTypeNames[fs.FeeTypeID],
+					FeeStructureID:   fs.FeeStructureID,
+					Amount:           amount,
+					Concession:       roundMoney(concession),
+					Installments:     len(posted),
+				})
+			}
+		}
+		if req.DryRun {
+			return errDryRun
+		}
+		return nil
+	})
+	if err != nil && err != errDryRun {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "dues run failed; nothing was posted"})
+		return
+	}
+	result.Amount = roundMoney(result.Amount)
+	result.Concessions = roundMoney(result.Concessions)
+
+	if !req.DryRun {
+		middleware.Audit(c, "fees.dues_run", "fee_dues", req.Term, nil, gin.H{
+			"institute_id": req.InstituteID, "course_name": req.CourseName, "as_of": asOf.Format("2006-01-02"),
+			"students": result.Students, "charged": result.Charged, "already_charged": result.AlreadyCharged,
+			"amount": result.Amount, "concessions": result.Concessions,
+		})
+		if result.Charged > 0 {
+			SendAdminNotification("fee_dues_generated", gin.H{"term": req.Term, "charged": result.Charged, "amount": result.Amount})
+		}
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+func entryIDs(entries []models.FeeLedgerEntry) []int64 {
+	ids := make([]int64, len(entries))
+	for i, e := range entries {
+		ids[i] = e.EntryID
+	}
+	return ids
+}
@@ -0,0 +1,179 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/kiranraoboinapally/student/backend/internal/config"
+	"github.com/kiranraoboinapally/student/backend/internal/middleware"
+	"github.com/kiranraoboinapally/student/backend/internal/models"
+)
+
+// ======================== EXAM SESSIONS ========================
+
+// Exam session statuses
+const (
+	ExamSessionDraft  = "draft"
+	ExamSessionOpen   = "open" // accepting registrations within the registration window
+	ExamSessionClosed = "closed"
+)
+
+type examSessionRequest struct {
+	Name               string  `json:"name" binding:"required"`
+	Term               string  `json:"term"`
+	ExamType           string  `json:"exam_type" binding:"omitempty,oneof=regular supplementary special"`
+	StartDate          string  `json:"start_date"` // YYYY-MM-DD
+	EndDate            string  `json:"end_date"`
+	RegistrationOpens  string  `json:"registration_opens"`
+	RegistrationCloses string  `json:"registration_closes"`
+	AllowReappear      *bool   `json:"allow_reappear"`
+	ReappearFee        float64 `json:"reappear_fee" binding:"gte=0"`
+	Status             string  `json:"status" binding:"omitempty,oneof=draft open closed"`
+}
+
+// optDate parses an optional YYYY-MM-DD date
+func optDate(field, v string) (*time.Time, error) {
+	if strings.TrimSpace(v) == "" {
+		return nil, nil
+	}
+	t, err := time.Parse("2006-01-02", strings.TrimSpace(v))
+	if err != nil {
+		return nil, errors.New(field + " must be YYYY-MM-DD")
+	}
+	return &t, nil
+}
+
+func (r examSessionRequest) apply(s *models.ExamSession) error {
+	var err error
+	if s.StartDate, err = optDate("start_date", r.StartDate); err != nil {
+		return err
+	}
+	if s.EndDate, err = optDate("end_date", r.EndDate); err != nil {
+		return err
+	}
+	if s.RegistrationOpens, err = optDate("registration_opens", r.RegistrationOpens); err != nil {
+		return err
+	}
+	if s.RegistrationCloses, err = optDate("registration_closes", r.RegistrationCloses); err != nil {
+		return err
+	}
+	if s.StartDate != nil && s.EndDate != nil && s.EndDate.Before(*s.StartDate) {
+		return errors.New("end_date is before start_date")
+	}
+	if s.RegistrationOpens != nil && s.RegistrationCloses != nil && s.RegistrationCloses.Before(*s.RegistrationOpens) {
+		return errors.New("registration_closes is before registration_opens")
+	}
+	s.Name = strings.TrimSpace(r.Name)
+	s.Term = nil
+	if term := strings.TrimSpace(r.Term); term != "" {
+		s.Term = &term
+	}
+	s.ExamType = r.ExamType
+	if s.ExamType == "" {
+		s.ExamType = "regular"
+	}
+	s.AllowReappear = r.AllowReappear == nil || *r.AllowReappear
+	s.ReappearFee = roundMoney(r.ReappearFee)
+	if r.Status != "" {
+		s.Status = r.Status
+	} else if s.Status == "" {
+		s.Status = ExamSessionDraft
+	}
+	s.UpdatedAt = time.Now()
+	return nil
+}
+
+// registrationOpen reports whether a session accepts registrations today
+func registrationOpen(s models.ExamSession, now time.Time) bool {
+	if s.Status != ExamSessionOpen {
+		return false
+	}
+	today := now.Format("2006-01-02")
+	if s.RegistrationOpens != nil && today < s.RegistrationOpens.Format("2006-01-02") {
+		return false
+	}
+	if s.RegistrationCloses != nil && today > s.RegistrationCloses.Format("2006-01-02") {
+		return false
+	}
+	return true
+}
+
+// GetExamSessions lists exam sessions, newest first, optionally by status
+func GetExamSessions(c *gin.Context) {
+	q := config.DB.Model(&models.ExamSession{})
+	if status := c.Query("status"); status != "" {
+		q = q.Where("status = ?", status)
+	}
+	var sessions []models.ExamSession
+	if err := q.Order("exam_session_id DESC").Find(&sessions).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load exam sessions"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"sessions": sessions})
+}
+
+// CreateExamSession defines an examination cycle
+func CreateExamSession(c *gin.Context) {
+	var req examSessionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	session := models.ExamSession{CreatedAt: time.Now()}
+	if err := req.apply(&session); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if uid := c.GetInt64("user_id"); uid != 0 {
+		session.CreatedBy = &uid
+	}
+	var clash int64
+	config.DB.Model(&models.ExamSession{}).Where("name = ?", session.Name).Count(&clash)
+	if clash > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "an exam session with this name already exists"})
+		return
+	}
+	if err := config.DB.Create(&session).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create exam session"})
+		return
+	}
+	middleware.Audit(c, "exams.session.create", "exam_sessions", strconv.Itoa(session.ExamSessionID), nil, session)
+	c.JSON(http.StatusCreated, gin.H{"message": "exam session created", "session": session})
+}
+
+// UpdateExamSession edits a session, including opening and closing registration
+func UpdateExamSession(c *gin.Context) {
+	var req examSessionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	db := config.DB
+	var session models.ExamSession
+	if err := db.Where("exam_session_id = ?", c.Param("id")).First(&session).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "exam session not found"})
+		return
+	}
+	before := session
+	if err := req.apply(&session); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	var clash int64
+	db.Model(&models.ExamSession{}).Where("name = ? AND exam_session_id <> ?", session.Name, session.ExamSessionID).Count(&clash)
+	if clash > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "an exam session with this name already exists"})
+		return
+	}
+	if err := db.Save(&session).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update exam session"})
+		return
+	}
+	middleware.Audit(c, "exams.session.update", "exam_sessions", strconv.Itoa(session.ExamSessionID), before, session)
+	c.JSON(http.StatusOK, gin.H{"message": "exam session updated", "session": session})
+}
@@ -0,0 +1,164 @@
+package controllers
+
+import (
+	"math"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/kiranraoboinapally/student/backend/internal/models"
+)
+
+// ======================== FEE LEDGER ========================
+
+// Ledger entry types
+const (
+	LedgerCharge     = "charge"
+	LedgerPayment    = "payment"
+	LedgerAdjustment = "adjustment"
+	LedgerRefund     = "refund"   // money returned to the student
+	LedgerReversal   = "reversal" // a rejected payment taken back off the account
+	LedgerPenalty    = "penalty"  // late fee on an overdue charge; waived penalties are penalty credits
+	LedgerWaiver     = "waiver"
+)
+
+// Ledger entry statuses. Charges, adjustments, penalties, refunds, reversals and waivers are Posted; payments
+// keep the payment_status values the fee screens already show.
+const (
+	LedgerStatusPosted             = "Posted"
+	PaymentStatusPaid              = "Paid"
+	PaymentStatusPending           = "Pending"
+	PaymentStatusVerified          = "Verified"
+	PaymentStatusRejected          = "Rejected"
+	PaymentStatusRefunded          = "Refunded"
+	PaymentStatusPartiallyRefunded = "Partially Refunded"
+)
+
+// ledgerExcludedStatuses never count towards a balance: payments awaiting verification.
+// Rejected payments still count because their reversal entry cancels them out.
+var ledgerExcludedStatuses = []string{PaymentStatusPending}
+
+// builtinFeeTypes are created on first use so the legacy fee heads always resolve
+var builtinFeeTypes = map[string]bool{"Registration": true, "Examination": true, "Miscellaneous": true, "Revaluation": true, "Reappear": true}
+
+// resolveFeeType maps a fee head such as "Examination Fee" (or the bare type name) to its MasterFeeType
+func resolveFeeType(db *gorm.DB, head string) (models.MasterFeeType, error) {
+	name := strings.TrimSpace(head)
+	base := strings.TrimSpace(strings.TrimSuffix(name, " Fee"))
+	var ft models.MasterFeeType
+	if base == "" {
+		return ft, errUnknownFeeHead
+	}
+	res := db.Where("fee_type_name IN ? AND is_active = ?", []string{name, base}, true).Order("fee_type_id").Limit(1).Find(&ft)
+	if res.Error != nil {
+		return ft, res.Error
+	}
+	if res.RowsAffected > 0 {
+		return ft, nil
+	}
+	if !builtinFeeTypes[base] {
+		return ft, errUnknownFeeHead
+	}
+	err := db.Where(models.MasterFeeType{FeeTypeName: base}).
+		Attrs(models.MasterFeeType{IsActive: true, CreatedAt: time.Now()}).
+		FirstOrCreate(&ft).Error
+	return ft, err
+}
+
+// feeHeadLabel is the display name used by the fee screens, e.g. "Examination Fee"
+func feeHeadLabel(feeTypeName string) string {
+	if strings.HasSuffix(feeTypeName, "Fee") {
+		return feeTypeName
+	}
+	return feeTypeName + " Fee"
+}
+
+// postLedgerEntry appends an entry. Entries with a Reference are written at most once per entry
+// type; the bool reports whether this call inserted the row.
+func postLedgerEntry(tx *gorm.DB, e *models.FeeLedgerEntry) (bool, error) {
+	now := time.Now()
+	if e.CreatedAt.IsZero() {
+		e.CreatedAt = now
+	}
+	e.UpdatedAt = now
+	if e.Status == "" {
+		e.Status = LedgerStatusPosted
+	}
+	e.Debit = roundMoney(e.Debit)
+	e.Credit = roundMoney(e.Credit)
+	res := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(e)
+	return res.RowsAffected > 0, res.Error
+}
+
+func roundMoney(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+// countedEntries restricts a ledger query to entries that affect balances
+func countedEntries(db *gorm.DB) *gorm.DB {
+	return db.Where("fee_ledger.status NOT IN ?", ledgerExcludedStatuses)
+}
+
+// feeTypeBalance is one fee type's position on a student's account
+type feeTypeBalance struct {
+	FeeTypeID   int        `json:"fee_type_id"`
+	FeeTypeName string     `json:"fee_type_name"`
+	Charged     float64    `json:"charged"`
+	Paid        float64    `json:"paid"`
+	Waived      float64    `json:"waived"`
+	Penalty     float64    `json:"penalty"`
+	Balance     float64    `json:"balance"`
+	DueDate     *time.Time `json:"due_date"`
+}
+
+// ledgerBalanceColumns aggregates charged / paid / waived / penalty / balance over fee_ledger rows
+const ledgerBalanceColumns = `
+	COALESCE(SUM(CASE WHEN fee_ledger.entry_type IN ('charge','adjustment') THEN fee_ledger.debit - fee_ledger.credit ELSE 0 END),0) AS charged,
+	COALESCE(SUM(CASE WHEN fee_ledger.entry_type IN ('payment','refund','reversal') THEN fee_ledger.credit - fee_ledger.debit ELSE 0 END),0) AS paid,
+	COALESCE(SUM(CASE WHEN fee_ledger.entry_type = 'waiver' THEN fee_ledger.credit - fee_ledger.debit ELSE 0 END),0) AS waived,
+	COALESCE(SUM(CASE WHEN fee_ledger.entry_type = 'penalty' THEN fee_ledger.debit - fee_ledger.credit ELSE 0 END),0) AS penalty,
+	COALESCE(SUM(fee_ledger.debit - fee_ledger.credit),0) AS balance`
+
+// studentFeeBalances returns per-fee-type balances for one student
+func studentFeeBalances(db *gorm.DB, enrollment int64) ([]feeTypeBalance, error) {
+	var rows []feeTypeBalance
+	err := countedEntries(db.Table("fee_ledger")).
+		Select("fee_ledger.fee_type_id, master_fee_types.fee_type_name,"+ledgerBalanceColumns+
+			", MIN(CASE WHEN fee_ledger.entry_type = 'charge' THEN fee_ledger.due_date END) AS due_date").
+		Joins("JOIN master_fee_types ON master_fee_types.fee_type_id = fee_ledger.fee_type_id").
+		Where("fee_ledger.enrollment_number = ?", enrollment).
+		Group("fee_ledger.fee_type_id, master_fee_types.fee_type_name").
+		Order("master_fee_types.fee_type_name").
+		Scan(&rows).Error
+	return rows, err
+}
+
+// ledgerTotals is the aggregate position over a set of students
+type ledgerTotals struct {
+	Charged float64 `json:"charged"`
+	Paid    float64 `json:"paid"`
+	Waived  float64 `json:"waived"`
+	Penalty float64 `json:"penalty"`
+	Balance float64 `json:"balance"`
+}
+
+// sumLedger aggregates the ledger; pass a where clause (e.g. an institute filter) or "" for everything
+func sumLedger(db *gorm.DB, where string, args ...interface{}) ledgerTotals {
+	var t ledgerTotals
+	q := countedEntries(db.Table("fee_ledger")).Select(ledgerBalanceColumns)
+	if where != "" {
+		q = q.Where(where, args...)
+	}
+	q.Scan(&t)
+	return t
+}
+
+func optTimeString(t *time.Time, layout string) *string {
+	if t == nil {
+		return nil
+	}
+	s := t.Format(layout)
+	return &s
+}
@@ -0,0 +1,310 @@
+package controllers
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/kiranraoboinapally/student/backend/internal/config"
+	"github.com/kiranraoboinapally/student/backend/internal/models"
+	"github.com/kiranraoboinapally/student/backend/internal/pdf"
+	"github.com/kiranraoboinapally/student/backend/internal/qr"
+	"github.com/kiranraoboinapally/student/backend/internal/utils"
+)
+
+// ======================== FEE RECEIPTS ========================
+
+// financialYear is the April-March year a date falls in, e.g. "2026-27"
+func financialYear(t time.Time) string {
+	start := t.Year()
+	if t.Month() < time.April {
+		start--
+	}
+	return fmt.Sprintf("%d-%02d", start, (start+1)%100)
+}
+
+var (
+	wordsOnes = []string{"", "One", "Two", "Three", "Four", "Five", "Six", "Seven", "Eight", "Nine", "Ten",
+		"Eleven", "Twelve", "Thirteen", "Fourteen", "Fifteen", "Sixteen", "Seventeen", "Eighteen", "Nineteen"}
+	wordsTens = []string{"", "", "Twenty", "Thirty", "Forty", "Fifty", "Sixty", "Seventy", "Eighty", "Ninety"}
+)
+
+func wordsBelowThousand(n int64) string {
+	var parts []string
+	if n >= 100 {
+		parts = append(parts, wordsOnes[n/100], "Hundred")
+		n %= 100
+	}
+	if n >= 20 {
+		parts = append(parts, wordsTens[n/10])
+		n %= 10
+	}
+	if n > 0 {
+		parts = append(parts, wordsOnes[n])
+	}
+	return strings.Join(parts, " ")
+}
+
+// integerInWords spells n in the Indian system (thousand, lakh, crore)
+func integerInWords(n int64) string {
+	if n == 0 {
+		return "Zero"
+	}
+	var parts []string
+	if n >= 10000000 {
+		parts = append(parts, integerInWords(n/10000000), "Crore")
+		n %= 10000000
+	}
+	for _, unit := range []struct {
+		size int64
+		name string
+	}{{100000, "Lakh"}, {1000, "Thousand"}} {
+		if n >= unit.size {
+			parts = append(parts, wordsBelowThousand(n/unit.size), unit.name)
+			n %= unit.size
+		}
+	}
+	if n > 0 {
+		parts = append(parts, wordsBelowThousand(n))
+	}
+	return strings.Join(parts, " ")
+}
+
+// amountInWords renders an amount for a receipt, e.g. "Rupees One Thousand Fifty and Fifty Paise Only"
+func amountInWords(amount float64) string {
+	paise := int64(math.Round(amount * 100))
+	words := "Rupees " + integerInWords(paise/100)
+	if paise%100 != 0 {
+		words += " and " + integerInWords(paise%100) + " Paise"
+	}
+	return words + " Only"
+}
+
+// studentInstitute returns the student's institute, or a zero value when none is recorded
+func studentInstitute(db *gorm.DB, enrollment int64) models.Institute {
+	var inst models.Institute
+	db.Table("institutes").
+		Joins("JOIN master_students ON master_students.institute_id = institutes.institute_id").
+		Where("master_students.enrollment_number = ?", enrollment).
+		Select("institutes.*").Limit(1).Scan(&inst)
+	return inst
+}
+
+// issueReceipt numbers a receipt for a settled payment. It is idempotent per payment and must run
+// inside the transaction that settles the payment, so a number is only used when the payment commits.
+func issueReceipt(tx *gorm.DB, payment models.FeeLedgerEntry) (*models.FeeReceipt, error) {
+	var existing models.FeeReceipt
+	if tx.Where("payment_entry_id = ?", payment.EntryID).Limit(1).Find(&existing).RowsAffected > 0 {
+		return &existing, nil
+	}
+
+	inst := studentInstitute(tx, payment.EnrollmentNumber)
+	issued := time.Now()
+	if payment.TransactionDate != nil {
+		issued = *payment.TransactionDate
+	}
+	fy := financialYear(issued)
+
+	// The upsert locks the counter row until commit, so numbers stay gap-free and in order
+	if err := tx.Exec(`INSERT INTO receipt_sequences (institute_id, financial_year, last_number) VALUES (?, ?, 1)
+		ON DUPLICATE KEY UPDATE last_number = last_number + 1`, inst.InstituteID, fy).Error; err != nil {
+		return nil, err
+	}
+	var seq models.ReceiptSequence
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("institute_id = ? AND financial_year = ?", inst.InstituteID, fy).First(&seq).Error; err != nil {
+		return nil, err
+	}
+
+	code, err := utils.RandomToken(6)
+	if err != nil {
+		return nil, err
+	}
+	prefix := inst.InstituteCode
+	if prefix == "" {
+		prefix = "GEN"
+	}
+	receipt := models.FeeReceipt{
+		ReceiptNumber:    fmt.Sprintf("%s/%s/%06d", prefix, fy, seq.LastNumber),
+		InstituteID:      inst.InstituteID,
+		FinancialYear:    fy,
+		Sequence:         seq.LastNumber,
+		PaymentEntryID:   payment.EntryID,
+		EnrollmentNumber: payment.EnrollmentNumber,
+		Amount:           payment.Credit,
+		VerificationCode: strings.ToUpper(code),
+		IssuedAt:         issued,
+	}
+	if err := tx.Create(&receipt).Error; err != nil {
+		return nil, err
+	}
+	return &receipt, nil
+}
+
+// receiptVerifyURL is where the printed verification code can be checked
+func receiptVerifyURL(code string) string {
+	return config.AppBaseURL + "/api/verify/receipt/" + code
+}
+
+// renderReceipt draws the receipt PDF for a payment
+func renderReceipt(db *gorm.DB, receipt models.FeeReceipt) ([]byte, error) {
+	var payment models.FeeLedgerEntry
+	if err := db.Where("entry_id = ?", receipt.PaymentEntryID).First(&payment).Error; err != nil {
+		return nil, err
+	}
+	var student models.MasterStudent
+	db.Where("enrollment_number = ?", receipt.EnrollmentNumber).Limit(1).Find(&student)
+	var feeType models.MasterFeeType
+	db.Where("fee_type_id = ?", payment.FeeTypeID).Limit(1).Find(&feeType)
+	var inst models.Institute
+	if receipt.InstituteID != 0 {
+		db.Where("institute_id = ?", receipt.InstituteID).Limit(1).Find(&inst)
+	}
+
+	doc := pdf.New()
+	left, right := 50.0, pdf.PageWidth-50
+	mid := pdf.PageWidth / 2
+
+	name := inst.InstituteName
+	if name == "" {
+		name = safeString(student.InstituteName)
+	}
+	y := 70.0
+	doc.TextCenter(mid, y, 18, true, name)
+	var addr []string
+	for _, p := range []*string{inst.Address, inst.City, inst.State, inst.Pincode} {
+		if p != nil && *p != "" {
+			addr = append(addr, *p)
+		}
+	}
+	if len(addr) > 0 {
+		y += 18
+		doc.TextCenter(mid, y, 10, false, strings.Join(addr, ", "))
+	}
+	y += 30
+	doc.FillRect(left, y-16, right-left, 24, 0.9)
+	doc.TextCenter(mid, y, 14, true, "FEE RECEIPT")
+	y += 35
+	doc.Text(left, y, 11, true, "Receipt No: "+receipt.ReceiptNumber)
+	doc.TextRight(right, y, 11, false, "Date: "+receipt.IssuedAt.Format("02 Jan 2006"))
+	y += 15
+	doc.Line(left, y, right, y, 0.5)
+
+	rows := [][2]string{
+		{"Student Name", student.StudentName},
+		{"Enrollment Number", strconv.FormatInt(receipt.EnrollmentNumber, 10)},
+		{"Course", safeString(student.CourseName)},
+		{"Fee Head", feeHeadLabel(feeType.FeeTypeName)},
+		{"Transaction Number", safeString(payment.TransactionNumber)},
+		{"Transaction Date", safeString(optTimeString(payment.TransactionDate, "02 Jan 2006 15:04"))},
+		{"Payment Method", safeString(payment.PaymentMethod)},
+	}
+	y += 25
+	for _, r := range rows {
+		doc.Text(left+10, y, 11, true, r[0])
+		doc.Text(left+170, y, 11, false, r[1])
+		y += 20
+	}
+
+	y += 10
+	doc.Rect(left, y-15, right-left, 60, 0.8)
+	doc.Text(left+10, y+5, 12, true, "Amount Received")
+	doc.TextRight(right-10, y+5, 14, true, fmt.Sprintf("Rs. %.2f", receipt.Amount))
+	doc.Wrap(left+10, y+27, right-left-20, 10, false, amountInWords(receipt.Amount))
+	y += 80
+
+	if payment.Status == PaymentStatusRefunded || payment.Status == PaymentStatusPartiallyRefunded || payment.Status == PaymentStatusRejected {
+		doc.Text(left, y, 11, true, "Payment status: "+payment.Status)
+		y += 20
+	}
+
+	y += 10
+	qrTop := y - 12
+	if code, err := qr.Encode(receiptVerifyURL(receipt.VerificationCode)); err == nil {
+		drawQR(doc, right-80, qrTop, 80, code)
+	}
+	doc.Text(left, y, 10, true, "Verification code: "+receipt.VerificationCode)
+	y += 15
+	doc.Text(left, y, 9, false, fitText("Verify this receipt at "+receiptVerifyURL(receipt.VerificationCode), right-left-90, 9, false))
+	y = qrTop + 90
+	doc.Line(left, y, right, y, 0.3)
+	doc.TextCenter(mid, y+15, 8, false, "This is a computer-generated receipt and does not require a signature.")
+	return doc.Bytes(), nil
+}
+
+func sendReceiptPDF(c *gin.Context, receipt models.FeeReceipt) {
+	body, err := renderReceipt(config.DB, receipt)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate receipt"})
+		return
+	}
+	filename := "receipt-" + strings.ReplaceAll(receipt.ReceiptNumber, "/", "-") + ".pdf"
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	c.Data(http.StatusOK, "application/pdf", body)
+}
+
+// DownloadStudentReceipt returns the caller's receipt as a PDF
+func DownloadStudentReceipt(c *gin.Context) {
+	enrollment, err := getEnrollmentOrError(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	var receipt models.FeeReceipt
+	if err := config.DB.Where("receipt_id = ? AND enrollment_number = ?", c.Param("receipt_id"), enrollment).
+		First(&receipt).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "receipt not found"})
+		return
+	}
+	sendReceiptPDF(c, receipt)
+}
+
+// DownloadReceipt returns any student's receipt as a PDF
+func DownloadReceipt(c *gin.Context) {
+	var receipt models.FeeReceipt
+	if err := config.DB.Where("receipt_id = ?", c.Param("receipt_id")).First(&receipt).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "receipt not found"})
+		return
+	}
+	sendReceiptPDF(c, receipt)
+}
+
+// VerifyReceipt is the public check behind the code printed on receipts. It confirms the receipt
+// exists and shows its current payment status without exposing more of the student's record.
+func VerifyReceipt(c *gin.Context) {
+	code := strings.ToUpper(strings.TrimSpace(c.Param("code")))
+	db := config.DB
+	var receipt models.FeeReceipt
+	if code == "" || db.Where("verification_code = ?", code).Limit(1).Find(&receipt).RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"valid": false, "error": "no receipt matches this code"})
+		return
+	}
+	var payment models.FeeLedgerEntry
+	db.Where("entry_id = ?", receipt.PaymentEntryID).Limit(1).Find(&payment)
+	var student models.MasterStudent
+	db.Where("enrollment_number = ?", receipt.EnrollmentNumber).Limit(1).Find(&student)
+	var feeType models.MasterFeeType
+	db.Where("fee_type_id = ?", payment.FeeTypeID).Limit(1).Find(&feeType)
+	var inst models.Institute
+	if receipt.InstituteID != 0 {
+		db.Where("institute_id = ?", receipt.InstituteID).Limit(1).Find(&inst)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"valid":          true,
+		"receipt_number": receipt.ReceiptNumber,
+		"issued_at":      receipt.IssuedAt.Format("2006-01-02"),
+		"institute":      inst.InstituteName,
+		"student_name":   student.StudentName,
+		"fee_head":       feeHeadLabel(feeType.FeeTypeName),
+		"amount":         receipt.Amount,
+		"payment_status": payment.Status,
+	})
+}
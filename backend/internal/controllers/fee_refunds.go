@@ -0,0 +1,266 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/kiranraoboinapally/student/backend/internal/config"
+	"github.com/kiranraoboinapally/student/backend/internal/middleware"
+	"github.com/kiranraoboinapally/student/backend/internal/models"
+)
+
+// ======================== REFUNDS & REVERSALS ========================
+
+var errNothingToReverse = errors.New("payment has already been fully refunded or reversed")
+
+// paymentReversal describes money taken back off a recorded payment: a refund paid out to the
+// student, or a reversal when the payment itself is rejected.
+type paymentReversal struct {
+	EntryType         string // LedgerRefund or LedgerReversal
+	Amount            float64
+	Reference         *string
+	TransactionNumber *string
+	PaymentMethod     *string
+	Reason            string
+	CreatedBy         *int64
+}
+
+// reversedAmount is what has already been refunded or reversed against a payment entry
+func reversedAmount(tx *gorm.DB, paymentEntryID int64) float64 {
+	var total float64
+	tx.Model(&models.FeeLedgerEntry{}).
+		Select("COALESCE(SUM(debit),0)").
+		Where("parent_entry_id = ? AND entry_type IN ?", paymentEntryID, []string{LedgerRefund, LedgerReversal}).
+		Scan(&total)
+	return total
+}
+
+// reversePayment posts a refund or reversal linked to the payment and updates the payment's status.
+// The amount is capped at what remains unreversed. It returns the posted entry, or nil when the
+// reference was already posted. Run it inside a transaction; the student is emailed on commit.
+func reversePayment(tx *gorm.DB, paymentEntryID int64, r paymentReversal) (*models.FeeLedgerEntry, error) {
+	var payment models.FeeLedgerEntry
+	res := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("entry_id = ? AND entry_type = ?", paymentEntryID, LedgerPayment).Limit(1).Find(&payment)
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	reversed := reversedAmount(tx, payment.EntryID)
+	remaining := roundMoney(payment.Credit - reversed)
+	amount := roundMoney(r.Amount)
+	if amount <= 0 || amount > remaining {
+		amount = remaining
+	}
+	if amount <= 0 {
+		return nil, errNothingToReverse
+	}
+
+	now := time.Now()
+	method := r.PaymentMethod
+	if method == nil {
+		method = payment.PaymentMethod
+	}
+	entry := models.FeeLedgerEntry{
+		EnrollmentNumber:  payment.EnrollmentNumber,
+		FeeTypeID:         payment.FeeTypeID,
+		EntryType:         r.EntryType,
+		Debit:             amount,
+		Reference:         r.Reference,
+		ParentEntryID:     &payment.EntryID,
+		TransactionNumber: r.TransactionNumber,
+		TransactionDate:   &now,
+		Semester:          payment.Semester,
+		PaymentMethod:     method,
+		Description:       ptrString(truncate(r.Reason, 255)),
+		CreatedBy:         r.CreatedBy,
+	}
+	posted, err := postLedgerEntry(tx, &entry)
+	if err != nil || !posted {
+		return nil, err
+	}
+
+	status := PaymentStatusPartiallyRefunded
+	switch {
+	case r.EntryType == LedgerReversal:
+		status = PaymentStatusRejected
+	case reversed+amount >= payment.Credit:
+		status = PaymentStatusRefunded
+	}
+	if err := tx.Model(&payment).Updates(map[string]interface{}{"status": status, "updated_at": now}).Error; err != nil {
+		return nil, err
+	}
+
+	var user models.User
+	if tx.Where("username = ?", strconv.FormatInt(payment.EnrollmentNumber, 10)).Limit(1).Find(&user).RowsAffected > 0 && user.Email != "" {
+		subject, body := paymentReversalEmail(user.FullName, r.EntryType, amount, safeString(payment.TransactionNumber), r.Reason)
+		if err := QueueEmail(tx, user.Email, subject, body); err != nil {
+			return nil, err
+		}
+	}
+	return &entry, nil
+}
+
+// notifyPaymentReversal pushes a committed refund or reversal to the admin dashboards
+func notifyPaymentReversal(entry *models.FeeLedgerEntry) {
+	event := "payment_refunded"
+	if entry.EntryType == LedgerReversal {
+		event = "payment_reversed"
+	}
+	SendAdminNotification(event, gin.H{
+		"entry_id":          entry.EntryID,
+		"payment_id":        entry.ParentEntryID,
+		"enrollment_number": entry.EnrollmentNumber,
+		"amount":            entry.Debit,
+	})
+}
+
+// RefundPayment refunds a recorded payment in full or in part. Online payments are refunded
+// through the gateway; offline payments record the refund the accounts office paid out.
+func RefundPayment(c *gin.Context) {
+	var req struct {
+		PaymentID         int64   `json:"payment_id" binding:"required"` // fee_ledger entry id
+		Amount            float64 `json:"amount" binding:"gte=0"`        // 0 refunds whatever remains
+		Reason            string  `json:"reason" binding:"required"`
+		TransactionNumber string  `json:"transaction_number"` // offline only: cheque / transfer reference
+		PaymentMethod     string  `json:"payment_method"`     // offline only
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	db := config.DB
+	var payment models.FeeLedgerEntry
+	if err := db.Where("entry_id = ? AND entry_type = ?", req.PaymentID, LedgerPayment).First(&payment).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "payment not found"})
+		return
+	}
+	if payment.Status == PaymentStatusPending || payment.Status == PaymentStatusRejected {
+		c.JSON(http.StatusConflict, gin.H{"error": "only paid payments can be refunded; reject a pending payment instead"})
+		return
+	}
+	remaining := roundMoney(payment.Credit - reversedAmount(db, payment.EntryID))
+	amount := roundMoney(req.Amount)
+	if amount == 0 {
+		amount = remaining
+	}
+	if remaining <= 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": errNothingToReverse.Error()})
+		return
+	}
+	if amount > remaining {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "amount exceeds the refundable balance", "refundable": remaining})
+		return
+	}
+	before := gin.H{"payment_status": payment.Status, "refunded": payment.Credit - remaining}
+
+	var createdBy *int64
+	if uid := c.GetInt64("user_id"); uid != 0 {
+		createdBy = &uid
+	}
+
+	var gp models.GatewayPayment
+	online := db.Where("fee_record_id = ?", payment.EntryID).Limit(1).Find(&gp).RowsAffected > 0
+	if !online && payment.Reference != nil {
+		online = db.Where("payment_id = ?", *payment.Reference).Limit(1).Find(&gp).RowsAffected > 0
+	}
+
+	var refundID string
+	if online {
+		if !requireGateway(c) {
+			return
+		}
+		refund, err := paymentGateway.Refund(gp.PaymentID, toPaise(amount), map[string]string{
+			"reason":   truncate(req.Reason, 250),
+			"entry_id": strconv.FormatInt(payment.EntryID, 10),
+		})
+		if err != nil {
+			c.JSON(http.StatusBadGateway, gin.H{"error": "gateway refund failed: " + err.Error()})
+			return
+		}
+		refundID = refund.ID
+		// refund.processed arrives later for the same refund id and is a no-op
+		if _, err := applyGatewayRefund(db, gp.PaymentID, refund.ID, float64(refund.Amount)/100, req.Reason, createdBy); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "refund issued but could not be recorded; it will be applied by the webhook", "refund_id": refund.ID})
+			return
+		}
+	} else {
+		r := paymentReversal{
+			EntryType: LedgerRefund,
+			Amount:    amount,
+			Reason:    req.Reason,
+			CreatedBy: createdBy,
+		}
+		if req.TransactionNumber != "" {
+			r.Reference = ptrString(req.TransactionNumber)
+			r.TransactionNumber = ptrString(req.TransactionNumber)
+		}
+		if req.PaymentMethod != "" {
+			r.PaymentMethod = ptrString(req.PaymentMethod)
+		}
+		var entry *models.FeeLedgerEntry
+		err := db.Transaction(func(tx *gorm.DB) error {
+			var err error
+			entry, err = reversePayment(tx, payment.EntryID, r)
+			return err
+		})
+		if err != nil {
+			if err == errNothingToReverse {
+				c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to record refund"})
+			return
+		}
+		if entry == nil {
+			c.JSON(http.StatusConflict, gin.H{"error": "a refund with this transaction number is already recorded"})
+			return
+		}
+		notifyPaymentReversal(entry)
+	}
+
+	db.Where("entry_id = ?", payment.EntryID).First(&payment)
+	middleware.Audit(c, "fees.refund", "fee_ledger", strconv.FormatInt(payment.EntryID, 10), before,
+		gin.H{"payment_status": payment.Status, "amount": amount, "refund_id": refundID, "reason": req.Reason})
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":    "refund recorded",
+		"payment_id": payment.EntryID,
+		"amount":     amount,
+		"refund_id":  refundID,
+		"status":     payment.Status,
+	})
+}
+
+// paymentReversalEmail tells a student that a payment was rejected or refunded
+func paymentReversalEmail(fullName, entryType string, amount float64, transactionNo, reason string) (string, string) {
+	if entryType == LedgerReversal {
+		return "Your fee payment was not accepted", fmt.Sprintf(`Hello %s,
+
+Your fee payment of Rs. %.2f (transaction %s) was rejected and has been removed from your fee account.
+
+Reason: %s
+
+The amount is due again. Please contact your institute office if you believe this is a mistake.
+`, fullName, amount, transactionNo, reason)
+	}
+	return "A fee refund has been issued", fmt.Sprintf(`Hello %s,
+
+A refund of Rs. %.2f has been issued against your fee payment (transaction %s).
+
+Reason: %s
+
+Online refunds usually reach the original payment method within 5-7 working days. You can see the updated balance at %s.
+`, fullName, amount, transactionNo, reason, config.AppBaseURL)
+}
@@ -0,0 +1,680 @@
+package controllers
+
+import (
+	"encoding/csv"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/kiranraoboinapally/student/backend/internal/config"
+	"github.com/kiranraoboinapally/student/backend/internal/models"
+	"github.com/kiranraoboinapally/student/backend/internal/xlsx"
+)
+
+// ======================== FEE REPORTS ========================
+
+// maxReportExportRows caps a CSV/XLSX export; on screen the reports are paginated
+const maxReportExportRows = 50000
+
+// reportGroupings are the levels aging and projection reports can be rolled up to
+var reportGroupings = map[string][]string{
+	"institute": {"institute_name"},
+	"course":    {"institute_name", "course_name"},
+	"batch":     {"institute_name", "course_name", "batch"},
+}
+
+// reportQuery holds what every fee report needs from the query string: the output format, the page
+// and the student filters (as SQL on master_students aliased "s")
+type reportQuery struct {
+	Format      string // json, csv or xlsx
+	Page, Limit int
+	StudentSQL  string
+	StudentArgs []interface{}
+	FeeTypeID   int
+}
+
+func (q reportQuery) export() bool { return q.Format != "json" }
+
+// paging returns the LIMIT/OFFSET suffix; exports get every row up to maxReportExportRows
+func (q reportQuery) paging() string {
+	if q.export() {
+		return " LIMIT " + strconv.Itoa(maxReportExportRows)
+	}
+	return " LIMIT " + strconv.Itoa(q.Limit) + " OFFSET " + strconv.Itoa((q.Page-1)*q.Limit)
+}
+
+// bounds is paging for a report assembled in memory: the slice of n rows to return
+func (q reportQuery) bounds(n int) (int, int) {
+	from, to := 0, n
+	if q.export() {
+		to = maxReportExportRows
+	} else {
+		from, to = (q.Page-1)*q.Limit, q.Page*q.Limit
+	}
+	return min(from, n), min(to, n)
+}
+
+func (q reportQuery) pagination(total int64) gin.H {
+	return gin.H{
+		"page":        q.Page,
+		"limit":       q.Limit,
+		"total":       total,
+		"total_pages": (total + int64(q.Limit) - 1) / int64(q.Limit),
+	}
+}
+
+// parseReportQuery reads format, page/limit and the institute_id, course_name, batch, session and
+// fee_type_id filters. It writes the 400 itself and returns false on bad input.
+func parseReportQuery(c *gin.Context) (reportQuery, bool) {
+	q := reportQuery{Format: strings.ToLower(c.DefaultQuery("format", "json"))}
+	if q.Format != "json" && q.Format != "csv" && q.Format != "xlsx" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be json, csv or xlsx"})
+		return q, false
+	}
+	q.Page, _ = strconv.Atoi(c.DefaultQuery("page", "1"))
+	q.Limit, _ = strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if q.Page < 1 {
+		q.Page = 1
+	}
+	if q.Limit < 1 || q.Limit > 500 {
+		q.Limit = 50
+	}
+
+	var conds []string
+	if v := c.Query("institute_id"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid institute_id"})
+			return q, false
+		}
+		conds = append(conds, "s.institute_id = ?")
+		q.StudentArgs = append(q.StudentArgs, id)
+	}
+	for _, f := range []string{"course_name", "batch", "session"} {
+		if v := strings.TrimSpace(c.Query(f)); v != "" {
+			conds = append(conds, "s."+f+" = ?")
+			q.StudentArgs = append(q.StudentArgs, v)
+		}
+	}
+	if len(conds) > 0 {
+		q.StudentSQL = " AND " + strings.Join(conds, " AND ")
+	}
+	if v := c.Query("fee_type_id"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid fee_type_id"})
+			return q, false
+		}
+		q.FeeTypeID = id
+	}
+	return q, true
+}
+
+// reportGrouping resolves ?group_by= to the master_students columns to roll up by
+func reportGrouping(c *gin.Context) ([]string, bool) {
+	cols, ok := reportGroupings[c.DefaultQuery("group_by", "institute")]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "group_by must be institute, course or batch"})
+	}
+	return cols, ok
+}
+
+func qualified(cols []string, alias string) string {
+	out := make([]string, len(cols))
+	for i, col := range cols {
+		out[i] = alias + "." + col
+	}
+	return strings.Join(out, ", ")
+}
+
+// countReportRows counts the rows a report query returns before paging
+func countReportRows(query string, args []interface{}) (int64, error) {
+	var total int64
+	err := config.DB.Raw("SELECT COUNT(*) FROM ("+query+") report_rows", args...).Scan(&total).Error
+	return total, err
+}
+
+// reportDate parses an optional YYYY-MM-DD query parameter
+func reportDate(c *gin.Context, name string, def time.Time) (time.Time, bool) {
+	v := c.Query(name)
+	if v == "" {
+		return def, true
+	}
+	t, err := time.ParseInLocation("2006-01-02", v, time.Local)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": name + " must be YYYY-MM-DD"})
+		return t, false
+	}
+	return t, true
+}
+
+// sendReport writes rows as a CSV or XLSX attachment named <name>-<timestamp>
+func sendReport(c *gin.Context, format, name string, header []string, rows [][]interface{}) {
+	filename := name + "-" + time.Now().Format("20060102-150405") + "." + format
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	if format == "xlsx" {
+		c.Header("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+		c.Status(http.StatusOK)
+		xlsx.Write(c.Writer, name, header, rows)
+		return
+	}
+	c.Header("Content-Type", "text/csv")
+	w := csv.NewWriter(c.Writer)
+	w.Write(header)
+	for _, row := range rows {
+		record := make([]string, len(row))
+		for i, v := range row {
+			record[i] = reportCell(v)
+		}
+		w.Write(record)
+	}
+	w.Flush()
+}
+
+func reportCell(v interface{}) string {
+	switch t := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return t
+	case *string:
+		return optString(t)
+	case int:
+		return strconv.Itoa(t)
+	case int64:
+		return strconv.FormatInt(t, 10)
+	case float64:
+		return strconv.FormatFloat(t, 'f', 2, 64)
+	case *time.Time:
+		return safeString(optTimeString(t, "2006-01-02"))
+	}
+	return ""
+}
+
+// groupValues returns the group-by columns of a report row for export
+func groupValues(cols []string, inst, course, batch *string) []interface{} {
+	values := map[string]*string{"institute_name": inst, "course_name": course, "batch": batch}
+	out := make([]interface{}, len(cols))
+	for i, col := range cols {
+		out[i] = values[col]
+	}
+	return out
+}
+
+// groupKey identifies a report group by its groupValues
+func groupKey(values []interface{}) string {
+	parts := make([]string, len(values))
+	for i, v := range values {
+		if p, ok := v.(*string); ok && p != nil {
+			parts[i] = *p
+		}
+	}
+	return strings.Join(parts, "\x00")
+}
+
+// outstandingCTE splits each student's balance per fee type across the charges that make it up,
+// settling the oldest charges first, so what is left sits on the newest ones. Each outstanding row
+// carries its due date and days overdue as of asOf; charges without a due date fall due when posted.
+// Entries recorded after asOf are ignored, so the report can be run for a past date.
+const outstandingCTE = `
+WITH ledger AS (
+	SELECT l.entry_id, l.enrollment_number, l.fee_type_id, l.entry_type, l.debit, l.credit,
+		COALESCE(l.due_date, l.transaction_date, l.created_at) AS due_on
+	FROM fee_ledger l
+	WHERE l.status NOT IN ? AND COALESCE(l.transaction_date, l.created_at) < ?
+),
+balances AS (
+	SELECT enrollment_number, fee_type_id, SUM(debit - credit) AS balance
+	FROM ledger
+	GROUP BY enrollment_number, fee_type_id
+	HAVING SUM(debit - credit) > 0
+),
+items AS (
+	SELECT enrollment_number, fee_type_id, due_on, debit - credit AS amount,
+		SUM(debit - credit) OVER (PARTITION BY enrollment_number, fee_type_id ORDER BY due_on DESC, entry_id DESC) AS newer_total
+	FROM ledger
+	WHERE entry_type IN ('charge', 'adjustment', 'penalty') AND debit > credit
+),
+outstanding AS (
+	SELECT i.enrollment_number, i.fee_type_id, i.due_on, DATEDIFF(?, i.due_on) AS days_overdue,
+		LEAST(i.amount, b.balance - (i.newer_total - i.amount)) AS amount
+	FROM items i
+	JOIN balances b ON b.enrollment_number = i.enrollment_number AND b.fee_type_id = i.fee_type_id
+	WHERE b.balance > i.newer_total - i.amount
+)
+`
+
+func outstandingArgs(asOf time.Time) []interface{} {
+	return []interface{}{ledgerExcludedStatuses, asOf.AddDate(0, 0, 1), asOf.Format("2006-01-02")}
+}
+
+// outstandingFilter restricts the outstanding rows (aliased "o") to the report's students and fee type
+func outstandingFilter(q reportQuery) (string, []interface{}) {
+	where := " WHERE 1 = 1" + q.StudentSQL
+	args := append([]interface{}{}, q.StudentArgs...)
+	if q.FeeTypeID != 0 {
+		where += " AND o.fee_type_id = ?"
+		args = append(args, q.FeeTypeID)
+	}
+	return where, args
+}
+
+type agingRow struct {
+	InstituteName *string `json:"institute_name"`
+	CourseName    *string `json:"course_name,omitempty"`
+	Batch         *string `json:"batch,omitempty"`
+	Students      int64   `json:"students"`
+	NotDue        float64 `json:"not_due"`
+	Days0To30     float64 `gorm:"column:days_0_30" json:"days_0_30"`
+	Days31To60    float64 `gorm:"column:days_31_60" json:"days_31_60"`
+	Days61To90    float64 `gorm:"column:days_61_90" json:"days_61_90"`
+	Days90Plus    float64 `gorm:"column:days_90_plus" json:"days_90_plus"`
+	Outstanding   float64 `json:"outstanding"`
+}
+
+// GetFeeAgingReport buckets outstanding fees by days overdue (0-30, 31-60, 61-90, 90+) per
+// institute, course or batch (?group_by=). ?as_of= runs it for a past date.
+func GetFeeAgingReport(c *gin.Context) {
+	q, ok := parseReportQuery(c)
+	if !ok {
+		return
+	}
+	cols, ok := reportGrouping(c)
+	if !ok {
+		return
+	}
+	asOf, ok := reportDate(c, "as_of", time.Now())
+	if !ok {
+		return
+	}
+
+	where, filterArgs := outstandingFilter(q)
+	group := qualified(cols, "s")
+	query := outstandingCTE + `
+		SELECT ` + group + `, COUNT(DISTINCT o.enrollment_number) AS students,
+			SUM(CASE WHEN o.days_overdue < 0 THEN o.amount ELSE 0 END) AS not_due,
+			SUM(CASE WHEN o.days_overdue BETWEEN 0 AND 30 THEN o.amount ELSE 0 END) AS days_0_30,
+			SUM(CASE WHEN o.days_overdue BETWEEN 31 AND 60 THEN o.amount ELSE 0 END) AS days_31_60,
+			SUM(CASE WHEN o.days_overdue BETWEEN 61 AND 90 THEN o.amount ELSE 0 END) AS days_61_90,
+			SUM(CASE WHEN o.days_overdue > 90 THEN o.amount ELSE 0 END) AS days_90_plus,
+			SUM(o.amount) AS outstanding
+		FROM outstanding o
+		LEFT JOIN master_students s ON s.enrollment_number = o.enrollment_number` + where + `
+		GROUP BY ` + group
+	args := append(outstandingArgs(asOf), filterArgs...)
+
+	total, err := countReportRows(query, args)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to build report"})
+		return
+	}
+	rows := []agingRow{}
+	if err := config.DB.Raw(query+" ORDER BY outstanding DESC, "+group+q.paging(), args...).Scan(&rows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to build report"})
+		return
+	}
+
+	if q.export() {
+		header := append(append([]string{}, cols...), "students", "not_due", "days_0_30", "days_31_60", "days_61_90", "days_90_plus", "outstanding")
+		out := make([][]interface{}, 0, len(rows))
+		for _, r := range rows {
+			out = append(out, append(groupValues(cols, r.InstituteName, r.CourseName, r.Batch),
+				r.Students, r.NotDue, r.Days0To30, r.Days31To60, r.Days61To90, r.Days90Plus, r.Outstanding))
+		}
+		sendReport(c, q.Format, "fee-aging", header, out)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"as_of":      asOf.Format("2006-01-02"),
+		"group_by":   cols[len(cols)-1],
+		"rows":       rows,
+		"pagination": q.pagination(total),
+	})
+}
+
+type defaulterRow struct {
+	EnrollmentNumber int64      `json:"enrollment_number"`
+	StudentName      *string    `json:"student_name"`
+	FatherName       *string    `json:"father_name"`
+	Email            *string    `json:"email"`
+	Phone            *string    `json:"phone"`
+	InstituteName    *string    `json:"institute_name"`
+	CourseName       *string    `json:"course_name"`
+	Batch            *string    `json:"batch"`
+	Outstanding      float64    `json:"outstanding"`
+	Overdue          float64    `json:"overdue"`
+	MaxDaysOverdue   int        `json:"max_days_overdue"`
+	OldestDue        *time.Time `json:"oldest_due"`
+}
+
+// GetFeeDefaulters lists students with fees overdue by at least ?min_days= (default 1) totalling
+// at least ?min_amount=, largest first, with their contact details
+func GetFeeDefaulters(c *gin.Context) {
+	q, ok := parseReportQuery(c)
+	if !ok {
+		return
+	}
+	asOf, ok := reportDate(c, "as_of", time.Now())
+	if !ok {
+		return
+	}
+	minDays, err := strconv.Atoi(c.DefaultQuery("min_days", "1"))
+	if err != nil || minDays < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "min_days must be a non-negative number"})
+		return
+	}
+	minAmount, err := strconv.ParseFloat(c.DefaultQuery("min_amount", "0.01"), 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid min_amount"})
+		return
+	}
+
+	where, filterArgs := outstandingFilter(q)
+	query := outstandingCTE + `
+		SELECT o.enrollment_number, s.student_name, s.father_name, s.student_email_id AS email,
+			s.student_phone_number AS phone, s.institute_name, s.course_name, s.batch,
+			SUM(o.amount) AS outstanding,
+			SUM(CASE WHEN o.days_overdue >= ? THEN o.amount ELSE 0 END) AS overdue,
+			MAX(o.days_overdue) AS max_days_overdue,
+			MIN(CASE WHEN o.days_overdue >= ? THEN o.due_on END) AS oldest_due
+		FROM outstanding o
+		LEFT JOIN master_students s ON s.enrollment_number = o.enrollment_number` + where + `
+		GROUP BY o.enrollment_number, s.student_name, s.father_name, s.student_email_id,
+			s.student_phone_number, s.institute_name, s.course_name, s.batch
+		HAVING overdue >= ?`
+	args := append(outstandingArgs(asOf), minDays, minDays)
+	args = append(append(args, filterArgs...), minAmount)
+
+	total, err := countReportRows(query, args)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to build report"})
+		return
+	}
+	rows := []defaulterRow{}
+	if err := config.DB.Raw(query+" ORDER BY overdue DESC, o.enrollment_number"+q.paging(), args...).Scan(&rows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to build report"})
+		return
+	}
+
+	if q.export() {
+		header := []string{"enrollment_number", "student_name", "father_name", "email", "phone", "institute_name",
+			"course_name", "batch", "outstanding", "overdue", "max_days_overdue", "oldest_due"}
+		out := make([][]interface{}, 0, len(rows))
+		for _, r := range rows {
+			out = append(out, []interface{}{r.EnrollmentNumber, r.StudentName, r.FatherName, r.Email, r.Phone,
+				r.InstituteName, r.CourseName, r.Batch, r.Outstanding, r.Overdue, r.MaxDaysOverdue, r.OldestDue})
+		}
+		sendReport(c, q.Format, "fee-defaulters", header, out)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"as_of":      asOf.Format("2006-01-02"),
+		"defaulters": rows,
+		"pagination": q.pagination(total),
+	})
+}
+
+// collectionPeriods are the DATE_FORMAT buckets of the collection trend; weeks are ISO weeks
+var collectionPeriods = map[string]string{
+	"day":   "%Y-%m-%d",
+	"week":  "%x-W%v",
+	"month": "%Y-%m",
+}
+
+type collectionRow struct {
+	Period      string  `json:"period"`
+	FeeHead     string  `json:"fee_head"`
+	PaymentMode string  `json:"payment_mode"`
+	Payments    int64   `json:"payments"`
+	Collected   float64 `json:"collected"`
+	Refunded    float64 `json:"refunded"`
+	Net         float64 `json:"net"`
+}
+
+// GetFeeCollectionTrend totals money received per ?period= (day, week or month) between ?from= and
+// ?to=, by fee head and payment mode. Refunds count against the period they were paid out in;
+// payments awaiting verification and rejected payments are left out.
+func GetFeeCollectionTrend(c *gin.Context) {
+	q, ok := parseReportQuery(c)
+	if !ok {
+		return
+	}
+	period := c.DefaultQuery("period", "day")
+	layout, ok := collectionPeriods[period]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "period must be day, week or month"})
+		return
+	}
+	to, ok := reportDate(c, "to", time.Now())
+	if !ok {
+		return
+	}
+	def := map[string]time.Time{"day": to.AddDate(0, 0, -30), "week": to.AddDate(0, 0, -7*12), "month": to.AddDate(-1, 0, 0)}
+	from, ok := reportDate(c, "from", def[period])
+	if !ok {
+		return
+	}
+	if from.After(to) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from must not be after to"})
+		return
+	}
+
+	where := " WHERE m.received_on >= ? AND m.received_on < ?" + q.StudentSQL
+	filterArgs := append([]interface{}{from.Format("2006-01-02"), to.AddDate(0, 0, 1).Format("2006-01-02")}, q.StudentArgs...)
+	if q.FeeTypeID != 0 {
+		where += " AND m.fee_type_id = ?"
+		filterArgs = append(filterArgs, q.FeeTypeID)
+	}
+	query := `
+		SELECT DATE_FORMAT(m.received_on, '` + layout + `') AS period, t.fee_type_name AS fee_head, m.payment_mode,
+			SUM(m.payments) AS payments, SUM(m.collected) AS collected, SUM(m.refunded) AS refunded,
+			SUM(m.collected) - SUM(m.refunded) AS net
+		FROM (
+			SELECT l.enrollment_number, l.fee_type_id, COALESCE(l.transaction_date, l.created_at) AS received_on,
+				COALESCE(NULLIF(l.payment_method, ''), 'unknown') AS payment_mode,
+				1 AS payments, l.credit AS collected, 0 AS refunded
+			FROM fee_ledger l
+			WHERE l.entry_type = ? AND l.status NOT IN ?
+			UNION ALL
+			SELECT r.enrollment_number, r.fee_type_id, COALESCE(r.transaction_date, r.created_at),
+				COALESCE(NULLIF(p.payment_method, ''), 'unknown'),
+				0, 0, r.debit - r.credit
+			FROM fee_ledger r
+			LEFT JOIN fee_ledger p ON p.entry_id = r.parent_entry_id
+			WHERE r.entry_type = ?
+		) m
+		JOIN master_fee_types t ON t.fee_type_id = m.fee_type_id
+		LEFT JOIN master_students s ON s.enrollment_number = m.enrollment_number` + where + `
+		GROUP BY period, t.fee_type_name, m.payment_mode`
+	args := append([]interface{}{LedgerPayment, []string{PaymentStatusPending, PaymentStatusRejected}, LedgerRefund}, filterArgs...)
+
+	total, err := countReportRows(query, args)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to build report"})
+		return
+	}
+	rows := []collectionRow{}
+	if err := config.DB.Raw(query+" ORDER BY period, fee_head, m.payment_mode"+q.paging(), args...).Scan(&rows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to build report"})
+		return
+	}
+
+	if q.export() {
+		header := []string{"period", "fee_head", "payment_mode", "payments", "collected", "refunded", "net"}
+		out := make([][]interface{}, 0, len(rows))
+		for _, r := range rows {
+			out = append(out, []interface{}{r.Period, r.FeeHead, r.PaymentMode, r.Payments, r.Collected, r.Refunded, r.Net})
+		}
+		sendReport(c, q.Format, "fee-collections", header, out)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"period":     period,
+		"from":       from.Format("2006-01-02"),
+		"to":         to.Format("2006-01-02"),
+		"rows":       rows,
+		"pagination": q.pagination(total),
+	})
+}
+
+type projectionRow struct {
+	InstituteName  *string `json:"institute_name"`
+	CourseName     *string `json:"course_name,omitempty"`
+	Batch          *string `json:"batch,omitempty"`
+	Students       int64   `json:"students"`
+	Projected      float64 `json:"projected"`
+	Charged        float64 `json:"charged"`
+	Collected      float64 `json:"collected"`
+	Outstanding    float64 `json:"outstanding"`
+	Shortfall      float64 `gorm:"-" json:"shortfall"`       // projected - collected
+	CollectionRate float64 `gorm:"-" json:"collection_rate"` // collected as a % of projected
+}
+
+// GetFeeProjectionReport compares what the fee structures in force project against what the ledger
+// has charged and collected, per institute, course or batch. Each active student is projected the
+// most specific structure per fee type, as the dues run would charge it; ?as_of=YYYY-MM-DD picks the
+// structures (default today). expected_fee_collections is no longer read, as nothing has written it
+// since the ledger replaced it. Students are the active ones plus anyone with ledger entries.
+func GetFeeProjectionReport(c *gin.Context) {
+	q, ok := parseReportQuery(c)
+	if !ok {
+		return
+	}
+	cols, ok := reportGrouping(c)
+	if !ok {
+		return
+	}
+	asOf, ok := reportDate(c, "as_of", time.Now())
+	if !ok {
+		return
+	}
+
+	db := config.DB
+	structures, err := effectiveStructures(db, asOf)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load fee structures"})
+		return
+	}
+	if q.FeeTypeID != 0 {
+		filtered := structures[:0]
+		for _, fs := range structures {
+			if fs.FeeTypeID == q.FeeTypeID {
+				filtered = append(filtered, fs)
+			}
+		}
+		structures = filtered
+	}
+
+	// Students are rolled up by the attributes fee structures match on, so each combination is
+	// resolved once rather than per student
+	ledgerWhere := "fee_ledger.status NOT IN ?"
+	args := []interface{}{ledgerExcludedStatuses}
+	if q.FeeTypeID != 0 {
+		ledgerWhere += " AND fee_ledger.fee_type_id = ?"
+		args = append(args, q.FeeTypeID)
+	}
+	args = append(args, q.StudentArgs...)
+	var profiles []struct {
+		models.MasterStudent
+		Active      bool
+		Students    int64
+		Charged     float64
+		Collected   float64
+		Outstanding float64
+	}
+	err = db.Raw(`
+		SELECT s.institute_name, s.course_name, s.batch, s.session, s.program_pattern,
+			COALESCE(LOWER(s.student_status) = 'active', FALSE) AS active, COUNT(*) AS students,
+			COALESCE(SUM(b.charged),0) AS charged,
+			COALESCE(SUM(b.paid),0) AS collected,
+			COALESCE(SUM(b.balance),0) AS outstanding
+		FROM master_students s
+		LEFT JOIN (
+			SELECT fee_ledger.enrollment_number,`+ledgerBalanceColumns+`
+			FROM fee_ledger
+			WHERE `+ledgerWhere+`
+			GROUP BY fee_ledger.enrollment_number
+		) b ON b.enrollment_number = s.enrollment_number
+		WHERE (LOWER(s.student_status) = 'active' OR b.enrollment_number IS NOT NULL)`+q.StudentSQL+`
+		GROUP BY s.institute_name, s.course_name, s.batch, s.session, s.program_pattern, active`, args...).
+		Scan(&profiles).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to build report"})
+		return
+	}
+
+	rows := []projectionRow{}
+	index := map[string]int{}
+	for _, p := range profiles {
+		key := groupKey(groupValues(cols, p.InstituteName, p.CourseName, p.Batch))
+		i, seen := index[key]
+		if !seen {
+			row := projectionRow{InstituteName: p.InstituteName}
+			for _, col := range cols {
+				switch col {
+				case "course_name":
+					row.CourseName = p.CourseName
+				case "batch":
+					row.Batch = p.Batch
+				}
+			}
+			i = len(rows)
+			index[key] = i
+			rows = append(rows, row)
+		}
+		r := &rows[i]
+		r.Students += p.Students
+		r.Charged += p.Charged
+		r.Collected += p.Collected
+		r.Outstanding += p.Outstanding
+		if p.Active {
+			for _, fs := range resolveStructures(structures, p.MasterStudent) {
+				r.Projected += roundMoney(fs.FeeAmount) * float64(p.Students)
+			}
+		}
+	}
+	for i := range rows {
+		r := &rows[i]
+		r.Projected = roundMoney(r.Projected)
+		r.Charged = roundMoney(r.Charged)
+		r.Collected = roundMoney(r.Collected)
+		r.Outstanding = roundMoney(r.Outstanding)
+		r.Shortfall = roundMoney(r.Projected - r.Collected)
+		if r.Projected > 0 {
+			r.CollectionRate = roundMoney(r.Collected / r.Projected * 100)
+		}
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		if rows[i].Projected != rows[j].Projected {
+			return rows[i].Projected > rows[j].Projected
+		}
+		return groupKey(groupValues(cols, rows[i].InstituteName, rows[i].CourseName, rows[i].Batch)) <
+			groupKey(groupValues(cols, rows[j].InstituteName, rows[j].CourseName, rows[j].Batch))
+	})
+	total := int64(len(rows))
+	if from, to := q.bounds(len(rows)); from < to {
+		rows = rows[from:to]
+	} else {
+		rows = []projectionRow{}
+	}
+
+	if q.export() {
+		header := append(append([]string{}, cols...), "students", "projected", "charged", "collected", "outstanding", "shortfall", "collection_rate")
+		out := make([][]interface{}, 0, len(rows))
+		for _, r := range rows {
+			out = append(out, append(groupValues(cols, r.InstituteName, r.CourseName, r.Batch),
+				r.Students, r.Projected, r.Charged, r.Collected, r.Outstanding, r.Shortfall, r.CollectionRate))
+		}
+		sendReport(c, q.Format, "fee-projection", header, out)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"group_by":   cols[len(cols)-1],
+		"rows":       rows,
+		"pagination": q.pagination(total),
+	})
+}
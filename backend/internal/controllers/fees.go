@@ -1,33 +1,51 @@
 package controllers
 
 import (
+	"errors"
 	"fmt"
+	"log"
 	"net/http"
-	"os"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
 	"github.com/kiranraoboinapally/student/backend/internal/config"
+	"github.com/kiranraoboinapally/student/backend/internal/middleware"
 	"github.com/kiranraoboinapally/student/backend/internal/models"
-	razorpay "github.com/razorpay/razorpay-go"
-	utils "github.com/razorpay/razorpay-go/utils"
+	"github.com/kiranraoboinapally/student/backend/internal/payments"
 )
 
-var (
-	RazorpayKeyID  string
-	RazorpaySecret string
-	rzpClient      *razorpay.Client
-)
+// paymentGateway is nil when online payments are disabled
+var paymentGateway payments.PaymentGateway
+
+// InitPaymentGateway selects the gateway from PAYMENT_GATEWAY. Missing Razorpay credentials
+// disable online payments instead of stopping the server.
+func InitPaymentGateway() {
+	switch config.PaymentGateway {
+	case "razorpay":
+		if config.RazorpayKeyID == "" || config.RazorpaySecret == "" {
+			log.Println("payments: RAZORPAY_KEY_ID/RAZORPAY_SECRET not set, online payments disabled")
+			return
+		}
+		paymentGateway = payments.NewRazorpayGateway(config.RazorpayKeyID, config.RazorpaySecret, config.RazorpayWebhookSecret)
+	case "mock":
+		log.Println("payments: using the in-memory mock gateway")
+		paymentGateway = payments.NewMockGateway("", config.RazorpayWebhookSecret)
+	default:
+		log.Println("payments: online payments disabled")
+	}
+}
 
-func InitRazorpay() {
-	RazorpayKeyID = os.Getenv("RAZORPAY_KEY_ID")
-	RazorpaySecret = os.Getenv("RAZORPAY_SECRET")
-	if RazorpayKeyID == "" || RazorpaySecret == "" {
-		panic("RAZORPAY_KEY_ID and RAZORPAY_SECRET env vars missing")
+// requireGateway answers 503 when no gateway is configured
+func requireGateway(c *gin.Context) bool {
+	if paymentGateway == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Online payments are not enabled"})
+		return false
 	}
-	rzpClient = razorpay.NewClient(RazorpayKeyID, RazorpaySecret)
+	return true
 }
 
 func getStudentEnrollment(c *gin.Context) (int64, error) {
@@ -66,18 +84,26 @@ type UnifiedFeeRecord struct {
 	TransactionDate string  `json:"transaction_date"`
 	Status          string  `json:"payment_status"`
 	CreatedAt       string  `json:"created_at"`
+	ReceiptID       *int64  `json:"receipt_id,omitempty"`
+	ReceiptNumber   *string `json:"receipt_number,omitempty"`
 }
 
 type DueFeeRecord struct {
-	FeeDueID       int64   `json:"fee_due_id"`
-	FeeType        string  `json:"fee_type"`
-	FeeHead        string  `json:"fee_head"`
-	OriginalAmount float64 `json:"original_amount"`
-	AmountPaid     float64 `json:"amount_paid"`
-	DueDate        string  `json:"due_date"`
-	Status         string  `json:"status"`
+	FeeDueID       int64        `json:"fee_due_id"`
+	FeeType        string       `json:"fee_type"`
+	FeeHead        string       `json:"fee_head"`
+	OriginalAmount float64      `json:"original_amount"` // principal + late fee
+	AmountPaid     float64      `json:"amount_paid"`     // payments + waivers
+	Principal      float64      `json:"principal"`
+	LateFee        float64      `json:"late_fee"`
+	TotalPayable   float64      `json:"total_payable"`
+	DueDate        string       `json:"due_date"` // earliest unpaid due date; empty when the charge has none
+	DaysOverdue    int          `json:"days_overdue"`
+	Status         string       `json:"status"`
+	Installments   []openCharge `json:"installments"` // each charge, oldest due first, with what is still unpaid
 }
 
+// GetStudentFees returns outstanding balances per fee type and the payment history, both from the ledger
 func GetStudentFees(c *gin.Context) {
 	enrollment, err := getEnrollmentOrError(c)
 	if err != nil {
@@ -85,46 +111,76 @@ func GetStudentFees(c *gin.Context) {
 		return
 	}
 	db := config.DB
-	var expected models.ExpectedFee
+
+	balances, err := studentFeeBalances(db, enrollment)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load fees"})
+		return
+	}
+	now := time.Now()
 	dues := []DueFeeRecord{}
-	if err := db.Where("enrollment_number = ?", enrollment).First(&expected).Error; err == nil {
-		if bal := expected.ExpectedRegFee - expected.RegistrationFeePaid; bal > 0 {
-			dues = append(dues, DueFeeRecord{FeeType: "Registration", FeeHead: "Registration Fee", OriginalAmount: expected.ExpectedRegFee, AmountPaid: expected.RegistrationFeePaid, DueDate: time.Now().AddDate(0, 3, 0).Format("2006-01-02"), Status: "Pending"})
+	for _, b := range balances {
+		if b.Balance <= 0 {
+			continue
 		}
-		if bal := expected.ExpectedExamFee - expected.ExamFeePaid; bal > 0 {
-			dues = append(dues, DueFeeRecord{FeeType: "Examination", FeeHead: "Examination Fee", OriginalAmount: expected.ExpectedExamFee, AmountPaid: expected.ExamFeePaid, DueDate: time.Now().AddDate(0, 3, 0).Format("2006-01-02"), Status: "Pending"})
+		due := DueFeeRecord{
+			FeeDueID:       int64(b.FeeTypeID),
+			FeeType:        b.FeeTypeName,
+			FeeHead:        feeHeadLabel(b.FeeTypeName),
+			OriginalAmount: b.Charged + b.Penalty,
+			AmountPaid:     b.Paid + b.Waived,
+			Principal:      b.Charged,
+			LateFee:        b.Penalty,
+			TotalPayable:   b.Balance,
+			Status:         "Pending",
 		}
-	}
-	payments := []UnifiedFeeRecord{}
-	var registration []models.RegistrationFee
-	if err := db.Where("enrollment_number = ?", enrollment).Order("transaction_date desc").Find(&registration).Error; err == nil {
-		for _, r := range registration {
-			createdAtStr := ""
-			if r.CreatedAt != nil {
-				createdAtStr = *r.CreatedAt
+		charges, _ := openCharges(db, enrollment, b.FeeTypeID)
+		due.Installments = charges
+		for _, ch := range charges {
+			if ch.Outstanding > 0 && ch.DueDate != nil {
+				due.DueDate = ch.DueDate.Format("2006-01-02")
+				if days := daysOverdue(*ch.DueDate, now); days > 0 {
+					due.DaysOverdue = days
+					due.Status = "Overdue"
+				}
+				break
 			}
-			payments = append(payments, UnifiedFeeRecord{ID: r.RegnFeeID, Type: "Registration", Head: "Registration Fee", OriginalAmount: safeFloat(r.FeeAmount), AmountPaid: safeFloat(r.FeeAmount), TransactionNo: safeString(r.TransactionNumber), TransactionDate: safeString(r.TransactionDate), Status: safeString(r.PaymentStatus), CreatedAt: createdAtStr})
 		}
+		dues = append(dues, due)
 	}
-	var examination []models.ExaminationFee
-	if err := db.Where("enrollment_number = ?", enrollment).Order("transaction_date desc").Find(&examination).Error; err == nil {
-		for _, e := range examination {
-			createdAtStr := ""
-			if e.CreatedAt != nil {
-				createdAtStr = *e.CreatedAt
-			}
-			payments = append(payments, UnifiedFeeRecord{ID: e.ExamFeeID, Type: "Examination", Head: "Examination Fee", OriginalAmount: safeFloat(e.FeeAmount), AmountPaid: safeFloat(e.FeeAmount), TransactionNo: safeString(e.TransactionNo), TransactionDate: safeString(e.TransactionDate), Status: safeString(e.PaymentStatus), CreatedAt: createdAtStr})
-		}
+
+	var rows []struct {
+		models.FeeLedgerEntry
+		FeeTypeName   string
+		ReceiptID     *int64
+		ReceiptNumber *string
 	}
-	var miscellaneous []models.MiscellaneousFee
-	if err := db.Where("enrollment_number = ?", enrollment).Order("transaction_date desc").Find(&miscellaneous).Error; err == nil {
-		for _, m := range miscellaneous {
-			createdAtStr := ""
-			if m.CreatedAt != nil {
-				createdAtStr = *m.CreatedAt
-			}
-			payments = append(payments, UnifiedFeeRecord{ID: m.MiscFeeID, Type: "Miscellaneous", Head: "Miscellaneous Fee", OriginalAmount: safeFloat(m.FeeAmount), AmountPaid: safeFloat(m.FeeAmount), TransactionNo: safeString(m.TransactionNo), TransactionDate: safeString(m.TransactionDate), Status: safeString(m.PaymentStatus), CreatedAt: createdAtStr})
+	db.Table("fee_ledger").
+		Select("fee_ledger.*, master_fee_types.fee_type_name, fee_receipts.receipt_id, fee_receipts.receipt_number").
+		Joins("JOIN master_fee_types ON master_fee_types.fee_type_id = fee_ledger.fee_type_id").
+		Joins("LEFT JOIN fee_receipts ON fee_receipts.payment_entry_id = fee_ledger.entry_id").
+		Where("fee_ledger.enrollment_number = ? AND fee_ledger.entry_type IN ?", enrollment, []string{LedgerPayment, LedgerRefund, LedgerReversal}).
+		Order("fee_ledger.transaction_date DESC, fee_ledger.entry_id DESC").
+		Scan(&rows)
+	payments := []UnifiedFeeRecord{}
+	for _, r := range rows {
+		amount := r.Credit
+		if r.EntryType != LedgerPayment {
+			amount = -r.Debit
 		}
+		payments = append(payments, UnifiedFeeRecord{
+			ID:              r.EntryID,
+			Type:            r.FeeTypeName,
+			Head:            feeHeadLabel(r.FeeTypeName),
+			OriginalAmount:  amount,
+			AmountPaid:      amount,
+			TransactionNo:   safeString(r.TransactionNumber),
+			TransactionDate: safeString(optTimeString(r.TransactionDate, "2006-01-02")),
+			Status:          r.Status,
+			CreatedAt:       r.CreatedAt.Format("2006-01-02 15:04:05"),
+			ReceiptID:       r.ReceiptID,
+			ReceiptNumber:   r.ReceiptNumber,
+		})
 	}
 	c.JSON(http.StatusOK, gin.H{"dues": dues, "payments": payments})
 }
@@ -142,24 +198,104 @@ func safeFloat(ptr *float64) float64 {
 	return *ptr
 }
 
+// paymentAmountError is a payment amount the student may not pay, with the response to send
+type paymentAmountError struct {
+	Status int
+	Body   gin.H
+}
+
+func (e paymentAmountError) Error() string { return fmt.Sprint(e.Body["error"]) }
+
+// paymentAmountFor validates what a student wants to pay on a fee type. Paying an installment
+// defaults the amount to what is outstanding on it; paying ahead on later installments is allowed up
+// to what is owed on the fee type in total, less what open gateway orders on it may still collect.
+// Nothing may be paid on a fee type with no balance due.
+func paymentAmountFor(db *gorm.DB, enrollment int64, feeTypeID int, amount float64, chargeID *int64) (float64, error) {
+	if chargeID != nil {
+		charges, err := openCharges(db, enrollment, feeTypeID)
+		if err != nil {
+			return 0, err
+		}
+		var target *openCharge
+		for i := range charges {
+			if charges[i].EntryID == *chargeID {
+				target = &charges[i]
+			}
+		}
+		if target == nil {
+			return 0, paymentAmountError{http.StatusBadRequest, gin.H{"error": "Installment not found"}}
+		}
+		if target.Outstanding <= 0 {
+			return 0, paymentAmountError{http.StatusConflict, gin.H{"error": "Installment is already paid"}}
+		}
+		if amount == 0 {
+			amount = target.Outstanding
+		}
+	}
+	if amount <= 0 {
+		return 0, paymentAmountError{http.StatusBadRequest, gin.H{"error": "amount is required"}}
+	}
+	totals := sumLedger(db, "fee_ledger.enrollment_number = ? AND fee_ledger.fee_type_id = ?", enrollment, feeTypeID)
+	if totals.Balance <= 0 {
+		return 0, paymentAmountError{http.StatusBadRequest, gin.H{"error": "Nothing is outstanding on this fee", "balance": 0}}
+	}
+	pending, err := openOrderAmount(db, enrollment, feeTypeID)
+	if err != nil {
+		return 0, err
+	}
+	payable := roundMoney(totals.Balance - pending)
+	if payable <= 0 {
+		return 0, paymentAmountError{http.StatusConflict, gin.H{"error": "A payment for the rest of this fee is already in progress; try again once it completes or expires",
+			"balance": totals.Balance, "pending": pending}}
+	}
+	if roundMoney(amount) > payable {
+		if pending > 0 {
+			return 0, paymentAmountError{http.StatusBadRequest, gin.H{"error": "Amount exceeds the outstanding balance less payments in progress",
+				"balance": totals.Balance, "pending": pending}}
+		}
+		return 0, paymentAmountError{http.StatusBadRequest, gin.H{"error": "Amount exceeds the outstanding balance", "balance": totals.Balance}}
+	}
+	return amount, nil
+}
+
 type RequestPaymentRequest struct {
-	Amount  float64 `json:"amount" binding:"required,gt=0"`
-	FeeHead string  `json:"fee_head" binding:"required"`
-	FeeType string  `json:"fee_type" binding:"required"`
+	Amount   float64 `json:"amount" binding:"gte=0"` // may be omitted when paying an installment
+	FeeHead  string  `json:"fee_head" binding:"required"`
+	FeeType  string  `json:"fee_type" binding:"required"`
+	ChargeID *int64  `json:"charge_id"` // installment to pay; any excess is paid ahead on later ones
 }
 
 func RequestPayment(c *gin.Context) {
+	if !requireGateway(c) {
+		return
+	}
 	var req RequestPaymentRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	feeType, err := resolveFeeType(config.DB, req.FeeHead)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid fee head"})
+		return
+	}
 	enrollment, err := getEnrollmentOrError(c)
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
 		return
 	}
 	db := config.DB
+
+	amount, err := paymentAmountFor(db, enrollment, feeType.FeeTypeID, req.Amount, req.ChargeID)
+	if err != nil {
+		var pe paymentAmountError
+		if errors.As(err, &pe) {
+			c.JSON(pe.Status, pe.Body)
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load fees"})
+		return
+	}
 	var user models.User
 	if err := db.Where("username = ?", strconv.FormatInt(enrollment, 10)).First(&user).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
@@ -178,60 +314,114 @@ func RequestPayment(c *gin.Context) {
 		}
 		studentName = master.StudentName
 	}
-	order, err := rzpClient.Order.Create(map[string]interface{}{"amount": int(req.Amount * 100), "currency": "INR", "receipt": fmt.Sprintf("fee_%d_%d", enrollment, time.Now().Unix())}, nil)
+	// Notes carry the attribution the webhook needs when the browser never calls back
+	notes := map[string]string{"enrollment": strconv.FormatInt(enrollment, 10), "fee_head": req.FeeHead, "fee_type": req.FeeType}
+	if req.ChargeID != nil {
+		notes["charge_id"] = strconv.FormatInt(*req.ChargeID, 10)
+	}
+	paise := toPaise(amount)
+	order, err := paymentGateway.CreateOrder(paise, "INR", fmt.Sprintf("fee_%d_%d", enrollment, time.Now().Unix()), notes)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create order"})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"success": true, "order_id": order["id"], "key_id": RazorpayKeyID, "amount": req.Amount, "name": instituteName, "description": req.FeeHead, "prefill": gin.H{"name": studentName, "email": user.Email, "contact": contact}})
+	if err := createPaymentOrder(db, order.ID, enrollment, req.FeeHead, req.FeeType, req.ChargeID, float64(paise)/100); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create order"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "order_id": order.ID, "gateway": paymentGateway.Name(), "key_id": paymentGateway.KeyID(), "amount": float64(paise) / 100, "name": instituteName, "description": req.FeeHead, "prefill": gin.H{"name": studentName, "email": user.Email, "contact": contact}})
 }
 
+// VerifyPaymentAndRecord settles the caller's own order after confirming the payment with the gateway.
+// Amount, fee head and enrollment come from the stored order; client-sent values are ignored.
 func VerifyPaymentAndRecord(c *gin.Context) {
+	if !requireGateway(c) {
+		return
+	}
 	var payload struct {
-		RazorpayOrderID   string  `json:"razorpay_order_id" binding:"required"`
-		RazorpayPaymentID string  `json:"razorpay_payment_id" binding:"required"`
-		RazorpaySignature string  `json:"razorpay_signature" binding:"required"`
-		Amount            float64 `json:"amount" binding:"required,gt=0"`
-		FeeHead           string  `json:"fee_head" binding:"required"`
-		FeeType           string  `json:"fee_type" binding:"required"`
-		Enrollment        int64   `json:"enrollment" binding:"required"`
+		RazorpayOrderID   string `json:"razorpay_order_id" binding:"required"`
+		RazorpayPaymentID string `json:"razorpay_payment_id" binding:"required"`
+		RazorpaySignature string `json:"razorpay_signature" binding:"required"`
 	}
 	if err := c.ShouldBindJSON(&payload); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	if !utils.VerifyPaymentSignature(map[string]interface{}{"razorpay_order_id": payload.RazorpayOrderID, "razorpay_payment_id": payload.RazorpayPaymentID}, payload.RazorpaySignature, RazorpaySecret) {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid signature"})
+	enrollment, err := getStudentEnrollment(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
 		return
 	}
 	db := config.DB
-	now := time.Now().Format("2006-01-02")
-	status := ptrString("Paid")
-	switch payload.FeeHead {
-	case "Registration Fee":
-		db.Create(&models.RegistrationFee{EnrollmentNumber: payload.Enrollment, FeeType: &payload.FeeType, FeeAmount: &payload.Amount, TransactionNumber: &payload.RazorpayPaymentID, TransactionDate: &now, PaymentStatus: status})
-	case "Examination Fee":
-		db.Create(&models.ExaminationFee{EnrollmentNumber: payload.Enrollment, FeeType: &payload.FeeType, FeeAmount: &payload.Amount, TransactionNo: &payload.RazorpayPaymentID, TransactionDate: &now, PaymentStatus: status})
-	case "Miscellaneous Fee":
-		db.Create(&models.MiscellaneousFee{EnrollmentNumber: payload.Enrollment, FeeType: &payload.FeeType, FeeAmount: &payload.Amount, TransactionNo: &payload.RazorpayPaymentID, TransactionDate: &now, PaymentStatus: status})
-	default:
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid fee head"})
+	order, err := findPaymentOrder(db, payload.RazorpayOrderID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load order"})
 		return
 	}
-	var expected models.ExpectedFee
-	if err := db.Where("enrollment_number = ?", payload.Enrollment).First(&expected).Error; err == nil {
-		updates := map[string]interface{}{"total_paid": expected.TotalPaid + payload.Amount}
-		switch payload.FeeHead {
-		case "Registration Fee":
-			updates["registration_fee_paid"] = expected.RegistrationFeePaid + payload.Amount
-		case "Examination Fee":
-			updates["exam_fee_paid"] = expected.ExamFeePaid + payload.Amount
-		}
-		db.Model(&expected).Updates(updates)
+	if order == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+		return
+	}
+	if order.EnrollmentNumber != enrollment {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Order does not belong to you"})
+		return
+	}
+	if !paymentGateway.VerifyPaymentSignature(payload.RazorpayOrderID, payload.RazorpayPaymentID, payload.RazorpaySignature) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid signature"})
+		return
+	}
+	payment, err := paymentGateway.FetchPayment(payload.RazorpayPaymentID)
+	if err == payments.ErrNotFound {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Payment not found at gateway"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Could not confirm payment with gateway"})
+		return
+	}
+	recorded, err := settlePaymentOrder(db, order, payment, "client")
+	if err == errPaymentMismatch {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Payment does not match order"})
+		return
 	}
-	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Payment verified and recorded", "payment_id": payload.RazorpayPaymentID})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record payment"})
+		return
+	}
+	middleware.Audit(c, "payments.verify", "payment_order", order.OrderID, nil,
+		gin.H{"payment_id": payment.ID, "amount": order.Amount, "fee_head": order.FeeHead})
+	// The webhook may already have recorded this payment; either way it is recorded once
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Payment verified and recorded", "payment_id": payment.ID, "amount": order.Amount, "already_recorded": !recorded})
 }
 
-func PayFee(c *gin.Context) {
-	c.JSON(http.StatusBadRequest, gin.H{"error": "Use /fees/request-payment and /fees/verify-payment"})
+// MockCheckout stands in for the gateway's checkout popup when PAYMENT_GATEWAY=mock. It returns the
+// same fields the browser posts to /fees/verify-payment.
+func MockCheckout(c *gin.Context) {
+	mock, ok := paymentGateway.(*payments.MockGateway)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Mock gateway is not enabled"})
+		return
+	}
+	var req struct {
+		Fail bool `json:"fail"`
+	}
+	c.ShouldBindJSON(&req)
+
+	enrollment, err := getStudentEnrollment(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	orderID := c.Param("order_id")
+	order, err := findPaymentOrder(config.DB, orderID)
+	if err != nil || order == nil || order.EnrollmentNumber != enrollment {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+		return
+	}
+	payment, signature, err := mock.Pay(orderID, !req.Fail)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Order not found at gateway"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": payment.Status, "razorpay_order_id": orderID, "razorpay_payment_id": payment.ID, "razorpay_signature": signature})
 }
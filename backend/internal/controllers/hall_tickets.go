@@ -0,0 +1,791 @@
+package controllers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/jpeg" // decoders for student photos
+	_ "image/png"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/kiranraoboinapally/student/backend/internal/config"
+	"github.com/kiranraoboinapally/student/backend/internal/middleware"
+	"github.com/kiranraoboinapally/student/backend/internal/models"
+	"github.com/kiranraoboinapally/student/backend/internal/pdf"
+	"github.com/kiranraoboinapally/student/backend/internal/qr"
+	"github.com/kiranraoboinapally/student/backend/internal/utils"
+)
+
+// ======================== HALL TICKETS ========================
+
+const maxPhotoBytes = 2 << 20
+
+// photoTypes are the accepted student photo formats, by sniffed content type
+var photoTypes = map[string]string{"image/jpeg": ".jpg", "image/png": ".png"}
+
+func photoDir() string {
+	return filepath.Join(config.UploadDir, "student_photos")
+}
+
+// saveStudentPhoto stores a passport photo for hall tickets, replacing any earlier one
+func saveStudentPhoto(enrollment int64, fh *multipart.FileHeader) error {
+	if fh.Size > maxPhotoBytes {
+		return errors.New("photo must be 2 MB or smaller")
+	}
+	f, err := fh.Open()
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	data, err := io.ReadAll(io.LimitReader(f, maxPhotoBytes+1))
+	if err != nil {
+		return err
+	}
+	if len(data) > maxPhotoBytes {
+		return errors.New("photo must be 2 MB or smaller")
+	}
+	ext, ok := photoTypes[http.DetectContentType(data)]
+	if !ok {
+		return errors.New("photo must be a JPEG or PNG image")
+	}
+	if _, _, err := image.DecodeConfig(bytes.NewReader(data)); err != nil {
+		return errors.New("photo could not be read as an image")
+	}
+	if err := os.MkdirAll(photoDir(), 0o750); err != nil {
+		return err
+	}
+	base := strconv.FormatInt(enrollment, 10)
+	for _, other := range photoTypes {
+		if other != ext {
+			os.Remove(filepath.Join(photoDir(), base+other))
+		}
+	}
+	return os.WriteFile(filepath.Join(photoDir(), base+ext), data, 0o640)
+}
+
+// studentPhoto loads a student's photo, shrunk for printing, or nil when none has been uploaded
+func studentPhoto(enrollment int64) image.Image {
+	base := strconv.FormatInt(enrollment, 10)
+	for _, ext := range photoTypes {
+		f, err := os.Open(filepath.Join(photoDir(), base+ext))
+		if err != nil {
+			continue
+		}
+		img, _, err := image.Decode(f)
+		f.Close()
+		if err == nil {
+			return thumbnail(img, 300)
+		}
+	}
+	return nil
+}
+
+// thumbnail scales img down (nearest neighbour) so its longer side is at most limit pixels, which
+// keeps hall ticket PDFs small whatever the size of the upload
+func thumbnail(img image.Image, limit int) image.Image {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w <= limit && h <= limit {
+		return img
+	}
+	nw, nh := limit, h*limit/w
+	if h > w {
+		nw, nh = w*limit/h, limit
+	}
+	if nw < 1 {
+		nw = 1
+	}
+	if nh < 1 {
+		nh = 1
+	}
+	out := image.NewRGBA(image.Rect(0, 0, nw, nh))
+	for y := 0; y < nh; y++ {
+		for x := 0; x < nw; x++ {
+			out.Set(x, y, img.At(b.Min.X+x*w/nw, b.Min.Y+y*h/nh))
+		}
+	}
+	return out
+}
+
+// overdueDues is what a student still owes on charges due on or before cutoff; undated charges
+// count as due
+func overdueDues(db *gorm.DB, enrollment int64, cutoff time.Time) (float64, error) {
+	balances, err := studentFeeBalances(db, enrollment)
+	if err != nil {
+		return 0, err
+	}
+	var total float64
+	for _, b := range balances {
+		if b.Balance <= 0 {
+			continue
+		}
+		charges, err := openCharges(db, enrollment, b.FeeTypeID)
+		if err != nil {
+			return 0, err
+		}
+		for _, ch := range charges {
+			if ch.DueDate == nil || !ch.DueDate.After(cutoff) {
+				total += ch.Outstanding
+			}
+		}
+	}
+	return roundMoney(total), nil
+}
+
+// examPaper is one paper on a student's hall ticket, with their seat once the plan is generated
+type examPaper struct {
+	models.DateSheetEntry
+	Reappear bool   `json:"reappear"`
+	Room     string `json:"room,omitempty"`
+	Seat     string `json:"seat,omitempty"`
+}
+
+// studentPapers lists a student's papers in a session: the regular papers of their course and
+// semester (none when semester is 0) and the papers of their locked reappear registrations
+func studentPapers(db *gorm.DB, sessionID int, enrollment int64, course string, semester int) ([]examPaper, error) {
+	papers := []examPaper{}
+	seen := map[string]bool{}
+	if semester > 0 {
+		var regular []models.DateSheetEntry
+		if err := db.Where("exam_session_id = ? AND course_name = ? AND semester = ?", sessionID, course, semester).
+			Find(&regular).Error; err != nil {
+			return nil, err
+		}
+		for _, e := range regular {
+			seen[e.SubjectCode] = true
+			papers = append(papers, examPaper{DateSheetEntry: e})
+		}
+	}
+	var codes []string
+	db.Model(&models.ReappearStudent{}).
+		Where("exam_session_id = ? AND enrollment_no = ? AND status = ?", sessionID, enrollment, ReappearLocked).
+		Pluck("subject_code", &codes)
+	if len(codes) > 0 {
+		var backlog []models.DateSheetEntry
+		if err := db.Where("exam_session_id = ? AND course_name = ? AND subject_code IN ?", sessionID, course, codes).
+			Find(&backlog).Error; err != nil {
+			return nil, err
+		}
+		for _, e := range backlog {
+			if !seen[e.SubjectCode] {
+				papers = append(papers, examPaper{DateSheetEntry: e, Reappear: true})
+			}
+		}
+	}
+
+	var seats []models.SeatAllocation
+	db.Where("exam_session_id = ? AND enrollment_number = ?", sessionID, enrollment).Find(&seats)
+	if len(seats) > 0 {
+		var rooms []models.ExamRoom
+		db.Find(&rooms)
+		roomNames := map[int]string{}
+		for _, r := range rooms {
+			roomNames[r.RoomID] = r.Name
+		}
+		for i, p := range papers {
+			for _, s := range seats {
+				if s.ExamDate.Equal(p.ExamDate) && s.StartTime == p.StartTime {
+					papers[i].Room = roomNames[s.RoomID]
+					papers[i].Seat = fmt.Sprintf("R%d-B%d-S%d", s.BenchRow, s.BenchNo, s.Seat)
+				}
+			}
+		}
+	}
+	sort.Slice(papers, func(i, j int) bool {
+		if !papers[i].ExamDate.Equal(papers[j].ExamDate) {
+			return papers[i].ExamDate.Before(papers[j].ExamDate)
+		}
+		return papers[i].StartTime < papers[j].StartTime
+	})
+	return papers, nil
+}
+
+// hallTicketCheck is the outcome of the issue rules for one student
+type hallTicketCheck struct {
+	EnrollmentNumber int64                `json:"enrollment_number"`
+	StudentName      string               `json:"student_name"`
+	CourseName       string               `json:"course_name"`
+	Semester         int                  `json:"semester"` // 0 when only sitting backlog papers
+	Papers           []examPaper          `json:"papers"`
+	Dues             float64              `json:"dues"`
+	Attendance       []subjectEligibility `json:"attendance"`
+	Eligible         bool                 `json:"eligible"`
+	Reasons          []string             `json:"reasons"`
+}
+
+// checkHallTicket applies the issue rules: the student has papers in the session, has cleared the
+// fees due by the first day of the exams, and under the academic rules is neither detained nor
+// awaiting condonation in any regular paper. Attendance is not checked for backlog papers.
+func checkHallTicket(db *gorm.DB, session models.ExamSession, student models.MasterStudent) (hallTicketCheck, error) {
+	check := hallTicketCheck{
+		EnrollmentNumber: student.EnrollmentNumber,
+		StudentName:      student.StudentName,
+		CourseName:       safeString(student.CourseName),
+		Reasons:          []string{},
+	}
+	if session.ExamType != "supplementary" {
+		check.Semester = regularSemesters(db, []int64{student.EnrollmentNumber})[student.EnrollmentNumber]
+	}
+	papers, err := studentPapers(db, session.ExamSessionID, student.EnrollmentNumber, check.CourseName, check.Semester)
+	if err != nil {
+		return check, err
+	}
+	check.Papers = papers
+	if len(papers) == 0 {
+		check.Reasons = append(check.Reasons, "no papers are scheduled for you in this session")
+	}
+
+	cutoff := time.Now()
+	if session.StartDate != nil && session.StartDate.After(cutoff) {
+		cutoff = *session.StartDate
+	}
+	if check.Dues, err = overdueDues(db, student.EnrollmentNumber, cutoff); err != nil {
+		return check, err
+	}
+	if check.Dues > 0 {
+		check.Reasons = append(check.Reasons, fmt.Sprintf("fees of Rs. %.2f are outstanding", check.Dues))
+	}
+
+	regular := map[string]bool{}
+	for _, p := range papers {
+		if !p.Reappear {
+			regular[p.SubjectCode] = true
+		}
+	}
+	if len(regular) > 0 {
+		rules := currentAcademicRules(db)
+		standing, err := attendanceEligibility(db, rules, check.CourseName, check.Semester, []int64{student.EnrollmentNumber})
+		if err != nil {
+			return check, err
+		}
+		for _, s := range standing[student.EnrollmentNumber] {
+			if !regular[s.SubjectCode] {
+				continue
+			}
+			check.Attendance = append(check.Attendance, s)
+			switch {
+			case s.Status == EligibilityDetained && s.CondonationStatus == CondonationRejected:
+				check.Reasons = append(check.Reasons, fmt.Sprintf("detained in %s: attendance of %s is below the required %s and condonation was rejected",
+					s.SubjectCode, percentLabel(*s.Percent), percentLabel(rules.MinAttendancePercent)))
+			case s.Status == EligibilityDetained:
+				check.Reasons = append(check.Reasons, fmt.Sprintf("detained in %s: attendance of %s is below %s",
+					s.SubjectCode, percentLabel(*s.Percent), percentLabel(rules.CondonationMinPercent)))
+			case s.Status == EligibilityCondonable:
+				check.Reasons = append(check.Reasons, fmt.Sprintf("attendance in %s is %s, below the required %s, and needs an approved condonation",
+					s.SubjectCode, percentLabel(*s.Percent), percentLabel(rules.MinAttendancePercent)))
+			}
+		}
+	}
+	check.Eligible = len(check.Reasons) == 0
+	return check, nil
+}
+
+// issueHallTicket returns the student's ticket for the session, creating it on first issue
+func issueHallTicket(db *gorm.DB, session models.ExamSession, check hallTicketCheck, issuedBy *int64) (models.HallTicket, bool, error) {
+	var ticket models.HallTicket
+	if db.Where("exam_session_id = ? AND enrollment_number = ?", session.ExamSessionID, check.EnrollmentNumber).
+		Limit(1).Find(&ticket).RowsAffected > 0 {
+		return ticket, false, nil
+	}
+	code, err := utils.RandomToken(6)
+	if err != nil {
+		return ticket, false, err
+	}
+	ticket = models.HallTicket{
+		ExamSessionID:    session.ExamSessionID,
+		EnrollmentNumber: check.EnrollmentNumber,
+		TicketNumber:     fmt.Sprintf("HT-%d-%d", session.ExamSessionID, check.EnrollmentNumber),
+		CourseName:       check.CourseName,
+		Semester:         check.Semester,
+		VerificationCode: strings.ToUpper(code),
+		IssuedBy:         issuedBy,
+		IssuedAt:         time.Now(),
+	}
+	if err := db.Create(&ticket).Error; err != nil {
+		return ticket, false, err
+	}
+	return ticket, true, nil
+}
+
+// hallTicketVerifyURL is where the QR code and printed verification code can be checked
+func hallTicketVerifyURL(code string) string {
+	return config.AppBaseURL + "/api/verify/hall-ticket/" + code
+}
+
+// drawQR draws a QR code in a size x size box, including the quiet zone around it
+func drawQR(doc *pdf.Document, x, y, size float64, code *qr.Code) {
+	const quiet = 4
+	m := size / float64(code.Size+2*quiet)
+	for row, modules := range code.Modules {
+		for col := 0; col < code.Size; {
+			if !modules[col] {
+				col++
+				continue
+			}
+			start := col
+			for col < code.Size && modules[col] {
+				col++
+			}
+			doc.FillRect(x+float64(quiet+start)*m, y+float64(quiet+row)*m, float64(col-start)*m, m, 0)
+		}
+	}
+}
+
+// fitText shortens s with an ellipsis until it fits width
+func fitText(s string, width, size float64, bold bool) string {
+	if pdf.TextWidth(s, size, bold) <= width {
+		return s
+	}
+	r := []rune(s)
+	for len(r) > 0 && pdf.TextWidth(string(r)+"...", size, bold) > width {
+		r = r[:len(r)-1]
+	}
+	return string(r) + "..."
+}
+
+// renderHallTicket draws the hall ticket PDF
+func renderHallTicket(db *gorm.DB, session models.ExamSession, ticket models.HallTicket) ([]byte, error) {
+	var student models.MasterStudent
+	if err := db.Where("enrollment_number = ?", ticket.EnrollmentNumber).First(&student).Error; err != nil {
+		return nil, err
+	}
+	papers, err := studentPapers(db, session.ExamSessionID, ticket.EnrollmentNumber, ticket.CourseName, ticket.Semester)
+	if err != nil {
+		return nil, err
+	}
+	inst := studentInstitute(db, ticket.EnrollmentNumber)
+
+	doc := pdf.New()
+	left, right := 50.0, pdf.PageWidth-50
+	mid := pdf.PageWidth / 2
+
+	name := inst.InstituteName
+	if name == "" {
+		name = safeString(student.InstituteName)
+	}
+	y := 60.0
+	doc.TextCenter(mid, y, 16, true, name)
+	y += 20
+	title := session.Name
+	if session.Term != nil && *session.Term != "" {
+		title += " (" + *session.Term + ")"
+	}
+	doc.TextCenter(mid, y, 11, false, "Examination: "+title)
+	y += 28
+	doc.FillRect(left, y-16, right-left, 24, 0.9)
+	doc.TextCenter(mid, y, 14, true, "HALL TICKET")
+	y += 35
+	doc.Text(left, y, 11, true, "Hall Ticket No: "+ticket.TicketNumber)
+	doc.TextRight(right, y, 11, false, "Issued: "+ticket.IssuedAt.Format("02 Jan 2006"))
+	y += 15
+	doc.Line(left, y, right, y, 0.5)
+
+	// Photo at the top right with the QR code below it
+	photoX, photoY, photoW, photoH := right-100, y+12, 100.0, 120.0
+	if img := studentPhoto(ticket.EnrollmentNumber); img != nil {
+		doc.Image(photoX, photoY, photoW, photoH, img)
+	} else {
+		doc.TextCenter(photoX+photoW/2, photoY+photoH/2, 8, false, "Affix photograph")
+	}
+	doc.Rect(photoX, photoY, photoW, photoH, 0.8)
+	qrY := photoY + photoH + 8
+	if code, err := qr.Encode(hallTicketVerifyURL(ticket.VerificationCode)); err == nil {
+		drawQR(doc, photoX, qrY, photoW, code)
+	}
+
+	semester := "Backlog papers only"
+	if ticket.Semester > 0 {
+		semester = strconv.Itoa(ticket.Semester)
+	}
+	examType := session.ExamType
+	if examType == "" {
+		examType = "regular"
+	}
+	rows := [][2]string{
+		{"Student Name", student.StudentName},
+		{"Father's Name", safeString(student.FatherName)},
+		{"Enrollment Number", strconv.FormatInt(ticket.EnrollmentNumber, 10)},
+		{"Course", ticket.CourseName},
+		{"Semester", semester},
+		{"Examination Type", strings.ToUpper(examType[:1]) + examType[1:]},
+		{"Institute", name},
+	}
+	y += 30
+	for _, r := range rows {
+		doc.Text(left+10, y, 11, true, r[0])
+		doc.Text(left+140, y, 11, false, fitText(r[1], photoX-left-150, 11, false))
+		y += 20
+	}
+	if bottom := qrY + photoW + 25; y < bottom {
+		y = bottom
+	}
+
+	cols := []struct {
+		x     float64
+		title string
+	}{{left, "Date"}, {left + 70, "Time"}, {left + 140, "Code"}, {left + 200, "Subject"}, {left + 365, "Type"}, {left + 415, "Room / Seat"}}
+	header := func() {
+		doc.FillRect(left, y-13, right-left, 19, 0.9)
+		for _, col := range cols {
+			doc.Text(col.x+3, y, 9, true, col.title)
+		}
+		y += 20
+	}
+	header()
+	for _, p := range papers {
+		if y > pdf.PageHeight-170 {
+			doc.AddPage()
+			y = 60
+			header()
+		}
+		kind := "Regular"
+		if p.Reappear {
+			kind = "Backlog"
+		}
+		seat := strings.TrimSpace(p.Room + " " + p.Seat)
+		values := []string{p.ExamDate.Format("02 Jan 2006"), p.StartTime + "-" + p.EndTime, p.SubjectCode, p.SubjectName, kind, seat}
+		for i, col := range cols {
+			width := right - col.x - 6
+			if i+1 < len(cols) {
+				width = cols[i+1].x - col.x - 6
+			}
+			doc.Text(col.x+3, y, 9, false, fitText(values[i], width, 9, false))
+		}
+		y += 6
+		doc.Line(left, y, right, y, 0.3)
+		y += 14
+	}
+	if len(papers) == 0 {
+		doc.Text(left+3, y, 9, false, "No papers are scheduled yet.")
+		y += 20
+	}
+
+	y += 10
+	doc.Text(left, y, 10, true, "Instructions")
+	y += 15
+	for _, line := range []string{
+		"1. Bring this hall ticket and a photo identity card to every paper.",
+		"2. Be seated at least 15 minutes before the paper starts; late entry beyond 30 minutes is not allowed.",
+		"3. Mobile phones, smart watches and written material are not permitted in the examination hall.",
+		"4. Sit only at the room and seat allotted to you for each paper.",
+	} {
+		y = doc.Wrap(left, y, right-left, 9, false, line)
+	}
+
+	y += 45
+	doc.Line(left, y, left+160, y, 0.5)
+	doc.Line(right-160, y, right, y, 0.5)
+	doc.Text(left, y+12, 9, false, "Signature of Candidate")
+	doc.TextRight(right, y+12, 9, false, "Controller of Examinations")
+	y += 40
+	doc.Text(left, y, 10, true, "Verification code: "+ticket.VerificationCode)
+	y += 15
+	doc.Text(left, y, 9, false, "Verify this hall ticket at "+hallTicketVerifyURL(ticket.VerificationCode))
+	return doc.Bytes(), nil
+}
+
+func sendHallTicketPDF(c *gin.Context, session models.ExamSession, ticket models.HallTicket) {
+	body, err := renderHallTicket(config.DB, session, ticket)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate hall ticket"})
+		return
+	}
+	c.Header("Content-Disposition", `attachment; filename="hall-ticket-`+ticket.TicketNumber+`.pdf"`)
+	c.Data(http.StatusOK, "application/pdf", body)
+}
+
+// loadIssuableSession loads a session hall tickets can be issued for (any but a draft)
+func loadIssuableSession(db *gorm.DB, id string) (models.ExamSession, bool) {
+	var session models.ExamSession
+	if err := db.Where("exam_session_id = ? AND status <> ?", id, ExamSessionDraft).First(&session).Error; err != nil {
+		return session, false
+	}
+	return session, true
+}
+
+// IssueHallTickets checks the students of a course (optionally one institute), issuing hall tickets
+// to those who qualify and reporting why the rest were withheld. Students with no papers in the
+// session are skipped.
+func IssueHallTickets(c *gin.Context) {
+	var req struct {
+		CourseName  string `json:"course_name" binding:"required"`
+		InstituteID *int   `json:"institute_id"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	db := config.DB
+	session, ok := loadIssuableSession(db, c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "exam session not found or still a draft"})
+		return
+	}
+	q := db.Where("course_name = ?", req.CourseName)
+	if req.InstituteID != nil {
+		q = q.Where("institute_id = ?", *req.InstituteID)
+	}
+	var students []models.MasterStudent
+	if err := q.Order("enrollment_number").Find(&students).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load students"})
+		return
+	}
+
+	var issuedBy *int64
+	if uid := c.GetInt64("user_id"); uid != 0 {
+		issuedBy = &uid
+	}
+	issued, already, notSitting := 0, 0, 0
+	withheld := []hallTicketCheck{}
+	for _, s := range students {
+		check, err := checkHallTicket(db, session, s)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to check " + strconv.FormatInt(s.EnrollmentNumber, 10)})
+			return
+		}
+		if len(check.Papers) == 0 {
+			notSitting++
+			continue
+		}
+		if !check.Eligible {
+			check.Papers = nil
+			withheld = append(withheld, check)
+			continue
+		}
+		_, created, err := issueHallTicket(db, session, check, issuedBy)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to issue hall ticket for " + strconv.FormatInt(s.EnrollmentNumber, 10)})
+			return
+		}
+		if created {
+			issued++
+		} else {
+			already++
+		}
+	}
+	middleware.Audit(c, "exams.hall_tickets.issue", "hall_tickets", strconv.Itoa(session.ExamSessionID), nil,
+		gin.H{"course_name": req.CourseName, "issued": issued, "withheld": len(withheld)})
+	c.JSON(http.StatusOK, gin.H{
+		"message":        fmt.Sprintf("%d hall tickets issued", issued),
+		"issued":         issued,
+		"already_issued": already,
+		"not_sitting":    notSitting,
+		"withheld":       withheld,
+	})
+}
+
+// hallTicketView is a hall ticket with the student's name
+type hallTicketView struct {
+	models.HallTicket
+	StudentName string `json:"student_name"`
+}
+
+// GetHallTickets lists the tickets issued in a session, optionally for one course
+func GetHallTickets(c *gin.Context) {
+	db := config.DB
+	q := db.Where("exam_session_id = ?", c.Param("id"))
+	if course := c.Query("course_name"); course != "" {
+		q = q.Where("course_name = ?", course)
+	}
+	var tickets []models.HallTicket
+	if err := q.Order("enrollment_number").Find(&tickets).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load hall tickets"})
+		return
+	}
+	enrollments := make([]int64, len(tickets))
+	for i, t := range tickets {
+		enrollments[i] = t.EnrollmentNumber
+	}
+	names := map[int64]string{}
+	if len(enrollments) > 0 {
+		var students []models.MasterStudent
+		db.Select("enrollment_number, student_name").Where("enrollment_number IN ?", enrollments).Find(&students)
+		for _, s := range students {
+			names[s.EnrollmentNumber] = s.StudentName
+		}
+	}
+	out := make([]hallTicketView, len(tickets))
+	for i, t := range tickets {
+		out[i] = hallTicketView{HallTicket: t, StudentName: names[t.EnrollmentNumber]}
+	}
+	c.JSON(http.StatusOK, gin.H{"hall_tickets": out})
+}
+
+// DownloadHallTicket returns an issued hall ticket as a PDF
+func DownloadHallTicket(c *gin.Context) {
+	db := config.DB
+	var session models.ExamSession
+	if err := db.Where("exam_session_id = ?", c.Param("id")).First(&session).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "exam session not found"})
+		return
+	}
+	var ticket models.HallTicket
+	if err := db.Where("exam_session_id = ? AND enrollment_number = ?", session.ExamSessionID, c.Param("enrollment")).
+		First(&ticket).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "no hall ticket has been issued to this student"})
+		return
+	}
+	sendHallTicketPDF(c, session, ticket)
+}
+
+// GetMyExams lists the caller's papers in each published exam session, with whether their hall
+// ticket can be issued and why not
+func GetMyExams(c *gin.Context) {
+	enrollment, err := getEnrollmentOrError(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	db := config.DB
+	var student models.MasterStudent
+	if err := db.Where("enrollment_number = ?", enrollment).First(&student).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "student not found"})
+		return
+	}
+	var sessions []models.ExamSession
+	db.Where("status <> ?", ExamSessionDraft).Order("exam_session_id DESC").Find(&sessions)
+
+	type examView struct {
+		Session    models.ExamSession `json:"session"`
+		Check      hallTicketCheck    `json:"eligibility"`
+		HallTicket *models.HallTicket `json:"hall_ticket"`
+	}
+	exams := []examView{}
+	for _, s := range sessions {
+		var ticket models.HallTicket
+		found := db.Where("exam_session_id = ? AND enrollment_number = ?", s.ExamSessionID, enrollment).Limit(1).Find(&ticket).RowsAffected > 0
+		check, err := checkHallTicket(db, s, student)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load exams"})
+			return
+		}
+		if len(check.Papers) == 0 && !found {
+			continue
+		}
+		view := examView{Session: s, Check: check}
+		if found {
+			view.HallTicket = &ticket
+		}
+		exams = append(exams, view)
+	}
+	c.JSON(http.StatusOK, gin.H{"exams": exams})
+}
+
+// DownloadMyHallTicket returns the caller's hall ticket, issuing it first if they qualify
+func DownloadMyHallTicket(c *gin.Context) {
+	enrollment, err := getEnrollmentOrError(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	db := config.DB
+	session, ok := loadIssuableSession(db, c.Param("session_id"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "exam session not found"})
+		return
+	}
+	var ticket models.HallTicket
+	if db.Where("exam_session_id = ? AND enrollment_number = ?", session.ExamSessionID, enrollment).Limit(1).Find(&ticket).RowsAffected == 0 {
+		var student models.MasterStudent
+		if err := db.Where("enrollment_number = ?", enrollment).First(&student).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "student not found"})
+			return
+		}
+		check, err := checkHallTicket(db, session, student)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to check eligibility"})
+			return
+		}
+		if !check.Eligible {
+			c.JSON(http.StatusForbidden, gin.H{"error": "your hall ticket has been withheld", "reasons": check.Reasons})
+			return
+		}
+		if ticket, _, err = issueHallTicket(db, session, check, nil); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to issue hall ticket"})
+			return
+		}
+	}
+	sendHallTicketPDF(c, session, ticket)
+}
+
+// VerifyHallTicket is the public check behind the QR code on hall tickets, for invigilators
+func VerifyHallTicket(c *gin.Context) {
+	code := strings.ToUpper(strings.TrimSpace(c.Param("code")))
+	db := config.DB
+	var ticket models.HallTicket
+	if code == "" || db.Where("verification_code = ?", code).Limit(1).Find(&ticket).RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"valid": false, "error": "no hall ticket matches this code"})
+		return
+	}
+	var session models.ExamSession
+	db.Where("exam_session_id = ?", ticket.ExamSessionID).Limit(1).Find(&session)
+	var student models.MasterStudent
+	db.Where("enrollment_number = ?", ticket.EnrollmentNumber).Limit(1).Find(&student)
+
+	c.JSON(http.StatusOK, gin.H{
+		"valid":         true,
+		"ticket_number": ticket.TicketNumber,
+		"exam_session":  session.Name,
+		"student_name":  student.StudentName,
+		"course_name":   ticket.CourseName,
+		"semester":      ticket.Semester,
+		"issued_at":     ticket.IssuedAt.Format("2006-01-02"),
+	})
+}
+
+// UploadMyPhoto sets the caller's photo for hall tickets
+func UploadMyPhoto(c *gin.Context) {
+	enrollment, err := getEnrollmentOrError(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	fh, err := c.FormFile("photo")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "photo file is required"})
+		return
+	}
+	if err := saveStudentPhoto(enrollment, fh); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "photo updated"})
+}
+
+// UploadStudentPhoto sets a student's photo on their behalf
+func UploadStudentPhoto(c *gin.Context) {
+	enrollment, err := strconv.ParseInt(c.Param("enrollment"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid enrollment number"})
+		return
+	}
+	var count int64
+	config.DB.Model(&models.MasterStudent{}).Where("enrollment_number = ?", enrollment).Count(&count)
+	if count == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "student not found"})
+		return
+	}
+	fh, err := c.FormFile("photo")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "photo file is required"})
+		return
+	}
+	if err := saveStudentPhoto(enrollment, fh); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	middleware.Audit(c, "exams.photo.upload", "master_students", strconv.FormatInt(enrollment, 10), nil, nil)
+	c.JSON(http.StatusOK, gin.H{"message": "photo updated"})
+}
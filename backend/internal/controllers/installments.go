@@ -0,0 +1,281 @@
+package controllers
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/kiranraoboinapally/student/backend/internal/config"
+	"github.com/kiranraoboinapally/student/backend/internal/middleware"
+	"github.com/kiranraoboinapally/student/backend/internal/models"
+)
+
+// ======================== INSTALLMENT PLANS ========================
+
+// splitInstallments divides total by the plan's percentages; the last installment absorbs rounding
+func splitInstallments(total float64, items []models.InstallmentPlanItem) []float64 {
+	amounts := make([]float64, len(items))
+	var allocated float64
+	for i, item := range items {
+		if i == len(items)-1 {
+			amounts[i] = roundMoney(total - allocated)
+			break
+		}
+		amounts[i] = roundMoney(total * item.Percentage / 100)
+		allocated += amounts[i]
+	}
+	return amounts
+}
+
+// loadInstallmentPlan returns an active plan with its installments in order
+func loadInstallmentPlan(db *gorm.DB, planID int) (models.InstallmentPlan, error) {
+	var plan models.InstallmentPlan
+	err := db.Preload("Items", func(q *gorm.DB) *gorm.DB { return q.Order("sequence") }).
+		Where("plan_id = ? AND is_active = ?", planID, true).First(&plan).Error
+	return plan, err
+}
+
+// postInstallmentCharges posts one dated charge per installment. With a reference prefix each
+// installment is posted at most once, so re-running a dues generation is harmless.
+func postInstallmentCharges(tx *gorm.DB, template models.FeeLedgerEntry, total float64, plan models.InstallmentPlan, refPrefix string) ([]models.FeeLedgerEntry, error) {
+	amounts := splitInstallments(total, plan.Items)
+	var posted []models.FeeLedgerEntry
+	for i, item := range plan.Items {
+		if amounts[i] <= 0 {
+			continue
+		}
+		entry := template
+		entry.EntryType = LedgerCharge
+		entry.Debit = amounts[i]
+		entry.Credit = 0
+		due := item.DueDate
+		entry.DueDate = &due
+		seq := item.Sequence
+		entry.InstallmentNo = &seq
+		label := item.Label
+		if label == "" {
+			label = fmt.Sprintf("Installment %d of %d", i+1, len(plan.Items))
+		}
+		entry.Description = ptrString(label)
+		entry.Reference = nil
+		if refPrefix != "" {
+			entry.Reference = ptrString(fmt.Sprintf("%s:%d", refPrefix, item.Sequence))
+		}
+		inserted, err := postCharge(tx, &entry)
+		if err != nil {
+			return nil, err
+		}
+		if inserted {
+			posted = append(posted, entry)
+		}
+	}
+	return posted, nil
+}
+
+type installmentItemRequest struct {
+	Label      string  `json:"label"`
+	Percentage float64 `json:"percentage" binding:"gt=0,lte=100"`
+	DueDate    string  `json:"due_date" binding:"required"` // YYYY-MM-DD
+}
+
+// CreateInstallmentPlan defines an installment template (e.g. 40/30/30) on a fee structure
+func CreateInstallmentPlan(c *gin.Context) {
+	structureID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid fee structure id"})
+		return
+	}
+	var req struct {
+		Name         string                   `json:"name" binding:"required"`
+		Installments []installmentItemRequest `json:"installments" binding:"required,min=1,dive"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	db := config.DB
+	if db.Where("fee_structure_id = ?", structureID).Limit(1).Find(&models.FeeStructure{}).RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "fee structure not found"})
+		return
+	}
+
+	plan := models.InstallmentPlan{FeeStructureID: structureID, Name: req.Name, IsActive: true, CreatedAt: time.Now()}
+	if uid := c.GetInt64("user_id"); uid != 0 {
+		plan.CreatedBy = &uid
+	}
+	var total float64
+	var last time.Time
+	for i, in := range req.Installments {
+		due, err := time.Parse("2006-01-02", in.DueDate)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "due_date must be YYYY-MM-DD"})
+			return
+		}
+		if i > 0 && !due.After(last) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "installment due dates must be in increasing order"})
+			return
+		}
+		last = due
+		total += in.Percentage
+		plan.Items = append(plan.Items, models.InstallmentPlanItem{
+			Sequence: i + 1, Label: in.Label, Percentage: in.Percentage, DueDate: due,
+		})
+	}
+	if math.Abs(total-100) > 0.01 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "installment percentages must add up to 100"})
+		return
+	}
+
+	if err := db.Create(&plan).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create installment plan"})
+		return
+	}
+
+	middleware.Audit(c, "fees.installment_plan.create", "installment_plan", strconv.Itoa(plan.PlanID), nil, plan)
+
+	c.JSON(http.StatusCreated, gin.H{"message": "installment plan created", "plan": plan})
+}
+
+// GetInstallmentPlans lists the installment plans of a fee structure
+func GetInstallmentPlans(c *gin.Context) {
+	var plans []models.InstallmentPlan
+	if err := config.DB.Preload("Items", func(q *gorm.DB) *gorm.DB { return q.Order("sequence") }).
+		Where("fee_structure_id = ?", c.Param("id")).Order("plan_id").Find(&plans).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load installment plans"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"plans": plans})
+}
+
+// DeactivateInstallmentPlan retires a plan; charges already generated from it are unaffected
+func DeactivateInstallmentPlan(c *gin.Context) {
+	planID, err := strconv.Atoi(c.Param("plan_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid plan id"})
+		return
+	}
+	res := config.DB.Model(&models.InstallmentPlan{}).Where("plan_id = ?", planID).Update("is_active", false)
+	if res.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to deactivate plan"})
+		return
+	}
+	if res.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "installment plan not found"})
+		return
+	}
+	middleware.Audit(c, "fees.installment_plan.deactivate", "installment_plan", strconv.Itoa(planID),
+		gin.H{"is_active": true}, gin.H{"is_active": false})
+	c.JSON(http.StatusOK, gin.H{"message": "installment plan deactivated"})
+}
+
+// ======================== FEE REMINDERS ========================
+
+// sendFeeReminders emails students about overdue, unpaid charges and installments, at most once
+// every FEE_REMINDER_INTERVAL_DAYS per charge
+func sendFeeReminders(db *gorm.DB, now time.Time) (int, error) {
+	var students []int64
+	if err := db.Model(&models.FeeLedgerEntry{}).
+		Where("entry_type IN ? AND due_date < ?", []string{LedgerCharge, LedgerAdjustment}, now).
+		Distinct().Pluck("enrollment_number", &students).Error; err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	cutoff := now.AddDate(0, 0, -config.FeeReminderIntervalDays)
+	for _, enrollment := range students {
+		balances, err := studentFeeBalances(db, enrollment)
+		if err != nil {
+			return sent, err
+		}
+		var lines []string
+		var remind []int64
+		for _, b := range balances {
+			if b.Balance <= 0 {
+				continue
+			}
+			charges, err := openCharges(db, enrollment, b.FeeTypeID)
+			if err != nil {
+				return sent, err
+			}
+			for _, ch := range charges {
+				if ch.DueDate == nil || ch.Outstanding <= 0 || !ch.DueDate.Before(now) {
+					continue
+				}
+				var last models.FeeReminder
+				if db.Where("charge_id = ?", ch.EntryID).Limit(1).Find(&last).RowsAffected > 0 && last.LastSentAt.After(cutoff) {
+					continue
+				}
+				label := feeHeadLabel(b.FeeTypeName)
+				if ch.Description != nil && ch.InstallmentNo != nil {
+					label += " - " + *ch.Description
+				}
+				lines = append(lines, fmt.Sprintf("%s: Rs. %.2f, due %s (%d days overdue)",
+					label, ch.Outstanding, ch.DueDate.Format("02 Jan 2006"), daysOverdue(*ch.DueDate, now)))
+				remind = append(remind, ch.EntryID)
+			}
+		}
+		if len(remind) == 0 {
+			continue
+		}
+
+		var user models.User
+		if db.Where("username = ?", strconv.FormatInt(enrollment, 10)).Limit(1).Find(&user).RowsAffected == 0 || user.Email == "" {
+			continue
+		}
+		err = db.Transaction(func(tx *gorm.DB) error {
+			subject, body := feeReminderEmail(user.FullName, lines)
+			if err := QueueEmail(tx, user.Email, subject, body); err != nil {
+				return err
+			}
+			for _, chargeID := range remind {
+				if err := tx.Exec(`INSERT INTO fee_reminders (charge_id, enrollment_number, reminders_sent, last_sent_at)
+					VALUES (?, ?, 1, ?) ON DUPLICATE KEY UPDATE reminders_sent = reminders_sent + 1, last_sent_at = VALUES(last_sent_at)`,
+					chargeID, enrollment, now).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return sent, err
+		}
+		sent++
+	}
+	return sent, nil
+}
+
+// InitFeeReminderJob emails overdue fee reminders once a day (FEE_REMINDER_INTERVAL_DAYS, 0 disables)
+func InitFeeReminderJob() {
+	if config.FeeReminderIntervalDays <= 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(24 * time.Hour)
+		defer ticker.Stop()
+		for range ticker.C {
+			sent, err := sendFeeReminders(config.DB, time.Now())
+			if err != nil {
+				log.Printf("fee reminders: %v", err)
+			} else if sent > 0 {
+				log.Printf("fee reminders: reminded %d students", sent)
+			}
+		}
+	}()
+}
+
+// feeReminderEmail lists a student's overdue fees
+func feeReminderEmail(fullName string, overdue []string) (string, string) {
+	body := fmt.Sprintf("Hello %s,\n\nThe following fees are overdue on your account:\n\n", fullName)
+	for _, line := range overdue {
+		body += "  - " + line + "\n"
+	}
+	body += fmt.Sprintf("\nLate fees may apply until they are paid. You can pay online at %s.\n", config.AppBaseURL)
+	return "Fee payment overdue", body
+}
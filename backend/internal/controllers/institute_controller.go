@@ -7,6 +7,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/kiranraoboinapally/student/backend/internal/config"
+	"github.com/kiranraoboinapally/student/backend/internal/middleware"
 	"github.com/kiranraoboinapally/student/backend/internal/models"
 )
 
@@ -108,11 +109,13 @@ func UpdateInstitute(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "institute not found"})
 		return
 	}
+	before := institute
 	if err := c.ShouldBindJSON(&institute); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 	db.Save(&institute)
+	middleware.Audit(c, "institute.update", "institute", idStr, before, institute)
 	c.JSON(http.StatusOK, gin.H{"message": "institute updated", "data": institute})
 }
 
@@ -124,9 +127,14 @@ func DeleteInstitute(c *gin.Context) {
 		return
 	}
 	db := config.DB
+	var before models.Institute
+	db.First(&before, id)
+
 	if err := db.Delete(&models.Institute{}, id).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete institute"})
 		return
 	}
+
+	middleware.Audit(c, "institute.delete", "institute", idStr, before, nil)
 	c.JSON(http.StatusOK, gin.H{"message": "institute deleted"})
 }
@@ -268,24 +268,32 @@ func GetInstituteStudentFees(c *gin.Context) {
 	var instituteName string
 	db.Table("institutes").Select("institute_name").Where("institute_id = ?", instID).Scan(&instituteName)
 
-	// Get fee data for institute students
+	// Ledger payments of the institute's students
 	var fees []struct {
+		EntryID          int64   `json:"entry_id"`
 		EnrollmentNumber int64   `json:"enrollment_number"`
 		StudentName      string  `json:"student_name"`
 		FeeType          string  `json:"fee_type"`
 		FeeAmount        float64 `json:"fee_amount"`
 		PaymentStatus    string  `json:"payment_status"`
+		TransactionDate  *string `json:"transaction_date"`
 	}
-
-	// Get from registration_fees
-	db.Table("registration_fees").
-		Select("enrollment_number, student_name, fee_type, fee_amount, payment_status").
-		Where("institute_name = ?", instituteName).
+	db.Table("fee_ledger").
+		Select(`fee_ledger.entry_id, fee_ledger.enrollment_number, master_students.student_name,
+			master_fee_types.fee_type_name AS fee_type, fee_ledger.credit AS fee_amount,
+			fee_ledger.status AS payment_status, DATE_FORMAT(fee_ledger.transaction_date, '%Y-%m-%d') AS transaction_date`).
+		Joins("JOIN master_students ON master_students.enrollment_number = fee_ledger.enrollment_number").
+		Joins("JOIN master_fee_types ON master_fee_types.fee_type_id = fee_ledger.fee_type_id").
+		Where("master_students.institute_name = ? AND fee_ledger.entry_type = ?", instituteName, LedgerPayment).
+		Order("fee_ledger.transaction_date DESC").
 		Scan(&fees)
 
+	totals := sumLedger(db, "fee_ledger.enrollment_number IN (SELECT enrollment_number FROM master_students WHERE institute_name = ?)", instituteName)
+
 	c.JSON(http.StatusOK, gin.H{
-		"fees":  fees,
-		"total": len(fees),
+		"fees":    fees,
+		"total":   len(fees),
+		"summary": totals,
 	})
 }
 
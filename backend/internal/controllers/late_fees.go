@@ -0,0 +1,503 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/kiranraoboinapally/student/backend/internal/config"
+	"github.com/kiranraoboinapally/student/backend/internal/middleware"
+	"github.com/kiranraoboinapally/student/backend/internal/models"
+)
+
+// ======================== LATE FEES ========================
+
+// Late fee methods
+const (
+	LateFeeFlat   = "flat"
+	LateFeePerDay = "per_day"
+	LateFeeSlab   = "slab"
+)
+
+// lateFeeSlab sets the penalty once a charge is more than AfterDays late (counted after the grace period)
+type lateFeeSlab struct {
+	AfterDays int     `json:"after_days"`
+	Amount    float64 `json:"amount"`
+}
+
+func parseLateFeeSlabs(raw *string) ([]lateFeeSlab, error) {
+	var slabs []lateFeeSlab
+	if raw == nil || *raw == "" {
+		return slabs, nil
+	}
+	if err := json.Unmarshal([]byte(*raw), &slabs); err != nil {
+		return nil, err
+	}
+	sort.Slice(slabs, func(i, j int) bool { return slabs[i].AfterDays < slabs[j].AfterDays })
+	return slabs, nil
+}
+
+// lateFeeFor is the total penalty owed on a charge that is daysOverdue days past its due date
+func lateFeeFor(rule models.LateFeeRule, slabs []lateFeeSlab, daysOverdue int) float64 {
+	late := daysOverdue - rule.GraceDays
+	if late <= 0 {
+		return 0
+	}
+	var fee float64
+	switch rule.Method {
+	case LateFeeFlat:
+		fee = rule.Amount
+	case LateFeePerDay:
+		fee = rule.Amount * float64(late)
+	case LateFeeSlab:
+		for _, s := range slabs {
+			if late > s.AfterDays {
+				fee = s.Amount
+			}
+		}
+	}
+	if rule.MaxAmount > 0 && fee > rule.MaxAmount {
+		fee = rule.MaxAmount
+	}
+	return roundMoney(fee)
+}
+
+// daysOverdue counts whole calendar days from the due date to now
+func daysOverdue(due time.Time, now time.Time) int {
+	d := time.Date(due.Year(), due.Month(), due.Day(), 0, 0, 0, 0, now.Location())
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	return int(today.Sub(d).Hours() / 24)
+}
+
+// openCharge is one charge (or installment) on a student's account and how much of it is still unpaid
+type openCharge struct {
+	EntryID        int64      `json:"charge_id"`
+	FeeTypeID      int        `json:"fee_type_id"`
+	InstallmentNo  *int       `json:"installment_no"`
+	Description    *string    `json:"description"`
+	Amount         float64    `json:"amount"`
+	DueDate        *time.Time `json:"due_date"`
+	Outstanding    float64    `json:"outstanding"`
+	PenaltyAccrued float64    `json:"penalty_accrued"`
+	PenaltyWaived  float64    `json:"penalty_waived"`
+}
+
+// openCharges allocates a student's payments, waivers and credit adjustments for one fee type to
+// its charges. Credits aimed at a specific installment settle it first; everything else is applied
+// oldest due date first, so penalties only run while the principal is unpaid.
+func openCharges(db *gorm.DB, enrollment int64, feeTypeID int) ([]openCharge, error) {
+	var entries []models.FeeLedgerEntry
+	if err := countedEntries(db.Model(&models.FeeLedgerEntry{})).
+		Where("enrollment_number = ? AND fee_type_id = ?", enrollment, feeTypeID).
+		Order("entry_id").Find(&entries).Error; err != nil {
+		return nil, err
+	}
+
+	var charges []openCharge
+	index := map[int64]int{}
+	for _, e := range entries {
+		if e.EntryType != LedgerCharge && e.EntryType != LedgerAdjustment {
+			continue
+		}
+		if net := e.Debit - e.Credit; net > 0 {
+			index[e.EntryID] = len(charges)
+			charges = append(charges, openCharge{
+				EntryID: e.EntryID, FeeTypeID: e.FeeTypeID, InstallmentNo: e.InstallmentNo,
+				Description: e.Description, Amount: net, DueDate: e.DueDate,
+			})
+		}
+	}
+
+	// Net each credit of what was later refunded or reversed against it
+	reversed := map[int64]float64{}
+	for _, e := range entries {
+		if (e.EntryType == LedgerRefund || e.EntryType == LedgerReversal) && e.ParentEntryID != nil {
+			reversed[*e.ParentEntryID] += e.Debit - e.Credit
+		}
+	}
+
+	var pool float64
+	applied := make([]float64, len(charges))
+	for _, e := range entries {
+		switch e.EntryType {
+		case LedgerCharge, LedgerAdjustment:
+			if net := e.Debit - e.Credit; net < 0 {
+				pool -= net
+			}
+		case LedgerPenalty:
+			if e.ParentEntryID != nil {
+				if i, ok := index[*e.ParentEntryID]; ok {
+					charges[i].PenaltyAccrued += e.Debit
+					charges[i].PenaltyWaived += e.Credit
+				}
+			}
+		case LedgerRefund, LedgerReversal:
+			if e.ParentEntryID == nil {
+				pool -= e.Debit - e.Credit
+			}
+		default:
+			credit := e.Credit - e.Debit - reversed[e.EntryID]
+			if e.ParentEntryID != nil {
+				if i, ok := index[*e.ParentEntryID]; ok {
+					take := credit
+					if room := charges[i].Amount - applied[i]; take > room {
+						take = room
+					}
+					if take > 0 {
+						applied[i] += take
+						credit -= take
+					}
+				}
+			}
+			pool += credit
+		}
+	}
+
+	// Undated charges are settled last
+	order := make([]int, len(charges))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(x, y int) bool {
+		a, b := charges[order[x]].DueDate, charges[order[y]].DueDate
+		if a == nil || b == nil {
+			return a != nil && b == nil
+		}
+		return a.Before(*b)
+	})
+	for _, i := range order {
+		take := charges[i].Amount - applied[i]
+		if pool < take {
+			take = pool
+		}
+		if take > 0 {
+			applied[i] += take
+			pool -= take
+		}
+	}
+
+	sorted := make([]openCharge, 0, len(charges))
+	for _, i := range order {
+		charges[i].Outstanding = roundMoney(charges[i].Amount - applied[i])
+		sorted = append(sorted, charges[i])
+	}
+	return sorted, nil
+}
+
+// lateFeeRun summarises one assessment pass
+type lateFeeRun struct {
+	Students int     `json:"students"`
+	Charges  int     `json:"charges"`
+	Amount   float64 `json:"amount"`
+}
+
+// assessLateFees posts the penalty accrued since the last run on every overdue, unpaid charge.
+// Each posting tops the charge's penalty up to what the rule says it should be now, so repeated
+// runs (or a crashed run) never double-charge.
+func assessLateFees(db *gorm.DB, now time.Time) (lateFeeRun, error) {
+	var run lateFeeRun
+	var rules []models.LateFeeRule
+	if err := db.Where("is_active = ?", true).Find(&rules).Error; err != nil {
+		return run, err
+	}
+	for _, rule := range rules {
+		slabs, err := parseLateFeeSlabs(rule.Slabs)
+		if err != nil {
+			log.Printf("late fees: rule %d has invalid slabs: %v", rule.RuleID, err)
+			continue
+		}
+		var students []int64
+		db.Model(&models.FeeLedgerEntry{}).
+			Where("fee_type_id = ? AND entry_type IN ? AND due_date < ?", rule.FeeTypeID,
+				[]string{LedgerCharge, LedgerAdjustment}, now.AddDate(0, 0, -rule.GraceDays)).
+			Distinct().Pluck("enrollment_number", &students)
+
+		for _, enrollment := range students {
+			charges, err := openCharges(db, enrollment, rule.FeeTypeID)
+			if err != nil {
+				return run, err
+			}
+			posted := false
+			for _, ch := range charges {
+				if ch.DueDate == nil || ch.Outstanding <= 0 {
+					continue
+				}
+				days := daysOverdue(*ch.DueDate, now)
+				target := lateFeeFor(rule, slabs, days)
+				delta := roundMoney(target - ch.PenaltyAccrued)
+				if delta <= 0 {
+					continue
+				}
+				chargeID := ch.EntryID
+				inserted, err := postLedgerEntry(db, &models.FeeLedgerEntry{
+					EnrollmentNumber: enrollment,
+					FeeTypeID:        rule.FeeTypeID,
+					EntryType:        LedgerPenalty,
+					Debit:            delta,
+					Reference:        ptrString(fmt.Sprintf("latefee:%d:%.2f", chargeID, target)),
+					ParentEntryID:    &chargeID,
+					TransactionDate:  &now,
+					Description:      ptrString(fmt.Sprintf("Late fee: %d days overdue", days)),
+				})
+				if err != nil {
+					return run, err
+				}
+				if inserted {
+					posted = true
+					run.Charges++
+					run.Amount = roundMoney(run.Amount + delta)
+				}
+			}
+			if posted {
+				run.Students++
+			}
+		}
+	}
+	return run, nil
+}
+
+// InitLateFeeJob assesses late fees shortly after startup and then every LATE_FEE_INTERVAL_HOURS
+func InitLateFeeJob() {
+	if config.LateFeeIntervalHours <= 0 {
+		return
+	}
+	go func() {
+		time.Sleep(time.Minute)
+		ticker := time.NewTicker(time.Duration(config.LateFeeIntervalHours) * time.Hour)
+		defer ticker.Stop()
+		for {
+			run, err := assessLateFees(config.DB, time.Now())
+			if err != nil {
+				log.Printf("late fees: assessment failed: %v", err)
+			} else if run.Charges > 0 {
+				log.Printf("late fees: posted %.2f on %d charges for %d students", run.Amount, run.Charges, run.Students)
+			}
+			<-ticker.C
+		}
+	}()
+}
+
+// ======================== LATE FEE ADMIN ========================
+
+// GetLateFeeRules lists the late fee rule of every fee type that has one
+func GetLateFeeRules(c *gin.Context) {
+	var rules []struct {
+		models.LateFeeRule
+		FeeTypeName string `json:"fee_type_name"`
+	}
+	if err := config.DB.Table("late_fee_rules").
+		Select("late_fee_rules.*, master_fee_types.fee_type_name").
+		Joins("JOIN master_fee_types ON master_fee_types.fee_type_id = late_fee_rules.fee_type_id").
+		Order("master_fee_types.fee_type_name").
+		Scan(&rules).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load late fee rules"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"rules": rules})
+}
+
+// SaveLateFeeRule creates or replaces the late fee rule for a fee type
+func SaveLateFeeRule(c *gin.Context) {
+	feeTypeID, err := strconv.Atoi(c.Param("fee_type_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid fee type id"})
+		return
+	}
+	var req struct {
+		Method    string        `json:"method" binding:"required,oneof=flat per_day slab"`
+		Amount    float64       `json:"amount" binding:"gte=0"`
+		Slabs     []lateFeeSlab `json:"slabs"`
+		GraceDays int           `json:"grace_days" binding:"gte=0"`
+		MaxAmount float64       `json:"max_amount" binding:"gte=0"`
+		IsActive  *bool         `json:"is_active"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Method == LateFeeSlab && len(req.Slabs) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "slab rules need at least one slab"})
+		return
+	}
+	if req.Method != LateFeeSlab && req.Amount <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "amount must be positive"})
+		return
+	}
+	for _, s := range req.Slabs {
+		if s.AfterDays < 0 || s.Amount < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "slab days and amounts cannot be negative"})
+			return
+		}
+	}
+
+	db := config.DB
+	var feeType models.MasterFeeType
+	if err := db.Where("fee_type_id = ?", feeTypeID).First(&feeType).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "fee type not found"})
+		return
+	}
+
+	var rule models.LateFeeRule
+	existing := db.Where("fee_type_id = ?", feeTypeID).Limit(1).Find(&rule).RowsAffected > 0
+	before := rule
+
+	now := time.Now()
+	rule.FeeTypeID = feeTypeID
+	rule.Method = req.Method
+	rule.Amount = roundMoney(req.Amount)
+	rule.GraceDays = req.GraceDays
+	rule.MaxAmount = roundMoney(req.MaxAmount)
+	rule.Slabs = nil
+	if req.Method == LateFeeSlab {
+		raw, _ := json.Marshal(req.Slabs)
+		rule.Slabs = ptrString(string(raw))
+	}
+	rule.IsActive = req.IsActive == nil || *req.IsActive
+	if uid := c.GetInt64("user_id"); uid != 0 {
+		rule.UpdatedBy = &uid
+	}
+	rule.UpdatedAt = now
+	if !existing {
+		rule.CreatedAt = now
+	}
+	// Save writes is_active even when false
+	if err := db.Save(&rule).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save late fee rule"})
+		return
+	}
+
+	var auditBefore interface{}
+	if existing {
+		auditBefore = before
+	}
+	middleware.Audit(c, "fees.late_fee_rule", "late_fee_rule", strconv.Itoa(feeTypeID), auditBefore, rule)
+
+	c.JSON(http.StatusOK, gin.H{"message": "late fee rule saved", "rule": rule})
+}
+
+// RunLateFees assesses late fees now instead of waiting for the scheduled job
+func RunLateFees(c *gin.Context) {
+	run, err := assessLateFees(config.DB, time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "late fee assessment failed"})
+		return
+	}
+	middleware.Audit(c, "fees.late_fee_run", "late_fee_rule", "", nil, run)
+	c.JSON(http.StatusOK, run)
+}
+
+// WaiveLateFee waives all or part of the late fee accrued on one charge
+func WaiveLateFee(c *gin.Context) {
+	var req struct {
+		ChargeID int64   `json:"charge_id" binding:"required"`
+		Amount   float64 `json:"amount" binding:"gte=0"` // 0 waives the whole remaining late fee
+		Reason   string  `json:"reason" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var createdBy *int64
+	if uid := c.GetInt64("user_id"); uid != 0 {
+		createdBy = &uid
+	}
+
+	db := config.DB
+	var waiver models.FeeLedgerEntry
+	var remaining float64
+	err := db.Transaction(func(tx *gorm.DB) error {
+		// Locking the charge serialises waivers against it
+		var charge models.FeeLedgerEntry
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("entry_id = ? AND entry_type IN ?", req.ChargeID, []string{LedgerCharge, LedgerAdjustment}).
+			First(&charge).Error; err != nil {
+			return err
+		}
+		tx.Model(&models.FeeLedgerEntry{}).Select("COALESCE(SUM(debit - credit),0)").
+			Where("parent_entry_id = ? AND entry_type = ?", charge.EntryID, LedgerPenalty).Scan(&remaining)
+		remaining = roundMoney(remaining)
+
+		amount := roundMoney(req.Amount)
+		if amount == 0 {
+			amount = remaining
+		}
+		if remaining <= 0 || amount > remaining {
+			return errNothingToReverse
+		}
+		now := time.Now()
+		waiver = models.FeeLedgerEntry{
+			EnrollmentNumber: charge.EnrollmentNumber,
+			FeeTypeID:        charge.FeeTypeID,
+			EntryType:        LedgerPenalty,
+			Credit:           amount,
+			ParentEntryID:    &charge.EntryID,
+			TransactionDate:  &now,
+			Description:      ptrString(truncate("Late fee waived: "+req.Reason, 255)),
+			CreatedBy:        createdBy,
+		}
+		_, err := postLedgerEntry(tx, &waiver)
+		return err
+	})
+	if err != nil {
+		switch err {
+		case gorm.ErrRecordNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": "charge not found"})
+		case errNothingToReverse:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "amount exceeds the late fee outstanding on this charge", "waivable": remaining})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to waive late fee"})
+		}
+		return
+	}
+
+	middleware.Audit(c, "fees.late_fee_waive", "fee_ledger", strconv.FormatInt(req.ChargeID, 10),
+		gin.H{"late_fee": remaining},
+		gin.H{"late_fee": roundMoney(remaining - waiver.Credit), "waived": waiver.Credit, "reason": req.Reason})
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":   "late fee waived",
+		"waiver_id": waiver.EntryID,
+		"charge_id": req.ChargeID,
+		"waived":    waiver.Credit,
+		"remaining": roundMoney(remaining - waiver.Credit),
+	})
+}
+
+// GetLateFeeWaivers lists late fee waivers with who granted them
+func GetLateFeeWaivers(c *gin.Context) {
+	q := config.DB.Table("fee_ledger").
+		Select(`fee_ledger.entry_id AS waiver_id, fee_ledger.parent_entry_id AS charge_id, fee_ledger.enrollment_number,
+			master_fee_types.fee_type_name, fee_ledger.credit AS amount, fee_ledger.description,
+			fee_ledger.created_by AS waived_by, users.full_name AS waived_by_name, fee_ledger.created_at`).
+		Joins("JOIN master_fee_types ON master_fee_types.fee_type_id = fee_ledger.fee_type_id").
+		Joins("LEFT JOIN users ON users.user_id = fee_ledger.created_by").
+		Where("fee_ledger.entry_type = ? AND fee_ledger.credit > 0", LedgerPenalty)
+	if enrollment := c.Query("enrollment_number"); enrollment != "" {
+		q = q.Where("fee_ledger.enrollment_number = ?", enrollment)
+	}
+	var waivers []struct {
+		WaiverID         int64     `json:"waiver_id"`
+		ChargeID         *int64    `json:"charge_id"`
+		EnrollmentNumber int64     `json:"enrollment_number"`
+		FeeTypeName      string    `json:"fee_type_name"`
+		Amount           float64   `json:"amount"`
+		Description      *string   `json:"description"`
+		WaivedBy         *int64    `json:"waived_by"`
+		WaivedByName     *string   `json:"waived_by_name"`
+		CreatedAt        time.Time `json:"created_at"`
+	}
+	if err := q.Order("fee_ledger.entry_id DESC").Limit(500).Scan(&waivers).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load waivers"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"waivers": waivers})
+}
@@ -0,0 +1,168 @@
+package controllers
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/kiranraoboinapally/student/backend/internal/config"
+	"github.com/kiranraoboinapally/student/backend/internal/mailer"
+	"github.com/kiranraoboinapally/student/backend/internal/models"
+	"github.com/kiranraoboinapally/student/backend/internal/utils"
+)
+
+// ======================== EMAIL OUTBOX ========================
+
+const maxEmailAttempts = 6
+
+// How long a claimed message is left to its sender before another run may pick it up
+const emailClaimLease = 5 * time.Minute
+
+var mailTransport mailer.Mailer
+
+// InitMailer selects the mail driver and starts the outbox worker
+func InitMailer() {
+	switch config.MailDriver {
+	case "smtp":
+		mailTransport = mailer.NewSMTPMailer(config.SMTPHost, config.SMTPPort, config.SMTPUser, config.SMTPPass, config.MailFrom)
+	case "file":
+		mailTransport = mailer.NewFileMailer(config.MailOutboxDir, config.MailFrom)
+	default:
+		mailTransport = mailer.LogMailer{}
+	}
+
+	go func() {
+		ticker := time.NewTicker(15 * time.Second)
+		defer ticker.Stop()
+		for range ticker.C {
+			deliverPendingEmails()
+		}
+	}()
+}
+
+// QueueEmail stores a message in the outbox; pass a transaction to send only if it commits
+func QueueEmail(db *gorm.DB, to, subject, body string) error {
+	if to == "" {
+		return fmt.Errorf("missing recipient")
+	}
+	now := time.Now()
+	return db.Create(&models.EmailOutbox{
+		ToAddress:     to,
+		Subject:       subject,
+		Body:          body,
+		Status:        "pending",
+		NextAttemptAt: now,
+		CreatedAt:     now,
+	}).Error
+}
+
+// deliverPendingEmails sends due messages, retrying failures with exponential backoff. Each message
+// is claimed before it is sent so that overlapping runs, or several server instances, don't send it
+// twice; a claim left behind by a run that died mid-send lapses after emailClaimLease.
+func deliverPendingEmails() {
+	if mailTransport == nil {
+		return
+	}
+	db := config.DB
+
+	var batch []models.EmailOutbox
+	db.Where("status IN ? AND next_attempt_at <= ?", []string{"pending", "sending"}, time.Now()).
+		Order("email_id ASC").
+		Limit(50).
+		Find(&batch)
+
+	for _, e := range batch {
+		now := time.Now()
+		claim := db.Model(&models.EmailOutbox{}).
+			Where("email_id = ? AND status IN ? AND next_attempt_at <= ?", e.EmailID, []string{"pending", "sending"}, now).
+			Updates(map[string]interface{}{"status": "sending", "next_attempt_at": now.Add(emailClaimLease)})
+		if claim.Error != nil || claim.RowsAffected == 0 {
+			continue // claimed by another run
+		}
+
+		err := mailTransport.Send(mailer.Message{To: e.ToAddress, Subject: e.Subject, Body: e.Body})
+		now = time.Now()
+		if err == nil {
+			db.Model(&e).Updates(map[string]interface{}{"status": "sent", "sent_at": now, "attempts": e.Attempts + 1, "last_error": nil})
+			continue
+		}
+
+		attempts := e.Attempts + 1
+		msg := err.Error()
+		updates := map[string]interface{}{"status": "pending", "attempts": attempts, "last_error": msg}
+		if attempts >= maxEmailAttempts {
+			updates["status"] = "failed"
+			log.Printf("email %d to %s failed permanently: %v", e.EmailID, e.ToAddress, err)
+		} else {
+			// 1m, 2m, 4m, 8m, 16m
+			updates["next_attempt_at"] = now.Add(time.Minute * time.Duration(1<<(attempts-1)))
+		}
+		db.Model(&e).Updates(updates)
+	}
+}
+
+// tempPasswordOrGenerate keeps an admin-supplied temporary password or generates one
+func tempPasswordOrGenerate(supplied string) (string, error) {
+	if supplied != "" {
+		return supplied, nil
+	}
+	return utils.RandomToken(6)
+}
+
+// ======================== EMAIL TEMPLATES ========================
+
+func passwordResetEmail(fullName, token string) (string, string) {
+	link := fmt.Sprintf("%s/reset-password?token=%s", config.AppBaseURL, token)
+	return "Reset your password", fmt.Sprintf(`Hello %s,
+
+We received a request to reset your password. Open the link below within 1 hour to choose a new one:
+
+%s
+
+If you did not request this, you can ignore this email; your password will not change.
+`, fullName, link)
+}
+
+func registrationStatusEmail(fullName, status string) (string, string) {
+	if status == "active" {
+		return "Your registration has been approved", fmt.Sprintf(`Hello %s,
+
+Your student registration has been approved. You can now sign in at %s with your enrollment number and the password you chose.
+`, fullName, config.AppBaseURL)
+	}
+	return "Your registration was not approved", fmt.Sprintf(`Hello %s,
+
+Your student registration was not approved. Please contact your institute office for details.
+`, fullName)
+}
+
+func facultyStatusEmail(fullName, status, remarks string) (string, string) {
+	if status == "approved" {
+		return "Your faculty account has been approved", fmt.Sprintf(`Hello %s,
+
+Your faculty account has been approved by the university. Sign in at %s with the temporary password issued by your institute; you will be asked to change it on first login.
+`, fullName, config.AppBaseURL)
+	}
+	body := fmt.Sprintf(`Hello %s,
+
+Your faculty account was not approved by the university.
+`, fullName)
+	if remarks != "" {
+		body += "\nRemarks: " + remarks + "\n"
+	}
+	return "Your faculty account was not approved", body
+}
+
+func temporaryPasswordEmail(fullName, username, tempPassword string) (string, string) {
+	return "Your account has been created", fmt.Sprintf(`Hello %s,
+
+An account has been created for you.
+
+Username: %s
+Temporary password: %s
+
+Sign in at %s. You will be asked to change this password on first login.
+`, fullName, username, tempPassword, config.AppBaseURL)
+}
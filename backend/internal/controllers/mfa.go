@@ -0,0 +1,468 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/kiranraoboinapally/student/backend/internal/config"
+	"github.com/kiranraoboinapally/student/backend/internal/models"
+	"github.com/kiranraoboinapally/student/backend/internal/utils"
+)
+
+// ======================== TWO-FACTOR AUTH (TOTP) ========================
+
+const recoveryCodeCount = 10
+
+// roleRequiresMFA reports whether the admin has made a second factor mandatory for the role
+func roleRequiresMFA(db *gorm.DB, roleID int) bool {
+	var required bool
+	db.Model(&models.Role{}).Select("mfa_required").Where("role_id = ?", roleID).Scan(&required)
+	return required
+}
+
+// mfaChallenge returns the "MFA pending" response for users who need a second factor.
+// Users whose role requires MFA but who never enrolled get their provisioning URI here,
+// so enrollment is completed at /api/auth/mfa/verify without ever holding a full session.
+func mfaChallenge(db *gorm.DB, user *models.User) (gin.H, error) {
+	enrolling := !user.MFAEnabled
+	if enrolling && !roleRequiresMFA(db, user.RoleID) {
+		return nil, nil
+	}
+
+	pending, _, expiresAt, err := utils.GenerateMFAPendingToken(user.UserID, user.TokenVersion)
+	if err != nil {
+		return nil, err
+	}
+	resp := gin.H{
+		"mfa_required":            true,
+		"mfa_token":               pending,
+		"mfa_expires_in":          int64(time.Until(expiresAt).Seconds()),
+		"mfa_enrollment_required": enrolling,
+		"role_id":                 user.RoleID,
+	}
+
+	if enrolling {
+		if user.MFASecret == nil {
+			secret, err := utils.GenerateTOTPSecret()
+			if err != nil {
+				return nil, err
+			}
+			if err := db.Model(user).Updates(map[string]interface{}{"mfa_secret": secret, "mfa_last_step": 0}).Error; err != nil {
+				return nil, err
+			}
+			user.MFASecret = &secret
+		}
+		resp["mfa_secret"] = *user.MFASecret
+		resp["otpauth_uri"] = utils.TOTPProvisioningURI(config.MFAIssuer, user.Username, *user.MFASecret)
+	}
+	return resp, nil
+}
+
+// newRecoveryCodes replaces the user's recovery codes and returns the plain values once
+func newRecoveryCodes(db *gorm.DB, userID int64) ([]string, error) {
+	if err := db.Where("user_id = ?", userID).Delete(&models.MFARecoveryCode{}).Error; err != nil {
+		return nil, err
+	}
+	codes := make([]string, 0, recoveryCodeCount)
+	now := time.Now()
+	for i := 0; i < recoveryCodeCount; i++ {
+		raw, err := utils.RandomToken(5)
+		if err != nil {
+			return nil, err
+		}
+		code := raw[:5] + "-" + raw[5:]
+		if err := db.Create(&models.MFARecoveryCode{
+			UserID:    userID,
+			CodeHash:  utils.HashToken(normalizeRecoveryCode(code)),
+			CreatedAt: now,
+		}).Error; err != nil {
+			return nil, err
+		}
+		codes = append(codes, code)
+	}
+	return codes, nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
+
+// useRecoveryCode burns a matching unused recovery code
+func useRecoveryCode(db *gorm.DB, userID int64, code string) bool {
+	result := db.Model(&models.MFARecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, utils.HashToken(normalizeRecoveryCode(code))).
+		Update("used_at", time.Now())
+	return result.Error == nil && result.RowsAffected > 0
+}
+
+// checkTOTP verifies a code and records its step so it cannot be used twice
+func checkTOTP(db *gorm.DB, user *models.User, code string) bool {
+	if user.MFASecret == nil {
+		return false
+	}
+	step, ok := utils.VerifyTOTP(*user.MFASecret, code, time.Now(), user.MFALastStep)
+	if !ok {
+		return false
+	}
+	// Conditional update so two concurrent requests cannot both accept the same step
+	result := db.Model(&models.User{}).
+		Where("user_id = ? AND mfa_last_step < ?", user.UserID, step).
+		Update("mfa_last_step", step)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return false
+	}
+	user.MFALastStep = step
+	return true
+}
+
+type MFAVerifyRequest struct {
+	MFAToken     string `json:"mfa_token" binding:"required"`
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recovery_code"`
+}
+
+// VerifyMFA exchanges an MFA-pending token plus a TOTP or recovery code for a full session
+func VerifyMFA(c *gin.Context) {
+	var req MFAVerifyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Code == "" && req.RecoveryCode == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "code or recovery_code is required"})
+		return
+	}
+
+	claims, err := utils.ParseToken(req.MFAToken)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+	if typ, _ := claims["typ"].(string); typ != utils.TokenTypeMFAPending {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token type"})
+		return
+	}
+	userID, _ := utils.ClaimInt64(claims, "user_id")
+	tokenVersion, _ := utils.ClaimInt64(claims, "ver")
+	jti, _ := claims["jti"].(string)
+
+	db := config.DB
+	var user models.User
+	if err := db.Where("user_id = ?", userID).First(&user).Error; err != nil ||
+		user.Status != "active" || int64(user.TokenVersion) != tokenVersion {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "token revoked"})
+		return
+	}
+	var revoked int64
+	db.Model(&models.RevokedToken{}).Where("jti = ?", jti).Count(&revoked)
+	if revoked > 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "token revoked"})
+		return
+	}
+
+	subject := strconv.FormatInt(user.UserID, 10)
+	if wait, locked := throttleLockedFor(db, throttleMFAUser, subject); locked {
+		respondLocked(c, wait)
+		return
+	}
+	if user.MFASecret == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "mfa enrollment not started, log in again"})
+		return
+	}
+
+	var verified bool
+	if req.RecoveryCode != "" {
+		// Recovery codes only exist once enrollment is complete
+		verified = user.MFAEnabled && useRecoveryCode(db, user.UserID, req.RecoveryCode)
+	} else {
+		verified = checkTOTP(db, &user, req.Code)
+	}
+	if !verified {
+		throttleFailure(c, db, throttleMFAUser, subject)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid verification code"})
+		return
+	}
+	throttleReset(db, throttleMFAUser, subject)
+
+	// The pending token is single use
+	db.Create(&models.RevokedToken{
+		JTI:       jti,
+		UserID:    user.UserID,
+		ExpiresAt: time.Now().Add(utils.MFAPendingTTL),
+		CreatedAt: time.Now(),
+	})
+
+	var recoveryCodes []string
+	if !user.MFAEnabled {
+		// First successful code completes a login-time enrollment
+		tx := db.Begin()
+		tx.Model(&user).Update("mfa_enabled", true)
+		codes, err := newRecoveryCodes(tx, user.UserID)
+		if err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to enable mfa"})
+			return
+		}
+		tx.Commit()
+		recoveryCodes = codes
+	}
+
+	session, _, err := issueSession(c, db, user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "token creation failed"})
+		return
+	}
+	db.Model(&user).Update("last_login", time.Now())
+
+	session["force_password_change"] = user.IsTempPassword
+	session["role_id"] = user.RoleID
+	if recoveryCodes != nil {
+		session["recovery_codes"] = recoveryCodes
+	}
+	c.JSON(http.StatusOK, session)
+}
+
+// ======================== SELF-SERVICE ENROLLMENT ========================
+
+// GetMFAStatus reports the caller's second-factor state
+func GetMFAStatus(c *gin.Context) {
+	db := config.DB
+	var user models.User
+	if err := db.Where("user_id = ?", c.GetInt64("user_id")).First(&user).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		return
+	}
+	var remaining int64
+	db.Model(&models.MFARecoveryCode{}).Where("user_id = ? AND used_at IS NULL", user.UserID).Count(&remaining)
+
+	c.JSON(http.StatusOK, gin.H{
+		"mfa_enabled":              user.MFAEnabled,
+		"mfa_required":             roleRequiresMFA(db, user.RoleID),
+		"recovery_codes_remaining": remaining,
+	})
+}
+
+// EnrollMFA creates a new TOTP secret for the caller; it is not active until confirmed
+func EnrollMFA(c *gin.Context) {
+	db := config.DB
+	var user models.User
+	if err := db.Where("user_id = ?", c.GetInt64("user_id")).First(&user).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		return
+	}
+	if user.MFAEnabled {
+		c.JSON(http.StatusConflict, gin.H{"error": "mfa already enabled"})
+		return
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate secret"})
+		return
+	}
+	if err := db.Model(&user).Updates(map[string]interface{}{"mfa_secret": secret, "mfa_last_step": 0}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save secret"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"mfa_secret":  secret,
+		"otpauth_uri": utils.TOTPProvisioningURI(config.MFAIssuer, user.Username, secret),
+	})
+}
+
+type MFACodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// ConfirmMFA activates a pending enrollment and returns the recovery codes
+func ConfirmMFA(c *gin.Context) {
+	var req MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	db := config.DB
+	var user models.User
+	if err := db.Where("user_id = ?", c.GetInt64("user_id")).First(&user).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		return
+	}
+	if user.MFAEnabled {
+		c.JSON(http.StatusConflict, gin.H{"error": "mfa already enabled"})
+		return
+	}
+	if user.MFASecret == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "start enrollment first"})
+		return
+	}
+	if !checkTOTP(db, &user, req.Code) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid verification code"})
+		return
+	}
+
+	tx := db.Begin()
+	tx.Model(&user).Update("mfa_enabled", true)
+	codes, err := newRecoveryCodes(tx, user.UserID)
+	if err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to enable mfa"})
+		return
+	}
+	tx.Commit()
+
+	c.JSON(http.StatusOK, gin.H{"message": "mfa enabled", "recovery_codes": codes})
+}
+
+type DisableMFARequest struct {
+	Password string `json:"password" binding:"required"`
+	Code     string `json:"code" binding:"required"`
+}
+
+// DisableMFA turns off the caller's second factor unless their role requires it
+func DisableMFA(c *gin.Context) {
+	var req DisableMFARequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	db := config.DB
+	var user models.User
+	if err := db.Where("user_id = ?", c.GetInt64("user_id")).First(&user).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		return
+	}
+	if roleRequiresMFA(db, user.RoleID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "mfa is required for your role"})
+		return
+	}
+	if !user.MFAEnabled {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "mfa is not enabled"})
+		return
+	}
+	if !utils.CheckPasswordHash(user.PasswordHash, req.Password) || !checkTOTP(db, &user, req.Code) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid password or verification code"})
+		return
+	}
+
+	if err := clearMFA(db, user.UserID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to disable mfa"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "mfa disabled"})
+}
+
+// RegenerateRecoveryCodes invalidates old recovery codes and issues a new set
+func RegenerateRecoveryCodes(c *gin.Context) {
+	var req MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	db := config.DB
+	var user models.User
+	if err := db.Where("user_id = ?", c.GetInt64("user_id")).First(&user).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		return
+	}
+	if !user.MFAEnabled {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "mfa is not enabled"})
+		return
+	}
+	if !checkTOTP(db, &user, req.Code) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid verification code"})
+		return
+	}
+
+	codes, err := newRecoveryCodes(db, user.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate recovery codes"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"recovery_codes": codes})
+}
+
+func clearMFA(db *gorm.DB, userID int64) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.User{}).Where("user_id = ?", userID).
+			Updates(map[string]interface{}{"mfa_enabled": false, "mfa_secret": nil, "mfa_last_step": 0}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", userID).Delete(&models.MFARecoveryCode{}).Error
+	})
+}
+
+// ======================== ADMIN MFA POLICY ========================
+
+// GetRoleMFAPolicies lists every role with its MFA requirement
+func GetRoleMFAPolicies(c *gin.Context) {
+	var roles []models.Role
+	config.DB.Order("role_id").Find(&roles)
+	c.JSON(http.StatusOK, gin.H{"roles": roles})
+}
+
+type RoleMFAPolicyRequest struct {
+	Required *bool `json:"required" binding:"required"`
+}
+
+// SetRoleMFAPolicy makes MFA mandatory (or optional) for everyone in a role
+func SetRoleMFAPolicy(c *gin.Context) {
+	roleID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid role id"})
+		return
+	}
+	var req RoleMFAPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result := config.DB.Model(&models.Role{}).Where("role_id = ?", roleID).Update("mfa_required", *req.Required)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update role"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		var count int64
+		config.DB.Model(&models.Role{}).Where("role_id = ?", roleID).Count(&count)
+		if count == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "role not found"})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "mfa policy updated", "role_id": roleID, "mfa_required": *req.Required})
+}
+
+// ResetUserMFA removes a user's second factor (lost device) and signs them out everywhere
+func ResetUserMFA(c *gin.Context) {
+	userID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	db := config.DB
+	var user models.User
+	if err := db.Where("user_id = ?", userID).First(&user).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		return
+	}
+	if err := clearMFA(db, userID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to reset mfa"})
+		return
+	}
+	RevokeUserSessions(db, userID)
+
+	c.JSON(http.StatusOK, gin.H{"message": "mfa reset, user must enroll again at next login"})
+}
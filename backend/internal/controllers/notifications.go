@@ -8,9 +8,8 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
 	"github.com/gorilla/websocket"
-	"github.com/kiranraoboinapally/student/backend/internal/config"
+	"github.com/kiranraoboinapally/student/backend/internal/middleware"
 )
 
 var notifHub *notificationHub
@@ -78,30 +77,15 @@ func AdminWSHandler(c *gin.Context) {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "missing token"})
 		return
 	}
-	token, err := jwt.Parse(tokenStr, func(t *jwt.Token) (interface{}, error) {
-		return []byte(config.JwtSecret), nil
-	})
-	if err != nil || !token.Valid {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+	user, status, err := middleware.AuthenticateToken(tokenStr)
+	if err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
 		return
 	}
-	claims, ok := token.Claims.(jwt.MapClaims)
-	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token claims"})
+	if !middleware.RoleHasPermission(user.RoleID, "notifications.view") {
+		c.JSON(http.StatusForbidden, gin.H{"error": "forbidden: missing permission notifications.view"})
 		return
 	}
-	var roleID int
-	if v, ok := claims["role_id"].(float64); ok {
-		roleID = int(v)
-	}
-	if roleID != 1 {
-		c.JSON(http.StatusForbidden, gin.H{"error": "forbidden: admin only"})
-		return
-	}
-	var userID int64
-	if v, ok := claims["user_id"].(float64); ok {
-		userID = int64(v)
-	}
 
 	w := c.Writer
 	r := c.Request
@@ -111,7 +95,7 @@ func AdminWSHandler(c *gin.Context) {
 		return
 	}
 
-	cl := &client{userID: userID, conn: conn, send: make(chan []byte, 256)}
+	cl := &client{userID: user.UserID, conn: conn, send: make(chan []byte, 256)}
 	notifHub.register <- cl
 	go cl.writer()
 	cl.reader()
@@ -0,0 +1,245 @@
+package controllers
+
+import (
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/kiranraoboinapally/student/backend/internal/config"
+	"github.com/kiranraoboinapally/student/backend/internal/middleware"
+	"github.com/kiranraoboinapally/student/backend/internal/models"
+	"github.com/kiranraoboinapally/student/backend/internal/utils"
+)
+
+// ======================== OFFLINE PAYMENTS ========================
+
+// offlinePaymentModes are the ways a payment can reach the institute outside the gateway
+var offlinePaymentModes = map[string]bool{"cash": true, "dd": true, "cheque": true, "neft": true, "rtgs": true, "imps": true, "upi": true}
+
+const maxProofBytes = 5 << 20
+
+// proofTypes are the accepted proof formats, by sniffed content type
+var proofTypes = map[string]string{"application/pdf": ".pdf", "image/jpeg": ".jpg", "image/png": ".png"}
+
+var errDuplicateOfflinePayment = errors.New("a payment with this mode and reference number has already been submitted")
+
+func proofDir() string {
+	return filepath.Join(config.UploadDir, "payment_proofs")
+}
+
+// saveProof stores an uploaded proof under a random name and returns its record (without the
+// payment id). The caller removes the file if the payment is not saved.
+func saveProof(fh *multipart.FileHeader) (models.PaymentProof, error) {
+	var proof models.PaymentProof
+	if fh.Size > maxProofBytes {
+		return proof, errors.New("proof must be 5 MB or smaller")
+	}
+	f, err := fh.Open()
+	if err != nil {
+		return proof, err
+	}
+	defer f.Close()
+	data, err := io.ReadAll(io.LimitReader(f, maxProofBytes+1))
+	if err != nil {
+		return proof, err
+	}
+	if len(data) > maxProofBytes {
+		return proof, errors.New("proof must be 5 MB or smaller")
+	}
+	contentType := http.DetectContentType(data)
+	ext, ok := proofTypes[contentType]
+	if !ok {
+		return proof, errors.New("proof must be a PDF, JPEG or PNG file")
+	}
+	name, err := utils.RandomToken(16)
+	if err != nil {
+		return proof, err
+	}
+	if err := os.MkdirAll(proofDir(), 0o750); err != nil {
+		return proof, err
+	}
+	proof.StoredName = name + ext
+	if err := os.WriteFile(filepath.Join(proofDir(), proof.StoredName), data, 0o640); err != nil {
+		return proof, err
+	}
+	proof.FileName = truncate(filepath.Base(fh.Filename), 255)
+	proof.ContentType = contentType
+	proof.Size = int64(len(data))
+	return proof, nil
+}
+
+// submitOfflinePayment records a cash, DD or bank transfer payment as Pending with its proof. It
+// does not count towards the balance until someone other than the submitter verifies it.
+func submitOfflinePayment(c *gin.Context, enrollment int64) {
+	var req struct {
+		FeeHead         string  `form:"fee_head" binding:"required"`
+		Amount          float64 `form:"amount" binding:"gte=0"`
+		PaymentMode     string  `form:"payment_mode" binding:"required"`
+		ReferenceNumber string  `form:"reference_number"`                // DD / cheque / UTR number; optional for cash
+		PaymentDate     string  `form:"payment_date" binding:"required"` // YYYY-MM-DD
+		ChargeID        *int64  `form:"charge_id"`
+		Remarks         string  `form:"remarks"`
+	}
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	mode := strings.ToLower(strings.TrimSpace(req.PaymentMode))
+	if !offlinePaymentModes[mode] {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "payment_mode must be one of cash, dd, cheque, neft, rtgs, imps, upi"})
+		return
+	}
+	ref := strings.TrimSpace(req.ReferenceNumber)
+	if ref == "" && mode != "cash" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "reference_number is required for " + mode + " payments"})
+		return
+	}
+	paidOn, err := time.ParseInLocation("2006-01-02", req.PaymentDate, time.Local)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "payment_date must be YYYY-MM-DD"})
+		return
+	}
+	if paidOn.After(time.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "payment_date cannot be in the future"})
+		return
+	}
+	fh, err := c.FormFile("proof")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "proof is required"})
+		return
+	}
+
+	db := config.DB
+	feeType, err := resolveFeeType(db, req.FeeHead)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid fee head"})
+		return
+	}
+	amount, err := paymentAmountFor(db, enrollment, feeType.FeeTypeID, req.Amount, req.ChargeID)
+	if err != nil {
+		var pe paymentAmountError
+		if errors.As(err, &pe) {
+			c.JSON(pe.Status, pe.Body)
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load fees"})
+		return
+	}
+
+	proof, err := saveProof(fh)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var submittedBy *int64
+	if uid := c.GetInt64("user_id"); uid != 0 {
+		submittedBy = &uid
+	}
+	entry := models.FeeLedgerEntry{
+		EnrollmentNumber: enrollment,
+		FeeTypeID:        feeType.FeeTypeID,
+		EntryType:        LedgerPayment,
+		Credit:           amount,
+		Status:           PaymentStatusPending,
+		ParentEntryID:    req.ChargeID,
+		TransactionDate:  &paidOn,
+		PaymentMethod:    ptrString(mode),
+		Description:      ptrString(feeHeadLabel(feeType.FeeTypeName) + " - " + strings.ToUpper(mode)),
+		CreatedBy:        submittedBy,
+	}
+	if ref != "" {
+		entry.Reference = ptrString(truncate("offline:"+mode+":"+ref, 100))
+		entry.TransactionNumber = ptrString(truncate(ref, 100))
+	}
+	if req.Remarks != "" {
+		entry.Description = ptrString(truncate(*entry.Description+" - "+req.Remarks, 255))
+	}
+	err = db.Transaction(func(tx *gorm.DB) error {
+		inserted, err := postLedgerEntry(tx, &entry)
+		if err != nil {
+			return err
+		}
+		if !inserted {
+			return errDuplicateOfflinePayment
+		}
+		proof.PaymentEntryID = entry.EntryID
+		proof.UploadedBy = submittedBy
+		proof.CreatedAt = time.Now()
+		return tx.Create(&proof).Error
+	})
+	if err != nil {
+		os.Remove(filepath.Join(proofDir(), proof.StoredName))
+		if err == errDuplicateOfflinePayment {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to record payment"})
+		return
+	}
+
+	middleware.Audit(c, "fees.offline.submit", "fee_ledger", strconv.FormatInt(entry.EntryID, 10), nil,
+		gin.H{"enrollment_number": enrollment, "fee_type_id": feeType.FeeTypeID, "amount": amount, "mode": mode, "reference": ref})
+	SendAdminNotification("offline_payment_submitted", gin.H{
+		"payment_id": entry.EntryID,
+		"enrollment": enrollment,
+		"amount":     amount,
+		"mode":       mode,
+	})
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":    "payment submitted for verification",
+		"payment_id": entry.EntryID,
+		"amount":     amount,
+		"status":     entry.Status,
+	})
+}
+
+// PayFee lets a student report an offline payment (multipart form with a "proof" file)
+func PayFee(c *gin.Context) {
+	enrollment, err := getEnrollmentOrError(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	submitOfflinePayment(c, enrollment)
+}
+
+// InstituteRecordOfflinePayment lets an institute admin record a payment collected at the counter
+// for one of the institute's students
+func InstituteRecordOfflinePayment(c *gin.Context) {
+	instituteID, _ := c.Get("institute_id")
+	instID := instituteID.(int)
+	enrollment, err := strconv.ParseInt(c.PostForm("enrollment_number"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "enrollment_number is required"})
+		return
+	}
+	var n int64
+	config.DB.Table("master_students").Where("enrollment_number = ? AND institute_id = ?", enrollment, instID).Count(&n)
+	if n == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "student not found in this institute"})
+		return
+	}
+	submitOfflinePayment(c, enrollment)
+}
+
+// GetPaymentProof returns the proof attached to an offline payment
+func GetPaymentProof(c *gin.Context) {
+	var proof models.PaymentProof
+	if err := config.DB.Where("payment_entry_id = ?", c.Param("id")).First(&proof).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "no proof attached to this payment"})
+		return
+	}
+	c.Header("Content-Type", proof.ContentType)
+	c.FileAttachment(filepath.Join(proofDir(), proof.StoredName), proof.FileName)
+}
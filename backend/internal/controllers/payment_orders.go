@@ -0,0 +1,158 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/kiranraoboinapally/student/backend/internal/config"
+	"github.com/kiranraoboinapally/student/backend/internal/models"
+	"github.com/kiranraoboinapally/student/backend/internal/payments"
+)
+
+// ======================== PAYMENT ORDERS ========================
+
+// Payment order statuses
+const (
+	OrderStatusCreated  = "created"
+	OrderStatusPaid     = "paid"
+	OrderStatusExpired  = "expired"
+	OrderStatusMismatch = "mismatch"
+)
+
+var errPaymentMismatch = errors.New("payment does not match order")
+
+// toPaise converts rupees to the gateway's integer unit without float truncation (0.29*100 = 28.99...)
+func toPaise(amount float64) int64 {
+	return int64(math.Round(amount * 100))
+}
+
+// createPaymentOrder persists the order the gateway just issued so verification never trusts the client
+func createPaymentOrder(db *gorm.DB, orderID string, enrollment int64, feeHead, feeType string, chargeID *int64, amount float64) error {
+	now := time.Now()
+	return db.Create(&models.PaymentOrder{
+		OrderID:          orderID,
+		EnrollmentNumber: enrollment,
+		FeeHead:          feeHead,
+		FeeType:          feeType,
+		ChargeID:         chargeID,
+		Amount:           amount,
+		Currency:         "INR",
+		Status:           OrderStatusCreated,
+		ExpiresAt:        now.Add(time.Duration(config.PaymentOrderTTLMinutes) * time.Minute),
+		CreatedAt:        now,
+		UpdatedAt:        now,
+	}).Error
+}
+
+// openOrderAmount is what a student has in gateway orders on a fee type that are still open and
+// unexpired, and so may yet be paid
+func openOrderAmount(db *gorm.DB, enrollment int64, feeTypeID int) (float64, error) {
+	var open []struct {
+		FeeHead string
+		Amount  float64
+	}
+	if err := db.Model(&models.PaymentOrder{}).Select("fee_head, SUM(amount) AS amount").
+		Where("enrollment_number = ? AND status = ? AND expires_at > ?", enrollment, OrderStatusCreated, time.Now()).
+		Group("fee_head").Scan(&open).Error; err != nil {
+		return 0, err
+	}
+	var total float64
+	for _, o := range open {
+		if ft, err := resolveFeeType(db, o.FeeHead); err == nil && ft.FeeTypeID == feeTypeID {
+			total += o.Amount
+		}
+	}
+	return roundMoney(total), nil
+}
+
+// findPaymentOrder returns the order, or nil when it was created before orders were persisted
+func findPaymentOrder(db *gorm.DB, orderID string) (*models.PaymentOrder, error) {
+	var order models.PaymentOrder
+	res := db.Where("order_id = ?", orderID).Limit(1).Find(&order)
+	if res.Error != nil || res.RowsAffected == 0 {
+		return nil, res.Error
+	}
+	return &order, nil
+}
+
+// settlePaymentOrder records a captured payment against its order using the order's enrollment,
+// fee head and amount. The gateway-reported amount must match what the order was created for.
+func settlePaymentOrder(db *gorm.DB, order *models.PaymentOrder, payment payments.Payment, source string) (bool, error) {
+	if payment.OrderID != order.OrderID || payment.Status != "captured" || payment.Amount != toPaise(order.Amount) {
+		db.Model(&models.PaymentOrder{}).
+			Where("order_id = ? AND status IN ?", order.OrderID, []string{OrderStatusCreated, OrderStatusExpired}).
+			Updates(map[string]interface{}{"status": OrderStatusMismatch, "updated_at": time.Now()})
+		return false, errPaymentMismatch
+	}
+
+	recorded := false
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		recorded, err = recordCapturedPayment(tx, capturedPayment{
+			PaymentID:  payment.ID,
+			OrderID:    order.OrderID,
+			Enrollment: order.EnrollmentNumber,
+			FeeHead:    order.FeeHead,
+			FeeType:    order.FeeType,
+			ChargeID:   order.ChargeID,
+			Amount:     order.Amount,
+		}, source)
+		if err != nil {
+			return err
+		}
+		// An expired order can still be paid late; the money was taken so it is credited
+		now := time.Now()
+		return tx.Model(&models.PaymentOrder{}).
+			Where("order_id = ? AND status IN ?", order.OrderID, []string{OrderStatusCreated, OrderStatusExpired}).
+			Updates(map[string]interface{}{"status": OrderStatusPaid, "payment_id": payment.ID, "paid_at": now, "updated_at": now}).Error
+	})
+	return recorded, err
+}
+
+// reconcilePaymentOrder settles an overdue order from the gateway's view of it, or expires it
+func reconcilePaymentOrder(db *gorm.DB, order *models.PaymentOrder) error {
+	attempts, err := paymentGateway.FetchOrderPayments(order.OrderID)
+	if err != nil && err != payments.ErrNotFound {
+		return err
+	}
+	for _, p := range attempts {
+		if p.Status != "captured" {
+			continue
+		}
+		if _, err := settlePaymentOrder(db, order, p, "reconcile"); err != nil {
+			return fmt.Errorf("settle %s: %w", p.ID, err)
+		}
+		return nil
+	}
+	return db.Model(&models.PaymentOrder{}).
+		Where("order_id = ? AND status = ?", order.OrderID, OrderStatusCreated).
+		Updates(map[string]interface{}{"status": OrderStatusExpired, "updated_at": time.Now()}).Error
+}
+
+// InitPaymentOrderSweep periodically reconciles orders that were never verified before they expired
+func InitPaymentOrderSweep() {
+	if paymentGateway == nil {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(5 * time.Minute)
+		defer ticker.Stop()
+		for range ticker.C {
+			db := config.DB
+			var overdue []models.PaymentOrder
+			db.Where("status = ? AND expires_at < ?", OrderStatusCreated, time.Now()).
+				Order("expires_at").Limit(100).Find(&overdue)
+			for i := range overdue {
+				if err := reconcilePaymentOrder(db, &overdue[i]); err != nil {
+					// Left as created so the next sweep retries it
+					log.Printf("payment order sweep: %s: %v", overdue[i].OrderID, err)
+				}
+			}
+		}
+	}()
+}
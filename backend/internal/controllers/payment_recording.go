@@ -0,0 +1,191 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/kiranraoboinapally/student/backend/internal/models"
+)
+
+// ======================== PAYMENT RECORDING ========================
+
+// Fee heads of the built-in fee types
+const (
+	FeeHeadRegistration  = "Registration Fee"
+	FeeHeadExamination   = "Examination Fee"
+	FeeHeadMiscellaneous = "Miscellaneous Fee"
+)
+
+var errUnknownFeeHead = errors.New("invalid fee head")
+
+// capturedPayment is a successful gateway payment ready to be posted to the fee ledger
+type capturedPayment struct {
+	PaymentID  string
+	OrderID    string
+	Enrollment int64
+	FeeHead    string
+	FeeType    string
+	ChargeID   *int64 // installment the student chose to pay
+	Amount     float64
+}
+
+// recordCapturedPayment posts the ledger payment for a gateway payment exactly once and issues its receipt.
+// It returns false (and no error) when the payment id was already recorded by another path.
+func recordCapturedPayment(db *gorm.DB, p capturedPayment, source string) (bool, error) {
+	feeType, err := resolveFeeType(db, p.FeeHead)
+	if err != nil {
+		return false, err
+	}
+
+	recorded := false
+	err = db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		gp := models.GatewayPayment{
+			PaymentID:        p.PaymentID,
+			OrderID:          p.OrderID,
+			EnrollmentNumber: p.Enrollment,
+			FeeHead:          p.FeeHead,
+			FeeType:          p.FeeType,
+			Amount:           p.Amount,
+			Status:           "captured",
+			Source:           source,
+			CreatedAt:        now,
+			UpdatedAt:        now,
+		}
+		// The primary key on payment_id is what makes concurrent webhook + callback safe
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&gp)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			// Only a previously failed attempt may be upgraded to captured
+			upgrade := tx.Model(&models.GatewayPayment{}).
+				Where("payment_id = ? AND status = ?", p.PaymentID, "failed").
+				Updates(map[string]interface{}{
+					"order_id": p.OrderID, "enrollment_number": p.Enrollment, "fee_head": p.FeeHead,
+					"fee_type": p.FeeType, "amount": p.Amount, "status": "captured", "source": source,
+					"error_reason": nil, "updated_at": now,
+				})
+			if upgrade.Error != nil {
+				return upgrade.Error
+			}
+			if upgrade.RowsAffected == 0 {
+				return nil
+			}
+		}
+
+		entry := models.FeeLedgerEntry{
+			EnrollmentNumber:  p.Enrollment,
+			FeeTypeID:         feeType.FeeTypeID,
+			EntryType:         LedgerPayment,
+			Credit:            p.Amount,
+			Status:            PaymentStatusPaid,
+			Reference:         ptrString(p.PaymentID),
+			ParentEntryID:     p.ChargeID,
+			TransactionNumber: ptrString(p.PaymentID),
+			TransactionDate:   &now,
+			PaymentMethod:     ptrString("online"),
+			Description:       ptrString(p.FeeType),
+		}
+		if _, err := postLedgerEntry(tx, &entry); err != nil {
+			return err
+		}
+		if _, err := issueReceipt(tx, entry); err != nil {
+			return err
+		}
+		if err := tx.Model(&models.GatewayPayment{}).Where("payment_id = ?", p.PaymentID).
+			Update("fee_record_id", entry.EntryID).Error; err != nil {
+			return err
+		}
+		recorded = true
+		return nil
+	})
+	return recorded, err
+}
+
+// recordFailedPayment notes a failed attempt; it never overwrites a captured payment
+func recordFailedPayment(db *gorm.DB, p capturedPayment, reason string) error {
+	now := time.Now()
+	return db.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.GatewayPayment{
+		PaymentID:        p.PaymentID,
+		OrderID:          p.OrderID,
+		EnrollmentNumber: p.Enrollment,
+		FeeHead:          p.FeeHead,
+		FeeType:          p.FeeType,
+		Amount:           p.Amount,
+		Status:           "failed",
+		Source:           "webhook",
+		ErrorReason:      ptrString(truncate(reason, 255)),
+		CreatedAt:        now,
+		UpdatedAt:        now,
+	}).Error
+}
+
+// applyGatewayRefund posts a refund against a recorded payment, once per refund id.
+// Returns false when the payment is unknown.
+func applyGatewayRefund(db *gorm.DB, paymentID, refundID string, amount float64, reason string, createdBy *int64) (bool, error) {
+	found := false
+	var posted *models.FeeLedgerEntry
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var gp models.GatewayPayment
+		res := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("payment_id = ?", paymentID).Limit(1).Find(&gp)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 || gp.Status == "failed" {
+			return nil
+		}
+		found = true
+
+		refunded := gp.RefundedAmount + amount
+		if refunded > gp.Amount {
+			refunded = gp.Amount
+		}
+		delta := refunded - gp.RefundedAmount
+		if delta <= 0 {
+			return nil
+		}
+
+		var payment models.FeeLedgerEntry
+		if gp.FeeRecordID != nil {
+			tx.Where("entry_id = ?", *gp.FeeRecordID).Limit(1).Find(&payment)
+		} else {
+			tx.Where("entry_type = ? AND reference = ?", LedgerPayment, paymentID).Limit(1).Find(&payment)
+		}
+		if payment.EntryID == 0 {
+			return fmt.Errorf("no ledger payment for %s", paymentID)
+		}
+		entry, err := reversePayment(tx, payment.EntryID, paymentReversal{
+			EntryType:         LedgerRefund,
+			Amount:            delta,
+			Reference:         ptrString(refundID),
+			TransactionNumber: ptrString(refundID),
+			PaymentMethod:     ptrString("online"),
+			Reason:            reason,
+			CreatedBy:         createdBy,
+		})
+		if err == errNothingToReverse {
+			return nil
+		}
+		if err != nil || entry == nil {
+			return err
+		}
+		posted = entry
+
+		status := "partially_refunded"
+		if refunded >= gp.Amount {
+			status = "refunded"
+		}
+		return tx.Model(&gp).Updates(map[string]interface{}{
+			"refunded_amount": refunded, "status": status, "updated_at": time.Now(),
+		}).Error
+	})
+	if err == nil && posted != nil {
+		notifyPaymentReversal(posted)
+	}
+	return found, err
+}
@@ -0,0 +1,210 @@
+package controllers
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm/clause"
+
+	"github.com/kiranraoboinapally/student/backend/internal/config"
+	"github.com/kiranraoboinapally/student/backend/internal/middleware"
+	"github.com/kiranraoboinapally/student/backend/internal/models"
+	"github.com/kiranraoboinapally/student/backend/internal/payments"
+)
+
+// ======================== RAZORPAY WEBHOOK ========================
+
+// maxWebhookBody guards the unauthenticated endpoint against oversized payloads
+const maxWebhookBody = 1 << 20
+
+type razorpayPaymentEntity struct {
+	ID               string          `json:"id"`
+	OrderID          string          `json:"order_id"`
+	Amount           int64           `json:"amount"` // paise
+	Status           string          `json:"status"`
+	Notes            json.RawMessage `json:"notes"` // object, or [] when empty
+	ErrorDescription string          `json:"error_description"`
+}
+
+type razorpayRefundEntity struct {
+	ID        string `json:"id"`
+	PaymentID string `json:"payment_id"`
+	Amount    int64  `json:"amount"` // paise
+}
+
+type razorpayWebhookEvent struct {
+	Event     string `json:"event"`
+	CreatedAt int64  `json:"created_at"`
+	Payload   struct {
+		Payment struct {
+			Entity razorpayPaymentEntity `json:"entity"`
+		} `json:"payment"`
+		Refund struct {
+			Entity razorpayRefundEntity `json:"entity"`
+		} `json:"refund"`
+	} `json:"payload"`
+}
+
+// RazorpayWebhook verifies X-Razorpay-Signature and records captured, failed and refunded payments.
+// Deliveries are de-duplicated by event id and payments by payment id, so retries are harmless.
+func RazorpayWebhook(c *gin.Context) {
+	if paymentGateway == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "webhook not configured"})
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxWebhookBody))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "could not read body"})
+		return
+	}
+	signature := c.GetHeader("X-Razorpay-Signature")
+	if signature == "" || !paymentGateway.VerifyWebhookSignature(body, signature) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid signature"})
+		return
+	}
+
+	var evt razorpayWebhookEvent
+	if err := json.Unmarshal(body, &evt); err != nil || evt.Event == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload"})
+		return
+	}
+
+	entityID := evt.Payload.Payment.Entity.ID
+	if evt.Event == "refund.processed" {
+		entityID = evt.Payload.Refund.Entity.ID
+	}
+	eventID := c.GetHeader("X-Razorpay-Event-Id")
+	if eventID == "" {
+		eventID = evt.Event + ":" + entityID
+	}
+
+	db := config.DB
+	record := models.WebhookEvent{
+		Provider:   "razorpay",
+		EventID:    eventID,
+		Event:      evt.Event,
+		EntityID:   entityID,
+		Status:     "received",
+		Payload:    string(body),
+		ReceivedAt: time.Now(),
+	}
+	inserted := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&record)
+	if inserted.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to store event"})
+		return
+	}
+	if inserted.RowsAffected == 0 {
+		// Seen before: only a delivery that failed last time is processed again
+		db.Where("event_id = ?", eventID).First(&record)
+		if record.Status == "processed" || record.Status == "ignored" {
+			c.JSON(http.StatusOK, gin.H{"status": "duplicate"})
+			return
+		}
+	}
+
+	status, procErr := processRazorpayEvent(evt)
+	updates := map[string]interface{}{"status": status, "error": nil}
+	if procErr != nil {
+		msg := procErr.Error()
+		updates["error"] = msg
+		log.Printf("razorpay webhook %s (%s) failed: %v", evt.Event, eventID, procErr)
+	}
+	db.Model(&models.WebhookEvent{}).Where("id = ?", record.ID).Updates(updates)
+
+	middleware.Audit(c, "payments.webhook."+evt.Event, "gateway_payment", entityID, nil, gin.H{"status": status})
+
+	if procErr != nil {
+		// Non-2xx makes Razorpay retry the delivery
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "processing failed"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": status})
+}
+
+// processRazorpayEvent applies one verified event and returns processed or ignored
+func processRazorpayEvent(evt razorpayWebhookEvent) (string, error) {
+	db := config.DB
+	switch evt.Event {
+	case "payment.captured":
+		entity := evt.Payload.Payment.Entity
+		order, err := findPaymentOrder(db, entity.OrderID)
+		if err != nil {
+			return "failed", err
+		}
+		if order != nil {
+			payment := payments.Payment{ID: entity.ID, OrderID: entity.OrderID, Status: entity.Status, Amount: entity.Amount}
+			if _, err := settlePaymentOrder(db, order, payment, "webhook"); err != nil {
+				if err == errPaymentMismatch {
+					// Flagged on the order for review; retrying would not change the outcome
+					log.Printf("razorpay webhook: payment %s does not match order %s", entity.ID, entity.OrderID)
+					return "ignored", nil
+				}
+				return "failed", err
+			}
+			return "processed", nil
+		}
+
+		// Orders created before payment_orders existed are attributed from their notes
+		p, ok := paymentFromEntity(entity)
+		if !ok {
+			return "ignored", nil
+		}
+		if _, err := recordCapturedPayment(db, p, "webhook"); err != nil {
+			if err == errUnknownFeeHead {
+				return "ignored", nil
+			}
+			return "failed", err
+		}
+		return "processed", nil
+
+	case "payment.failed":
+		entity := evt.Payload.Payment.Entity
+		p, _ := paymentFromEntity(entity)
+		p.PaymentID = entity.ID
+		if err := recordFailedPayment(db, p, entity.ErrorDescription); err != nil {
+			return "failed", err
+		}
+		return "processed", nil
+
+	case "refund.processed":
+		refund := evt.Payload.Refund.Entity
+		found, err := applyGatewayRefund(db, refund.PaymentID, refund.ID, float64(refund.Amount)/100, "Refund processed by Razorpay", nil)
+		if err != nil {
+			return "failed", err
+		}
+		if !found {
+			return "ignored", nil
+		}
+		return "processed", nil
+	}
+	return "ignored", nil
+}
+
+// paymentFromEntity reads the enrollment and fee head that RequestPayment stored in the order notes
+func paymentFromEntity(e razorpayPaymentEntity) (capturedPayment, bool) {
+	notes := map[string]string{}
+	json.Unmarshal(e.Notes, &notes)
+
+	p := capturedPayment{
+		PaymentID: e.ID,
+		OrderID:   e.OrderID,
+		FeeHead:   notes["fee_head"],
+		FeeType:   notes["fee_type"],
+		Amount:    float64(e.Amount) / 100,
+	}
+	enrollment, err := strconv.ParseInt(notes["enrollment"], 10, 64)
+	if err != nil || e.ID == "" {
+		return p, false
+	}
+	p.Enrollment = enrollment
+	if chargeID, err := strconv.ParseInt(notes["charge_id"], 10, 64); err == nil {
+		p.ChargeID = &chargeID
+	}
+	return p, true
+}
@@ -0,0 +1,318 @@
+package controllers
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/kiranraoboinapally/student/backend/internal/config"
+	"github.com/kiranraoboinapally/student/backend/internal/models"
+	"github.com/kiranraoboinapally/student/backend/internal/payments"
+)
+
+// The recorded deliveries in testdata/razorpay are replayed through the handler, signed with
+// testWebhookSecret the way Razorpay signs them. Tests that settle payments need a scratch MySQL
+// database in TEST_DATABASE_DSN, e.g. user:pass@tcp(localhost:3306)/student_test?parseTime=true,
+// and are skipped without one.
+
+const testWebhookSecret = "whsec_test"
+
+// Ids used in the fixtures; each test swaps in fresh ones so runs never collide in the database
+const (
+	fixturePaymentID  = "pay_TestCaptured0001"
+	fixtureOrderID    = "order_TestOrder00001"
+	fixtureEnrollment = "2023000001"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// useMockGateway installs a mock gateway for the duration of the test
+func useMockGateway(t *testing.T) *payments.MockGateway {
+	t.Helper()
+	mock := payments.NewMockGateway("", testWebhookSecret)
+	prev := paymentGateway
+	paymentGateway = mock
+	t.Cleanup(func() { paymentGateway = prev })
+	return mock
+}
+
+// useTestDB points config.DB at TEST_DATABASE_DSN and migrates the tables the payment path writes
+func useTestDB(t *testing.T) {
+	t.Helper()
+	dsn := os.Getenv("TEST_DATABASE_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_DSN not set")
+	}
+	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	if err := db.AutoMigrate(&models.Role{}, &models.User{}, &models.Institute{}, &models.MasterStudent{},
+		&models.MasterFeeType{}, &models.FeeLedgerEntry{}, &models.FeeReceipt{}, &models.ReceiptSequence{},
+		&models.GatewayPayment{}, &models.PaymentOrder{}, &models.WebhookEvent{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	prev := config.DB
+	config.DB = db
+	t.Cleanup(func() { config.DB = prev })
+}
+
+// fixture reads a recorded delivery, replacing the fixture ids with the given ones
+func fixture(t *testing.T, name string, replace map[string]string) []byte {
+	t.Helper()
+	body, err := os.ReadFile(filepath.Join("..", "..", "testdata", "razorpay", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for from, to := range replace {
+		body = bytes.ReplaceAll(body, []byte(from), []byte(to))
+	}
+	return body
+}
+
+func signWebhook(body []byte) string {
+	mac := hmac.New(sha256.New, []byte(testWebhookSecret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// deliverWebhook posts one delivery to RazorpayWebhook and returns the response
+func deliverWebhook(t *testing.T, body []byte, signature, eventID string) (int, map[string]interface{}) {
+	t.Helper()
+	r := gin.New()
+	r.POST("/webhooks/razorpay", RazorpayWebhook)
+
+	req := httptest.NewRequest(http.MethodPost, "/webhooks/razorpay", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if signature != "" {
+		req.Header.Set("X-Razorpay-Signature", signature)
+	}
+	if eventID != "" {
+		req.Header.Set("X-Razorpay-Event-Id", eventID)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w.Code, decodeBody(t, w)
+}
+
+// verifyCallback posts the checkout result to VerifyPaymentAndRecord as the given student
+func verifyCallback(t *testing.T, userID int64, orderID, paymentID, signature string) (int, map[string]interface{}) {
+	t.Helper()
+	r := gin.New()
+	r.POST("/fees/verify-payment", func(c *gin.Context) { c.Set("user_id", userID) }, VerifyPaymentAndRecord)
+
+	body, _ := json.Marshal(gin.H{"razorpay_order_id": orderID, "razorpay_payment_id": paymentID, "razorpay_signature": signature})
+	req := httptest.NewRequest(http.MethodPost, "/fees/verify-payment", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w.Code, decodeBody(t, w)
+}
+
+func decodeBody(t *testing.T, w *httptest.ResponseRecorder) map[string]interface{} {
+	t.Helper()
+	out := map[string]interface{}{}
+	if err := json.Unmarshal(w.Body.Bytes(), &out); err != nil {
+		t.Fatalf("response %q: %v", w.Body.String(), err)
+	}
+	return out
+}
+
+// testStudent creates a student login whose username is a fresh enrollment number
+func testStudent(t *testing.T) (int64, int64) {
+	t.Helper()
+	var role models.Role
+	if err := config.DB.Where(models.Role{RoleName: "student"}).FirstOrCreate(&role).Error; err != nil {
+		t.Fatal(err)
+	}
+	enrollment := 9000000000 + time.Now().UnixNano()%1000000000
+	user := models.User{Username: strconv.FormatInt(enrollment, 10), Email: fmt.Sprintf("%d@test.local", enrollment),
+		RoleID: role.RoleID, Status: "active"}
+	if err := config.DB.Create(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+	return user.UserID, enrollment
+}
+
+// paidOrder creates a stored order for the student and pays it at the mock gateway, returning the
+// order, the payment and the checkout signature the browser would post back
+func paidOrder(t *testing.T, mock *payments.MockGateway, enrollment int64) (payments.Order, payments.Payment, string) {
+	t.Helper()
+	order, err := mock.CreateOrder(150000, "INR", "test", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := createPaymentOrder(config.DB, order.ID, enrollment, FeeHeadExamination, "Semester Exam", nil, 1500); err != nil {
+		t.Fatal(err)
+	}
+	payment, signature, err := mock.Pay(order.ID, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return order, payment, signature
+}
+
+// ledgerPayments counts the ledger payments posted for a gateway payment id
+func ledgerPayments(t *testing.T, paymentID string) int64 {
+	t.Helper()
+	var n int64
+	config.DB.Model(&models.FeeLedgerEntry{}).Where("entry_type = ? AND reference = ?", LedgerPayment, paymentID).Count(&n)
+	return n
+}
+
+func TestRazorpayWebhookRejectsBadSignature(t *testing.T) {
+	useMockGateway(t)
+	body := fixture(t, "payment_captured.json", nil)
+
+	tests := []struct {
+		name      string
+		body      []byte
+		signature string
+	}{
+		{"missing signature", body, ""},
+		{"wrong secret", body, hex.EncodeToString(hmac.New(sha256.New, []byte("other")).Sum(nil))},
+		{"tampered body", bytes.Replace(body, []byte("150000"), []byte("1"), 1), signWebhook(body)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, resp := deliverWebhook(t, tt.body, tt.signature, "evt_bad_signature")
+			if code != http.StatusBadRequest || resp["error"] != "invalid signature" {
+				t.Fatalf("got %d %v, want 400 invalid signature", code, resp)
+			}
+		})
+	}
+}
+
+func TestRazorpayWebhookNotConfigured(t *testing.T) {
+	prev := paymentGateway
+	paymentGateway = nil
+	defer func() { paymentGateway = prev }()
+
+	body := fixture(t, "payment_captured.json", nil)
+	if code, _ := deliverWebhook(t, body, signWebhook(body), ""); code != http.StatusServiceUnavailable {
+		t.Fatalf("got %d, want 503", code)
+	}
+}
+
+func TestRazorpayWebhookReplayFixtures(t *testing.T) {
+	useMockGateway(t)
+	useTestDB(t)
+	_, enrollment := testStudent(t)
+	suffix := strconv.FormatInt(time.Now().UnixNano(), 36)
+	replace := map[string]string{
+		fixturePaymentID:       "pay_replay_" + suffix,
+		fixtureOrderID:         "order_replay_" + suffix,
+		fixtureEnrollment:      strconv.FormatInt(enrollment, 10),
+		"pay_TestFailed00001":  "pay_replay_failed_" + suffix,
+		"rfnd_TestRefund00001": "rfnd_replay_" + suffix,
+	}
+
+	// Orders without a stored payment_order are attributed from their notes
+	for _, name := range []string{"payment_captured.json", "payment_failed.json", "refund_processed.json"} {
+		body := fixture(t, name, replace)
+		code, resp := deliverWebhook(t, body, signWebhook(body), "evt_"+suffix+"_"+name)
+		if code != http.StatusOK || resp["status"] != "processed" {
+			t.Fatalf("%s: got %d %v, want 200 processed", name, code, resp)
+		}
+	}
+
+	var gp models.GatewayPayment
+	config.DB.Where("payment_id = ?", "pay_replay_"+suffix).First(&gp)
+	if gp.Status != "partially_refunded" || gp.RefundedAmount != 500 {
+		t.Fatalf("captured payment is %s with %.2f refunded, want partially_refunded with 500", gp.Status, gp.RefundedAmount)
+	}
+	var failed models.GatewayPayment
+	config.DB.Where("payment_id = ?", "pay_replay_failed_"+suffix).First(&failed)
+	if failed.Status != "failed" {
+		t.Fatalf("failed payment is %q, want failed", failed.Status)
+	}
+}
+
+func TestRazorpayWebhookDuplicateDelivery(t *testing.T) {
+	mock := useMockGateway(t)
+	useTestDB(t)
+	_, enrollment := testStudent(t)
+	order, payment, _ := paidOrder(t, mock, enrollment)
+
+	body := fixture(t, "payment_captured.json", map[string]string{fixturePaymentID: payment.ID, fixtureOrderID: order.ID})
+	eventID := "evt_dup_" + payment.ID
+
+	if code, resp := deliverWebhook(t, body, signWebhook(body), eventID); code != http.StatusOK || resp["status"] != "processed" {
+		t.Fatalf("first delivery: got %d %v, want 200 processed", code, resp)
+	}
+	if code, resp := deliverWebhook(t, body, signWebhook(body), eventID); code != http.StatusOK || resp["status"] != "duplicate" {
+		t.Fatalf("retry: got %d %v, want 200 duplicate", code, resp)
+	}
+	// A second event id for the same payment is processed but must not post the payment again
+	if code, resp := deliverWebhook(t, body, signWebhook(body), eventID+"_b"); code != http.StatusOK {
+		t.Fatalf("new event id: got %d %v, want 200", code, resp)
+	}
+	if n := ledgerPayments(t, payment.ID); n != 1 {
+		t.Fatalf("%d ledger payments, want 1", n)
+	}
+}
+
+func TestRazorpayWebhookAndCallbackOrdering(t *testing.T) {
+	tests := []struct {
+		name         string
+		webhookFirst bool
+	}{
+		{"webhook before callback", true},
+		{"callback before webhook", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := useMockGateway(t)
+			useTestDB(t)
+			userID, enrollment := testStudent(t)
+			order, payment, signature := paidOrder(t, mock, enrollment)
+			body := fixture(t, "payment_captured.json", map[string]string{fixturePaymentID: payment.ID, fixtureOrderID: order.ID})
+
+			webhook := func() {
+				code, resp := deliverWebhook(t, body, signWebhook(body), "evt_order_"+payment.ID)
+				if code != http.StatusOK || resp["status"] != "processed" {
+					t.Fatalf("webhook: got %d %v, want 200 processed", code, resp)
+				}
+			}
+			callback := func(alreadyRecorded bool) {
+				code, resp := verifyCallback(t, userID, order.ID, payment.ID, signature)
+				if code != http.StatusOK || resp["already_recorded"] != alreadyRecorded {
+					t.Fatalf("callback: got %d %v, want 200 already_recorded=%v", code, resp, alreadyRecorded)
+				}
+			}
+			if tt.webhookFirst {
+				webhook()
+				callback(true)
+			} else {
+				callback(false)
+				webhook()
+			}
+
+			if n := ledgerPayments(t, payment.ID); n != 1 {
+				t.Fatalf("%d ledger payments, want 1", n)
+			}
+			var stored models.PaymentOrder
+			config.DB.Where("order_id = ?", order.ID).First(&stored)
+			if stored.Status != OrderStatusPaid {
+				t.Fatalf("order is %q, want paid", stored.Status)
+			}
+		})
+	}
+}
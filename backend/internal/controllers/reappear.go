@@ -0,0 +1,560 @@
+package controllers
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/kiranraoboinapally/student/backend/internal/config"
+	"github.com/kiranraoboinapally/student/backend/internal/grading"
+	"github.com/kiranraoboinapally/student/backend/internal/middleware"
+	"github.com/kiranraoboinapally/student/backend/internal/models"
+)
+
+// ======================== REAPPEAR (BACKLOG) EXAMS ========================
+
+// Reappear registration statuses
+const (
+	ReappearRegistered = "registered" // waiting for the fee and the lock
+	ReappearLocked     = "locked"     // paid and frozen for exam scheduling
+)
+
+// Reappear result policies (config.ReappearResultPolicy)
+const (
+	ReappearPolicyBest   = "best"   // the highest of the original mark and every attempt
+	ReappearPolicyLatest = "latest" // the most recent attempt, even if lower
+)
+
+type reappearKey struct {
+	enrollment int64
+	semester   int
+	subject    string
+}
+
+func attemptKey(r models.ReappearStudent) reappearKey {
+	return reappearKey{r.EnrollmentNo, intValue(r.Semester), safeString(r.SubjectCode)}
+}
+
+func intValue(p *int) int {
+	if p == nil {
+		return 0
+	}
+	return *p
+}
+
+// attemptPercent is the mark a graded reappear attempt scored out of 100
+func attemptPercent(r models.ReappearStudent) float64 {
+	if r.Absent || r.MarksObtained == nil {
+		return 0
+	}
+	return math.Min(*r.MarksObtained, 100)
+}
+
+// applyReappearResults replaces each re-sat mark with the attempt that counts under
+// config.ReappearResultPolicy. Substituted marks get MarkID 0, so saveSemesterResult leaves the
+// original student_marks row (the first attempt) as it was.
+func applyReappearResults(db *gorm.DB, byStudent map[int64][]models.StudentMark) error {
+	if len(byStudent) == 0 {
+		return nil
+	}
+	enrollments := make([]int64, 0, len(byStudent))
+	for e := range byStudent {
+		enrollments = append(enrollments, e)
+	}
+	var attempts []models.ReappearStudent
+	if err := db.Where("enrollment_no IN ? AND result_at IS NOT NULL", enrollments).
+		Order("result_at, reappear_id").Find(&attempts).Error; err != nil {
+		return err
+	}
+	if len(attempts) == 0 {
+		return nil
+	}
+	tries := map[reappearKey][]models.ReappearStudent{}
+	for _, a := range attempts {
+		tries[attemptKey(a)] = append(tries[attemptKey(a)], a)
+	}
+
+	latest := config.ReappearResultPolicy == ReappearPolicyLatest
+	for enrollment, marks := range byStudent {
+		for i, m := range marks {
+			list := tries[reappearKey{enrollment, m.Semester, m.SubjectCode}]
+			if len(list) == 0 {
+				continue
+			}
+			score, replaced := markPercent(m), false
+			for _, a := range list {
+				if p := attemptPercent(a); latest || p > score {
+					score, replaced = p, true
+				}
+			}
+			if replaced {
+				m.MarkID = 0
+				m.MarksObtained, m.TotalMarks, m.Percentage = score, 0, 0
+				m.Status = "reappear"
+				marks[i] = m
+			}
+		}
+	}
+	return nil
+}
+
+// backlogSubject is a subject a student has not cleared after any reappear attempts
+type backlogSubject struct {
+	Semester    int     `json:"semester"`
+	SubjectCode string  `json:"subject_code"`
+	SubjectName string  `json:"subject_name"`
+	Percent     float64 `json:"percent"`
+	Grade       string  `json:"grade"`
+	Attempts    int     `json:"attempts"` // graded reappear attempts so far
+}
+
+// studentBacklogs lists the failed subjects in a student's marks, with reappear results applied
+func studentBacklogs(db *gorm.DB, scale grading.Scale, enrollment int64) ([]backlogSubject, error) {
+	var marks []models.StudentMark
+	if err := db.Where("enrollment_number = ?", enrollment).Order("semester, subject_code").Find(&marks).Error; err != nil {
+		return nil, err
+	}
+	byStudent := map[int64][]models.StudentMark{enrollment: marks}
+	if err := applyReappearResults(db, byStudent); err != nil {
+		return nil, err
+	}
+	var attempts []models.ReappearStudent
+	db.Where("enrollment_no = ? AND result_at IS NOT NULL", enrollment).Find(&attempts)
+	counts := map[reappearKey]int{}
+	for _, a := range attempts {
+		counts[attemptKey(a)]++
+	}
+	backlogs := []backlogSubject{}
+	for _, m := range byStudent[enrollment] {
+		percent := markPercent(m)
+		band := scale.Lookup(percent)
+		if band.Passed() {
+			continue
+		}
+		backlogs = append(backlogs, backlogSubject{
+			Semester: m.Semester, SubjectCode: m.SubjectCode, SubjectName: m.SubjectName,
+			Percent: roundMoney(percent), Grade: band.Grade, Attempts: counts[reappearKey{enrollment, m.Semester, m.SubjectCode}],
+		})
+	}
+	return backlogs, nil
+}
+
+// reappearView is a registration with its session, subject name and fee status
+type reappearView struct {
+	models.ReappearStudent
+	StudentName string `json:"student_name,omitempty"`
+	SubjectName string `json:"subject_name"`
+	SessionName string `json:"session_name"`
+	FeePaid     bool   `json:"fee_paid"`
+}
+
+func reappearViews(db *gorm.DB, rows []models.ReappearStudent) []reappearView {
+	var codes []string
+	var sessionIDs []int
+	var enrollments []int64
+	for _, r := range rows {
+		codes = append(codes, safeString(r.SubjectCode))
+		sessionIDs = append(sessionIDs, intValue(r.ExamSessionID))
+		enrollments = append(enrollments, r.EnrollmentNo)
+	}
+	subjects, sessions, students := map[string]string{}, map[int]string{}, map[int64]string{}
+	if len(rows) > 0 {
+		var sm []models.SubjectMaster
+		db.Where("subject_code IN ?", codes).Find(&sm)
+		for _, s := range sm {
+			subjects[s.SubjectCode] = s.SubjectName
+		}
+		var es []models.ExamSession
+		db.Where("exam_session_id IN ?", sessionIDs).Find(&es)
+		for _, s := range es {
+			sessions[s.ExamSessionID] = s.Name
+		}
+		var ms []models.MasterStudent
+		db.Select("enrollment_number, student_name").Where("enrollment_number IN ?", enrollments).Find(&ms)
+		for _, s := range ms {
+			students[s.EnrollmentNumber] = s.StudentName
+		}
+	}
+	views := make([]reappearView, 0, len(rows))
+	for _, r := range rows {
+		paid, _ := chargeSettled(db, r.EnrollmentNo, r.FeeChargeID)
+		views = append(views, reappearView{
+			ReappearStudent: r, StudentName: students[r.EnrollmentNo], SubjectName: subjects[safeString(r.SubjectCode)],
+			SessionName: sessions[intValue(r.ExamSessionID)], FeePaid: paid,
+		})
+	}
+	return views
+}
+
+// reappearSubject counts a session's registrations for one subject
+type reappearSubject struct {
+	SubjectCode string `json:"subject_code"`
+	Semester    int    `json:"semester"`
+	Registered  int64  `json:"registered"`
+	Locked      int64  `json:"locked"`
+}
+
+// reappearSubjects summarises a session's registrations per subject; the locked counts are what
+// the session's exams are scheduled and seated for
+func reappearSubjects(db *gorm.DB, sessionID int) ([]reappearSubject, error) {
+	var out []reappearSubject
+	err := db.Model(&models.ReappearStudent{}).
+		Select(`subject_code, semester, COUNT(*) AS registered,
+			SUM(CASE WHEN status = ? THEN 1 ELSE 0 END) AS locked`, ReappearLocked).
+		Where("exam_session_id = ?", sessionID).
+		Group("subject_code, semester").Order("semester, subject_code").Scan(&out).Error
+	return out, err
+}
+
+// GetMyBacklogs shows the caller's uncleared subjects, their reappear registrations and the exam
+// sessions currently open for registration
+func GetMyBacklogs(c *gin.Context) {
+	enrollment, err := getEnrollmentOrError(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	db := config.DB
+	scale, err := loadGradingScale(db)
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "grading scale is not configured"})
+		return
+	}
+	backlogs, err := studentBacklogs(db, scale, enrollment)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load marks"})
+		return
+	}
+	var registrations []models.ReappearStudent
+	db.Where("enrollment_no = ? AND exam_session_id IS NOT NULL", enrollment).Order("reappear_id DESC").Find(&registrations)
+
+	var sessions []models.ExamSession
+	db.Where("status = ? AND allow_reappear = ?", ExamSessionOpen, true).Order("exam_session_id").Find(&sessions)
+	open := []models.ExamSession{}
+	now := time.Now()
+	for _, s := range sessions {
+		if registrationOpen(s, now) {
+			open = append(open, s)
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"backlogs":      backlogs,
+		"registrations": reappearViews(db, registrations),
+		"sessions":      open,
+		"policy":        config.ReappearResultPolicy,
+		"max_subjects":  currentAcademicRules(db).MaxReappearSubjects, // per session; 0 for no limit
+	})
+}
+
+// RegisterReappear registers the caller for reappear exams in an open session, one row and one
+// fee charge per subject. Fees are paid through the usual payment flow before the office locks
+// the registrations.
+func RegisterReappear(c *gin.Context) {
+	enrollment, err := getEnrollmentOrError(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	var req struct {
+		ExamSessionID int      `json:"exam_session_id" binding:"required"`
+		SubjectCodes  []string `json:"subject_codes" binding:"required,min=1"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	db := config.DB
+	var session models.ExamSession
+	if err := db.Where("exam_session_id = ?", req.ExamSessionID).First(&session).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "exam session not found"})
+		return
+	}
+	if !session.AllowReappear || !registrationOpen(session, time.Now()) {
+		c.JSON(http.StatusConflict, gin.H{"error": "reappear registration is not open for this session"})
+		return
+	}
+	scale, err := loadGradingScale(db)
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "grading scale is not configured"})
+		return
+	}
+	backlogs, err := studentBacklogs(db, scale, enrollment)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load marks"})
+		return
+	}
+	backlogOf := map[string]backlogSubject{}
+	for _, b := range backlogs {
+		backlogOf[b.SubjectCode] = b
+	}
+	seen := map[string]bool{}
+	for _, code := range req.SubjectCodes {
+		if _, ok := backlogOf[code]; !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": code + " is not a backlog subject"})
+			return
+		}
+		if seen[code] {
+			c.JSON(http.StatusBadRequest, gin.H{"error": code + " is listed twice"})
+			return
+		}
+		seen[code] = true
+	}
+	var existing []string
+	db.Model(&models.ReappearStudent{}).
+		Where("enrollment_no = ? AND exam_session_id = ? AND subject_code IN ?", enrollment, session.ExamSessionID, req.SubjectCodes).
+		Pluck("subject_code", &existing)
+	if len(existing) > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "already registered for " + existing[0] + " in this session"})
+		return
+	}
+	if limit := currentAcademicRules(db).MaxReappearSubjects; limit > 0 {
+		var registered int64
+		db.Model(&models.ReappearStudent{}).Where("enrollment_no = ? AND exam_session_id = ?", enrollment, session.ExamSessionID).Count(&registered)
+		if int(registered)+len(req.SubjectCodes) > limit {
+			c.JSON(http.StatusConflict, gin.H{"error": "the academic rules allow at most " + strconv.Itoa(limit) + " reappear subjects per session"})
+			return
+		}
+	}
+
+	now := time.Now()
+	var uid *int64
+	if id := c.GetInt64("user_id"); id != 0 {
+		uid = &id
+	}
+	registrations := make([]models.ReappearStudent, 0, len(req.SubjectCodes))
+	var fees []gin.H
+	err = db.Transaction(func(tx *gorm.DB) error {
+		var feeType models.MasterFeeType
+		if session.ReappearFee > 0 {
+			ft, err := resolveFeeType(tx, "Reappear")
+			if err != nil {
+				return err
+			}
+			feeType = ft
+		}
+		for _, code := range req.SubjectCodes {
+			b := backlogOf[code]
+			sem, subject, sessionID := b.Semester, b.SubjectCode, session.ExamSessionID
+			reg := models.ReappearStudent{
+				EnrollmentNo:  enrollment,
+				YearSem:       ptrString(strconv.Itoa(sem)),
+				Amount:        session.ReappearFee,
+				ExamSessionID: &sessionID,
+				Semester:      &sem,
+				SubjectCode:   &subject,
+				Status:        ptrString(ReappearRegistered),
+				CreatedAt:     &now,
+			}
+			if err := tx.Create(&reg).Error; err != nil {
+				return err
+			}
+			if session.ReappearFee > 0 {
+				charge := models.FeeLedgerEntry{
+					EnrollmentNumber: enrollment,
+					FeeTypeID:        feeType.FeeTypeID,
+					EntryType:        LedgerCharge,
+					Debit:            session.ReappearFee,
+					Reference:        ptrString("reappear:" + strconv.FormatInt(reg.ReappearID, 10)),
+					DueDate:          session.RegistrationCloses,
+					Semester:         &sem,
+					Description:      ptrString(truncate("Reappear - "+subject+" ("+session.Name+")", 255)),
+					CreatedBy:        uid,
+				}
+				if _, err := postLedgerEntry(tx, &charge); err != nil {
+					return err
+				}
+				reg.FeeChargeID = &charge.EntryID
+				if err := tx.Model(&reg).Update("fee_charge_id", charge.EntryID).Error; err != nil {
+					return err
+				}
+				fees = append(fees, gin.H{"charge_id": charge.EntryID, "subject_code": subject, "amount": session.ReappearFee})
+			}
+			registrations = append(registrations, reg)
+		}
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to register"})
+		return
+	}
+
+	middleware.Audit(c, "reappear.register", "reappear_students", strconv.FormatInt(enrollment, 10), nil,
+		gin.H{"exam_session_id": session.ExamSessionID, "subject_codes": req.SubjectCodes})
+	c.JSON(http.StatusCreated, gin.H{
+		"message":       "registered for reappear exams",
+		"registrations": registrations,
+		"fees":          fees,
+		"fee_head":      "Reappear Fee",
+		"total":         roundMoney(session.ReappearFee * float64(len(registrations))),
+	})
+}
+
+// GetReappearRegistrations lists a session's reappear registrations with a per-subject summary,
+// filterable by subject and status
+func GetReappearRegistrations(c *gin.Context) {
+	sessionID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid session id"})
+		return
+	}
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 500 {
+		limit = 100
+	}
+	db := config.DB
+	subjects, err := reappearSubjects(db, sessionID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load registrations"})
+		return
+	}
+	query := db.Model(&models.ReappearStudent{}).Where("exam_session_id = ?", sessionID)
+	if v := c.Query("subject_code"); v != "" {
+		query = query.Where("subject_code = ?", v)
+	}
+	if v := c.Query("status"); v != "" {
+		query = query.Where("status = ?", v)
+	}
+	var total int64
+	query.Count(&total)
+	var rows []models.ReappearStudent
+	if err := query.Order("subject_code, enrollment_no").Limit(limit).Offset((page - 1) * limit).Find(&rows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load registrations"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"subjects":      subjects,
+		"registrations": reappearViews(db, rows),
+		"pagination": gin.H{
+			"page":        page,
+			"limit":       limit,
+			"total":       total,
+			"total_pages": (total + int64(limit) - 1) / int64(limit),
+		},
+	})
+}
+
+// LockReappearRegistrations freezes a session's paid registrations (optionally for one subject)
+// so they can be scheduled. Unpaid registrations stay open and are reported.
+func LockReappearRegistrations(c *gin.Context) {
+	var req struct {
+		SubjectCode string `json:"subject_code"`
+	}
+	_ = c.ShouldBindJSON(&req)
+	db := config.DB
+	var session models.ExamSession
+	if err := db.Where("exam_session_id = ?", c.Param("id")).First(&session).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "exam session not found"})
+		return
+	}
+	query := db.Where("exam_session_id = ? AND status = ?", session.ExamSessionID, ReappearRegistered)
+	if req.SubjectCode != "" {
+		query = query.Where("subject_code = ?", req.SubjectCode)
+	}
+	var pending []models.ReappearStudent
+	if err := query.Find(&pending).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load registrations"})
+		return
+	}
+	var paid []int64
+	for _, r := range pending {
+		if ok, err := chargeSettled(db, r.EnrollmentNo, r.FeeChargeID); err == nil && ok {
+			paid = append(paid, r.ReappearID)
+		}
+	}
+	if len(paid) > 0 {
+		updates := map[string]interface{}{"status": ReappearLocked, "locked_at": time.Now()}
+		if uid := c.GetInt64("user_id"); uid != 0 {
+			updates["locked_by"] = uid
+		}
+		if err := db.Model(&models.ReappearStudent{}).
+			Where("reappear_id IN ? AND status = ?", paid, ReappearRegistered).Updates(updates).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to lock registrations"})
+			return
+		}
+	}
+	summary := gin.H{"exam_session_id": session.ExamSessionID, "subject_code": req.SubjectCode, "locked": len(paid), "unpaid": len(pending) - len(paid)}
+	middleware.Audit(c, "reappear.lock", "exam_sessions", strconv.Itoa(session.ExamSessionID), nil, summary)
+	summary["message"] = "registrations locked"
+	c.JSON(http.StatusOK, summary)
+}
+
+// RecordReappearResults stores reappear exam marks (out of 100) on locked registrations and
+// recomputes the affected students' semester results under the reappear policy
+func RecordReappearResults(c *gin.Context) {
+	var req struct {
+		Results []struct {
+			EnrollmentNumber int64   `json:"enrollment_number" binding:"required"`
+			SubjectCode      string  `json:"subject_code" binding:"required"`
+			MarksObtained    float64 `json:"marks_obtained" binding:"gte=0,lte=100"`
+			Absent           bool    `json:"absent"`
+		} `json:"results" binding:"required,min=1,dive"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	sessionID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid session id"})
+		return
+	}
+
+	var badRow error
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		earliest := map[int64]int{}
+		for _, r := range req.Results {
+			var reg models.ReappearStudent
+			if tx.Where("exam_session_id = ? AND enrollment_no = ? AND subject_code = ? AND status = ?",
+				sessionID, r.EnrollmentNumber, r.SubjectCode, ReappearLocked).Limit(1).Find(&reg).RowsAffected == 0 {
+				badRow = errors.New("no locked registration for " + strconv.FormatInt(r.EnrollmentNumber, 10) + " in " + r.SubjectCode)
+				return badRow
+			}
+			marks := roundMoney(r.MarksObtained)
+			if err := tx.Model(&reg).Updates(map[string]interface{}{
+				"marks_obtained": marks, "absent": r.Absent, "result_at": now,
+			}).Error; err != nil {
+				return err
+			}
+			sem := intValue(reg.Semester)
+			if s, ok := earliest[r.EnrollmentNumber]; !ok || sem < s {
+				earliest[r.EnrollmentNumber] = sem
+			}
+		}
+		enrollments := make([]int64, 0, len(earliest))
+		for e := range earliest {
+			enrollments = append(enrollments, e)
+		}
+		sort.Slice(enrollments, func(i, j int) bool { return enrollments[i] < enrollments[j] })
+		for _, e := range enrollments {
+			if err := recomputeResultsFrom(tx, e, earliest[e]); err != nil {
+				badRow = errors.New(strconv.FormatInt(e, 10) + ": " + err.Error())
+				return badRow
+			}
+		}
+		return nil
+	})
+	switch {
+	case badRow != nil:
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": badRow.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save reappear results"})
+		return
+	}
+
+	middleware.Audit(c, "reappear.results", "exam_sessions", strconv.Itoa(sessionID), nil,
+		gin.H{"total_records": len(req.Results), "policy": config.ReappearResultPolicy})
+	c.JSON(http.StatusOK, gin.H{"message": "reappear results saved", "total_records": len(req.Results)})
+}
@@ -0,0 +1,648 @@
+package controllers
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/kiranraoboinapally/student/backend/internal/config"
+	"github.com/kiranraoboinapally/student/backend/internal/middleware"
+	"github.com/kiranraoboinapally/student/backend/internal/models"
+	"github.com/kiranraoboinapally/student/backend/internal/xlsx"
+)
+
+// ======================== PAYMENT RECONCILIATION ========================
+
+// Statement line statuses. matched, mismatch and manual lines have a payment_reconciliation row.
+const (
+	ReconMatched   = "matched"  // reference (or amount and date) and amount agree
+	ReconMismatch  = "mismatch" // reference matched but the amount differs
+	ReconManual    = "manual"   // matched by hand
+	ReconUnmatched = "unmatched"
+	ReconIgnored   = "ignored" // not a fee payment, e.g. interest or a transfer
+)
+
+const maxStatementBytes = 10 << 20
+
+// reconciledPaymentStatuses are payments that should show up on a statement
+var reconciledPaymentStatuses = []string{PaymentStatusPaid, PaymentStatusVerified, PaymentStatusRefunded, PaymentStatusPartiallyRefunded}
+
+// statementColumns lists the header names banks and gateways use for each field
+var statementColumns = map[string][]string{
+	"date":      {"date", "txn date", "transaction date", "value date", "posting date", "settled at", "settlement date", "created at"},
+	"reference": {"reference", "ref", "ref no", "reference no", "reference number", "utr", "utr no", "transaction id", "transaction number", "txn id", "payment id", "cheque no", "chq/ref no", "entity id"},
+	"amount":    {"amount", "credit", "credit amount", "deposit", "deposits", "settled amount", "amount (inr)", "cr"},
+	"narration": {"narration", "description", "particulars", "remarks", "details"},
+}
+
+type statementRow struct {
+	LineNo    int
+	Date      time.Time
+	Reference string
+	Amount    float64
+	Narration string
+}
+
+// readStatementFile returns the cells of a CSV or XLSX statement
+func readStatementFile(name string, data []byte) ([][]string, error) {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".xlsx":
+		return xlsx.Read(bytes.NewReader(data), int64(len(data)))
+	case ".csv", ".txt", "":
+		r := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))))
+		r.FieldsPerRecord = -1
+		r.LazyQuotes = true
+		return r.ReadAll()
+	}
+	return nil, errors.New("statement must be a .csv or .xlsx file")
+}
+
+// findStatementHeader locates the header row among the first rows (banks often put account details
+// above it) and maps each field to its column
+func findStatementHeader(rows [][]string) (int, map[string]int, error) {
+	for i := 0; i < len(rows) && i < 15; i++ {
+		cols := map[string]int{}
+		for j, cell := range rows[i] {
+			name := strings.ToLower(strings.TrimSpace(cell))
+			for field, aliases := range statementColumns {
+				if _, ok := cols[field]; ok {
+					continue
+				}
+				for _, alias := range aliases {
+					if name == alias {
+						cols[field] = j
+						break
+					}
+				}
+			}
+		}
+		if _, ok := cols["date"]; ok {
+			if _, ok := cols["amount"]; ok {
+				return i, cols, nil
+			}
+		}
+	}
+	return 0, nil, errors.New("no header row with date and amount columns found")
+}
+
+var statementDateLayouts = []string{
+	"2006-01-02", "2006-01-02 15:04:05", "2006-01-02T15:04:05Z07:00", "02/01/2006", "02/01/2006 15:04:05",
+	"02/01/2006 15:04", "02-01-2006", "02-01-2006 15:04:05", "02-Jan-2006", "02 Jan 2006", "02-Jan-06", "02/01/06",
+}
+
+func parseStatementDate(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	for _, layout := range statementDateLayouts {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	// XLSX dates arrive as serial numbers
+	if f, err := strconv.ParseFloat(s, 64); err == nil && f > 20000 && f < 80000 {
+		t := xlsx.SerialToTime(f)
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, time.Local), nil
+	}
+	return time.Time{}, fmt.Errorf("unrecognised date %q", s)
+}
+
+// parseStatementAmount reads amounts such as "1,250.00", "Rs. 500", "500.00 CR" or "(200.00)"
+func parseStatementAmount(s string) (float64, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	negative := false
+	if strings.HasPrefix(s, "(") && strings.HasSuffix(s, ")") {
+		negative = true
+		s = strings.Trim(s, "()")
+	}
+	if strings.HasSuffix(s, "DR") {
+		negative = true
+	}
+	s = strings.TrimSuffix(strings.TrimSuffix(s, "DR"), "CR")
+	s = strings.NewReplacer(",", "", "RS.", "", "RS", "", "INR", "", "₹", "", " ", "").Replace(s)
+	if s == "" || s == "-" {
+		return 0, nil
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, fmt.Errorf("unrecognised amount %q", s)
+	}
+	if negative {
+		v = -v
+	}
+	return roundMoney(v), nil
+}
+
+// parseStatement extracts the credit lines of a statement. Debits and blank amounts are skipped;
+// rows that cannot be read are reported by line number.
+func parseStatement(rows [][]string) ([]statementRow, int, []string, error) {
+	header, cols, err := findStatementHeader(rows)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	cell := func(row []string, field string) string {
+		j, ok := cols[field]
+		if !ok || j >= len(row) {
+			return ""
+		}
+		return strings.TrimSpace(row[j])
+	}
+
+	var lines []statementRow
+	var problems []string
+	skipped := 0
+	for i := header + 1; i < len(rows); i++ {
+		row := rows[i]
+		lineNo := i + 1
+		if strings.TrimSpace(strings.Join(row, "")) == "" {
+			continue
+		}
+		amount, err := parseStatementAmount(cell(row, "amount"))
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("line %d: %v", lineNo, err))
+			continue
+		}
+		if amount <= 0 {
+			skipped++
+			continue
+		}
+		date, err := parseStatementDate(cell(row, "date"))
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("line %d: %v", lineNo, err))
+			continue
+		}
+		lines = append(lines, statementRow{
+			LineNo:    lineNo,
+			Date:      date,
+			Reference: truncate(cell(row, "reference"), 100),
+			Amount:    amount,
+			Narration: truncate(cell(row, "narration"), 255),
+		})
+	}
+	return lines, skipped, problems, nil
+}
+
+// unreconciledPayments is the ledger payments no statement line has claimed yet
+func unreconciledPayments(db *gorm.DB) *gorm.DB {
+	return db.Model(&models.FeeLedgerEntry{}).
+		Where("fee_ledger.entry_type = ? AND fee_ledger.status IN ?", LedgerPayment, reconciledPaymentStatuses).
+		Where("NOT EXISTS (SELECT 1 FROM payment_reconciliation r WHERE r.payment_id = fee_ledger.entry_id)")
+}
+
+// reconcilePayment records that a statement line settles a payment. It returns false when another
+// line already claimed the payment.
+func reconcilePayment(tx *gorm.DB, line *models.BankStatementLine, payment models.FeeLedgerEntry, status, remarks string, by *int64) (bool, error) {
+	now := time.Now()
+	diff := roundMoney(line.Amount - payment.Credit)
+	amount := line.Amount
+	recon := models.PaymentReconciliation{
+		PaymentID:        payment.EntryID,
+		BankDate:         &line.TxnDate,
+		Reference:        line.Reference,
+		ReconciledAmount: &amount,
+		DifferenceAmount: &diff,
+		Status:           status,
+		ReconciledBy:     by,
+		ReconciledAt:     &now,
+		CreatedAt:        now,
+	}
+	if remarks != "" {
+		recon.Remarks = ptrString(truncate(remarks, 255))
+	}
+	res := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&recon)
+	if res.Error != nil || res.RowsAffected == 0 {
+		return false, res.Error
+	}
+	line.Status = status
+	line.PaymentEntryID = &payment.EntryID
+	line.ReconciliationID = &recon.ReconciliationID
+	line.Remarks = recon.Remarks
+	line.UpdatedAt = now
+	return true, tx.Save(line).Error
+}
+
+// matchStatementLine tries the line's reference first, then a unique payment of the same amount
+// within the date tolerance. Lines that match nothing, or more than one payment, stay unmatched.
+func matchStatementLine(tx *gorm.DB, line *models.BankStatementLine, by *int64) error {
+	if line.Reference != nil && *line.Reference != "" {
+		var payment models.FeeLedgerEntry
+		if unreconciledPayments(tx).
+			Where("fee_ledger.transaction_number = ? OR fee_ledger.reference = ?", *line.Reference, *line.Reference).
+			Order("fee_ledger.entry_id").Limit(1).Find(&payment).RowsAffected > 0 {
+			status, remarks := ReconMatched, ""
+			if math.Abs(line.Amount-payment.Credit) >= 0.01 {
+				status = ReconMismatch
+				remarks = fmt.Sprintf("statement shows %.2f, payment recorded %.2f", line.Amount, payment.Credit)
+			}
+			_, err := reconcilePayment(tx, line, payment, status, remarks, by)
+			return err
+		}
+	}
+
+	tolerance := time.Duration(config.ReconciliationDateToleranceDays) * 24 * time.Hour
+	day := time.Date(line.TxnDate.Year(), line.TxnDate.Month(), line.TxnDate.Day(), 0, 0, 0, 0, line.TxnDate.Location())
+	var candidates []models.FeeLedgerEntry
+	if err := unreconciledPayments(tx).
+		Where("fee_ledger.credit = ?", line.Amount).
+		Where("COALESCE(fee_ledger.transaction_date, fee_ledger.created_at) >= ? AND COALESCE(fee_ledger.transaction_date, fee_ledger.created_at) < ?",
+			day.Add(-tolerance), day.Add(tolerance+24*time.Hour)).
+		Limit(2).Find(&candidates).Error; err != nil {
+		return err
+	}
+	if len(candidates) == 1 {
+		_, err := reconcilePayment(tx, line, candidates[0], ReconMatched, "matched on amount and date", by)
+		return err
+	}
+	if len(candidates) > 1 {
+		line.Remarks = ptrString("several payments match this amount and date; match it by hand")
+		return tx.Save(line).Error
+	}
+	return nil
+}
+
+// ImportBankStatement uploads a bank or gateway settlement statement (CSV or XLSX), stores its
+// credit lines and matches them to payments. The same file cannot be imported twice.
+func ImportBankStatement(c *gin.Context) {
+	fh, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "file is required"})
+		return
+	}
+	if fh.Size > maxStatementBytes {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "statement is larger than 10 MB"})
+		return
+	}
+	source := c.DefaultPostForm("source", "bank")
+	if source != "bank" && source != "gateway" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "source must be 'bank' or 'gateway'"})
+		return
+	}
+	f, err := fh.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "could not read file"})
+		return
+	}
+	data, err := io.ReadAll(io.LimitReader(f, maxStatementBytes))
+	f.Close()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "could not read file"})
+		return
+	}
+
+	rows, err := readStatementFile(fh.Filename, data)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	parsed, skipped, problems, err := parseStatement(rows)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(parsed) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "no credit lines found in the statement", "problems": problems})
+		return
+	}
+
+	sum := sha256.Sum256(data)
+	db := config.DB
+	var uploadedBy *int64
+	if uid := c.GetInt64("user_id"); uid != 0 {
+		uploadedBy = &uid
+	}
+	imp := models.BankStatementImport{
+		FileName:   truncate(filepath.Base(fh.Filename), 255),
+		FileHash:   hex.EncodeToString(sum[:]),
+		Source:     source,
+		TotalLines: len(parsed),
+		UploadedBy: uploadedBy,
+		CreatedAt:  time.Now(),
+	}
+	if db.Where("file_hash = ?", imp.FileHash).Limit(1).Find(&models.BankStatementImport{}).RowsAffected > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "this statement has already been imported"})
+		return
+	}
+
+	counts := map[string]int{}
+	var total, matchedAmount float64
+	err = db.Transaction(func(tx *gorm.DB) error {
+		for _, p := range parsed {
+			if imp.StatementFrom == nil || p.Date.Before(*imp.StatementFrom) {
+				d := p.Date
+				imp.StatementFrom = &d
+			}
+			if imp.StatementTo == nil || p.Date.After(*imp.StatementTo) {
+				d := p.Date
+				imp.StatementTo = &d
+			}
+		}
+		if err := tx.Create(&imp).Error; err != nil {
+			return err
+		}
+		for _, p := range parsed {
+			now := time.Now()
+			line := models.BankStatementLine{
+				ImportID:  imp.ImportID,
+				LineNo:    p.LineNo,
+				TxnDate:   p.Date,
+				Amount:    p.Amount,
+				Status:    ReconUnmatched,
+				CreatedAt: now,
+				UpdatedAt: now,
+			}
+			if p.Reference != "" {
+				line.Reference = ptrString(p.Reference)
+			}
+			if p.Narration != "" {
+				line.Narration = ptrString(p.Narration)
+			}
+			if err := tx.Create(&line).Error; err != nil {
+				return err
+			}
+			if err := matchStatementLine(tx, &line, uploadedBy); err != nil {
+				return err
+			}
+			counts[line.Status]++
+			total += line.Amount
+			if line.Status != ReconUnmatched {
+				matchedAmount += line.Amount
+			}
+		}
+		imp.MatchedLines = counts[ReconMatched] + counts[ReconMismatch]
+		return tx.Model(&imp).Update("matched_lines", imp.MatchedLines).Error
+	})
+	if err != nil {
+		if strings.Contains(err.Error(), "Duplicate") {
+			c.JSON(http.StatusConflict, gin.H{"error": "this statement has already been imported"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to import statement"})
+		return
+	}
+
+	summary := gin.H{
+		"import_id":      imp.ImportID,
+		"lines":          len(parsed),
+		"matched":        counts[ReconMatched],
+		"mismatched":     counts[ReconMismatch],
+		"unmatched":      counts[ReconUnmatched],
+		"skipped":        skipped,
+		"total_amount":   roundMoney(total),
+		"matched_amount": roundMoney(matchedAmount),
+		"problems":       problems,
+	}
+	middleware.Audit(c, "fees.reconciliation.import", "bank_statement_import", strconv.FormatInt(imp.ImportID, 10), nil,
+		gin.H{"file_name": imp.FileName, "source": source, "summary": summary})
+	c.JSON(http.StatusCreated, summary)
+}
+
+// GetStatementImports lists imported statements, newest first
+func GetStatementImports(c *gin.Context) {
+	var imports []models.BankStatementImport
+	if err := config.DB.Order("import_id DESC").Limit(200).Find(&imports).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load imports"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"imports": imports})
+}
+
+// reconciliationRange reads from / to (YYYY-MM-DD, inclusive), defaulting to the last 30 days
+func reconciliationRange(c *gin.Context) (time.Time, time.Time, error) {
+	now := time.Now()
+	to := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	from := to.AddDate(0, 0, -30)
+	if s := c.Query("from"); s != "" {
+		t, err := time.ParseInLocation("2006-01-02", s, time.Local)
+		if err != nil {
+			return from, to, errors.New("from must be YYYY-MM-DD")
+		}
+		from = t
+	}
+	if s := c.Query("to"); s != "" {
+		t, err := time.ParseInLocation("2006-01-02", s, time.Local)
+		if err != nil {
+			return from, to, errors.New("to must be YYYY-MM-DD")
+		}
+		to = t
+	}
+	return from, to.AddDate(0, 0, 1), nil
+}
+
+// GetUnmatchedReconciliation lists what still needs a manual decision: statement lines that matched
+// no payment, and payments that no statement line has confirmed
+func GetUnmatchedReconciliation(c *gin.Context) {
+	from, to, err := reconciliationRange(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	db := config.DB
+
+	var lines []models.BankStatementLine
+	db.Where("status = ? AND txn_date >= ? AND txn_date < ?", ReconUnmatched, from, to).
+		Order("txn_date, line_id").Limit(1000).Find(&lines)
+
+	var payments []struct {
+		EntryID           int64      `json:"payment_id"`
+		EnrollmentNumber  int64      `json:"enrollment_number"`
+		StudentName       *string    `json:"student_name"`
+		FeeTypeName       string     `json:"fee_type"`
+		Credit            float64    `json:"amount"`
+		TransactionNumber *string    `json:"transaction_number"`
+		TransactionDate   *time.Time `json:"transaction_date"`
+		PaymentMethod     *string    `json:"payment_method"`
+		Status            string     `json:"status"`
+	}
+	unreconciledPayments(db).
+		Select(`fee_ledger.entry_id, fee_ledger.enrollment_number, master_students.student_name, master_fee_types.fee_type_name,
+			fee_ledger.credit, fee_ledger.transaction_number, fee_ledger.transaction_date, fee_ledger.payment_method, fee_ledger.status`).
+		Joins("JOIN master_fee_types ON master_fee_types.fee_type_id = fee_ledger.fee_type_id").
+		Joins("LEFT JOIN master_students ON master_students.enrollment_number = fee_ledger.enrollment_number").
+		Where("COALESCE(fee_ledger.transaction_date, fee_ledger.created_at) >= ? AND COALESCE(fee_ledger.transaction_date, fee_ledger.created_at) < ?", from, to).
+		Order("fee_ledger.transaction_date, fee_ledger.entry_id").Limit(1000).Scan(&payments)
+
+	c.JSON(http.StatusOK, gin.H{"statement_lines": lines, "payments": payments})
+}
+
+// ResolveStatementLine settles a line by hand: match it to a payment, ignore it (not a fee
+// payment), or unmatch a wrong automatic match so it can be matched again
+func ResolveStatementLine(c *gin.Context) {
+	var req struct {
+		Action    string `json:"action" binding:"required,oneof=match ignore unmatch"`
+		PaymentID int64  `json:"payment_id"` // fee_ledger payment entry, for match
+		Remarks   string `json:"remarks"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	var by *int64
+	if uid := c.GetInt64("user_id"); uid != 0 {
+		by = &uid
+	}
+
+	db := config.DB
+	var line models.BankStatementLine
+	var before string
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("line_id = ?", c.Param("id")).First(&line).Error; err != nil {
+			return err
+		}
+		before = line.Status
+		switch req.Action {
+		case "match":
+			if line.Status != ReconUnmatched {
+				return errReconConflict("line is already " + line.Status)
+			}
+			var payment models.FeeLedgerEntry
+			if unreconciledPayments(tx).Where("fee_ledger.entry_id = ?", req.PaymentID).Limit(1).Find(&payment).RowsAffected == 0 {
+				return errReconConflict("payment not found or already reconciled")
+			}
+			ok, err := reconcilePayment(tx, &line, payment, ReconManual, req.Remarks, by)
+			if err == nil && !ok {
+				return errReconConflict("payment is already reconciled")
+			}
+			return err
+		case "ignore":
+			if line.Status != ReconUnmatched {
+				return errReconConflict("line is already " + line.Status)
+			}
+			line.Status = ReconIgnored
+		case "unmatch":
+			if line.ReconciliationID == nil {
+				return errReconConflict("line is not matched")
+			}
+			if err := tx.Delete(&models.PaymentReconciliation{}, *line.ReconciliationID).Error; err != nil {
+				return err
+			}
+			line.Status = ReconUnmatched
+			line.PaymentEntryID = nil
+			line.ReconciliationID = nil
+		}
+		if req.Remarks != "" {
+			line.Remarks = ptrString(truncate(req.Remarks, 255))
+		}
+		line.UpdatedAt = time.Now()
+		return tx.Save(&line).Error
+	})
+	if err != nil {
+		var conflict errReconConflict
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "statement line not found"})
+		case errors.As(err, &conflict):
+			c.JSON(http.StatusConflict, gin.H{"error": string(conflict)})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update statement line"})
+		}
+		return
+	}
+
+	middleware.Audit(c, "fees.reconciliation."+req.Action, "bank_statement_line", strconv.FormatInt(line.LineID, 10),
+		gin.H{"status": before}, gin.H{"status": line.Status, "payment_id": line.PaymentEntryID, "remarks": req.Remarks})
+	c.JSON(http.StatusOK, gin.H{"message": "statement line updated", "line": line})
+}
+
+type errReconConflict string
+
+func (e errReconConflict) Error() string { return string(e) }
+
+type reconciliationDay struct {
+	Date              string  `json:"date"`
+	Payments          int64   `json:"payments"`
+	PaymentAmount     float64 `json:"payment_amount"`
+	ReconciledCount   int64   `json:"reconciled_payments"`
+	ReconciledAmount  float64 `json:"reconciled_amount"`
+	StatementLines    int64   `json:"statement_lines"`
+	StatementAmount   float64 `json:"statement_amount"`
+	UnmatchedLines    int64   `json:"unmatched_lines"`
+	UnmatchedAmount   float64 `json:"unmatched_amount"`
+	DifferenceAmount  float64 `json:"difference_amount"`
+	UnreconciledCount int64   `json:"unreconciled_payments"`
+}
+
+// GetReconciliationSummary totals each day's payments against the statement lines dated that day
+func GetReconciliationSummary(c *gin.Context) {
+	from, to, err := reconciliationRange(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	db := config.DB
+
+	var paymentDays []struct {
+		Day              string
+		Payments         int64
+		PaymentAmount    float64
+		ReconciledCount  int64
+		ReconciledAmount float64
+	}
+	db.Raw(`
+		SELECT DATE_FORMAT(COALESCE(l.transaction_date, l.created_at), '%Y-%m-%d') AS day,
+			COUNT(*) AS payments, COALESCE(SUM(l.credit),0) AS payment_amount,
+			COUNT(r.reconciliation_id) AS reconciled_count,
+			COALESCE(SUM(CASE WHEN r.reconciliation_id IS NOT NULL THEN l.credit END),0) AS reconciled_amount
+		FROM fee_ledger l
+		LEFT JOIN payment_reconciliation r ON r.payment_id = l.entry_id
+		WHERE l.entry_type = ? AND l.status IN ?
+			AND COALESCE(l.transaction_date, l.created_at) >= ? AND COALESCE(l.transaction_date, l.created_at) < ?
+		GROUP BY day`, LedgerPayment, reconciledPaymentStatuses, from, to).Scan(&paymentDays)
+
+	var lineDays []struct {
+		Day             string
+		StatementLines  int64
+		StatementAmount float64
+		UnmatchedLines  int64
+		UnmatchedAmount float64
+		Difference      float64
+	}
+	db.Raw(`
+		SELECT DATE_FORMAT(s.txn_date, '%Y-%m-%d') AS day,
+			COUNT(*) AS statement_lines, COALESCE(SUM(s.amount),0) AS statement_amount,
+			SUM(CASE WHEN s.status = ? THEN 1 ELSE 0 END) AS unmatched_lines,
+			COALESCE(SUM(CASE WHEN s.status = ? THEN s.amount END),0) AS unmatched_amount,
+			COALESCE(SUM(r.difference_amount),0) AS difference
+		FROM bank_statement_lines s
+		LEFT JOIN payment_reconciliation r ON r.reconciliation_id = s.reconciliation_id
+		WHERE s.txn_date >= ? AND s.txn_date < ?
+		GROUP BY day`, ReconUnmatched, ReconUnmatched, from, to).Scan(&lineDays)
+
+	days := map[string]*reconciliationDay{}
+	get := func(d string) *reconciliationDay {
+		if days[d] == nil {
+			days[d] = &reconciliationDay{Date: d}
+		}
+		return days[d]
+	}
+	for _, p := range paymentDays {
+		d := get(p.Day)
+		d.Payments, d.PaymentAmount = p.Payments, roundMoney(p.PaymentAmount)
+		d.ReconciledCount, d.ReconciledAmount = p.ReconciledCount, roundMoney(p.ReconciledAmount)
+		d.UnreconciledCount = p.Payments - p.ReconciledCount
+	}
+	for _, l := range lineDays {
+		d := get(l.Day)
+		d.StatementLines, d.StatementAmount = l.StatementLines, roundMoney(l.StatementAmount)
+		d.UnmatchedLines, d.UnmatchedAmount = l.UnmatchedLines, roundMoney(l.UnmatchedAmount)
+		d.DifferenceAmount = roundMoney(l.Difference)
+	}
+	summary := make([]reconciliationDay, 0, len(days))
+	for _, d := range days {
+		summary = append(summary, *d)
+	}
+	sort.Slice(summary, func(i, j int) bool { return summary[i].Date > summary[j].Date })
+	c.JSON(http.StatusOK, gin.H{"days": summary})
+}
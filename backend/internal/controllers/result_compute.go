@@ -0,0 +1,309 @@
+package controllers
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/kiranraoboinapally/student/backend/internal/config"
+	"github.com/kiranraoboinapally/student/backend/internal/grading"
+	"github.com/kiranraoboinapally/student/backend/internal/middleware"
+	"github.com/kiranraoboinapally/student/backend/internal/models"
+)
+
+// ======================== RESULT COMPUTATION ========================
+
+// Semester result statuses
+const (
+	ResultPass = "Pass"
+	ResultATKT = "ATKT" // failed subjects within the allowed limit; promoted with backlogs
+	ResultFail = "Fail"
+)
+
+// absentMarkStatuses are student_marks statuses that score zero whatever marks were entered
+var absentMarkStatuses = map[string]bool{"absent": true, "ab": true, "detained": true}
+
+// loadGradingScale reads grade_mapping as a validated scale
+func loadGradingScale(db *gorm.DB) (grading.Scale, error) {
+	var rows []models.GradeMapping
+	if err := db.Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	return grading.NewScale(rows)
+}
+
+// markPercent is the subject percentage a mark is graded on: the stored percentage, else the total
+// (internal + external) or the marks obtained, which are out of 100. Rewriting marks_obtained clears
+// the stored figures (see clearedMarkTotals).
+func markPercent(m models.StudentMark) float64 {
+	if absentMarkStatuses[strings.ToLower(strings.TrimSpace(m.Status))] {
+		return 0
+	}
+	switch {
+	case m.Percentage > 0:
+		return m.Percentage
+	case m.TotalMarks > 0:
+		return math.Min(m.TotalMarks, 100)
+	}
+	return math.Min(m.MarksObtained, 100)
+}
+
+// clearedMarkTotals nulls a student_marks row's stored total and percentage. Anything that rewrites
+// marks_obtained clears them too, or markPercent would keep grading the figures of the old mark.
+var clearedMarkTotals = map[string]interface{}{"total_marks_obtained": nil, "percentage": nil}
+
+// upsertStudentMarks writes marks by student, semester and subject, overwriting the mark, grade and
+// status of rows already there
+func upsertStudentMarks(db *gorm.DB, records []models.StudentMark) error {
+	return db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "enrollment_number"}, {Name: "semester"}, {Name: "subject_code"}},
+		DoUpdates: append(clause.AssignmentColumns([]string{"marks_obtained", "grade", "status"}), clause.Assignments(clearedMarkTotals)...),
+	}).CreateInBatches(&records, 500).Error
+}
+
+// reviseStudentMark changes the mark, grade and status of one published subject mark. It goes through
+// the table rather than the model, which would leave out the read-only total and percentage.
+func reviseStudentMark(db *gorm.DB, enrollment int64, semester int, subjectCode string, marks float64, grade *string, status string) error {
+	updates := map[string]interface{}{"marks_obtained": marks, "grade": grade, "status": status}
+	for k, v := range clearedMarkTotals {
+		updates[k] = v
+	}
+	return db.Table("student_marks").
+		Where("enrollment_number = ? AND semester = ? AND subject_code = ?", enrollment, semester, subjectCode).
+		Updates(updates).Error
+}
+
+// subjectCredits maps subject codes to credits, preferring the course's own subject when the same
+// code exists in several courses
+func subjectCredits(db *gorm.DB, courseName string) (map[string]int64, error) {
+	var subjects []models.SubjectMaster
+	if err := db.Find(&subjects).Error; err != nil {
+		return nil, err
+	}
+	credits := map[string]int64{}
+	for _, s := range subjects {
+		if _, seen := credits[s.SubjectCode]; !seen || s.CourseName == courseName {
+			credits[s.SubjectCode] = s.Credits
+		}
+	}
+	return credits, nil
+}
+
+// gradedSubject is one mark with its grade
+type gradedSubject struct {
+	MarkID      int64   `json:"mark_id"`
+	SubjectCode string  `json:"subject_code"`
+	SubjectName string  `json:"subject_name"`
+	Semester    int     `json:"semester"`
+	Credits     int64   `json:"credits"`
+	Percent     float64 `json:"percent"`
+	Grade       string  `json:"grade"`
+	GradePoints float64 `json:"grade_points"`
+	Passed      bool    `json:"passed"`
+}
+
+// semesterOutcome is a student's computed result for one semester
+type semesterOutcome struct {
+	EnrollmentNumber int64           `json:"enrollment_number"`
+	StudentName      string          `json:"student_name"`
+	Semester         int             `json:"semester"`
+	SGPA             float64         `json:"sgpa"`
+	CGPA             float64         `json:"cgpa"`
+	Percentage       float64         `json:"percentage"`
+	Credits          int64           `json:"credits"`
+	CreditsEarned    int64           `json:"credits_earned"`
+	Status           string          `json:"result_status"`
+	Backlogs         []string        `json:"backlogs"` // failed subject codes, this semester and earlier
+	Subjects         []gradedSubject `json:"subjects"`
+	Error            string          `json:"error,omitempty"`
+}
+
+// computeSemesterResult grades a student's marks for every semester up to semester. SGPA weighs the
+// semester's grade points by credits; CGPA does the same over all semesters so far, using each
+// subject's counted attempt (see applyReappearResults) so cleared backlogs count. Fails within the
+// rules' reappear limit give ATKT, since more backlogs than that cannot be cleared in one exam
+// session; with no limit, any fails give ATKT.
+func computeSemesterResult(rules models.AcademicRuleSet, scale grading.Scale, credits map[string]int64, marks []models.StudentMark, semester int) (semesterOutcome, error) {
+	out := semesterOutcome{Semester: semester, Backlogs: []string{}, Subjects: []gradedSubject{}}
+	var semScores, allScores []grading.Score
+	var percentTotal float64
+	for _, m := range marks {
+		if m.Semester > semester {
+			continue
+		}
+		cr, ok := credits[m.SubjectCode]
+		if !ok {
+			return out, errors.New("subject " + m.SubjectCode + " is not in the subject master")
+		}
+		percent := markPercent(m)
+		band := scale.Lookup(percent)
+		g := gradedSubject{
+			MarkID: m.MarkID, SubjectCode: m.SubjectCode, SubjectName: m.SubjectName, Semester: m.Semester,
+			Credits: cr, Percent: roundMoney(percent), Grade: band.Grade, GradePoints: band.Points, Passed: band.Passed(),
+		}
+		score := grading.Score{Credits: float64(cr), Points: band.Points}
+		allScores = append(allScores, score)
+		if !g.Passed {
+			out.Backlogs = append(out.Backlogs, m.SubjectCode)
+		}
+		if m.Semester == semester {
+			semScores = append(semScores, score)
+			percentTotal += percent
+			out.Credits += cr
+			if g.Passed {
+				out.CreditsEarned += cr
+			}
+		}
+		out.Subjects = append(out.Subjects, g)
+	}
+	if len(semScores) == 0 {
+		return out, errors.New("no marks for semester " + strconv.Itoa(semester))
+	}
+
+	out.SGPA = grading.GPA(semScores)
+	out.CGPA = grading.GPA(allScores)
+	out.Percentage = roundMoney(percentTotal / float64(len(semScores)))
+	switch n := len(out.Backlogs); {
+	case n == 0:
+		out.Status = ResultPass
+	case rules.MaxReappearSubjects == 0 || n <= rules.MaxReappearSubjects:
+		out.Status = ResultATKT
+	default:
+		out.Status = ResultFail
+	}
+	return out, nil
+}
+
+// saveSemesterResult writes the grades onto the marks and the semester result row
+func saveSemesterResult(tx *gorm.DB, r semesterOutcome) error {
+	for _, s := range r.Subjects {
+		if s.MarkID == 0 {
+			continue // a reappear attempt; the original mark keeps its own grade
+		}
+		if err := tx.Model(&models.StudentMark{}).Where("mark_id = ?", s.MarkID).
+			Update("grade", s.Grade).Error; err != nil {
+			return err
+		}
+	}
+	var existing models.SemesterResult
+	found := tx.Where("enrollment_number = ? AND semester = ?", r.EnrollmentNumber, r.Semester).
+		Limit(1).Find(&existing).RowsAffected > 0
+	existing.EnrollmentNumber = r.EnrollmentNumber
+	existing.Semester = r.Semester
+	existing.SGPA = r.SGPA
+	existing.CGPA = r.CGPA
+	existing.Percentage = r.Percentage
+	existing.ResultStatus = r.Status
+	if found {
+		return tx.Save(&existing).Error
+	}
+	return tx.Create(&existing).Error
+}
+
+// ComputeResults grades one semester's marks for an institute's course and writes SGPA, CGPA,
+// percentage and Pass/ATKT/Fail per student. With "preview" nothing is saved. Students whose marks
+// cannot be graded (e.g. a subject missing from the subject master) are reported and skipped.
+func ComputeResults(c *gin.Context) {
+	var req struct {
+		InstituteID int    `json:"institute_id" binding:"required"`
+		CourseName  string `json:"course_name" binding:"required"`
+		Semester    int    `json:"semester" binding:"required,min=1"`
+		Preview     bool   `json:"preview"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	db := config.DB
+	scale, err := loadGradingScale(db)
+	if err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+	credits, err := subjectCredits(db, req.CourseName)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load subjects"})
+		return
+	}
+
+	var students []models.MasterStudent
+	if err := db.Where("institute_id = ? AND course_name = ?", req.InstituteID, req.CourseName).
+		Where("enrollment_number IN (?)", db.Table("student_marks").Select("enrollment_number").Where("semester = ?", req.Semester)).
+		Order("enrollment_number").Find(&students).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load students"})
+		return
+	}
+	if len(students) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "no students of this course have marks for the semester"})
+		return
+	}
+	enrollments := make([]int64, len(students))
+	for i, s := range students {
+		enrollments[i] = s.EnrollmentNumber
+	}
+	var marks []models.StudentMark
+	if err := db.Where("enrollment_number IN ? AND semester <= ?", enrollments, req.Semester).
+		Order("enrollment_number, semester, subject_code").Find(&marks).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load marks"})
+		return
+	}
+	byStudent := map[int64][]models.StudentMark{}
+	for _, m := range marks {
+		byStudent[m.EnrollmentNumber] = append(byStudent[m.EnrollmentNumber], m)
+	}
+	if err := applyReappearResults(db, byStudent); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load reappear results"})
+		return
+	}
+
+	results := make([]semesterOutcome, 0, len(students))
+	summary := map[string]int{ResultPass: 0, ResultATKT: 0, ResultFail: 0, "errors": 0}
+	rules := currentAcademicRules(db)
+	for _, s := range students {
+		r, err := computeSemesterResult(rules, scale, credits, byStudent[s.EnrollmentNumber], req.Semester)
+		r.EnrollmentNumber = s.EnrollmentNumber
+		r.StudentName = s.StudentName
+		if err != nil {
+			r.Error = err.Error()
+			summary["errors"]++
+		} else {
+			summary[r.Status]++
+		}
+		results = append(results, r)
+	}
+
+	if !req.Preview {
+		err := db.Transaction(func(tx *gorm.DB) error {
+			for _, r := range results {
+				if r.Error != "" {
+					continue
+				}
+				if err := saveSemesterResult(tx, r); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save results"})
+			return
+		}
+		middleware.Audit(c, "results.compute", "semester_results", req.CourseName+":"+strconv.Itoa(req.Semester), nil,
+			gin.H{"institute_id": req.InstituteID, "course_name": req.CourseName, "semester": req.Semester, "summary": summary})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"preview":  req.Preview,
+		"semester": req.Semester,
+		"scale":    scale,
+		"summary":  summary,
+		"results":  results,
+	})
+}
@@ -0,0 +1,79 @@
+package controllers
+
+import (
+	"strings"
+	"testing"
+
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+
+	"github.com/kiranraoboinapally/student/backend/internal/grading"
+	"github.com/kiranraoboinapally/student/backend/internal/models"
+)
+
+// dryRunDB builds MySQL statements without a server and records the SQL of every create and update
+func dryRunDB(t *testing.T) (*gorm.DB, *[]string) {
+	t.Helper()
+	db, err := gorm.Open(mysql.New(mysql.Config{DSN: "test@tcp(127.0.0.1:1)/test", SkipInitializeWithVersion: true}),
+		&gorm.Config{DryRun: true, DisableAutomaticPing: true, SkipDefaultTransaction: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var statements []string
+	record := func(tx *gorm.DB) { statements = append(statements, tx.Statement.SQL.String()) }
+	db.Callback().Create().After("gorm:create").Register("test:record", record)
+	db.Callback().Update().After("gorm:update").Register("test:record", record)
+	return db, &statements
+}
+
+// A mark that arrived with a stored total and percentage must be graded on its revised mark once it
+// is republished or revalued
+func TestRevisedMarkOnStoredPercentage(t *testing.T) {
+	scale, err := grading.NewScale([]models.GradeMapping{
+		{ID: 1, Grade: "F", MarksPercent: "0-39", GradePoints: "0"},
+		{ID: 2, Grade: "B", MarksPercent: "40-79", GradePoints: "7"},
+		{ID: 3, Grade: "A", MarksPercent: "80-100", GradePoints: "10"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	credits := map[string]int64{"CS101": 4}
+	stored := models.StudentMark{MarkID: 1, Semester: 1, SubjectCode: "CS101", MarksObtained: 35, TotalMarks: 35, Percentage: 35}
+	before, err := computeSemesterResult(models.AcademicRuleSet{}, scale, credits, []models.StudentMark{stored}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(before.Backlogs) != 1 {
+		t.Fatalf("backlogs before the revision = %v; want [CS101]", before.Backlogs)
+	}
+
+	db, statements := dryRunDB(t)
+	if err := reviseStudentMark(db, 2023000001, 1, "CS101", 85, nil, MarkStatusPass); err != nil {
+		t.Fatal(err)
+	}
+	if err := upsertStudentMarks(db, []models.StudentMark{{EnrollmentNumber: 2023000001, Semester: 1, SubjectCode: "CS101", MarksObtained: 85}}); err != nil {
+		t.Fatal(err)
+	}
+	if len(*statements) != 2 {
+		t.Fatalf("issued %d statements; want 2", len(*statements))
+	}
+	for i, name := range []string{"reviseStudentMark", "upsertStudentMarks"} {
+		sql := (*statements)[i]
+		for _, column := range []string{"`marks_obtained`=", "`total_marks_obtained`=", "`percentage`="} {
+			if !strings.Contains(sql, column) {
+				t.Errorf("%s does not set %s: %s", name, strings.TrimSuffix(column, "="), sql)
+			}
+		}
+	}
+
+	// As read back after either write: the stored figures are gone, so the revised mark counts
+	revised := stored
+	revised.MarksObtained, revised.TotalMarks, revised.Percentage = 85, 0, 0
+	after, err := computeSemesterResult(models.AcademicRuleSet{}, scale, credits, []models.StudentMark{revised}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if after.Status != ResultPass || after.Subjects[0].Grade != "A" {
+		t.Fatalf("result after the revision = %s with grade %s; want %s with grade A", after.Status, after.Subjects[0].Grade, ResultPass)
+	}
+}
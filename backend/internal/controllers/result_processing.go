@@ -0,0 +1,750 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/kiranraoboinapally/student/backend/internal/config"
+	"github.com/kiranraoboinapally/student/backend/internal/grading"
+	"github.com/kiranraoboinapally/student/backend/internal/middleware"
+	"github.com/kiranraoboinapally/student/backend/internal/models"
+)
+
+// ======================== RESULT PROCESSING ========================
+
+// Result batch statuses
+const (
+	ResultBatchProcessed = "processed"
+	ResultBatchPublished = "published"
+)
+
+// Subject mark statuses written by result processing
+const (
+	MarkStatusPass   = "Pass"
+	MarkStatusFail   = "Fail"
+	MarkStatusAbsent = "Absent"
+)
+
+var errBatchPublished = errors.New("this result batch is already published")
+
+// GetAssessmentSchemes lists every subject's assessment scheme with its components
+func GetAssessmentSchemes(c *gin.Context) {
+	schemes := []models.AssessmentScheme{}
+	if err := config.DB.Preload("Components").Order("subject_code").Find(&schemes).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch assessment schemes"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"schemes": schemes})
+}
+
+// SaveAssessmentScheme creates or replaces the scheme of a subject
+func SaveAssessmentScheme(c *gin.Context) {
+	code := strings.TrimSpace(c.Param("subject_code"))
+	var req struct {
+		InternalShare    *float64 `json:"internal_share"`
+		ExternalMaxMarks float64  `json:"external_max_marks"`
+		Components       []struct {
+			MarkType string  `json:"mark_type" binding:"required"`
+			Weight   float64 `json:"weight" binding:"gt=0"`
+			MaxMarks float64 `json:"max_marks" binding:"gt=0"`
+		} `json:"components"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	// Without an explicit share, internals carry the weightage set in the academic rules, which is
+	// also the most a scheme may give them
+	maxShare := currentAcademicRules(config.DB).InternalWeightage
+	share := maxShare
+	if req.InternalShare != nil {
+		share = *req.InternalShare
+	}
+	if share < 0 || share > maxShare {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("internal_share must be between 0 and %g, the internal weightage in the academic rules", maxShare)})
+		return
+	}
+	if share > 0 && len(req.Components) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "components are required when internal_share is above 0"})
+		return
+	}
+	if share < 100 && req.ExternalMaxMarks <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "external_max_marks is required when internal_share is below 100"})
+		return
+	}
+
+	db := config.DB
+	var subject models.SubjectMaster
+	if err := db.Where("subject_code = ?", code).First(&subject).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "subject not found: " + code})
+		return
+	}
+
+	seen := map[string]bool{}
+	var components []models.AssessmentComponent
+	for _, comp := range req.Components {
+		markType := strings.TrimSpace(comp.MarkType)
+		if seen[strings.ToLower(markType)] {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "duplicate component " + markType})
+			return
+		}
+		seen[strings.ToLower(markType)] = true
+		components = append(components, models.AssessmentComponent{MarkType: markType, Weight: comp.Weight, MaxMarks: comp.MaxMarks})
+	}
+
+	var scheme, before models.AssessmentScheme
+	err := db.Transaction(func(tx *gorm.DB) error {
+		found := tx.Preload("Components").Where("subject_code = ?", code).Limit(1).Find(&scheme).RowsAffected > 0
+		before = scheme
+		now := time.Now()
+		if !found {
+			scheme = models.AssessmentScheme{SubjectCode: code, CreatedAt: now}
+		}
+		scheme.InternalShare = share
+		scheme.ExternalMaxMarks = req.ExternalMaxMarks
+		scheme.UpdatedAt = now
+		if uid := c.GetInt64("user_id"); uid != 0 {
+			scheme.UpdatedBy = &uid
+		}
+		scheme.Components = nil
+		if err := tx.Save(&scheme).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("scheme_id = ?", scheme.SchemeID).Delete(&models.AssessmentComponent{}).Error; err != nil {
+			return err
+		}
+		for i := range components {
+			components[i].SchemeID = scheme.SchemeID
+		}
+		if len(components) > 0 {
+			if err := tx.Create(&components).Error; err != nil {
+				return err
+			}
+		}
+		scheme.Components = components
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save assessment scheme"})
+		return
+	}
+
+	middleware.Audit(c, "assessment_scheme.save", "assessment_schemes", code, before, scheme)
+	c.JSON(http.StatusOK, scheme)
+}
+
+// publishedStudents returns the students of an institute whose results for a semester were published
+// in a result batch
+func publishedStudents(db *gorm.DB, instituteID, semester int) (map[int64]bool, error) {
+	var enrollments []int64
+	err := db.Table("processed_marks").
+		Joins("JOIN result_batches ON result_batches.batch_id = processed_marks.batch_id").
+		Where("result_batches.institute_id = ? AND result_batches.semester = ? AND result_batches.status = ?", instituteID, semester, ResultBatchPublished).
+		Distinct().Pluck("processed_marks.enrollment_number", &enrollments).Error
+	out := map[int64]bool{}
+	for _, e := range enrollments {
+		out[e] = true
+	}
+	return out, err
+}
+
+// publishedResults returns the student/semester pairs (as "enrollment:semester") whose results were
+// published in a result batch, for the given students
+func publishedResults(db *gorm.DB, enrollments []int64) map[string]bool {
+	var rows []struct {
+		EnrollmentNumber int64
+		Semester         int
+	}
+	db.Table("processed_marks").
+		Select("DISTINCT processed_marks.enrollment_number, result_batches.semester").
+		Joins("JOIN result_batches ON result_batches.batch_id = processed_marks.batch_id").
+		Where("processed_marks.enrollment_number IN ? AND result_batches.status = ?", enrollments, ResultBatchPublished).
+		Scan(&rows)
+	out := map[string]bool{}
+	for _, r := range rows {
+		out[strconv.FormatInt(r.EnrollmentNumber, 10)+":"+strconv.Itoa(r.Semester)] = true
+	}
+	return out
+}
+
+// UploadExternalMarks records end-semester exam marks. Marks of a semester whose results are
+// already published for the student cannot be changed here.
+func UploadExternalMarks(c *gin.Context) {
+	var req struct {
+		Marks []struct {
+			EnrollmentNumber int64   `json:"enrollment_number" binding:"required"`
+			Semester         int     `json:"semester" binding:"required,min=1"`
+			SubjectCode      string  `json:"subject_code" binding:"required"`
+			MarksObtained    float64 `json:"marks_obtained" binding:"gte=0"`
+			Absent           bool    `json:"absent"`
+		} `json:"marks" binding:"required,min=1,dive"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	db := config.DB
+	var schemes []models.AssessmentScheme
+	db.Find(&schemes)
+	externalMax := map[string]float64{}
+	for _, s := range schemes {
+		externalMax[s.SubjectCode] = s.ExternalMaxMarks
+	}
+
+	var enrollments []int64
+	for _, m := range req.Marks {
+		enrollments = append(enrollments, m.EnrollmentNumber)
+	}
+	var known []int64
+	db.Table("master_students").Where("enrollment_number IN ?", enrollments).Pluck("enrollment_number", &known)
+	exists := map[int64]bool{}
+	for _, e := range known {
+		exists[e] = true
+	}
+	published := publishedResults(db, enrollments)
+
+	var uid *int64
+	if id := c.GetInt64("user_id"); id != 0 {
+		uid = &id
+	}
+	now := time.Now()
+	records := make([]models.ExternalMark, 0, len(req.Marks))
+	for _, m := range req.Marks {
+		if !exists[m.EnrollmentNumber] {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "student not found: " + strconv.FormatInt(m.EnrollmentNumber, 10)})
+			return
+		}
+		if published[strconv.FormatInt(m.EnrollmentNumber, 10)+":"+strconv.Itoa(m.Semester)] {
+			c.JSON(http.StatusConflict, gin.H{"error": "results are already published for student " + strconv.FormatInt(m.EnrollmentNumber, 10) + ", semester " + strconv.Itoa(m.Semester)})
+			return
+		}
+		max, ok := externalMax[m.SubjectCode]
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "no assessment scheme for subject " + m.SubjectCode})
+			return
+		}
+		if m.MarksObtained > max {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "marks for " + m.SubjectCode + " exceed the external maximum of " + strconv.FormatFloat(max, 'f', -1, 64)})
+			return
+		}
+		records = append(records, models.ExternalMark{
+			EnrollmentNumber: m.EnrollmentNumber, Semester: m.Semester, SubjectCode: m.SubjectCode,
+			MarksObtained: m.MarksObtained, Absent: m.Absent, EnteredBy: uid, CreatedAt: now, UpdatedAt: now,
+		})
+	}
+
+	if err := db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "enrollment_number"}, {Name: "semester"}, {Name: "subject_code"}},
+		DoUpdates: clause.AssignmentColumns([]string{"marks_obtained", "absent", "entered_by", "updated_at"}),
+	}).Create(&records).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save external marks"})
+		return
+	}
+
+	middleware.Audit(c, "marks.external.upload", "external_marks", "", nil, gin.H{"total_records": len(records)})
+	c.JSON(http.StatusOK, gin.H{"message": "external marks saved", "total_records": len(records)})
+}
+
+// resultIssue is why a student's result is withheld from a batch
+type resultIssue struct {
+	EnrollmentNumber int64  `json:"enrollment_number"`
+	SubjectCode      string `json:"subject_code,omitempty"`
+	Problem          string `json:"problem"`
+}
+
+// aggregateSubjectMarks combines locked internal components and the external mark into a final mark
+// out of 100. Each component counts obtained/max × weight, scaled so all internals together are
+// worth the scheme's InternalShare; the external exam makes up the rest.
+func aggregateSubjectMarks(scheme models.AssessmentScheme, internals map[string]models.InternalMark, external *models.ExternalMark) (internal, externalScore float64, absent bool, problem string) {
+	var weights, weighted float64
+	for _, comp := range scheme.Components {
+		weights += comp.Weight
+		m, ok := internals[strings.ToLower(comp.MarkType)]
+		if !ok {
+			return 0, 0, false, comp.MarkType + " marks are missing"
+		}
+		weighted += math.Min(m.MarksObtained, comp.MaxMarks) / comp.MaxMarks * comp.Weight
+	}
+	if weights > 0 {
+		internal = weighted / weights * scheme.InternalShare
+	}
+	if scheme.InternalShare < 100 {
+		if external == nil {
+			return 0, 0, false, "external marks are missing"
+		}
+		if external.Absent {
+			absent = true
+		} else {
+			externalScore = math.Min(external.MarksObtained, scheme.ExternalMaxMarks) / scheme.ExternalMaxMarks * (100 - scheme.InternalShare)
+		}
+	}
+	return roundMoney(internal), roundMoney(externalScore), absent, ""
+}
+
+// stagedResults is the outcome of processing an institute's semester
+type stagedResults struct {
+	Marks    []models.ProcessedMark
+	Issues   []resultIssue
+	Students int
+	Withheld int
+}
+
+// stageResults builds final subject marks for every student of the institute with locked internal
+// marks or external marks in the semester, leaving out students whose results were already published.
+// A student with any missing scheme or mark is withheld entirely, so a published result is never partial.
+func stageResults(db *gorm.DB, scale grading.Scale, instituteID, semester int) (stagedResults, error) {
+	var out stagedResults
+	studentIDs := db.Table("master_students").Select("enrollment_number").Where("institute_id = ?", instituteID)
+	published, err := publishedStudents(db, instituteID, semester)
+	if err != nil {
+		return out, err
+	}
+
+	var internals []models.InternalMark
+	if err := db.Where("institute_id = ? AND semester = ? AND status = ?", instituteID, semester, "locked").
+		Order("updated_at").Find(&internals).Error; err != nil {
+		return out, err
+	}
+	var externals []models.ExternalMark
+	if err := db.Where("semester = ? AND enrollment_number IN (?)", semester, studentIDs).Find(&externals).Error; err != nil {
+		return out, err
+	}
+
+	type key struct {
+		enrollment int64
+		subject    string
+	}
+	components := map[key]map[string]models.InternalMark{}
+	externalOf := map[key]*models.ExternalMark{}
+	var keys []key
+	add := func(k key) {
+		if _, ok := components[k]; !ok {
+			components[k] = map[string]models.InternalMark{}
+			keys = append(keys, k)
+		}
+	}
+	for _, m := range internals {
+		if published[m.EnrollmentNumber] {
+			continue
+		}
+		k := key{m.EnrollmentNumber, m.SubjectCode}
+		add(k)
+		components[k][strings.ToLower(m.MarkType)] = m // latest entry per component wins
+	}
+	for i := range externals {
+		if published[externals[i].EnrollmentNumber] {
+			continue
+		}
+		k := key{externals[i].EnrollmentNumber, externals[i].SubjectCode}
+		add(k)
+		externalOf[k] = &externals[i]
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].enrollment != keys[j].enrollment {
+			return keys[i].enrollment < keys[j].enrollment
+		}
+		return keys[i].subject < keys[j].subject
+	})
+
+	var schemes []models.AssessmentScheme
+	if err := db.Preload("Components").Find(&schemes).Error; err != nil {
+		return out, err
+	}
+	schemeOf := map[string]models.AssessmentScheme{}
+	for _, s := range schemes {
+		schemeOf[s.SubjectCode] = s
+	}
+	var subjects []models.SubjectMaster
+	db.Find(&subjects)
+	subjectOf := map[string]models.SubjectMaster{}
+	for _, s := range subjects {
+		subjectOf[s.SubjectCode] = s
+	}
+
+	byStudent := map[int64][]models.ProcessedMark{}
+	withheld := map[int64]bool{}
+	var order []int64
+	for _, k := range keys {
+		if _, ok := byStudent[k.enrollment]; !ok && !withheld[k.enrollment] {
+			order = append(order, k.enrollment)
+		}
+		scheme, ok := schemeOf[k.subject]
+		if !ok {
+			out.Issues = append(out.Issues, resultIssue{k.enrollment, k.subject, "no assessment scheme for the subject"})
+			withheld[k.enrollment] = true
+			continue
+		}
+		internal, external, absent, problem := aggregateSubjectMarks(scheme, components[k], externalOf[k])
+		if problem != "" {
+			out.Issues = append(out.Issues, resultIssue{k.enrollment, k.subject, problem})
+			withheld[k.enrollment] = true
+			continue
+		}
+		total := roundMoney(internal + external)
+		band := scale.Lookup(total)
+		status := MarkStatusPass
+		switch {
+		case absent:
+			band = scale.Lookup(0)
+			status = MarkStatusAbsent
+		case !band.Passed():
+			status = MarkStatusFail
+		}
+		subject := subjectOf[k.subject]
+		byStudent[k.enrollment] = append(byStudent[k.enrollment], models.ProcessedMark{
+			EnrollmentNumber: k.enrollment, SubjectCode: k.subject,
+			SubjectName: subject.SubjectName, SubjectType: subject.SubjectType,
+			InternalMarks: internal, ExternalMarks: external, TotalMarks: total,
+			Grade: band.Grade, GradePoints: band.Points, Status: status,
+		})
+	}
+
+	for _, enrollment := range order {
+		if withheld[enrollment] {
+			out.Withheld++
+			continue
+		}
+		out.Students++
+		out.Marks = append(out.Marks, byStudent[enrollment]...)
+	}
+	return out, nil
+}
+
+// processedAsStudentMark is the student_marks row a staged mark becomes on publication
+func processedAsStudentMark(p models.ProcessedMark, semester int, now time.Time) models.StudentMark {
+	grade := p.Grade
+	return models.StudentMark{
+		EnrollmentNumber: p.EnrollmentNumber,
+		Semester:         semester,
+		SubjectCode:      p.SubjectCode,
+		SubjectName:      p.SubjectName,
+		SubjectType:      p.SubjectType,
+		MarksObtained:    p.TotalMarks,
+		Grade:            &grade,
+		Status:           p.Status,
+		CreatedAt:        now,
+	}
+}
+
+// previewOutcomes computes each staged student's semester result without writing anything: the
+// staged marks stand in for the semester, earlier semesters come from student_marks
+func previewOutcomes(db *gorm.DB, scale grading.Scale, staged []models.ProcessedMark, semester int) ([]semesterOutcome, error) {
+	byStudent := map[int64][]models.StudentMark{}
+	var order []int64
+	now := time.Now()
+	for _, p := range staged {
+		if _, ok := byStudent[p.EnrollmentNumber]; !ok {
+			order = append(order, p.EnrollmentNumber)
+		}
+		byStudent[p.EnrollmentNumber] = append(byStudent[p.EnrollmentNumber], processedAsStudentMark(p, semester, now))
+	}
+	if len(order) == 0 {
+		return []semesterOutcome{}, nil
+	}
+	var earlier []models.StudentMark
+	if err := db.Where("enrollment_number IN ? AND semester < ?", order, semester).Find(&earlier).Error; err != nil {
+		return nil, err
+	}
+	for _, m := range earlier {
+		byStudent[m.EnrollmentNumber] = append(byStudent[m.EnrollmentNumber], m)
+	}
+	return studentOutcomes(db, scale, order, byStudent, semester)
+}
+
+// studentOutcomes runs computeSemesterResult for each student, using credits of the student's course
+func studentOutcomes(db *gorm.DB, scale grading.Scale, enrollments []int64, marks map[int64][]models.StudentMark, semester int) ([]semesterOutcome, error) {
+	if err := applyReappearResults(db, marks); err != nil {
+		return nil, err
+	}
+	var students []models.MasterStudent
+	if err := db.Where("enrollment_number IN ?", enrollments).Find(&students).Error; err != nil {
+		return nil, err
+	}
+	studentOf := map[int64]models.MasterStudent{}
+	for _, s := range students {
+		studentOf[s.EnrollmentNumber] = s
+	}
+	rules := currentAcademicRules(db)
+	creditsByCourse := map[string]map[string]int64{}
+	outcomes := make([]semesterOutcome, 0, len(enrollments))
+	for _, enrollment := range enrollments {
+		s := studentOf[enrollment]
+		course := safeString(s.CourseName)
+		credits, ok := creditsByCourse[course]
+		if !ok {
+			var err error
+			if credits, err = subjectCredits(db, course); err != nil {
+				return nil, err
+			}
+			creditsByCourse[course] = credits
+		}
+		r, err := computeSemesterResult(rules, scale, credits, marks[enrollment], semester)
+		r.EnrollmentNumber = enrollment
+		r.StudentName = s.StudentName
+		if err != nil {
+			r.Error = err.Error()
+		}
+		outcomes = append(outcomes, r)
+	}
+	return outcomes, nil
+}
+
+// ProcessResults aggregates an institute's locked internal marks and external marks for a semester
+// into final subject marks and grades, and stages them in a result batch for publishing. Processing
+// can be repeated until the batch is published; students withheld from a published batch go into a
+// follow-up batch once their marks are complete. "preview" returns the outcome without staging.
+func ProcessResults(c *gin.Context) {
+	var req struct {
+		InstituteID int  `json:"institute_id" binding:"required"`
+		Semester    int  `json:"semester" binding:"required,min=1"`
+		Preview     bool `json:"preview"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	db := config.DB
+	var batch models.ResultBatch
+	found := db.Where("institute_id = ? AND semester = ? AND status = ?", req.InstituteID, req.Semester, ResultBatchProcessed).
+		Order("round DESC").Limit(1).Find(&batch).RowsAffected > 0
+	var unlocked int64
+	db.Model(&models.InternalMark{}).
+		Where("institute_id = ? AND semester = ? AND status IN ?", req.InstituteID, req.Semester, []string{"draft", "submitted"}).
+		Count(&unlocked)
+	if unlocked > 0 && !req.Preview {
+		c.JSON(http.StatusConflict, gin.H{"error": "internal marks must all be locked before processing", "unlocked_marks": unlocked})
+		return
+	}
+
+	scale, err := loadGradingScale(db)
+	if err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+	staged, err := stageResults(db, scale, req.InstituteID, req.Semester)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to process results"})
+		return
+	}
+	if staged.Students == 0 && staged.Withheld == 0 {
+		var publishedBatches int64
+		db.Model(&models.ResultBatch{}).Where("institute_id = ? AND semester = ? AND status = ?", req.InstituteID, req.Semester, ResultBatchPublished).
+			Count(&publishedBatches)
+		if publishedBatches > 0 {
+			c.JSON(http.StatusConflict, gin.H{"error": "results are already published for every student with marks in this semester"})
+			return
+		}
+		c.JSON(http.StatusNotFound, gin.H{"error": "no locked internal or external marks for this institute and semester"})
+		return
+	}
+	outcomes, err := previewOutcomes(db, scale, staged.Marks, req.Semester)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to process results"})
+		return
+	}
+	issues := staged.Issues
+	if issues == nil {
+		issues = []resultIssue{}
+	}
+
+	if !req.Preview {
+		issuesJSON, _ := json.Marshal(issues)
+		err = db.Transaction(func(tx *gorm.DB) error {
+			now := time.Now()
+			if !found {
+				// Numbered after any published batches of the semester
+				var last int
+				tx.Model(&models.ResultBatch{}).Select("COALESCE(MAX(round),0)").
+					Where("institute_id = ? AND semester = ?", req.InstituteID, req.Semester).Scan(&last)
+				batch = models.ResultBatch{InstituteID: req.InstituteID, Semester: req.Semester, Round: last + 1}
+			}
+			batch.Status = ResultBatchProcessed
+			batch.Students = staged.Students
+			batch.Withheld = staged.Withheld
+			batch.Issues = ptrString(string(issuesJSON))
+			batch.ProcessedAt = now
+			if uid := c.GetInt64("user_id"); uid != 0 {
+				batch.ProcessedBy = &uid
+			}
+			if err := tx.Save(&batch).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("batch_id = ?", batch.BatchID).Delete(&models.ProcessedMark{}).Error; err != nil {
+				return err
+			}
+			for i := range staged.Marks {
+				staged.Marks[i].BatchID = batch.BatchID
+			}
+			if len(staged.Marks) == 0 {
+				return nil
+			}
+			return tx.CreateInBatches(&staged.Marks, 500).Error
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to stage results"})
+			return
+		}
+		middleware.Audit(c, "results.process", "result_batches", strconv.FormatInt(batch.BatchID, 10), nil,
+			gin.H{"institute_id": req.InstituteID, "semester": req.Semester, "students": staged.Students, "withheld": staged.Withheld})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"preview":        req.Preview,
+		"batch_id":       batch.BatchID,
+		"round":          batch.Round,
+		"students":       staged.Students,
+		"withheld":       staged.Withheld,
+		"unlocked_marks": unlocked,
+		"issues":         issues,
+		"results":        outcomes,
+	})
+}
+
+// GetResultBatches lists result batches, optionally for one institute or semester
+func GetResultBatches(c *gin.Context) {
+	query := config.DB.Model(&models.ResultBatch{})
+	if v := c.Query("institute_id"); v != "" {
+		query = query.Where("institute_id = ?", v)
+	}
+	if v := c.Query("semester"); v != "" {
+		query = query.Where("semester = ?", v)
+	}
+	batches := []models.ResultBatch{}
+	if err := query.Order("processed_at DESC").Find(&batches).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch result batches"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"batches": batches})
+}
+
+// GetResultBatch returns a batch with its staged subject marks
+func GetResultBatch(c *gin.Context) {
+	db := config.DB
+	var batch models.ResultBatch
+	if err := db.Where("batch_id = ?", c.Param("id")).First(&batch).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "result batch not found"})
+		return
+	}
+	marks := []models.ProcessedMark{}
+	db.Where("batch_id = ?", batch.BatchID).Order("enrollment_number, subject_code").Find(&marks)
+	c.JSON(http.StatusOK, gin.H{"batch": batch, "marks": marks})
+}
+
+// PublishResultBatch makes a processed batch visible in one transaction: the staged marks become
+// student_marks, every student's semester result is computed and saved, and the batch's students'
+// locked internal marks for the semester are marked published. Either all of it happens or none.
+// Withheld students stay unpublished, so their internal marks can still be completed and processed.
+func PublishResultBatch(c *gin.Context) {
+	db := config.DB
+	scale, err := loadGradingScale(db)
+	if err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+
+	var batch models.ResultBatch
+	var published int
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("batch_id = ?", c.Param("id")).First(&batch).Error; err != nil {
+			return err
+		}
+		if batch.Status == ResultBatchPublished {
+			return errBatchPublished
+		}
+		var staged []models.ProcessedMark
+		if err := tx.Where("batch_id = ?", batch.BatchID).Order("enrollment_number, subject_code").Find(&staged).Error; err != nil {
+			return err
+		}
+		if len(staged) == 0 {
+			return errors.New("the batch has no results to publish")
+		}
+
+		now := time.Now()
+		records := make([]models.StudentMark, 0, len(staged))
+		var enrollments []int64
+		seen := map[int64]bool{}
+		for _, p := range staged {
+			records = append(records, processedAsStudentMark(p, batch.Semester, now))
+			if !seen[p.EnrollmentNumber] {
+				seen[p.EnrollmentNumber] = true
+				enrollments = append(enrollments, p.EnrollmentNumber)
+			}
+		}
+		if err := upsertStudentMarks(tx, records); err != nil {
+			return err
+		}
+
+		var marks []models.StudentMark
+		if err := tx.Where("enrollment_number IN ? AND semester <= ?", enrollments, batch.Semester).
+			Order("enrollment_number, semester, subject_code").Find(&marks).Error; err != nil {
+			return err
+		}
+		byStudent := map[int64][]models.StudentMark{}
+		for _, m := range marks {
+			byStudent[m.EnrollmentNumber] = append(byStudent[m.EnrollmentNumber], m)
+		}
+		outcomes, err := studentOutcomes(tx, scale, enrollments, byStudent, batch.Semester)
+		if err != nil {
+			return err
+		}
+		for _, r := range outcomes {
+			if r.Error != "" {
+				return errors.New(strconv.FormatInt(r.EnrollmentNumber, 10) + ": " + r.Error)
+			}
+			if err := saveSemesterResult(tx, r); err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Model(&models.InternalMark{}).
+			Where("institute_id = ? AND semester = ? AND status = ? AND enrollment_number IN ?", batch.InstituteID, batch.Semester, "locked", enrollments).
+			Updates(map[string]interface{}{"status": "published", "published_at": now, "updated_at": now}).Error; err != nil {
+			return err
+		}
+		batch.Status = ResultBatchPublished
+		batch.PublishedAt = &now
+		if uid := c.GetInt64("user_id"); uid != 0 {
+			batch.PublishedBy = &uid
+		}
+		published = len(outcomes)
+		return tx.Save(&batch).Error
+	})
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "result batch not found"})
+		return
+	case errors.Is(err, errBatchPublished):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "results were not published: " + err.Error()})
+		return
+	}
+
+	middleware.Audit(c, "results.publish", "result_batches", strconv.FormatInt(batch.BatchID, 10), nil,
+		gin.H{"institute_id": batch.InstituteID, "semester": batch.Semester, "students": published})
+	SendAdminNotification("results_published", gin.H{
+		"institute_id": batch.InstituteID,
+		"semester":     batch.Semester,
+		"students":     published,
+	})
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "results published",
+		"batch_id": batch.BatchID,
+		"students": published,
+		"withheld": batch.Withheld,
+	})
+}
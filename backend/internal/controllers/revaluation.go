@@ -0,0 +1,564 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/kiranraoboinapally/student/backend/internal/config"
+	"github.com/kiranraoboinapally/student/backend/internal/middleware"
+	"github.com/kiranraoboinapally/student/backend/internal/models"
+)
+
+// ======================== REVALUATION ========================
+
+// Revaluation kinds: a fresh evaluation of the script, or only a recount of the marks awarded
+const (
+	RevaluationKindRevaluation = "revaluation"
+	RevaluationKindRetotalling = "retotalling"
+)
+
+// Revaluation request statuses
+const (
+	RevaluationApplied   = "applied"   // waiting for the fee and an evaluator
+	RevaluationAssigned  = "assigned"  // with the evaluator
+	RevaluationEvaluated = "evaluated" // revised mark awaiting approval
+	RevaluationApproved  = "approved"
+	RevaluationRejected  = "rejected"
+)
+
+var (
+	errRevaluationState = errors.New("the request is not at a stage where this can be done")
+	errRevaluationFee   = errors.New("the revaluation fee has not been paid yet")
+)
+
+func revaluationFee(kind string) float64 {
+	if kind == RevaluationKindRetotalling {
+		return float64(config.RetotallingFee)
+	}
+	return float64(config.RevaluationFee)
+}
+
+// revaluationLabel is the display name of a request kind
+func revaluationLabel(kind string) string {
+	if kind == RevaluationKindRetotalling {
+		return "Re-totalling"
+	}
+	return "Revaluation"
+}
+
+// resultsPublishedAt is when the student's results for a semester were published: the result batch
+// the student was published in, or for results published per component, the latest internal mark
+// publication
+func resultsPublishedAt(db *gorm.DB, enrollment int64, semester int) *time.Time {
+	var batch models.ResultBatch
+	if db.Where("status = ? AND semester = ? AND batch_id IN (?)", ResultBatchPublished, semester,
+		db.Table("processed_marks").Select("batch_id").Where("enrollment_number = ?", enrollment)).
+		Order("round DESC").Limit(1).Find(&batch).RowsAffected > 0 && batch.PublishedAt != nil {
+		return batch.PublishedAt
+	}
+	var published *time.Time
+	db.Model(&models.InternalMark{}).Select("MAX(published_at)").
+		Where("enrollment_number = ? AND semester = ? AND status = ?", enrollment, semester, "published").
+		Scan(&published)
+	return published
+}
+
+// chargeSettled reports whether a fee charge posted for a request has been paid off; no charge
+// (a zero fee) counts as settled
+func chargeSettled(db *gorm.DB, enrollment int64, chargeID *int64) (bool, error) {
+	if chargeID == nil {
+		return true, nil
+	}
+	var charge models.FeeLedgerEntry
+	if err := db.Where("entry_id = ?", *chargeID).First(&charge).Error; err != nil {
+		return false, err
+	}
+	charges, err := openCharges(db, enrollment, charge.FeeTypeID)
+	if err != nil {
+		return false, err
+	}
+	for _, ch := range charges {
+		if ch.EntryID == charge.EntryID {
+			return ch.Outstanding <= 0, nil
+		}
+	}
+	return true, nil
+}
+
+// originalMarkers are the users who entered the marks being challenged; they may not re-evaluate them
+func originalMarkers(db *gorm.DB, r models.RevaluationRequest) map[int64]bool {
+	var ids []int64
+	db.Model(&models.InternalMark{}).Distinct("entered_by").
+		Where("enrollment_number = ? AND semester = ? AND subject_code = ?", r.EnrollmentNumber, r.Semester, r.SubjectCode).
+		Pluck("entered_by", &ids)
+	var external models.ExternalMark
+	if db.Where("enrollment_number = ? AND semester = ? AND subject_code = ?", r.EnrollmentNumber, r.Semester, r.SubjectCode).
+		Limit(1).Find(&external).RowsAffected > 0 && external.EnteredBy != nil {
+		ids = append(ids, *external.EnteredBy)
+	}
+	out := map[int64]bool{}
+	for _, id := range ids {
+		out[id] = true
+	}
+	return out
+}
+
+// recomputeResultsFrom recalculates a student's semester results from semester onwards, since a
+// changed mark moves that semester's SGPA and every later CGPA
+func recomputeResultsFrom(tx *gorm.DB, enrollment int64, semester int) error {
+	scale, err := loadGradingScale(tx)
+	if err != nil {
+		return err
+	}
+	var student models.MasterStudent
+	tx.Where("enrollment_number = ?", enrollment).Limit(1).Find(&student)
+	credits, err := subjectCredits(tx, safeString(student.CourseName))
+	if err != nil {
+		return err
+	}
+	var marks []models.StudentMark
+	if err := tx.Where("enrollment_number = ?", enrollment).Order("semester, subject_code").Find(&marks).Error; err != nil {
+		return err
+	}
+	byStudent := map[int64][]models.StudentMark{enrollment: marks}
+	if err := applyReappearResults(tx, byStudent); err != nil {
+		return err
+	}
+	marks = byStudent[enrollment]
+	var semesters []int
+	tx.Model(&models.SemesterResult{}).Distinct("semester").
+		Where("enrollment_number = ? AND semester >= ?", enrollment, semester).Order("semester").Pluck("semester", &semesters)
+	if len(semesters) == 0 || semesters[0] != semester {
+		semesters = append([]int{semester}, semesters...)
+	}
+	rules := currentAcademicRules(tx)
+	for _, sem := range semesters {
+		r, err := computeSemesterResult(rules, scale, credits, marks, sem)
+		if err != nil {
+			return err
+		}
+		r.EnrollmentNumber = enrollment
+		if err := saveSemesterResult(tx, r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// revaluationView is a request with whether its fee has been paid
+type revaluationView struct {
+	models.RevaluationRequest
+	SubjectName string `json:"subject_name"`
+	FeePaid     bool   `json:"fee_paid"`
+}
+
+func revaluationViews(db *gorm.DB, requests []models.RevaluationRequest) []revaluationView {
+	codes := map[string]bool{}
+	for _, r := range requests {
+		codes[r.SubjectCode] = true
+	}
+	names := map[string]string{}
+	if len(codes) > 0 {
+		var list []string
+		for code := range codes {
+			list = append(list, code)
+		}
+		var subjects []models.SubjectMaster
+		db.Where("subject_code IN ?", list).Find(&subjects)
+		for _, s := range subjects {
+			names[s.SubjectCode] = s.SubjectName
+		}
+	}
+	views := make([]revaluationView, 0, len(requests))
+	for _, r := range requests {
+		paid, _ := chargeSettled(db, r.EnrollmentNumber, r.FeeChargeID)
+		views = append(views, revaluationView{RevaluationRequest: r, SubjectName: names[r.SubjectCode], FeePaid: paid})
+	}
+	return views
+}
+
+// ApplyForRevaluation lets a student challenge a published subject mark within the revaluation
+// window. The fee is posted as a charge the student pays through the usual payment flow.
+func ApplyForRevaluation(c *gin.Context) {
+	enrollment, err := getEnrollmentOrError(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	var req struct {
+		Semester    int    `json:"semester" binding:"required,min=1"`
+		SubjectCode string `json:"subject_code" binding:"required"`
+		Kind        string `json:"kind"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	kind := strings.ToLower(strings.TrimSpace(req.Kind))
+	if kind == "" {
+		kind = RevaluationKindRevaluation
+	}
+	if kind != RevaluationKindRevaluation && kind != RevaluationKindRetotalling {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "kind must be revaluation or retotalling"})
+		return
+	}
+
+	db := config.DB
+	var mark models.StudentMark
+	if err := db.Where("enrollment_number = ? AND semester = ? AND subject_code = ?", enrollment, req.Semester, req.SubjectCode).
+		First(&mark).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "no mark found for this subject and semester"})
+		return
+	}
+	published := resultsPublishedAt(db, enrollment, req.Semester)
+	if published == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "results for this semester have not been published"})
+		return
+	}
+	closes := published.AddDate(0, 0, config.RevaluationWindowDays)
+	if time.Now().After(closes) {
+		c.JSON(http.StatusConflict, gin.H{"error": "the revaluation window closed on " + closes.Format("02 Jan 2006")})
+		return
+	}
+	var existing int64
+	db.Model(&models.RevaluationRequest{}).
+		Where("enrollment_number = ? AND semester = ? AND subject_code = ?", enrollment, req.Semester, req.SubjectCode).
+		Where("kind = ? OR status IN ?", kind, []string{RevaluationApplied, RevaluationAssigned, RevaluationEvaluated}).
+		Count(&existing)
+	if existing > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "a " + kind + " request for this subject already exists"})
+		return
+	}
+
+	now := time.Now()
+	request := models.RevaluationRequest{
+		EnrollmentNumber: enrollment,
+		Semester:         req.Semester,
+		SubjectCode:      req.SubjectCode,
+		Kind:             kind,
+		Status:           RevaluationApplied,
+		OriginalMarks:    mark.MarksObtained,
+		OriginalGrade:    mark.Grade,
+		CreatedAt:        now,
+		UpdatedAt:        now,
+	}
+	fee := revaluationFee(kind)
+	var charge models.FeeLedgerEntry
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&request).Error; err != nil {
+			return err
+		}
+		if fee <= 0 {
+			return nil
+		}
+		feeType, err := resolveFeeType(tx, "Revaluation")
+		if err != nil {
+			return err
+		}
+		var uid *int64
+		if id := c.GetInt64("user_id"); id != 0 {
+			uid = &id
+		}
+		charge = models.FeeLedgerEntry{
+			EnrollmentNumber: enrollment,
+			FeeTypeID:        feeType.FeeTypeID,
+			EntryType:        LedgerCharge,
+			Debit:            fee,
+			Reference:        ptrString("revaluation:" + strconv.FormatInt(request.RequestID, 10)),
+			DueDate:          &now,
+			Semester:         &req.Semester,
+			Description:      ptrString(truncate(revaluationLabel(kind)+" - "+req.SubjectCode+" (semester "+strconv.Itoa(req.Semester)+")", 255)),
+			CreatedBy:        uid,
+		}
+		if _, err := postLedgerEntry(tx, &charge); err != nil {
+			return err
+		}
+		request.FeeChargeID = &charge.EntryID
+		return tx.Model(&request).Update("fee_charge_id", charge.EntryID).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to submit request"})
+		return
+	}
+
+	middleware.Audit(c, "revaluation.apply", "revaluation_requests", strconv.FormatInt(request.RequestID, 10), nil, request)
+	resp := gin.H{"message": kind + " request submitted", "request": request, "window_closes": closes.Format("2006-01-02")}
+	if request.FeeChargeID != nil {
+		resp["fee"] = gin.H{"charge_id": charge.EntryID, "fee_head": "Revaluation Fee", "amount": fee}
+	}
+	c.JSON(http.StatusCreated, resp)
+}
+
+// GetMyRevaluations lists the caller's revaluation requests
+func GetMyRevaluations(c *gin.Context) {
+	enrollment, err := getEnrollmentOrError(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	var requests []models.RevaluationRequest
+	config.DB.Where("enrollment_number = ?", enrollment).Order("request_id DESC").Find(&requests)
+	c.JSON(http.StatusOK, gin.H{"requests": revaluationViews(config.DB, requests)})
+}
+
+// GetRevaluationRequests lists requests for the examination office, with filters and pagination
+func GetRevaluationRequests(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 500 {
+		limit = 50
+	}
+	query := config.DB.Model(&models.RevaluationRequest{})
+	if v := c.Query("status"); v != "" {
+		query = query.Where("status = ?", v)
+	}
+	if v := c.Query("semester"); v != "" {
+		query = query.Where("semester = ?", v)
+	}
+	if v := c.Query("subject_code"); v != "" {
+		query = query.Where("subject_code = ?", v)
+	}
+	var total int64
+	query.Count(&total)
+	var requests []models.RevaluationRequest
+	if err := query.Order("request_id DESC").Limit(limit).Offset((page - 1) * limit).Find(&requests).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch revaluation requests"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"requests": revaluationViews(config.DB, requests),
+		"pagination": gin.H{
+			"page":        page,
+			"limit":       limit,
+			"total":       total,
+			"total_pages": (total + int64(limit) - 1) / int64(limit),
+		},
+	})
+}
+
+// AssignRevaluation hands a paid request to a faculty evaluator who did not enter the original marks
+func AssignRevaluation(c *gin.Context) {
+	var req struct {
+		FacultyID int64 `json:"faculty_id" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	db := config.DB
+	var request models.RevaluationRequest
+	if err := db.Where("request_id = ?", c.Param("id")).First(&request).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "revaluation request not found"})
+		return
+	}
+	if request.Status != RevaluationApplied && request.Status != RevaluationAssigned {
+		c.JSON(http.StatusConflict, gin.H{"error": errRevaluationState.Error()})
+		return
+	}
+	if paid, err := chargeSettled(db, request.EnrollmentNumber, request.FeeChargeID); err != nil || !paid {
+		c.JSON(http.StatusConflict, gin.H{"error": errRevaluationFee.Error()})
+		return
+	}
+	var faculty models.Faculty
+	if err := db.Where("faculty_id = ? AND approval_status = ?", req.FacultyID, "approved").First(&faculty).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "evaluator must be an approved faculty member"})
+		return
+	}
+	if originalMarkers(db, request)[faculty.UserID] {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "the evaluator entered the original marks; choose someone else"})
+		return
+	}
+
+	before := request
+	res := db.Model(&models.RevaluationRequest{}).
+		Where("request_id = ? AND status IN ?", request.RequestID, []string{RevaluationApplied, RevaluationAssigned}).
+		Updates(map[string]interface{}{"status": RevaluationAssigned, "evaluator_id": faculty.UserID, "updated_at": time.Now()})
+	if res.Error != nil || res.RowsAffected == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": errRevaluationState.Error()})
+		return
+	}
+	request.Status = RevaluationAssigned
+	request.EvaluatorID = &faculty.UserID
+	middleware.Audit(c, "revaluation.assign", "revaluation_requests", strconv.FormatInt(request.RequestID, 10), before, request)
+	c.JSON(http.StatusOK, gin.H{"message": "request assigned", "request": request})
+}
+
+// FacultyGetRevaluations lists the requests assigned to the calling evaluator
+func FacultyGetRevaluations(c *gin.Context) {
+	var requests []models.RevaluationRequest
+	config.DB.Where("evaluator_id = ? AND status IN ?", c.GetInt64("user_id"), []string{RevaluationAssigned, RevaluationEvaluated}).
+		Order("request_id").Find(&requests)
+	c.JSON(http.StatusOK, gin.H{"requests": revaluationViews(config.DB, requests)})
+}
+
+// FacultyEvaluateRevaluation records the evaluator's revised mark (out of 100) for approval
+func FacultyEvaluateRevaluation(c *gin.Context) {
+	var req struct {
+		RevisedMarks *float64 `json:"revised_marks" binding:"required"`
+		Remarks      string   `json:"remarks"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if *req.RevisedMarks < 0 || *req.RevisedMarks > 100 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "revised_marks must be between 0 and 100"})
+		return
+	}
+	db := config.DB
+	scale, err := loadGradingScale(db)
+	if err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+	uid := c.GetInt64("user_id")
+	var request models.RevaluationRequest
+	if err := db.Where("request_id = ? AND evaluator_id = ?", c.Param("id"), uid).First(&request).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "revaluation request not found"})
+		return
+	}
+	if request.Status != RevaluationAssigned {
+		c.JSON(http.StatusConflict, gin.H{"error": errRevaluationState.Error()})
+		return
+	}
+
+	revised := roundMoney(*req.RevisedMarks)
+	grade := scale.Lookup(revised).Grade
+	now := time.Now()
+	updates := map[string]interface{}{
+		"status": RevaluationEvaluated, "revised_marks": revised, "revised_grade": grade,
+		"evaluated_at": now, "updated_at": now,
+	}
+	if req.Remarks != "" {
+		updates["evaluator_remarks"] = truncate(req.Remarks, 255)
+	}
+	res := db.Model(&models.RevaluationRequest{}).Where("request_id = ? AND status = ?", request.RequestID, RevaluationAssigned).Updates(updates)
+	if res.Error != nil || res.RowsAffected == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": errRevaluationState.Error()})
+		return
+	}
+	middleware.Audit(c, "revaluation.evaluate", "revaluation_requests", strconv.FormatInt(request.RequestID, 10), nil,
+		gin.H{"original_marks": request.OriginalMarks, "revised_marks": revised, "revised_grade": grade})
+	SendAdminNotification("revaluation_evaluated", gin.H{"request_id": request.RequestID, "subject_code": request.SubjectCode})
+	c.JSON(http.StatusOK, gin.H{"message": "revised mark submitted for approval", "revised_marks": revised, "revised_grade": grade})
+}
+
+// ReviewRevaluation approves or rejects an evaluated request. Approval writes the revised mark to
+// student_marks (the original stays on the request) and recomputes the affected semester results.
+// Either way the student is emailed the outcome.
+func ReviewRevaluation(c *gin.Context) {
+	var req struct {
+		Action  string `json:"action" binding:"required,oneof=approve reject"`
+		Remarks string `json:"remarks"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	db := config.DB
+	uid := c.GetInt64("user_id")
+	var request models.RevaluationRequest
+	if err := db.Where("request_id = ?", c.Param("id")).First(&request).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "revaluation request not found"})
+		return
+	}
+	if request.EvaluatorID != nil && *request.EvaluatorID == uid {
+		c.JSON(http.StatusForbidden, gin.H{"error": "the evaluator cannot approve their own revision"})
+		return
+	}
+	approved := req.Action == "approve"
+	status := RevaluationRejected
+	if approved {
+		status = RevaluationApproved
+	}
+
+	before := request
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("request_id = ?", request.RequestID).First(&request).Error; err != nil {
+			return err
+		}
+		if request.Status != RevaluationEvaluated || request.RevisedMarks == nil {
+			return errRevaluationState
+		}
+		now := time.Now()
+		updates := map[string]interface{}{"status": status, "reviewed_by": uid, "reviewed_at": now, "updated_at": now}
+		if req.Remarks != "" {
+			updates["review_remarks"] = truncate(req.Remarks, 255)
+		}
+		if err := tx.Model(&request).Updates(updates).Error; err != nil {
+			return err
+		}
+		request.Status = status
+
+		if approved && *request.RevisedMarks != request.OriginalMarks {
+			scale, err := loadGradingScale(tx)
+			if err != nil {
+				return err
+			}
+			markStatus := MarkStatusPass
+			if !scale.Lookup(*request.RevisedMarks).Passed() {
+				markStatus = MarkStatusFail
+			}
+			if err := reviseStudentMark(tx, request.EnrollmentNumber, request.Semester, request.SubjectCode,
+				*request.RevisedMarks, request.RevisedGrade, markStatus); err != nil {
+				return err
+			}
+			if err := recomputeResultsFrom(tx, request.EnrollmentNumber, request.Semester); err != nil {
+				return err
+			}
+		}
+
+		var user models.User
+		if tx.Where("username = ?", strconv.FormatInt(request.EnrollmentNumber, 10)).Limit(1).Find(&user).RowsAffected > 0 && user.Email != "" {
+			remarks := req.Remarks
+			if remarks == "" {
+				remarks = safeString(request.EvaluatorRemarks)
+			}
+			subject, body := revaluationOutcomeEmail(user.FullName, request.Kind, request.SubjectCode, request.Semester,
+				approved, request.OriginalMarks, request.RevisedMarks, remarks)
+			return QueueEmail(tx, user.Email, subject, body)
+		}
+		return nil
+	})
+	switch {
+	case errors.Is(err, errRevaluationState):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to review request: " + err.Error()})
+		return
+	}
+
+	middleware.Audit(c, "revaluation.review", "revaluation_requests", strconv.FormatInt(request.RequestID, 10), before, request)
+	c.JSON(http.StatusOK, gin.H{"message": "request " + status, "request": request})
+}
+
+// revaluationOutcomeEmail tells a student how a revaluation or re-totalling request ended
+func revaluationOutcomeEmail(fullName, kind, subjectCode string, semester int, approved bool, original float64, revised *float64, remarks string) (string, string) {
+	label := revaluationLabel(kind)
+	body := fmt.Sprintf("Hello %s,\n\nYour %s request for %s (semester %d) has been completed.\n\n", fullName, strings.ToLower(label), subjectCode, semester)
+	switch {
+	case !approved:
+		body += fmt.Sprintf("Your original mark of %.2f stands.\n", original)
+	case revised != nil && *revised != original:
+		body += fmt.Sprintf("Your mark has been changed from %.2f to %.2f and your semester result has been updated.\n", original, *revised)
+	default:
+		body += fmt.Sprintf("There is no change to your mark of %.2f.\n", original)
+	}
+	if remarks != "" {
+		body += "\nRemarks: " + remarks + "\n"
+	}
+	body += fmt.Sprintf("\nYou can see your results at %s.\n", config.AppBaseURL)
+	return label + " result for " + subjectCode, body
+}
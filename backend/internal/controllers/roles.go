@@ -0,0 +1,302 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/kiranraoboinapally/student/backend/internal/config"
+	"github.com/kiranraoboinapally/student/backend/internal/middleware"
+	"github.com/kiranraoboinapally/student/backend/internal/models"
+)
+
+// ======================== ROLES & PERMISSIONS ========================
+
+// GetPermissions lists the permission catalogue
+func GetPermissions(c *gin.Context) {
+	var perms []models.Permission
+	config.DB.Order("code").Find(&perms)
+	c.JSON(http.StatusOK, gin.H{"permissions": perms})
+}
+
+// GetRoles lists every role with its permission codes and user count
+func GetRoles(c *gin.Context) {
+	db := config.DB
+	var roles []models.Role
+	db.Order("role_id").Find(&roles)
+
+	type roleView struct {
+		models.Role
+		System      bool     `json:"system"`
+		Permissions []string `json:"permissions"`
+		UserCount   int64    `json:"user_count"`
+	}
+	out := make([]roleView, 0, len(roles))
+	for _, r := range roles {
+		var count int64
+		db.Model(&models.User{}).Where("role_id = ?", r.RoleID).Count(&count)
+		_, system := middleware.SystemRoles[r.RoleID]
+		out = append(out, roleView{
+			Role:        r,
+			System:      system,
+			Permissions: middleware.RolePermissions(r.RoleID),
+			UserCount:   count,
+		})
+	}
+	c.JSON(http.StatusOK, gin.H{"roles": out})
+}
+
+// GetMyPermissions returns the caller's permission codes so the UI can hide what they cannot use
+func GetMyPermissions(c *gin.Context) {
+	roleID := c.GetInt("role_id")
+	c.JSON(http.StatusOK, gin.H{"role_id": roleID, "permissions": middleware.RolePermissions(roleID)})
+}
+
+type RoleRequest struct {
+	RoleName    string   `json:"role_name"`
+	Permissions []string `json:"permissions"`
+}
+
+// resolvePermissions maps codes to permission rows, rejecting unknown codes
+func resolvePermissions(db *gorm.DB, codes []string) ([]models.Permission, string) {
+	var perms []models.Permission
+	if len(codes) == 0 {
+		return perms, ""
+	}
+	db.Where("code IN ?", codes).Find(&perms)
+	if len(perms) != len(uniqueStrings(codes)) {
+		known := make(map[string]bool, len(perms))
+		for _, p := range perms {
+			known[p.Code] = true
+		}
+		for _, code := range codes {
+			if !known[code] {
+				return nil, code
+			}
+		}
+	}
+	return perms, ""
+}
+
+func uniqueStrings(in []string) []string {
+	seen := make(map[string]bool, len(in))
+	out := make([]string, 0, len(in))
+	for _, s := range in {
+		if !seen[s] {
+			seen[s] = true
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+func setRolePermissions(tx *gorm.DB, roleID int, perms []models.Permission) error {
+	if err := tx.Where("role_id = ?", roleID).Delete(&models.RolePermission{}).Error; err != nil {
+		return err
+	}
+	for _, p := range perms {
+		if err := tx.Create(&models.RolePermission{RoleID: roleID, PermissionID: p.PermissionID}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CreateRole adds a custom role such as "Fee Clerk" with a chosen permission set
+func CreateRole(c *gin.Context) {
+	var req RoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.RoleName = strings.TrimSpace(req.RoleName)
+	if req.RoleName == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "role_name is required"})
+		return
+	}
+
+	db := config.DB
+	perms, unknown := resolvePermissions(db, req.Permissions)
+	if unknown != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unknown permission: " + unknown})
+		return
+	}
+
+	var existing int64
+	db.Model(&models.Role{}).Where("role_name = ?", req.RoleName).Count(&existing)
+	if existing > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "role name already exists"})
+		return
+	}
+
+	role := models.Role{RoleName: req.RoleName}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&role).Error; err != nil {
+			return err
+		}
+		return setRolePermissions(tx, role.RoleID, perms)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create role"})
+		return
+	}
+	middleware.InvalidatePermissionCache()
+
+	c.JSON(http.StatusCreated, gin.H{"message": "role created", "role_id": role.RoleID})
+}
+
+// UpdateRole renames a role and/or replaces its permission set
+func UpdateRole(c *gin.Context) {
+	roleID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid role id"})
+		return
+	}
+	var req RoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	db := config.DB
+	var role models.Role
+	if err := db.Where("role_id = ?", roleID).First(&role).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "role not found"})
+		return
+	}
+
+	perms, unknown := resolvePermissions(db, req.Permissions)
+	if unknown != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unknown permission: " + unknown})
+		return
+	}
+
+	// Stop admins from locking themselves out of role management
+	if req.Permissions != nil && roleID == c.GetInt("role_id") {
+		keeps := false
+		for _, p := range perms {
+			if p.Code == "roles.manage" {
+				keeps = true
+			}
+		}
+		if !keeps {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "cannot remove roles.manage from your own role"})
+			return
+		}
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if name := strings.TrimSpace(req.RoleName); name != "" && name != role.RoleName {
+			if err := tx.Model(&role).Update("role_name", name).Error; err != nil {
+				return err
+			}
+		}
+		if req.Permissions != nil {
+			return setRolePermissions(tx, roleID, perms)
+		}
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update role"})
+		return
+	}
+	middleware.InvalidatePermissionCache()
+
+	c.JSON(http.StatusOK, gin.H{"message": "role updated", "role_id": roleID})
+}
+
+// DeleteRole removes an unused custom role
+func DeleteRole(c *gin.Context) {
+	roleID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid role id"})
+		return
+	}
+	if _, system := middleware.SystemRoles[roleID]; system {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "built-in roles cannot be deleted"})
+		return
+	}
+
+	db := config.DB
+	var users int64
+	db.Model(&models.User{}).Where("role_id = ?", roleID).Count(&users)
+	if users > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "role is still assigned to users"})
+		return
+	}
+
+	var deleted int64
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("role_id = ?", roleID).Delete(&models.RolePermission{}).Error; err != nil {
+			return err
+		}
+		result := tx.Where("role_id = ?", roleID).Delete(&models.Role{})
+		deleted = result.RowsAffected
+		return result.Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete role"})
+		return
+	}
+	if deleted == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "role not found"})
+		return
+	}
+	middleware.InvalidatePermissionCache()
+
+	c.JSON(http.StatusOK, gin.H{"message": "role deleted"})
+}
+
+type AssignRoleRequest struct {
+	RoleID int `json:"role_id" binding:"required"`
+}
+
+// AssignUserRole moves a user to another role; takes effect on their next request
+func AssignUserRole(c *gin.Context) {
+	userID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+	var req AssignRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if userID == c.GetInt64("user_id") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "cannot change your own role"})
+		return
+	}
+
+	db := config.DB
+	var role models.Role
+	if err := db.Where("role_id = ?", req.RoleID).First(&role).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "role not found"})
+		return
+	}
+
+	var previousRole int
+	db.Model(&models.User{}).Select("role_id").Where("user_id = ?", userID).Scan(&previousRole)
+
+	result := db.Model(&models.User{}).Where("user_id = ?", userID).Update("role_id", req.RoleID)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to assign role"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		var count int64
+		db.Model(&models.User{}).Where("user_id = ?", userID).Count(&count)
+		if count == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+			return
+		}
+	}
+
+	middleware.Audit(c, "user.assign_role", "user", strconv.FormatInt(userID, 10),
+		gin.H{"role_id": previousRole}, gin.H{"role_id": req.RoleID})
+
+	c.JSON(http.StatusOK, gin.H{"message": "role assigned", "user_id": userID, "role_id": req.RoleID, "role_name": role.RoleName})
+}
@@ -0,0 +1,622 @@
+package controllers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/kiranraoboinapally/student/backend/internal/config"
+	"github.com/kiranraoboinapally/student/backend/internal/middleware"
+	"github.com/kiranraoboinapally/student/backend/internal/models"
+)
+
+// ======================== SCHOLARSHIPS & CONCESSIONS ========================
+
+// Scheme methods and grant statuses
+const (
+	ScholarshipPercentage = "percentage"
+	ScholarshipFixed      = "fixed"
+
+	GrantPending  = "pending"
+	GrantApproved = "approved"
+	GrantRejected = "rejected"
+	GrantRevoked  = "revoked"
+)
+
+func schemeFeeTypes(s models.ScholarshipScheme) []int {
+	var ids []int
+	if s.FeeTypeIDs != nil && *s.FeeTypeIDs != "" {
+		json.Unmarshal([]byte(*s.FeeTypeIDs), &ids)
+	}
+	return ids
+}
+
+// schemeCovers reports whether the scheme applies to a fee type; no list means every fee type
+func schemeCovers(s models.ScholarshipScheme, feeTypeID int) bool {
+	ids := schemeFeeTypes(s)
+	if len(ids) == 0 {
+		return true
+	}
+	for _, id := range ids {
+		if id == feeTypeID {
+			return true
+		}
+	}
+	return false
+}
+
+// checkScholarshipEligibility returns why a student does not qualify for a scheme, or nil
+func checkScholarshipEligibility(db *gorm.DB, s models.ScholarshipScheme, enrollment int64) error {
+	var student models.MasterStudent
+	if err := db.Where("enrollment_number = ?", enrollment).First(&student).Error; err != nil {
+		return errors.New("student not found")
+	}
+	match := func(want, have *string) bool {
+		return want == nil || *want == "" || (have != nil && strings.EqualFold(strings.TrimSpace(*want), strings.TrimSpace(*have)))
+	}
+	if !match(s.CourseName, student.CourseName) {
+		return errors.New("course does not match the scheme")
+	}
+	if !match(s.Batch, student.Batch) {
+		return errors.New("batch does not match the scheme")
+	}
+	if !match(s.Session, student.Session) {
+		return errors.New("session does not match the scheme")
+	}
+	if s.MinCGPA != nil && *s.MinCGPA > 0 {
+		var latest models.SemesterResult
+		if db.Where("enrollment_number = ?", enrollment).Order("semester DESC").Limit(1).Find(&latest).RowsAffected == 0 {
+			return errors.New("no semester result to check CGPA against")
+		}
+		if latest.CGPA < *s.MinCGPA {
+			return fmt.Errorf("CGPA %.2f is below the required %.2f", latest.CGPA, *s.MinCGPA)
+		}
+	}
+	return nil
+}
+
+// grantWaiverReference ties a concession entry to its grant and charge so it is posted once
+func grantWaiverReference(grantID, chargeID int64) string {
+	return fmt.Sprintf("scholarship:%d:%d", grantID, chargeID)
+}
+
+// postGrantWaiver posts the concession one grant gives on one charge. base is the charge amount the
+// concession is computed on (negative for a downward adjustment); limit caps a positive concession.
+// Fixed amounts and caps apply per fee type across all of the grant's charges.
+func postGrantWaiver(tx *gorm.DB, grant models.ScholarshipGrant, scheme models.ScholarshipScheme, charge models.FeeLedgerEntry, base, limit float64) (float64, error) {
+	var already float64
+	tx.Model(&models.FeeLedgerEntry{}).Select("COALESCE(SUM(credit - debit),0)").
+		Where("entry_type = ? AND fee_type_id = ? AND reference LIKE ?", LedgerWaiver, charge.FeeTypeID,
+			fmt.Sprintf("scholarship:%d:%%", grant.GrantID)).
+		Scan(&already)
+
+	var amount float64
+	switch scheme.Method {
+	case ScholarshipPercentage:
+		amount = base * scheme.Value / 100
+	case ScholarshipFixed:
+		if base <= 0 {
+			return 0, nil
+		}
+		amount = scheme.Value - already
+	}
+	if scheme.MaxAmount > 0 && amount > scheme.MaxAmount-already {
+		amount = scheme.MaxAmount - already
+	}
+	if amount > limit {
+		amount = limit
+	}
+	if amount < -already {
+		amount = -already
+	}
+	amount = roundMoney(amount)
+	if amount == 0 {
+		return 0, nil
+	}
+
+	chargeID := charge.EntryID
+	entry := models.FeeLedgerEntry{
+		EnrollmentNumber: charge.EnrollmentNumber,
+		FeeTypeID:        charge.FeeTypeID,
+		EntryType:        LedgerWaiver,
+		Reference:        ptrString(grantWaiverReference(grant.GrantID, chargeID)),
+		ParentEntryID:    &chargeID,
+		Semester:         charge.Semester,
+		InstallmentNo:    charge.InstallmentNo,
+		Description:      ptrString(truncate(scheme.Name, 255)),
+		CreatedBy:        grant.ReviewedBy,
+	}
+	if amount > 0 {
+		entry.Credit = amount
+	} else {
+		entry.Debit = -amount
+	}
+	inserted, err := postLedgerEntry(tx, &entry)
+	if err != nil || !inserted {
+		return 0, err
+	}
+	return amount, nil
+}
+
+// applyScholarships posts the student's approved concessions on a newly posted charge or adjustment
+func applyScholarships(tx *gorm.DB, charge models.FeeLedgerEntry) error {
+	net := charge.Debit - charge.Credit
+	if net == 0 {
+		return nil
+	}
+	var grants []models.ScholarshipGrant
+	if err := tx.Where("enrollment_number = ? AND status = ?", charge.EnrollmentNumber, GrantApproved).
+		Order("grant_id").Find(&grants).Error; err != nil {
+		return err
+	}
+	remaining := net
+	for _, g := range grants {
+		var scheme models.ScholarshipScheme
+		if tx.Where("scheme_id = ? AND is_active = ?", g.SchemeID, true).Limit(1).Find(&scheme).RowsAffected == 0 ||
+			!schemeCovers(scheme, charge.FeeTypeID) {
+			continue
+		}
+		limit := remaining
+		if net < 0 {
+			limit = 0
+		}
+		applied, err := postGrantWaiver(tx, g, scheme, charge, net, limit)
+		if err != nil {
+			return err
+		}
+		remaining -= applied
+	}
+	return nil
+}
+
+// postCharge posts a charge (or adjustment) and the concessions the student is entitled to on it.
+// Every dues path goes through here so approved scholarships apply automatically.
+func postCharge(tx *gorm.DB, entry *models.FeeLedgerEntry) (bool, error) {
+	inserted, err := postLedgerEntry(tx, entry)
+	if err != nil || !inserted {
+		return inserted, err
+	}
+	return true, applyScholarships(tx, *entry)
+}
+
+// applyGrantToOpenCharges gives a newly approved grant on the unpaid part of charges already posted
+func applyGrantToOpenCharges(tx *gorm.DB, grant models.ScholarshipGrant, scheme models.ScholarshipScheme) (float64, error) {
+	var feeTypeIDs []int
+	tx.Model(&models.FeeLedgerEntry{}).Where("enrollment_number = ? AND entry_type = ?", grant.EnrollmentNumber, LedgerCharge).
+		Distinct().Pluck("fee_type_id", &feeTypeIDs)
+	var total float64
+	for _, feeTypeID := range feeTypeIDs {
+		if !schemeCovers(scheme, feeTypeID) {
+			continue
+		}
+		charges, err := openCharges(tx, grant.EnrollmentNumber, feeTypeID)
+		if err != nil {
+			return total, err
+		}
+		for _, ch := range charges {
+			if ch.Outstanding <= 0 {
+				continue
+			}
+			var charge models.FeeLedgerEntry
+			if err := tx.Where("entry_id = ?", ch.EntryID).First(&charge).Error; err != nil {
+				return total, err
+			}
+			applied, err := postGrantWaiver(tx, grant, scheme, charge, ch.Amount, ch.Outstanding)
+			if err != nil {
+				return total, err
+			}
+			total += applied
+		}
+	}
+	return roundMoney(total), nil
+}
+
+// ======================== SCHEMES ========================
+
+type scholarshipSchemeRequest struct {
+	Name       string   `json:"name" binding:"required"`
+	Kind       string   `json:"kind" binding:"required,oneof=scholarship concession"`
+	Method     string   `json:"method" binding:"required,oneof=percentage fixed"`
+	Value      float64  `json:"value" binding:"gt=0"`
+	MaxAmount  float64  `json:"max_amount" binding:"gte=0"`
+	FeeTypeIDs []int    `json:"fee_type_ids"`
+	CourseName *string  `json:"course_name"`
+	Batch      *string  `json:"batch"`
+	Session    *string  `json:"session"`
+	MinCGPA    *float64 `json:"min_cgpa"`
+	IsActive   *bool    `json:"is_active"`
+}
+
+func (r scholarshipSchemeRequest) apply(s *models.ScholarshipScheme) error {
+	if r.Method == ScholarshipPercentage && r.Value > 100 {
+		return errors.New("percentage cannot exceed 100")
+	}
+	s.Name = strings.TrimSpace(r.Name)
+	s.Kind = r.Kind
+	s.Method = r.Method
+	s.Value = roundMoney(r.Value)
+	s.MaxAmount = roundMoney(r.MaxAmount)
+	s.FeeTypeIDs = nil
+	if len(r.FeeTypeIDs) > 0 {
+		raw, _ := json.Marshal(r.FeeTypeIDs)
+		s.FeeTypeIDs = ptrString(string(raw))
+	}
+	s.CourseName, s.Batch, s.Session, s.MinCGPA = r.CourseName, r.Batch, r.Session, r.MinCGPA
+	s.IsActive = r.IsActive == nil || *r.IsActive
+	s.UpdatedAt = time.Now()
+	return nil
+}
+
+// GetScholarshipSchemes lists schemes with their approved grant counts
+func GetScholarshipSchemes(c *gin.Context) {
+	var schemes []struct {
+		models.ScholarshipScheme
+		ApprovedGrants int64 `json:"approved_grants"`
+		PendingGrants  int64 `json:"pending_grants"`
+	}
+	if err := config.DB.Table("scholarship_schemes").
+		Select(`scholarship_schemes.*,
+			(SELECT COUNT(*) FROM scholarship_grants g WHERE g.scheme_id = scholarship_schemes.scheme_id AND g.status = 'approved') AS approved_grants,
+			(SELECT COUNT(*) FROM scholarship_grants g WHERE g.scheme_id = scholarship_schemes.scheme_id AND g.status = 'pending') AS pending_grants`).
+		Order("scholarship_schemes.name").Scan(&schemes).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load schemes"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"schemes": schemes})
+}
+
+// CreateScholarshipScheme defines a scholarship or concession
+func CreateScholarshipScheme(c *gin.Context) {
+	var req scholarshipSchemeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	scheme := models.ScholarshipScheme{CreatedAt: time.Now()}
+	if err := req.apply(&scheme); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if uid := c.GetInt64("user_id"); uid != 0 {
+		scheme.CreatedBy = &uid
+	}
+	if err := config.DB.Create(&scheme).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create scheme"})
+		return
+	}
+	middleware.Audit(c, "scholarships.scheme.create", "scholarship_scheme", strconv.Itoa(scheme.SchemeID), nil, scheme)
+	c.JSON(http.StatusCreated, gin.H{"message": "scheme created", "scheme": scheme})
+}
+
+// UpdateScholarshipScheme edits a scheme. Concessions already posted are not recalculated.
+func UpdateScholarshipScheme(c *gin.Context) {
+	var req scholarshipSchemeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	db := config.DB
+	var scheme models.ScholarshipScheme
+	if err := db.Where("scheme_id = ?", c.Param("id")).First(&scheme).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "scheme not found"})
+		return
+	}
+	before := scheme
+	if err := req.apply(&scheme); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := db.Save(&scheme).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update scheme"})
+		return
+	}
+	middleware.Audit(c, "scholarships.scheme.update", "scholarship_scheme", strconv.Itoa(scheme.SchemeID), before, scheme)
+	c.JSON(http.StatusOK, gin.H{"message": "scheme updated", "scheme": scheme})
+}
+
+// ======================== GRANTS ========================
+
+// GetScholarshipGrants lists grants, filterable by status, scheme and student
+func GetScholarshipGrants(c *gin.Context) {
+	q := config.DB.Table("scholarship_grants").
+		Select("scholarship_grants.*, scholarship_schemes.name AS scheme_name, master_students.student_name").
+		Joins("JOIN scholarship_schemes ON scholarship_schemes.scheme_id = scholarship_grants.scheme_id").
+		Joins("LEFT JOIN master_students ON master_students.enrollment_number = scholarship_grants.enrollment_number")
+	if status := c.Query("status"); status != "" {
+		q = q.Where("scholarship_grants.status = ?", status)
+	}
+	if schemeID := c.Query("scheme_id"); schemeID != "" {
+		q = q.Where("scholarship_grants.scheme_id = ?", schemeID)
+	}
+	if enrollment := c.Query("enrollment_number"); enrollment != "" {
+		q = q.Where("scholarship_grants.enrollment_number = ?", enrollment)
+	}
+	var grants []struct {
+		models.ScholarshipGrant
+		SchemeName  string  `json:"scheme_name"`
+		StudentName *string `json:"student_name"`
+	}
+	if err := q.Order("scholarship_grants.grant_id DESC").Limit(1000).Scan(&grants).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load grants"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"grants": grants})
+}
+
+// CreateScholarshipGrants proposes a scheme for one or more students. Ineligible students are
+// skipped with the reason; the rest wait for approval.
+func CreateScholarshipGrants(c *gin.Context) {
+	var req struct {
+		SchemeID          int     `json:"scheme_id" binding:"required"`
+		EnrollmentNumbers []int64 `json:"enrollment_numbers" binding:"required,min=1"`
+		Remarks           string  `json:"remarks"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	db := config.DB
+	var scheme models.ScholarshipScheme
+	if err := db.Where("scheme_id = ? AND is_active = ?", req.SchemeID, true).First(&scheme).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "scheme not found or inactive"})
+		return
+	}
+
+	var requestedBy *int64
+	if uid := c.GetInt64("user_id"); uid != 0 {
+		requestedBy = &uid
+	}
+	created := []models.ScholarshipGrant{}
+	skipped := []gin.H{}
+	for _, enrollment := range req.EnrollmentNumbers {
+		if err := checkScholarshipEligibility(db, scheme, enrollment); err != nil {
+			skipped = append(skipped, gin.H{"enrollment_number": enrollment, "reason": err.Error()})
+			continue
+		}
+		now := time.Now()
+		grant := models.ScholarshipGrant{
+			SchemeID:         scheme.SchemeID,
+			EnrollmentNumber: enrollment,
+			Status:           GrantPending,
+			RequestedBy:      requestedBy,
+			CreatedAt:        now,
+			UpdatedAt:        now,
+		}
+		if req.Remarks != "" {
+			grant.Remarks = ptrString(truncate(req.Remarks, 255))
+		}
+		if err := db.Create(&grant).Error; err != nil {
+			skipped = append(skipped, gin.H{"enrollment_number": enrollment, "reason": "already granted or requested"})
+			continue
+		}
+		created = append(created, grant)
+	}
+
+	middleware.Audit(c, "scholarships.grant.request", "scholarship_scheme", strconv.Itoa(scheme.SchemeID), nil,
+		gin.H{"created": len(created), "skipped": skipped})
+	if len(created) > 0 {
+		SendAdminNotification("scholarship_grants_pending", gin.H{"scheme_id": scheme.SchemeID, "count": len(created)})
+	}
+	c.JSON(http.StatusCreated, gin.H{"created": created, "skipped": skipped})
+}
+
+// ReviewScholarshipGrant approves, rejects or revokes a grant. The reviewer must not be the person
+// who requested it. Approval applies the concession to the student's unpaid charges straight away;
+// revoking stops future concessions but leaves those already posted.
+func ReviewScholarshipGrant(c *gin.Context) {
+	var req struct {
+		Action  string `json:"action" binding:"required,oneof=approve reject revoke"`
+		Remarks string `json:"remarks"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	uid := c.GetInt64("user_id")
+
+	db := config.DB
+	var grant models.ScholarshipGrant
+	if err := db.Where("grant_id = ?", c.Param("id")).First(&grant).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "grant not found"})
+		return
+	}
+	var scheme models.ScholarshipScheme
+	if err := db.Where("scheme_id = ?", grant.SchemeID).First(&scheme).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "scheme not found"})
+		return
+	}
+
+	from, to := GrantPending, GrantApproved
+	switch req.Action {
+	case "reject":
+		to = GrantRejected
+	case "revoke":
+		from, to = GrantApproved, GrantRevoked
+	}
+	if grant.Status != from {
+		c.JSON(http.StatusConflict, gin.H{"error": "grant is " + grant.Status})
+		return
+	}
+	if req.Action == "approve" {
+		if grant.RequestedBy != nil && *grant.RequestedBy == uid {
+			c.JSON(http.StatusForbidden, gin.H{"error": "a grant must be approved by someone other than the requester"})
+			return
+		}
+		if !scheme.IsActive {
+			c.JSON(http.StatusConflict, gin.H{"error": "scheme is inactive"})
+			return
+		}
+		if err := checkScholarshipEligibility(db, scheme, grant.EnrollmentNumber); err != nil {
+			c.JSON(http.StatusConflict, gin.H{"error": "student is no longer eligible: " + err.Error()})
+			return
+		}
+	}
+
+	before := grant.Status
+	var applied float64
+	err := db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		updates := map[string]interface{}{"status": to, "reviewed_by": uid, "reviewed_at": now, "updated_at": now}
+		if req.Remarks != "" {
+			updates["remarks"] = truncate(req.Remarks, 255)
+		}
+		res := tx.Model(&models.ScholarshipGrant{}).Where("grant_id = ? AND status = ?", grant.GrantID, from).Updates(updates)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return errNothingToReverse
+		}
+		if to != GrantApproved {
+			return nil
+		}
+		grant.Status = to
+		grant.ReviewedBy = &uid
+		var err error
+		applied, err = applyGrantToOpenCharges(tx, grant, scheme)
+		if err != nil {
+			return err
+		}
+
+		var user models.User
+		if tx.Where("username = ?", strconv.FormatInt(grant.EnrollmentNumber, 10)).Limit(1).Find(&user).RowsAffected > 0 && user.Email != "" {
+			subject, body := scholarshipApprovedEmail(user.FullName, scheme.Name, applied)
+			return QueueEmail(tx, user.Email, subject, body)
+		}
+		return nil
+	})
+	if err != nil {
+		if err == errNothingToReverse {
+			c.JSON(http.StatusConflict, gin.H{"error": "grant was updated by someone else"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update grant"})
+		return
+	}
+
+	middleware.Audit(c, "scholarships.grant."+req.Action, "scholarship_grant", strconv.FormatInt(grant.GrantID, 10),
+		gin.H{"status": before}, gin.H{"status": to, "applied": applied, "remarks": req.Remarks})
+
+	c.JSON(http.StatusOK, gin.H{"message": "grant " + to, "grant_id": grant.GrantID, "status": to, "applied": applied})
+}
+
+// GetStudentScholarships lists the caller's scholarships and what they have saved so far
+func GetStudentScholarships(c *gin.Context) {
+	enrollment, err := getEnrollmentOrError(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	var grants []struct {
+		GrantID    int64   `json:"grant_id"`
+		SchemeName string  `json:"scheme_name"`
+		Kind       string  `json:"kind"`
+		Method     string  `json:"method"`
+		Value      float64 `json:"value"`
+		Status     string  `json:"status"`
+		Disbursed  float64 `json:"disbursed"`
+	}
+	config.DB.Table("scholarship_grants g").
+		Select(`g.grant_id, s.name AS scheme_name, s.kind, s.method, s.value, g.status,
+			COALESCE((SELECT SUM(l.credit - l.debit) FROM fee_ledger l
+				WHERE l.entry_type = 'waiver' AND l.reference LIKE CONCAT('scholarship:', g.grant_id, ':%')),0) AS disbursed`).
+		Joins("JOIN scholarship_schemes s ON s.scheme_id = g.scheme_id").
+		Where("g.enrollment_number = ?", enrollment).
+		Order("g.grant_id").Scan(&grants)
+	c.JSON(http.StatusOK, gin.H{"scholarships": grants})
+}
+
+// ======================== DISBURSEMENT REPORT ========================
+
+type schemeDisbursement struct {
+	SchemeID       int     `json:"scheme_id"`
+	Name           string  `json:"name"`
+	Kind           string  `json:"kind"`
+	Method         string  `json:"method"`
+	Value          float64 `json:"value"`
+	ApprovedGrants int64   `json:"approved_grants"`
+	Beneficiaries  int64   `json:"beneficiaries"`
+	Disbursed      float64 `json:"disbursed"`
+}
+
+// GetScholarshipDisbursementReport totals the concessions each scheme has given, optionally over a
+// date range (from / to, YYYY-MM-DD). ?format=csv downloads it.
+func GetScholarshipDisbursementReport(c *gin.Context) {
+	ledgerFilter := "l.entry_type = 'waiver' AND l.reference LIKE CONCAT('scholarship:', g.grant_id, ':%')"
+	var args []interface{}
+	if from := c.Query("from"); from != "" {
+		if _, err := time.Parse("2006-01-02", from); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "from must be YYYY-MM-DD"})
+			return
+		}
+		ledgerFilter += " AND l.created_at >= ?"
+		args = append(args, from)
+	}
+	if to := c.Query("to"); to != "" {
+		t, err := time.Parse("2006-01-02", to)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "to must be YYYY-MM-DD"})
+			return
+		}
+		ledgerFilter += " AND l.created_at < ?"
+		args = append(args, t.AddDate(0, 0, 1).Format("2006-01-02"))
+	}
+
+	var rows []schemeDisbursement
+	if err := config.DB.Raw(`
+		SELECT s.scheme_id, s.name, s.kind, s.method, s.value,
+			(SELECT COUNT(*) FROM scholarship_grants a WHERE a.scheme_id = s.scheme_id AND a.status = 'approved') AS approved_grants,
+			COUNT(DISTINCT l.enrollment_number) AS beneficiaries,
+			COALESCE(SUM(l.credit - l.debit),0) AS disbursed
+		FROM scholarship_schemes s
+		LEFT JOIN scholarship_grants g ON g.scheme_id = s.scheme_id
+		LEFT JOIN fee_ledger l ON `+ledgerFilter+`
+		GROUP BY s.scheme_id, s.name, s.kind, s.method, s.value
+		ORDER BY s.name`, args...).Scan(&rows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to build report"})
+		return
+	}
+
+	if c.Query("format") != "csv" {
+		var total float64
+		for _, r := range rows {
+			total += r.Disbursed
+		}
+		c.JSON(http.StatusOK, gin.H{"schemes": rows, "total_disbursed": roundMoney(total)})
+		return
+	}
+
+	filename := "scholarship-disbursements-" + time.Now().Format("20060102-150405") + ".csv"
+	c.Header("Content-Type", "text/csv")
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	w := csv.NewWriter(c.Writer)
+	w.Write([]string{"scheme_id", "name", "kind", "method", "value", "approved_grants", "beneficiaries", "disbursed"})
+	for _, r := range rows {
+		w.Write([]string{
+			strconv.Itoa(r.SchemeID), r.Name, r.Kind, r.Method,
+			strconv.FormatFloat(r.Value, 'f', 2, 64),
+			strconv.FormatInt(r.ApprovedGrants, 10),
+			strconv.FormatInt(r.Beneficiaries, 10),
+			strconv.FormatFloat(r.Disbursed, 'f', 2, 64),
+		})
+	}
+	w.Flush()
+}
+
+// scholarshipApprovedEmail tells a student about an approved grant and what it took off their dues
+func scholarshipApprovedEmail(fullName, scheme string, applied float64) (string, string) {
+	body := fmt.Sprintf("Hello %s,\n\nYou have been awarded %s.\n\n", fullName, scheme)
+	if applied > 0 {
+		body += fmt.Sprintf("Rs. %.2f has been taken off your outstanding fees. ", applied)
+	}
+	body += fmt.Sprintf("It will also be applied to future dues automatically. You can see your updated balance at %s.\n", config.AppBaseURL)
+	return "Scholarship awarded", body
+}
@@ -0,0 +1,366 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/kiranraoboinapally/student/backend/internal/config"
+	"github.com/kiranraoboinapally/student/backend/internal/middleware"
+	"github.com/kiranraoboinapally/student/backend/internal/models"
+)
+
+// ======================== EXAM ROOMS & SEATING PLANS ========================
+
+type examRoomRequest struct {
+	Name          string `json:"name" binding:"required"`
+	Building      string `json:"building"`
+	Rows          int    `json:"rows" binding:"required,min=1"`
+	BenchesPerRow int    `json:"benches_per_row" binding:"required,min=1"`
+	SeatsPerBench int    `json:"seats_per_bench" binding:"omitempty,min=1,max=4"`
+	IsActive      *bool  `json:"is_active"`
+}
+
+func (r examRoomRequest) apply(room *models.ExamRoom) {
+	room.Name = strings.TrimSpace(r.Name)
+	room.Building = nil
+	if b := strings.TrimSpace(r.Building); b != "" {
+		room.Building = &b
+	}
+	room.Rows = r.Rows
+	room.BenchesPerRow = r.BenchesPerRow
+	room.SeatsPerBench = r.SeatsPerBench
+	if room.SeatsPerBench == 0 {
+		room.SeatsPerBench = 2
+	}
+	room.IsActive = r.IsActive == nil || *r.IsActive
+	room.UpdatedAt = time.Now()
+}
+
+// GetExamRooms lists exam rooms with their capacity
+func GetExamRooms(c *gin.Context) {
+	var rooms []models.ExamRoom
+	if err := config.DB.Order("name").Find(&rooms).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load rooms"})
+		return
+	}
+	type roomView struct {
+		models.ExamRoom
+		Capacity int `json:"capacity"`
+	}
+	out := make([]roomView, len(rooms))
+	for i, r := range rooms {
+		out[i] = roomView{ExamRoom: r, Capacity: r.Rows * r.BenchesPerRow * r.SeatsPerBench}
+	}
+	c.JSON(http.StatusOK, gin.H{"rooms": out})
+}
+
+// CreateExamRoom adds an exam room
+func CreateExamRoom(c *gin.Context) {
+	var req examRoomRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	room := models.ExamRoom{CreatedAt: time.Now()}
+	req.apply(&room)
+	var clash int64
+	config.DB.Model(&models.ExamRoom{}).Where("name = ?", room.Name).Count(&clash)
+	if clash > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "a room with this name already exists"})
+		return
+	}
+	if err := config.DB.Create(&room).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create room"})
+		return
+	}
+	middleware.Audit(c, "exams.room.create", "exam_rooms", strconv.Itoa(room.RoomID), nil, room)
+	c.JSON(http.StatusCreated, gin.H{"message": "room created", "room": room})
+}
+
+// UpdateExamRoom edits a room's layout or retires it
+func UpdateExamRoom(c *gin.Context) {
+	var req examRoomRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	db := config.DB
+	var room models.ExamRoom
+	if err := db.Where("room_id = ?", c.Param("id")).First(&room).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "room not found"})
+		return
+	}
+	before := room
+	req.apply(&room)
+	var clash int64
+	db.Model(&models.ExamRoom{}).Where("name = ? AND room_id <> ?", room.Name, room.RoomID).Count(&clash)
+	if clash > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "a room with this name already exists"})
+		return
+	}
+	if err := db.Save(&room).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update room"})
+		return
+	}
+	middleware.Audit(c, "exams.room.update", "exam_rooms", strconv.Itoa(room.RoomID), before, room)
+	c.JSON(http.StatusOK, gin.H{"message": "room updated", "room": room})
+}
+
+// seatCandidate is a hall ticket holder sitting a paper in the slot being planned
+type seatCandidate struct {
+	EnrollmentNumber int64
+	SubjectCode      string
+}
+
+// slotCandidates lists the hall ticket holders with a paper starting at date and start: regular
+// candidates of each scheduled course and semester, and locked reappear registrants of each subject
+func slotCandidates(db *gorm.DB, session models.ExamSession, date time.Time, start string) ([]seatCandidate, error) {
+	var entries []models.DateSheetEntry
+	if err := db.Where("exam_session_id = ? AND exam_date = ? AND start_time = ?", session.ExamSessionID, date, start).
+		Find(&entries).Error; err != nil {
+		return nil, err
+	}
+	seen := map[int64]bool{}
+	var out []seatCandidate
+	add := func(enrollments []int64, subject string) {
+		for _, e := range enrollments {
+			if !seen[e] {
+				seen[e] = true
+				out = append(out, seatCandidate{EnrollmentNumber: e, SubjectCode: subject})
+			}
+		}
+	}
+	for _, e := range entries {
+		if session.ExamType != "supplementary" {
+			var regular []int64
+			if err := db.Model(&models.HallTicket{}).
+				Where("exam_session_id = ? AND course_name = ? AND semester = ?", session.ExamSessionID, e.CourseName, e.Semester).
+				Order("enrollment_number").Pluck("enrollment_number", &regular).Error; err != nil {
+				return nil, err
+			}
+			add(regular, e.SubjectCode)
+		}
+		var backlog []int64
+		if err := db.Table("reappear_students r").
+			Joins("JOIN hall_tickets t ON t.exam_session_id = r.exam_session_id AND t.enrollment_number = r.enrollment_no").
+			Where("r.exam_session_id = ? AND r.subject_code = ? AND r.status = ? AND t.course_name = ?",
+				session.ExamSessionID, e.SubjectCode, ReappearLocked, e.CourseName).
+			Order("r.enrollment_no").Pluck("r.enrollment_no", &backlog).Error; err != nil {
+			return nil, err
+		}
+		add(backlog, e.SubjectCode)
+	}
+	return out, nil
+}
+
+// interleaveBySubject orders candidates so that students next to each other sit different papers.
+// Each subject's students stay in roll-number order; at every step the subject with the most
+// students left, other than the one just placed, goes next.
+func interleaveBySubject(candidates []seatCandidate) []seatCandidate {
+	queues := map[string][]seatCandidate{}
+	var subjects []string
+	for _, c := range candidates {
+		if _, ok := queues[c.SubjectCode]; !ok {
+			subjects = append(subjects, c.SubjectCode)
+		}
+		queues[c.SubjectCode] = append(queues[c.SubjectCode], c)
+	}
+	sort.Strings(subjects)
+	for _, s := range subjects {
+		q := queues[s]
+		sort.Slice(q, func(i, j int) bool { return q[i].EnrollmentNumber < q[j].EnrollmentNumber })
+	}
+
+	out := make([]seatCandidate, 0, len(candidates))
+	last := ""
+	for len(out) < len(candidates) {
+		pick := ""
+		for _, s := range subjects {
+			if len(queues[s]) == 0 || (s == last && len(subjects) > 1) {
+				continue
+			}
+			if pick == "" || len(queues[s]) > len(queues[pick]) {
+				pick = s
+			}
+		}
+		if pick == "" {
+			// Only the subject just placed has students left
+			pick = last
+		}
+		out = append(out, queues[pick][0])
+		queues[pick] = queues[pick][1:]
+		last = pick
+	}
+	return out
+}
+
+// GenerateSeatingPlan allocates the students sitting papers in one slot to benches in the chosen
+// rooms, filling rooms in the order given and each bench seat by seat, with subjects interleaved.
+// Any earlier plan for the slot is replaced.
+func GenerateSeatingPlan(c *gin.Context) {
+	var req struct {
+		ExamDate  string `json:"exam_date" binding:"required"` // YYYY-MM-DD
+		StartTime string `json:"start_time" binding:"required"`
+		RoomIDs   []int  `json:"room_ids" binding:"required,min=1"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	date, err := time.Parse("2006-01-02", req.ExamDate)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "exam_date must be YYYY-MM-DD"})
+		return
+	}
+	mins, ok := clockMinutes(req.StartTime)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "start_time must be HH:MM"})
+		return
+	}
+	start := fmt.Sprintf("%02d:%02d", mins/60, mins%60)
+
+	db := config.DB
+	var session models.ExamSession
+	if err := db.Where("exam_session_id = ?", c.Param("id")).First(&session).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "exam session not found"})
+		return
+	}
+	var found []models.ExamRoom
+	db.Where("room_id IN ? AND is_active = ?", req.RoomIDs, true).Find(&found)
+	byID := map[int]models.ExamRoom{}
+	for _, r := range found {
+		byID[r.RoomID] = r
+	}
+	var rooms []models.ExamRoom
+	capacity := 0
+	for _, id := range req.RoomIDs {
+		r, ok := byID[id]
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("room %d not found or inactive", id)})
+			return
+		}
+		delete(byID, id) // ignore repeats
+		rooms = append(rooms, r)
+		capacity += r.Rows * r.BenchesPerRow * r.SeatsPerBench
+	}
+
+	candidates, err := slotCandidates(db, session, date, start)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load candidates"})
+		return
+	}
+	if len(candidates) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "no hall ticket holders have a paper in this slot"})
+		return
+	}
+	if len(candidates) > capacity {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error": fmt.Sprintf("%d students need seats but the rooms hold %d", len(candidates), capacity),
+		})
+		return
+	}
+
+	ordered := interleaveBySubject(candidates)
+	now := time.Now()
+	allocations := make([]models.SeatAllocation, 0, len(ordered))
+	type roomSummary struct {
+		RoomID    int            `json:"room_id"`
+		Name      string         `json:"name"`
+		Capacity  int            `json:"capacity"`
+		Allocated int            `json:"allocated"`
+		Subjects  map[string]int `json:"subjects"`
+	}
+	summary := []roomSummary{}
+	next := 0
+	for _, r := range rooms {
+		if next == len(ordered) {
+			break
+		}
+		s := roomSummary{RoomID: r.RoomID, Name: r.Name, Capacity: r.Rows * r.BenchesPerRow * r.SeatsPerBench, Subjects: map[string]int{}}
+		for row := 1; row <= r.Rows && next < len(ordered); row++ {
+			for bench := 1; bench <= r.BenchesPerRow && next < len(ordered); bench++ {
+				for seat := 1; seat <= r.SeatsPerBench && next < len(ordered); seat++ {
+					cand := ordered[next]
+					next++
+					allocations = append(allocations, models.SeatAllocation{
+						ExamSessionID: session.ExamSessionID, ExamDate: date, StartTime: start,
+						EnrollmentNumber: cand.EnrollmentNumber, RoomID: r.RoomID,
+						BenchRow: row, BenchNo: bench, Seat: seat, SubjectCode: cand.SubjectCode, CreatedAt: now,
+					})
+					s.Allocated++
+					s.Subjects[cand.SubjectCode]++
+				}
+			}
+		}
+		summary = append(summary, s)
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("exam_session_id = ? AND exam_date = ? AND start_time = ?", session.ExamSessionID, date, start).
+			Delete(&models.SeatAllocation{}).Error; err != nil {
+			return err
+		}
+		return tx.CreateInBatches(&allocations, 500).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save seating plan"})
+		return
+	}
+	middleware.Audit(c, "exams.seating.generate", "seat_allocations", strconv.Itoa(session.ExamSessionID), nil,
+		gin.H{"exam_date": req.ExamDate, "start_time": start, "students": len(allocations), "rooms": req.RoomIDs})
+	c.JSON(http.StatusOK, gin.H{"message": fmt.Sprintf("%d students seated", len(allocations)), "rooms": summary})
+}
+
+// GetSeatingPlan returns the seating plan of one slot in room, row, bench and seat order, as JSON or
+// exported with format=csv|xlsx for display outside the rooms
+func GetSeatingPlan(c *gin.Context) {
+	date, err := time.Parse("2006-01-02", c.Query("exam_date"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "exam_date must be YYYY-MM-DD"})
+		return
+	}
+	mins, ok := clockMinutes(c.Query("start_time"))
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "start_time must be HH:MM"})
+		return
+	}
+	start := fmt.Sprintf("%02d:%02d", mins/60, mins%60)
+
+	var rows []struct {
+		RoomName         string `json:"room"`
+		BenchRow         int    `json:"bench_row"`
+		BenchNo          int    `json:"bench_no"`
+		Seat             int    `json:"seat"`
+		EnrollmentNumber int64  `json:"enrollment_number"`
+		StudentName      string `json:"student_name"`
+		SubjectCode      string `json:"subject_code"`
+	}
+	if err := config.DB.Table("seat_allocations a").
+		Select("r.name AS room_name, a.bench_row, a.bench_no, a.seat, a.enrollment_number, COALESCE(s.student_name, '') AS student_name, a.subject_code").
+		Joins("JOIN exam_rooms r ON r.room_id = a.room_id").
+		Joins("LEFT JOIN master_students s ON s.enrollment_number = a.enrollment_number").
+		Where("a.exam_session_id = ? AND a.exam_date = ? AND a.start_time = ?", c.Param("id"), date, start).
+		Order("r.name, a.bench_row, a.bench_no, a.seat").
+		Scan(&rows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load seating plan"})
+		return
+	}
+
+	if format := c.Query("format"); format == "csv" || format == "xlsx" {
+		out := make([][]interface{}, len(rows))
+		for i, r := range rows {
+			out[i] = []interface{}{r.RoomName, r.BenchRow, r.BenchNo, r.Seat, r.EnrollmentNumber, r.StudentName, r.SubjectCode}
+		}
+		sendReport(c, format, "seating-plan",
+			[]string{"Room", "Row", "Bench", "Seat", "Enrollment Number", "Student Name", "Subject"}, out)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"exam_date": c.Query("exam_date"), "start_time": start, "seats": rows})
+}
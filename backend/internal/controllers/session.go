@@ -0,0 +1,99 @@
+package controllers
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/kiranraoboinapally/student/backend/internal/config"
+	"github.com/kiranraoboinapally/student/backend/internal/models"
+	"github.com/kiranraoboinapally/student/backend/internal/utils"
+)
+
+// ======================== AUTH SESSIONS ========================
+
+// issueSession creates an access token plus a rotating refresh token for the user
+func issueSession(c *gin.Context, db *gorm.DB, user models.User) (gin.H, *models.RefreshToken, error) {
+	accessToken, _, accessExp, err := utils.GenerateAccessToken(user.UserID, user.Username, user.RoleID, user.TokenVersion)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rawRefresh, err := utils.RandomToken(32)
+	if err != nil {
+		return nil, nil, err
+	}
+	refresh := models.RefreshToken{
+		UserID:    user.UserID,
+		TokenHash: utils.HashToken(rawRefresh),
+		ExpiresAt: time.Now().Add(time.Hour * time.Duration(config.JwtExpiresHours)),
+		UserAgent: truncate(c.Request.UserAgent(), 255),
+		ClientIP:  c.ClientIP(),
+		CreatedAt: time.Now(),
+	}
+	if err := db.Create(&refresh).Error; err != nil {
+		return nil, nil, err
+	}
+
+	return gin.H{
+		"token":              accessToken,
+		"expires_in":         int64(time.Until(accessExp).Seconds()),
+		"refresh_token":      rawRefresh,
+		"expires_in_hours":   config.JwtExpiresHours, // lifetime of the refresh session
+		"refresh_expires_at": refresh.ExpiresAt,
+	}, &refresh, nil
+}
+
+// RevokeUserSessions invalidates every access and refresh token issued to a user
+func RevokeUserSessions(db *gorm.DB, userID int64) error {
+	now := time.Now()
+	if err := db.Model(&models.User{}).
+		Where("user_id = ?", userID).
+		Update("token_version", gorm.Expr("token_version + 1")).Error; err != nil {
+		return err
+	}
+	return db.Model(&models.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", now).Error
+}
+
+// revokeAccessToken denylists the access token used for the current request
+func revokeAccessToken(c *gin.Context, db *gorm.DB, userID int64) {
+	jti := c.GetString("jti")
+	if jti == "" {
+		return
+	}
+	exp, ok := c.Get("token_exp")
+	expiresAt := time.Now().Add(time.Minute * time.Duration(config.JwtAccessMinutes))
+	if ok {
+		expiresAt = exp.(time.Time)
+	}
+	db.Where("jti = ?", jti).FirstOrCreate(&models.RevokedToken{
+		JTI:       jti,
+		UserID:    userID,
+		ExpiresAt: expiresAt,
+		CreatedAt: time.Now(),
+	})
+}
+
+// InitSessionCleanup periodically purges refresh tokens and denylist entries that can no longer be used
+func InitSessionCleanup() {
+	go func() {
+		ticker := time.NewTicker(time.Hour)
+		defer ticker.Stop()
+		for range ticker.C {
+			db := config.DB
+			now := time.Now()
+			db.Where("expires_at < ?", now).Delete(&models.RevokedToken{})
+			db.Where("expires_at < ?", now.AddDate(0, 0, -7)).Delete(&models.RefreshToken{})
+		}
+	}()
+}
+
+func truncate(s string, n int) string {
+	if len(s) > n {
+		return s[:n]
+	}
+	return s
+}
@@ -75,8 +75,11 @@ func GetStudentDashboard(c *gin.Context) {
 	}
 	db := config.DB
 
-	var expected models.ExpectedFee
-	db.Where("enrollment_number = ?", enrollment).First(&expected)
+	totals := sumLedger(db, "fee_ledger.enrollment_number = ?", enrollment)
+	status := "Paid"
+	if totals.Balance > 0 {
+		status = "Pending"
+	}
 
 	var latestResult models.SemesterResult
 	db.Where("enrollment_number = ?", enrollment).
@@ -85,10 +88,11 @@ func GetStudentDashboard(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{
 		"fee_summary": gin.H{
-			"total_expected": expected.TotalExpected,
-			"total_paid":     expected.TotalPaid,
-			"total_due":      expected.TotalExpected - expected.TotalPaid,
-			"status":         expected.OverallStatus,
+			"total_expected": totals.Charged,
+			"total_paid":     totals.Paid,
+			"total_waived":   totals.Waived,
+			"total_due":      totals.Balance,
+			"status":         status,
 		},
 		"latest_result": latestResult,
 	})
@@ -102,29 +106,34 @@ func GetStudentFeeSummary(c *gin.Context) {
 	}
 	db := config.DB
 
-	var expected models.ExpectedFee
-	db.Where("enrollment_number = ?", enrollment).First(&expected)
-
-	c.JSON(http.StatusOK, expected)
-}
-
-func GetStudentRegistrationFees(c *gin.Context) {
-	enrollment, err := getEnrollmentOrError(c)
+	balances, err := studentFeeBalances(db, enrollment)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load fee summary"})
 		return
 	}
-	db := config.DB
+	totals := sumLedger(db, "fee_ledger.enrollment_number = ?", enrollment)
 
-	var fees []models.RegistrationFee
-	db.Where("enrollment_number = ?", enrollment).
-		Order("transaction_date desc").
-		Find(&fees)
+	c.JSON(http.StatusOK, gin.H{
+		"enrollment_number": enrollment,
+		"total_expected":    totals.Charged,
+		"total_paid":        totals.Paid,
+		"total_waived":      totals.Waived,
+		"total_late_fee":    totals.Penalty,
+		"total_due":         totals.Balance,
+		"fee_types":         balances,
+	})
+}
 
-	c.JSON(http.StatusOK, fees)
+func GetStudentRegistrationFees(c *gin.Context) {
+	studentLedgerPayments(c, FeeHeadRegistration)
 }
 
 func GetStudentExaminationFees(c *gin.Context) {
+	studentLedgerPayments(c, FeeHeadExamination)
+}
+
+// studentLedgerPayments lists the caller's payments for one fee head
+func studentLedgerPayments(c *gin.Context, feeHead string) {
 	enrollment, err := getEnrollmentOrError(c)
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
@@ -132,10 +141,13 @@ func GetStudentExaminationFees(c *gin.Context) {
 	}
 	db := config.DB
 
-	var fees []models.ExaminationFee
-	db.Where("enrollment_number = ?", enrollment).
-		Order("transaction_date desc").
-		Find(&fees)
+	fees := []models.FeeLedgerEntry{}
+	feeType, err := resolveFeeType(db, feeHead)
+	if err == nil {
+		db.Where("enrollment_number = ? AND fee_type_id = ? AND entry_type = ?", enrollment, feeType.FeeTypeID, LedgerPayment).
+			Order("transaction_date desc").
+			Find(&fees)
+	}
 
 	c.JSON(http.StatusOK, fees)
 }
@@ -0,0 +1,139 @@
+// Package grading turns the free-text bands of grade_mapping ("90-100", "<40", "80 & above") into
+// a numeric scale and computes credit-weighted grade point averages.
+package grading
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/kiranraoboinapally/student/backend/internal/models"
+)
+
+// Band is one grade with the range of percentages it covers
+type Band struct {
+	ID     int     `json:"id"`
+	Grade  string  `json:"grade"`
+	Min    float64 `json:"min"`
+	Max    float64 `json:"max"`
+	Points float64 `json:"grade_points"`
+}
+
+// Passed reports whether the grade clears the subject; a zero-point grade is a fail
+func (b Band) Passed() bool { return b.Points > 0 }
+
+// Scale is a validated set of bands ordered from the lowest range up
+type Scale []Band
+
+var (
+	rangeBand = regexp.MustCompile(`^(\d+(?:\.\d+)?)\s*(?:-|–|to)\s*(\d+(?:\.\d+)?)$`)
+	aboveBand = regexp.MustCompile(`^(?:>=?|above|from)?\s*(\d+(?:\.\d+)?)\s*(?:\+|and above|& above|or above|or more|and more)?$`)
+	belowBand = regexp.MustCompile(`^(?:<|below|less than|under)\s*(\d+(?:\.\d+)?)$`)
+)
+
+// ParseBand reads a marks range such as "90-100", "90 to 100", "90+", ">= 90", "<40" or "below 40".
+// Open-ended bands run to 100 or from 0; "<40" stops just short of 40.
+func ParseBand(s string) (min, max float64, err error) {
+	text := strings.ToLower(strings.TrimSpace(strings.ReplaceAll(s, "%", "")))
+	text = strings.Join(strings.Fields(text), " ")
+	if m := rangeBand.FindStringSubmatch(text); m != nil {
+		min, _ = strconv.ParseFloat(m[1], 64)
+		max, _ = strconv.ParseFloat(m[2], 64)
+	} else if m := belowBand.FindStringSubmatch(text); m != nil {
+		max, _ = strconv.ParseFloat(m[1], 64)
+		max -= 0.01
+	} else if m := aboveBand.FindStringSubmatch(text); m != nil && text != m[1] {
+		min, _ = strconv.ParseFloat(m[1], 64)
+		max = 100
+	} else {
+		return 0, 0, fmt.Errorf("cannot read marks range %q", s)
+	}
+	if min > max || min < 0 || max > 100 {
+		return 0, 0, fmt.Errorf("marks range %q must lie within 0-100", s)
+	}
+	return min, max, nil
+}
+
+// NewScale parses and validates grade mappings: every band must parse, have numeric grade points,
+// not overlap another band, and together the bands must cover 0 to 100. Gaps of up to one mark
+// between integer bands ("80-89", "90-100") are fine; a percentage takes the band it is at or above.
+func NewScale(rows []models.GradeMapping) (Scale, error) {
+	var scale Scale
+	var problems []string
+	for _, r := range rows {
+		min, max, err := ParseBand(r.MarksPercent)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("grade %s: %v", r.Grade, err))
+			continue
+		}
+		points, err := strconv.ParseFloat(strings.TrimSpace(r.GradePoints), 64)
+		if err != nil || points < 0 {
+			problems = append(problems, fmt.Sprintf("grade %s: grade points %q is not a number", r.Grade, r.GradePoints))
+			continue
+		}
+		scale = append(scale, Band{ID: r.ID, Grade: strings.TrimSpace(r.Grade), Min: min, Max: max, Points: points})
+	}
+	if len(rows) == 0 {
+		problems = append(problems, "no grades are defined")
+	}
+	sort.Slice(scale, func(i, j int) bool { return scale[i].Min < scale[j].Min })
+
+	if len(problems) == 0 {
+		if scale[0].Min != 0 {
+			problems = append(problems, fmt.Sprintf("nothing covers 0-%g", scale[0].Min))
+		}
+		for i := 1; i < len(scale); i++ {
+			prev, cur := scale[i-1], scale[i]
+			switch {
+			case cur.Min <= prev.Max:
+				problems = append(problems, fmt.Sprintf("grades %s and %s overlap", prev.Grade, cur.Grade))
+			case cur.Min-prev.Max > 1:
+				problems = append(problems, fmt.Sprintf("nothing covers %g-%g", prev.Max, cur.Min))
+			}
+		}
+		if top := scale[len(scale)-1]; top.Max != 100 {
+			problems = append(problems, fmt.Sprintf("nothing covers %g-100", top.Max))
+		}
+	}
+	if len(problems) > 0 {
+		return nil, errors.New("invalid grading scale: " + strings.Join(problems, "; "))
+	}
+	return scale, nil
+}
+
+// Lookup returns the band a percentage falls in: the highest band whose minimum it reaches
+func (s Scale) Lookup(percent float64) Band {
+	percent = math.Max(0, math.Min(100, percent))
+	i := sort.Search(len(s), func(i int) bool { return s[i].Min > percent })
+	if i == 0 {
+		return s[0]
+	}
+	return s[i-1]
+}
+
+// Score is one graded subject in a GPA
+type Score struct {
+	Credits float64
+	Points  float64
+}
+
+// GPA is the credit-weighted average of grade points, rounded to two places. Zero-credit subjects
+// (audit courses) do not count; with no credits at all the GPA is 0.
+func GPA(scores []Score) float64 {
+	var weighted, credits float64
+	for _, s := range scores {
+		if s.Credits <= 0 {
+			continue
+		}
+		weighted += s.Credits * s.Points
+		credits += s.Credits
+	}
+	if credits == 0 {
+		return 0
+	}
+	return math.Round(weighted/credits*100) / 100
+}
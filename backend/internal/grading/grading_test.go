@@ -0,0 +1,166 @@
+package grading
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/kiranraoboinapally/student/backend/internal/models"
+)
+
+func TestParseBand(t *testing.T) {
+	tests := []struct {
+		in       string
+		min, max float64
+		wantErr  bool
+	}{
+		{in: "90-100", min: 90, max: 100},
+		{in: "90 - 100", min: 90, max: 100},
+		{in: "90% - 100%", min: 90, max: 100},
+		{in: "90 to 100", min: 90, max: 100},
+		{in: "52.5-59.99", min: 52.5, max: 59.99},
+		{in: "<40", min: 0, max: 39.99},
+		{in: "below 40", min: 0, max: 39.99},
+		{in: "80 & above", min: 80, max: 100},
+		{in: "80 and above", min: 80, max: 100},
+		{in: "90+", min: 90, max: 100},
+		{in: ">= 90", min: 90, max: 100},
+		{in: "  ABOVE   75 ", min: 75, max: 100},
+		{in: "90", wantErr: true},
+		{in: "", wantErr: true},
+		{in: "A+", wantErr: true},
+		{in: "60-50", wantErr: true},
+		{in: "90-110", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			min, max, err := ParseBand(tt.in)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ParseBand(%q) = %g, %g; want an error", tt.in, min, max)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseBand(%q): %v", tt.in, err)
+			}
+			if min != tt.min || max != tt.max {
+				t.Fatalf("ParseBand(%q) = %g, %g; want %g, %g", tt.in, min, max, tt.min, tt.max)
+			}
+		})
+	}
+}
+
+// mappings builds grade_mapping rows from grade, range and points triples
+func mappings(bands ...[3]string) []models.GradeMapping {
+	rows := make([]models.GradeMapping, len(bands))
+	for i, b := range bands {
+		rows[i] = models.GradeMapping{ID: i + 1, Grade: b[0], MarksPercent: b[1], GradePoints: b[2]}
+	}
+	return rows
+}
+
+func TestNewScale(t *testing.T) {
+	tests := []struct {
+		name    string
+		rows    []models.GradeMapping
+		grades  []string // lowest band first
+		wantErr string
+	}{
+		{
+			name:   "integer bands with one-mark gaps",
+			rows:   mappings([3]string{"A", "80-100", "10"}, [3]string{"B", "60-79", "8"}, [3]string{"C", "40-59", "6"}, [3]string{"F", "0-39", "0"}),
+			grades: []string{"F", "C", "B", "A"},
+		},
+		{
+			name:   "open-ended bands",
+			rows:   mappings([3]string{"F", "<40", "0"}, [3]string{"P", "40-79.99", "6"}, [3]string{"D", "80 & above", "10"}),
+			grades: []string{"F", "P", "D"},
+		},
+		{
+			name:    "shared boundary overlaps",
+			rows:    mappings([3]string{"F", "0-40", "0"}, [3]string{"P", "40-100", "6"}),
+			wantErr: "grades F and P overlap",
+		},
+		{
+			name:    "nested bands overlap",
+			rows:    mappings([3]string{"F", "0-100", "0"}, [3]string{"A", "90+", "10"}),
+			wantErr: "overlap",
+		},
+		{
+			name:    "gap between bands",
+			rows:    mappings([3]string{"F", "0-39", "0"}, [3]string{"P", "45-100", "6"}),
+			wantErr: "nothing covers 39-45",
+		},
+		{
+			name:    "bottom not covered",
+			rows:    mappings([3]string{"P", "10-100", "6"}),
+			wantErr: "nothing covers 0-10",
+		},
+		{
+			name:    "top not covered",
+			rows:    mappings([3]string{"F", "0-39", "0"}, [3]string{"P", "40-89", "6"}),
+			wantErr: "nothing covers 89-100",
+		},
+		{
+			name:    "unreadable range",
+			rows:    mappings([3]string{"F", "0-39", "0"}, [3]string{"P", "pass", "6"}),
+			wantErr: `grade P: cannot read marks range "pass"`,
+		},
+		{
+			name:    "grade points not a number",
+			rows:    mappings([3]string{"F", "0-39", "0"}, [3]string{"P", "40-100", "six"}),
+			wantErr: `grade P: grade points "six" is not a number`,
+		},
+		{
+			name:    "no grades",
+			rows:    nil,
+			wantErr: "no grades are defined",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scale, err := NewScale(tt.rows)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("NewScale error = %v; want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewScale: %v", err)
+			}
+			var grades []string
+			for _, b := range scale {
+				grades = append(grades, b.Grade)
+			}
+			if strings.Join(grades, ",") != strings.Join(tt.grades, ",") {
+				t.Fatalf("scale grades = %v; want %v", grades, tt.grades)
+			}
+		})
+	}
+}
+
+func TestScaleLookup(t *testing.T) {
+	scale, err := NewScale(mappings([3]string{"F", "0-39", "0"}, [3]string{"C", "40-59", "6"}, [3]string{"A", "60-100", "10"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		percent float64
+		grade   string
+	}{
+		{-5, "F"},
+		{0, "F"},
+		{39.5, "F"}, // in the gap below 40: stays in the band it is at or above
+		{40, "C"},
+		{59.99, "C"},
+		{60, "A"},
+		{100, "A"},
+		{120, "A"},
+	}
+	for _, tt := range tests {
+		if got := scale.Lookup(tt.percent).Grade; got != tt.grade {
+			t.Errorf("Lookup(%g) = %s; want %s", tt.percent, got, tt.grade)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package middleware
+
+import (
+	"encoding/json"
+	"log"
+	"reflect"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/kiranraoboinapally/student/backend/internal/config"
+	"github.com/kiranraoboinapally/student/backend/internal/models"
+	"github.com/kiranraoboinapally/student/backend/internal/utils"
+)
+
+// ======================== AUDIT TRAIL ========================
+
+const auditEntryKey = "audit_entry"
+
+// AuditEntry is what a handler knows about the change it made
+type AuditEntry struct {
+	Action     string
+	EntityType string
+	EntityID   string
+	Before     interface{}
+	After      interface{}
+}
+
+// Audit describes the change made by the current request. AuditTrail writes it once the
+// handler returns; without it a generic row (method + route) is still recorded.
+func Audit(c *gin.Context, action, entityType, entityID string, before, after interface{}) {
+	c.Set(auditEntryKey, &AuditEntry{
+		Action:     action,
+		EntityType: entityType,
+		EntityID:   entityID,
+		Before:     before,
+		After:      after,
+	})
+}
+
+// RequestID tags every request with an id (reusing the client's X-Request-ID when sent)
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader("X-Request-ID")
+		if id == "" || len(id) > 64 {
+			id, _ = utils.RandomToken(12)
+		}
+		c.Set("request_id", id)
+		c.Header("X-Request-ID", id)
+		c.Next()
+	}
+}
+
+// AuditTrail records every mutating request in audit_log after the handler has run
+func AuditTrail() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case "GET", "HEAD", "OPTIONS":
+			c.Next()
+			return
+		}
+
+		c.Next()
+
+		entry := models.AuditLog{
+			Action:     c.Request.Method + " " + c.FullPath(),
+			EntityID:   c.Param("id"),
+			Method:     c.Request.Method,
+			Path:       truncateString(c.Request.URL.Path, 255),
+			StatusCode: c.Writer.Status(),
+			ClientIP:   c.ClientIP(),
+			RequestID:  c.GetString("request_id"),
+			CreatedAt:  time.Now(),
+		}
+		if userID, ok := c.Get("user_id"); ok {
+			id := userID.(int64)
+			entry.ActorUserID = &id
+		}
+		entry.ActorUsername = c.GetString("username")
+		if roleID, ok := c.Get("role_id"); ok {
+			id := roleID.(int)
+			entry.RoleID = &id
+		}
+		if instituteID, ok := c.Get("institute_id"); ok {
+			id := instituteID.(int)
+			entry.InstituteID = &id
+		}
+
+		if v, ok := c.Get(auditEntryKey); ok {
+			a := v.(*AuditEntry)
+			entry.Action = a.Action
+			entry.EntityType = a.EntityType
+			entry.EntityID = a.EntityID
+			entry.Before = toJSON(a.Before)
+			entry.After = toJSON(a.After)
+			entry.Changes = toJSON(auditDiff(a.Before, a.After))
+		}
+
+		if err := config.DB.Create(&entry).Error; err != nil {
+			log.Printf("audit: failed to record %s %s: %v", entry.Method, entry.Path, err)
+		}
+	}
+}
+
+// auditDiff returns {field: {"from": x, "to": y}} for every top-level field that changed
+func auditDiff(before, after interface{}) map[string]interface{} {
+	if before == nil && after == nil {
+		return nil
+	}
+	b, a := toMap(before), toMap(after)
+	diff := make(map[string]interface{})
+	for k, av := range a {
+		if bv, ok := b[k]; !ok || !reflect.DeepEqual(bv, av) {
+			diff[k] = gin.H{"from": b[k], "to": av}
+		}
+	}
+	for k, bv := range b {
+		if _, ok := a[k]; !ok {
+			diff[k] = gin.H{"from": bv, "to": nil}
+		}
+	}
+	return diff
+}
+
+// toMap round-trips a value through JSON so structs and maps compare the same way
+func toMap(v interface{}) map[string]interface{} {
+	m := map[string]interface{}{}
+	if v == nil {
+		return m
+	}
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return m
+	}
+	if err := json.Unmarshal(raw, &m); err != nil {
+		// Scalars (e.g. a rules text blob) diff as a single "value" field
+		var scalar interface{}
+		json.Unmarshal(raw, &scalar)
+		m["value"] = scalar
+	}
+	return m
+}
+
+func toJSON(v interface{}) *string {
+	if v == nil {
+		return nil
+	}
+	if m, ok := v.(map[string]interface{}); ok && len(m) == 0 {
+		return nil
+	}
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	s := string(raw)
+	return &s
+}
+
+func truncateString(s string, n int) string {
+	if len(s) > n {
+		return s[:n]
+	}
+	return s
+}
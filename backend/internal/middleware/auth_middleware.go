@@ -1,14 +1,16 @@
 package middleware
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
 	"github.com/kiranraoboinapally/student/backend/internal/config"
+	"github.com/kiranraoboinapally/student/backend/internal/models"
+	"github.com/kiranraoboinapally/student/backend/internal/utils"
 )
 
 // Role constants for clarity
@@ -19,6 +21,71 @@ const (
 	RoleStudent         = 5
 )
 
+// TokenUser is the account behind a valid access token
+type TokenUser struct {
+	UserID      int64
+	Username    string
+	JTI         string
+	ExpiresAt   *time.Time
+	RoleID      int
+	InstituteID *int
+}
+
+// AuthenticateToken validates an access token: its signature and type, that the account is still
+// active, and that it has not been revoked by a token_version bump or a logged-out jti. On failure it
+// returns the HTTP status to answer with.
+func AuthenticateToken(tokenStr string) (*TokenUser, int, error) {
+	claims, err := utils.ParseToken(tokenStr)
+	if err != nil {
+		return nil, http.StatusUnauthorized, err
+	}
+	typ, _ := claims["typ"].(string)
+	if typ == utils.TokenTypeMFAPending {
+		return nil, http.StatusUnauthorized, errors.New("mfa verification required")
+	}
+	if typ != "" && typ != utils.TokenTypeAccess {
+		return nil, http.StatusUnauthorized, errors.New("invalid token type")
+	}
+
+	userID, ok := utils.ClaimInt64(claims, "user_id")
+	if !ok {
+		return nil, http.StatusUnauthorized, errors.New("invalid user_id in token")
+	}
+	user := &TokenUser{UserID: userID}
+	user.Username, _ = claims["username"].(string)
+	user.JTI, _ = claims["jti"].(string)
+	tokenVersion, _ := utils.ClaimInt64(claims, "ver")
+	if exp, ok := utils.ClaimInt64(claims, "exp"); ok {
+		t := time.Unix(exp, 0)
+		user.ExpiresAt = &t
+	}
+
+	var result struct {
+		RoleID       int    `gorm:"column:role_id"`
+		InstituteID  *int   `gorm:"column:institute_id"`
+		Status       string `gorm:"column:status"`
+		TokenVersion int64  `gorm:"column:token_version"`
+	}
+	if err := config.DB.Table("users").Select("role_id, institute_id, status, token_version").Where("user_id = ?", userID).Scan(&result).Error; err != nil {
+		return nil, http.StatusInternalServerError, errors.New("could not fetch user role")
+	}
+
+	// Revocation: password changes / logout-all bump token_version, logout denylists the jti
+	if result.Status != "active" || result.TokenVersion != tokenVersion {
+		return nil, http.StatusUnauthorized, errors.New("token revoked")
+	}
+	if user.JTI != "" {
+		var revoked int64
+		config.DB.Model(&models.RevokedToken{}).Where("jti = ?", user.JTI).Count(&revoked)
+		if revoked > 0 {
+			return nil, http.StatusUnauthorized, errors.New("token revoked")
+		}
+	}
+	user.RoleID = result.RoleID
+	user.InstituteID = result.InstituteID
+	return user, http.StatusOK, nil
+}
+
 // AuthRoleMiddleware validates JWT and optionally checks if user has one of the allowed roles
 func AuthRoleMiddleware(allowedRoles ...int) gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -32,46 +99,26 @@ func AuthRoleMiddleware(allowedRoles ...int) gin.HandlerFunc {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid authorization header"})
 			return
 		}
-		tokenStr := parts[1]
-
-		token, err := jwt.Parse(tokenStr, func(t *jwt.Token) (interface{}, error) {
-			return []byte(config.JwtSecret), nil
-		})
-		if err != nil || !token.Valid {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+		user, status, err := AuthenticateToken(parts[1])
+		if err != nil {
+			c.AbortWithStatusJSON(status, gin.H{"error": err.Error()})
 			return
 		}
 
-		claims, ok := token.Claims.(jwt.MapClaims)
-		if !ok {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid token claims"})
-			return
+		c.Set("user_id", user.UserID)
+		if user.Username != "" {
+			c.Set("username", user.Username)
 		}
-		if expRaw, ok := claims["exp"].(float64); ok {
-			if time.Now().After(time.Unix(int64(expRaw), 0)) {
-				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "token expired"})
-				return
-			}
+		if user.JTI != "" {
+			c.Set("jti", user.JTI)
 		}
-
-		var userID int64
-		switch v := claims["user_id"].(type) {
-		case float64:
-			userID = int64(v)
-		case int64:
-			userID = v
-		case int:
-			userID = int64(v)
-		default:
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid user_id in token"})
-			return
+		if user.ExpiresAt != nil {
+			c.Set("token_exp", *user.ExpiresAt)
 		}
 
-		username, _ := claims["username"].(string)
-
-		c.Set("user_id", userID)
-		if username != "" {
-			c.Set("username", username)
+		c.Set("role_id", user.RoleID)
+		if user.InstituteID != nil {
+			c.Set("institute_id", *user.InstituteID)
 		}
 
 		if len(allowedRoles) == 0 {
@@ -79,21 +126,8 @@ func AuthRoleMiddleware(allowedRoles ...int) gin.HandlerFunc {
 			return
 		}
 
-		var result struct {
-			RoleID      int  `gorm:"column:role_id"`
-			InstituteID *int `gorm:"column:institute_id"`
-		}
-		if err := config.DB.Table("users").Select("role_id, institute_id").Where("user_id = ?", userID).Scan(&result).Error; err != nil {
-			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "could not fetch user role"})
-			return
-		}
-
 		for _, r := range allowedRoles {
-			if r == result.RoleID {
-				c.Set("role_id", result.RoleID)
-				if result.InstituteID != nil {
-					c.Set("institute_id", *result.InstituteID)
-				}
+			if r == user.RoleID {
 				c.Next()
 				return
 			}
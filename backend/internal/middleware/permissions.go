@@ -0,0 +1,197 @@
+package middleware
+
+import (
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/kiranraoboinapally/student/backend/internal/config"
+	"github.com/kiranraoboinapally/student/backend/internal/models"
+)
+
+// PermissionDef describes a permission and which built-in roles get it when it is first created
+type PermissionDef struct {
+	Code         string
+	Description  string
+	DefaultRoles []int
+}
+
+var adminOnly = []int{RoleUniversityAdmin}
+
+// PermissionCatalog is every permission the API checks. New entries are inserted on startup
+// and granted to their default roles once; later edits by admins are never overwritten.
+var PermissionCatalog = []PermissionDef{
+	{"dashboard.view", "View university dashboard, statistics and pending approvals", adminOnly},
+	{"notifications.view", "Receive live admin notifications", adminOnly},
+	{"faculty.approve", "Approve or reject faculty accounts", adminOnly},
+	{"faculty.manage", "Create, edit and delete faculty", adminOnly},
+	{"courses.approve", "Approve or reject institute course-stream requests", adminOnly},
+	{"marks.view", "View internal marks across institutes", adminOnly},
+	{"marks.lock", "Lock submitted marks", adminOnly},
+	{"marks.publish", "Publish results", adminOnly},
+	{"results.compute", "Compute SGPA, CGPA and semester result status", adminOnly},
+	{"results.revaluation", "Assign and approve revaluation requests", adminOnly},
+	{"exams.manage", "Manage exam sessions and reappear registrations", adminOnly},
+	{"fees.view", "View fee payment history", adminOnly},
+	{"fees.verify", "Verify fee payments", adminOnly},
+	{"fees.refund", "Refund fee payments", adminOnly},
+	{"fees.reconcile", "Import bank statements and reconcile payments", adminOnly},
+	{"fees.waive", "Waive late fees", adminOnly},
+	{"fees.manage", "Manage fee types, structures and dues", adminOnly},
+	{"scholarships.manage", "Define scholarship schemes and request grants", adminOnly},
+	{"scholarships.approve", "Approve, reject and revoke scholarship grants", adminOnly},
+	{"students.view", "View students", adminOnly},
+	{"students.approve", "Approve or reject student registrations", adminOnly},
+	{"attendance.view", "View attendance summaries", adminOnly},
+	{"institutes.manage", "Manage institutes and their users", adminOnly},
+	{"academics.manage", "Manage courses, subjects, departments, grading and academic rules", adminOnly},
+	{"notices.manage", "Manage notices", adminOnly},
+	{"users.manage", "Create users, assign roles and reset MFA", adminOnly},
+	{"roles.manage", "Manage roles, permissions and MFA policy", adminOnly},
+	{"audit.view", "View and export the audit log", adminOnly},
+	{"portal.faculty", "Use the faculty portal", []int{RoleFaculty}},
+	{"portal.institute", "Use the institute admin portal", []int{RoleInstituteAdmin}},
+	{"portal.student", "Use the student portal", []int{RoleStudent}},
+}
+
+// SystemRoles are the built-in roles that cannot be deleted
+var SystemRoles = map[int]string{
+	RoleUniversityAdmin: "University Admin",
+	RoleFaculty:         "Faculty",
+	RoleInstituteAdmin:  "Institute Admin",
+	RoleStudent:         "Student",
+}
+
+// InitPermissions seeds the permission catalogue and the built-in roles' permission sets
+func InitPermissions() {
+	db := config.DB
+	for id, name := range SystemRoles {
+		db.Where("role_id = ?", id).Attrs(models.Role{RoleID: id, RoleName: name}).FirstOrCreate(&models.Role{})
+	}
+
+	for _, def := range PermissionCatalog {
+		var perm models.Permission
+		result := db.Where("code = ?", def.Code).Limit(1).Find(&perm)
+		if result.Error != nil {
+			log.Printf("Warning: permission lookup failed for %s: %v", def.Code, result.Error)
+			continue
+		}
+		if result.RowsAffected > 0 {
+			continue
+		}
+
+		perm = models.Permission{Code: def.Code, Description: def.Description}
+		if err := db.Create(&perm).Error; err != nil {
+			log.Printf("Warning: failed to seed permission %s: %v", def.Code, err)
+			continue
+		}
+		for _, roleID := range def.DefaultRoles {
+			db.Create(&models.RolePermission{RoleID: roleID, PermissionID: perm.PermissionID})
+		}
+	}
+	InvalidatePermissionCache()
+}
+
+// ======================== PERMISSION CACHE ========================
+
+const permissionCacheTTL = time.Minute
+
+var permCache = struct {
+	sync.RWMutex
+	byRole   map[int]map[string]bool
+	loadedAt time.Time
+}{}
+
+// InvalidatePermissionCache forces the next check to reload role permissions
+func InvalidatePermissionCache() {
+	permCache.Lock()
+	permCache.byRole = nil
+	permCache.Unlock()
+}
+
+func loadPermissions() (map[int]map[string]bool, error) {
+	permCache.RLock()
+	if permCache.byRole != nil && time.Since(permCache.loadedAt) < permissionCacheTTL {
+		byRole := permCache.byRole
+		permCache.RUnlock()
+		return byRole, nil
+	}
+	permCache.RUnlock()
+
+	var rows []struct {
+		RoleID int
+		Code   string
+	}
+	if err := config.DB.Table("role_permissions").
+		Select("role_permissions.role_id, permissions.code").
+		Joins("JOIN permissions ON permissions.permission_id = role_permissions.permission_id").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	byRole := make(map[int]map[string]bool)
+	for _, r := range rows {
+		if byRole[r.RoleID] == nil {
+			byRole[r.RoleID] = make(map[string]bool)
+		}
+		byRole[r.RoleID][r.Code] = true
+	}
+
+	permCache.Lock()
+	permCache.byRole = byRole
+	permCache.loadedAt = time.Now()
+	permCache.Unlock()
+	return byRole, nil
+}
+
+// RoleHasPermission reports whether a role has been granted the permission
+func RoleHasPermission(roleID int, perm string) bool {
+	byRole, err := loadPermissions()
+	if err != nil {
+		return false
+	}
+	return byRole[roleID][perm]
+}
+
+// RolePermissions lists the permission codes granted to a role
+func RolePermissions(roleID int) []string {
+	byRole, err := loadPermissions()
+	if err != nil {
+		return []string{}
+	}
+	codes := make([]string, 0, len(byRole[roleID]))
+	for _, def := range PermissionCatalog {
+		if byRole[roleID][def.Code] {
+			codes = append(codes, def.Code)
+		}
+	}
+	return codes
+}
+
+// RequirePermission allows the request when the caller's role has any of the given permissions.
+// It must run after AuthRoleMiddleware, which puts role_id in the context.
+func RequirePermission(perms ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		roleID, exists := c.Get("role_id")
+		if !exists {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+			return
+		}
+
+		byRole, err := loadPermissions()
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "could not load permissions"})
+			return
+		}
+		for _, p := range perms {
+			if byRole[roleID.(int)][p] {
+				c.Next()
+				return
+			}
+		}
+
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "forbidden: missing permission " + perms[0]})
+	}
+}
@@ -0,0 +1,134 @@
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/kiranraoboinapally/student/backend/internal/config"
+	"github.com/kiranraoboinapally/student/backend/internal/models"
+)
+
+// RateLimitStore counts hits per key in fixed windows
+type RateLimitStore interface {
+	Hit(key string, window time.Duration) (hits int, resetAt time.Time, err error)
+}
+
+var rateLimitStore RateLimitStore = NewMemoryRateLimitStore()
+
+// InitRateLimiter selects the shared DB store when RATE_LIMIT_STORE=db, otherwise stays in-process
+func InitRateLimiter() {
+	if config.RateLimitStore == "db" {
+		rateLimitStore = NewSQLRateLimitStore(config.DB)
+	}
+}
+
+// RateLimit allows at most limit requests per client IP per window for the named group
+func RateLimit(name string, limit int, window time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := name + ":" + c.ClientIP()
+		hits, resetAt, err := rateLimitStore.Hit(key, window)
+		if err != nil {
+			// Fail open: an unavailable store must not lock everyone out
+			c.Next()
+			return
+		}
+
+		remaining := limit - hits
+		if remaining < 0 {
+			remaining = 0
+		}
+		c.Header("X-RateLimit-Limit", strconv.Itoa(limit))
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(remaining))
+
+		if hits > limit {
+			retryAfter := int(math.Ceil(time.Until(resetAt).Seconds()))
+			c.Header("Retry-After", strconv.Itoa(retryAfter))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+				"error":               "too many requests, slow down",
+				"retry_after_seconds": retryAfter,
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
+// ======================== IN-PROCESS STORE ========================
+
+type memoryBucket struct {
+	resetAt time.Time
+	hits    int
+}
+
+type memoryRateLimitStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*memoryBucket
+	lastSweep time.Time
+}
+
+// NewMemoryRateLimitStore keeps counters in memory; fine for a single instance
+func NewMemoryRateLimitStore() RateLimitStore {
+	return &memoryRateLimitStore{buckets: make(map[string]*memoryBucket), lastSweep: time.Now()}
+}
+
+func (s *memoryRateLimitStore) Hit(key string, window time.Duration) (int, time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(s.lastSweep) > 10*time.Minute {
+		for k, b := range s.buckets {
+			if now.After(b.resetAt) {
+				delete(s.buckets, k)
+			}
+		}
+		s.lastSweep = now
+	}
+
+	b, ok := s.buckets[key]
+	if !ok || !now.Before(b.resetAt) {
+		b = &memoryBucket{resetAt: now.Add(window)}
+		s.buckets[key] = b
+	}
+	b.hits++
+	return b.hits, b.resetAt, nil
+}
+
+// ======================== SHARED (DB) STORE ========================
+
+type sqlRateLimitStore struct {
+	db *gorm.DB
+}
+
+// NewSQLRateLimitStore shares counters across instances through the rate_limit_buckets table
+func NewSQLRateLimitStore(db *gorm.DB) RateLimitStore {
+	return &sqlRateLimitStore{db: db}
+}
+
+func (s *sqlRateLimitStore) Hit(key string, window time.Duration) (int, time.Time, error) {
+	now := time.Now()
+	cutoff := now.Add(-window)
+
+	err := s.db.Exec(`
+		INSERT INTO rate_limit_buckets (bucket_key, window_start, hits)
+		VALUES (?, ?, 1)
+		ON DUPLICATE KEY UPDATE
+			hits = IF(window_start <= ?, 1, hits + 1),
+			window_start = IF(window_start <= ?, VALUES(window_start), window_start)
+	`, key, now, cutoff, cutoff).Error
+	if err != nil {
+		return 0, now, err
+	}
+
+	var bucket models.RateLimitBucket
+	if err := s.db.Where("bucket_key = ?", key).First(&bucket).Error; err != nil {
+		return 0, now, err
+	}
+	return bucket.Hits, bucket.WindowStart.Add(window), nil
+}
@@ -16,17 +16,39 @@ type User struct {
 	Status         string     `gorm:"column:status" json:"status"`
 	LastLogin      *time.Time `gorm:"column:last_login" json:"last_login"`
 	IsTempPassword bool       `gorm:"column:is_temp_password" json:"-"`
+	TokenVersion   int        `gorm:"column:token_version;default:0" json:"-"` // bumped to revoke every session
+	MFAEnabled     bool       `gorm:"column:mfa_enabled;default:false" json:"mfa_enabled"`
+	MFASecret      *string    `gorm:"column:mfa_secret;size:64" json:"-"`      // base32 TOTP secret, set on enrollment
+	MFALastStep    int64      `gorm:"column:mfa_last_step;default:0" json:"-"` // last accepted TOTP step, blocks replay
 	CreatedAt      *time.Time `gorm:"column:created_at" json:"created_at"`
 	UpdatedAt      *time.Time `gorm:"column:updated_at" json:"updated_at"`
 }
 
 type Role struct {
-	RoleID   int    `gorm:"column:role_id;primaryKey;autoIncrement" json:"role_id"`
-	RoleName string `gorm:"column:role_name;unique" json:"role_name"`
+	RoleID      int    `gorm:"column:role_id;primaryKey;autoIncrement" json:"role_id"`
+	RoleName    string `gorm:"column:role_name;unique" json:"role_name"`
+	MFARequired bool   `gorm:"column:mfa_required;default:false" json:"mfa_required"`
 }
 
 func (Role) TableName() string { return "roles" }
 
+// Permission is a single capability checked by RequirePermission, e.g. "fees.verify"
+type Permission struct {
+	PermissionID int    `gorm:"column:permission_id;primaryKey;autoIncrement" json:"permission_id"`
+	Code         string `gorm:"column:code;size:64;uniqueIndex;not null" json:"code"`
+	Description  string `gorm:"column:description;size:255" json:"description"`
+}
+
+func (Permission) TableName() string { return "permissions" }
+
+// RolePermission grants a permission to a role
+type RolePermission struct {
+	RoleID       int `gorm:"column:role_id;primaryKey" json:"role_id"`
+	PermissionID int `gorm:"column:permission_id;primaryKey" json:"permission_id"`
+}
+
+func (RolePermission) TableName() string { return "role_permissions" }
+
 type Assignment struct {
 	AssignmentID int64     `gorm:"column:assignment_id;primaryKey;autoIncrement" json:"assignment_id"`
 	CourseID     int       `gorm:"column:course_id" json:"course_id"`
@@ -157,9 +179,9 @@ func (GradeMapping) TableName() string { return "grade_mapping" }
 
 type StudentMark struct {
 	MarkID           int64     `gorm:"column:mark_id;primaryKey" json:"mark_id"`
-	EnrollmentNumber int64     `gorm:"column:enrollment_number" json:"enrollment_number"`
-	Semester         int       `gorm:"column:semester" json:"semester"`
-	SubjectCode      string    `gorm:"column:subject_code" json:"subject_code"`
+	EnrollmentNumber int64     `gorm:"column:enrollment_number;uniqueIndex:idx_student_marks_subject,priority:1" json:"enrollment_number"`
+	Semester         int       `gorm:"column:semester;uniqueIndex:idx_student_marks_subject,priority:2" json:"semester"`
+	SubjectCode      string    `gorm:"column:subject_code;uniqueIndex:idx_student_marks_subject,priority:3" json:"subject_code"`
 	SubjectName      string    `gorm:"column:subject_name" json:"subject_name"`
 	SubjectType      string    `gorm:"column:subject_type" json:"subject_type"`
 	MarksObtained    float64   `gorm:"column:marks_obtained" json:"marks_obtained"`
@@ -268,15 +290,16 @@ type FeePayment struct {
 
 func (FeePayment) TableName() string { return "fee_payments" }
 
+// PaymentReconciliation confirms a fee_ledger payment against a bank or gateway statement line
 type PaymentReconciliation struct {
 	ReconciliationID int        `gorm:"column:reconciliation_id;primaryKey" json:"reconciliation_id"`
-	PaymentID        int        `gorm:"column:payment_id" json:"payment_id"`
+	PaymentID        int64      `gorm:"column:payment_id;uniqueIndex" json:"payment_id"` // fee_ledger payment entry
 	BankDate         *time.Time `gorm:"column:bank_statement_date" json:"bank_date"`
 	Reference        *string    `gorm:"column:bank_statement_reference" json:"reference"`
 	ReconciledAmount *float64   `gorm:"column:reconciled_amount" json:"reconciled_amount"`
 	DifferenceAmount *float64   `gorm:"column:difference_amount" json:"difference_amount"`
 	Status           string     `gorm:"column:reconciliation_status" json:"status"`
-	ReconciledBy     *int       `gorm:"column:reconciled_by" json:"reconciled_by"`
+	ReconciledBy     *int64     `gorm:"column:reconciled_by" json:"reconciled_by"`
 	ReconciledAt     *time.Time `gorm:"column:reconciled_at" json:"reconciled_at"`
 	Remarks          *string    `gorm:"column:remarks" json:"remarks"`
 	CreatedAt        time.Time  `gorm:"column:created_at" json:"created_at"`
@@ -369,12 +392,25 @@ type FeeData struct {
 
 func (FeeData) TableName() string { return "fee_data" }
 
+// ReappearStudent is a backlog exam registration: one subject a student re-sits in an exam session.
+// Legacy rows only carry the enrollment, year/semester and amount paid.
 type ReappearStudent struct {
-	ReappearID   int64   `gorm:"column:reappear_id;primaryKey" json:"reappear_id"`
-	EnrollmentNo int64   `gorm:"column:enrollment_no" json:"enrollment_number"`
-	YearSem      *string `gorm:"column:year_sem" json:"year_sem"`
-	Amount       float64 `gorm:"column:amount" json:"amount"`
-	TxnNo        *string `gorm:"column:txn_no" json:"txn_no"`
+	ReappearID    int64      `gorm:"column:reappear_id;primaryKey" json:"reappear_id"`
+	EnrollmentNo  int64      `gorm:"column:enrollment_no" json:"enrollment_number"`
+	YearSem       *string    `gorm:"column:year_sem" json:"year_sem"`
+	Amount        float64    `gorm:"column:amount" json:"amount"`
+	TxnNo         *string    `gorm:"column:txn_no" json:"txn_no"`
+	ExamSessionID *int       `gorm:"column:exam_session_id" json:"exam_session_id"`
+	Semester      *int       `gorm:"column:semester" json:"semester"` // semester the failed subject belongs to
+	SubjectCode   *string    `gorm:"column:subject_code;size:50" json:"subject_code"`
+	Status        *string    `gorm:"column:status;size:20" json:"status"`                           // registered, locked
+	FeeChargeID   *int64     `gorm:"column:fee_charge_id" json:"fee_charge_id"`                     // fee_ledger charge the student pays
+	MarksObtained *float64   `gorm:"column:marks_obtained;type:decimal(6,2)" json:"marks_obtained"` // reappear result, out of 100
+	Absent        bool       `gorm:"column:absent;default:false" json:"absent"`
+	ResultAt      *time.Time `gorm:"column:result_at" json:"result_at"`
+	LockedAt      *time.Time `gorm:"column:locked_at" json:"locked_at"`
+	LockedBy      *int64     `gorm:"column:locked_by" json:"locked_by"`
+	CreatedAt     *time.Time `gorm:"column:created_at" json:"created_at"`
 }
 
 func (ReappearStudent) TableName() string { return "reappear_students" }
@@ -530,3 +566,586 @@ type FacultyCourseAssignment struct {
 }
 
 func (FacultyCourseAssignment) TableName() string { return "faculty_course_assignments" }
+
+// ======================== AUTH SESSIONS ========================
+
+// RefreshToken is a rotating, server-side refresh token (only the hash is stored)
+type RefreshToken struct {
+	ID         int64      `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	UserID     int64      `gorm:"column:user_id;index;not null" json:"user_id"`
+	TokenHash  string     `gorm:"column:token_hash;size:64;uniqueIndex;not null" json:"-"`
+	ExpiresAt  time.Time  `gorm:"column:expires_at" json:"expires_at"`
+	RevokedAt  *time.Time `gorm:"column:revoked_at" json:"revoked_at"`
+	ReplacedBy *int64     `gorm:"column:replaced_by" json:"replaced_by"`
+	UserAgent  string     `gorm:"column:user_agent;size:255" json:"user_agent"`
+	ClientIP   string     `gorm:"column:client_ip;size:64" json:"client_ip"`
+	CreatedAt  time.Time  `gorm:"column:created_at" json:"created_at"`
+}
+
+func (RefreshToken) TableName() string { return "refresh_tokens" }
+
+// RevokedToken denylists an access token (by jti) until it would have expired anyway
+type RevokedToken struct {
+	JTI       string    `gorm:"column:jti;size:64;primaryKey" json:"jti"`
+	UserID    int64     `gorm:"column:user_id" json:"user_id"`
+	ExpiresAt time.Time `gorm:"column:expires_at;index" json:"expires_at"`
+	CreatedAt time.Time `gorm:"column:created_at" json:"created_at"`
+}
+
+func (RevokedToken) TableName() string { return "revoked_tokens" }
+
+// AuthThrottle tracks failed authentication attempts per subject (username or client IP)
+type AuthThrottle struct {
+	ID           int64      `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	Scope        string     `gorm:"column:scope;size:32;uniqueIndex:idx_auth_throttle_subject;not null" json:"scope"` // login_user, login_ip, reset_ip
+	Subject      string     `gorm:"column:subject;size:191;uniqueIndex:idx_auth_throttle_subject;not null" json:"subject"`
+	FailedCount  int        `gorm:"column:failed_count;default:0" json:"failed_count"`
+	LockoutCount int        `gorm:"column:lockout_count;default:0" json:"lockout_count"` // drives exponential backoff
+	LastFailedAt *time.Time `gorm:"column:last_failed_at" json:"last_failed_at"`
+	LockedUntil  *time.Time `gorm:"column:locked_until" json:"locked_until"`
+	UpdatedAt    time.Time  `gorm:"column:updated_at" json:"updated_at"`
+}
+
+func (AuthThrottle) TableName() string { return "auth_throttles" }
+
+// RateLimitBucket is the shared fixed-window counter used when RATE_LIMIT_STORE=db
+type RateLimitBucket struct {
+	BucketKey   string    `gorm:"column:bucket_key;size:191;primaryKey" json:"bucket_key"`
+	WindowStart time.Time `gorm:"column:window_start" json:"window_start"`
+	Hits        int       `gorm:"column:hits" json:"hits"`
+}
+
+func (RateLimitBucket) TableName() string { return "rate_limit_buckets" }
+
+// ======================== EMAIL OUTBOX ========================
+
+// EmailOutbox queues outgoing mail so delivery survives SMTP outages and restarts
+type EmailOutbox struct {
+	EmailID       int64      `gorm:"column:email_id;primaryKey;autoIncrement" json:"email_id"`
+	ToAddress     string     `gorm:"column:to_address;size:255;not null" json:"to_address"`
+	Subject       string     `gorm:"column:subject;size:255" json:"subject"`
+	Body          string     `gorm:"column:body;type:text" json:"-"`                              // may contain reset links / temp passwords
+	Status        string     `gorm:"column:status;size:20;index;default:'pending'" json:"status"` // pending, sending, sent, failed
+	Attempts      int        `gorm:"column:attempts;default:0" json:"attempts"`
+	LastError     *string    `gorm:"column:last_error;type:text" json:"last_error"`
+	NextAttemptAt time.Time  `gorm:"column:next_attempt_at;index" json:"next_attempt_at"`
+	SentAt        *time.Time `gorm:"column:sent_at" json:"sent_at"`
+	CreatedAt     time.Time  `gorm:"column:created_at" json:"created_at"`
+}
+
+func (EmailOutbox) TableName() string { return "email_outbox" }
+
+// ======================== TWO-FACTOR AUTH ========================
+
+// MFARecoveryCode is a single-use fallback code for a user who lost their authenticator
+type MFARecoveryCode struct {
+	ID        int64      `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	UserID    int64      `gorm:"column:user_id;index;not null" json:"user_id"`
+	CodeHash  string     `gorm:"column:code_hash;size:64;not null" json:"-"`
+	UsedAt    *time.Time `gorm:"column:used_at" json:"used_at"`
+	CreatedAt time.Time  `gorm:"column:created_at" json:"created_at"`
+}
+
+func (MFARecoveryCode) TableName() string { return "mfa_recovery_codes" }
+
+// ======================== AUDIT LOG ========================
+
+// AuditLog is an append-only record of a state-changing request
+type AuditLog struct {
+	AuditID       int64     `gorm:"column:audit_id;primaryKey;autoIncrement" json:"audit_id"`
+	ActorUserID   *int64    `gorm:"column:actor_user_id;index" json:"actor_user_id"`
+	ActorUsername string    `gorm:"column:actor_username;size:100" json:"actor_username"`
+	RoleID        *int      `gorm:"column:role_id" json:"role_id"`
+	InstituteID   *int      `gorm:"column:institute_id;index" json:"institute_id"`
+	Action        string    `gorm:"column:action;size:100;index" json:"action"`
+	EntityType    string    `gorm:"column:entity_type;size:50;index:idx_audit_entity" json:"entity_type"`
+	EntityID      string    `gorm:"column:entity_id;size:64;index:idx_audit_entity" json:"entity_id"`
+	Before        *string   `gorm:"column:before_data;type:json" json:"before"`
+	After         *string   `gorm:"column:after_data;type:json" json:"after"`
+	Changes       *string   `gorm:"column:changes;type:json" json:"changes"`
+	Method        string    `gorm:"column:method;size:10" json:"method"`
+	Path          string    `gorm:"column:path;size:255" json:"path"`
+	StatusCode    int       `gorm:"column:status_code" json:"status_code"`
+	ClientIP      string    `gorm:"column:client_ip;size:64" json:"client_ip"`
+	RequestID     string    `gorm:"column:request_id;size:64;index" json:"request_id"`
+	CreatedAt     time.Time `gorm:"column:created_at;index" json:"created_at"`
+}
+
+func (AuditLog) TableName() string { return "audit_log" }
+
+// ======================== GATEWAY PAYMENTS ========================
+
+// GatewayPayment records each online payment exactly once, keyed by the gateway payment id,
+// whichever of the webhook or the browser callback arrives first
+type GatewayPayment struct {
+	PaymentID        string    `gorm:"column:payment_id;size:64;primaryKey" json:"payment_id"`
+	OrderID          string    `gorm:"column:order_id;size:64;index" json:"order_id"`
+	EnrollmentNumber int64     `gorm:"column:enrollment_number;index" json:"enrollment_number"`
+	FeeHead          string    `gorm:"column:fee_head;size:50" json:"fee_head"`
+	FeeType          string    `gorm:"column:fee_type;size:100" json:"fee_type"`
+	Amount           float64   `gorm:"column:amount" json:"amount"`
+	RefundedAmount   float64   `gorm:"column:refunded_amount;default:0" json:"refunded_amount"`
+	Status           string    `gorm:"column:status;size:20;index" json:"status"` // captured, failed, refunded, partially_refunded
+	Source           string    `gorm:"column:source;size:20" json:"source"`       // webhook, client
+	FeeRecordID      *int64    `gorm:"column:fee_record_id" json:"fee_record_id"`
+	ErrorReason      *string   `gorm:"column:error_reason;size:255" json:"error_reason"`
+	CreatedAt        time.Time `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt        time.Time `gorm:"column:updated_at" json:"updated_at"`
+}
+
+func (GatewayPayment) TableName() string { return "gateway_payments" }
+
+// WebhookEvent keeps every verified webhook delivery so retries are not processed twice
+type WebhookEvent struct {
+	ID         int64     `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	Provider   string    `gorm:"column:provider;size:20" json:"provider"`
+	EventID    string    `gorm:"column:event_id;size:100;uniqueIndex" json:"event_id"`
+	Event      string    `gorm:"column:event;size:50;index" json:"event"`
+	EntityID   string    `gorm:"column:entity_id;size:64;index" json:"entity_id"`
+	Status     string    `gorm:"column:status;size:20" json:"status"` // received, processed, ignored, failed
+	Error      *string   `gorm:"column:error;type:text" json:"error"`
+	Payload    string    `gorm:"column:payload;type:longtext" json:"-"`
+	ReceivedAt time.Time `gorm:"column:received_at" json:"received_at"`
+}
+
+func (WebhookEvent) TableName() string { return "webhook_events" }
+
+// PaymentOrder is the server-side record of a gateway order; verification trusts it, not the client
+type PaymentOrder struct {
+	OrderID          string     `gorm:"column:order_id;size:64;primaryKey" json:"order_id"`
+	EnrollmentNumber int64      `gorm:"column:enrollment_number;index" json:"enrollment_number"`
+	FeeHead          string     `gorm:"column:fee_head;size:50" json:"fee_head"`
+	FeeType          string     `gorm:"column:fee_type;size:100" json:"fee_type"`
+	ChargeID         *int64     `gorm:"column:charge_id" json:"charge_id"` // installment being paid, if one was chosen
+	Amount           float64    `gorm:"column:amount" json:"amount"`
+	Currency         string     `gorm:"column:currency;size:3" json:"currency"`
+	Status           string     `gorm:"column:status;size:20;index" json:"status"` // created, paid, expired, mismatch
+	PaymentID        *string    `gorm:"column:payment_id;size:64" json:"payment_id"`
+	ExpiresAt        time.Time  `gorm:"column:expires_at;index" json:"expires_at"`
+	PaidAt           *time.Time `gorm:"column:paid_at" json:"paid_at"`
+	CreatedAt        time.Time  `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt        time.Time  `gorm:"column:updated_at" json:"updated_at"`
+}
+
+func (PaymentOrder) TableName() string { return "payment_orders" }
+
+// FeeLedgerEntry is one line of a student's fee account. Charges, penalties and refunds are debits; payments,
+// waivers and concessions are credits; adjustments may be either. Balance = SUM(debit) - SUM(credit).
+type FeeLedgerEntry struct {
+	EntryID           int64      `gorm:"column:entry_id;primaryKey;autoIncrement" json:"entry_id"`
+	EnrollmentNumber  int64      `gorm:"column:enrollment_number;index:idx_fee_ledger_student,priority:1;not null" json:"enrollment_number"`
+	FeeTypeID         int        `gorm:"column:fee_type_id;index:idx_fee_ledger_student,priority:2;not null" json:"fee_type_id"`
+	EntryType         string     `gorm:"column:entry_type;size:20;uniqueIndex:idx_fee_ledger_reference,priority:1;not null" json:"entry_type"` // charge, payment, adjustment, refund, reversal, penalty, waiver
+	Debit             float64    `gorm:"column:debit;type:decimal(12,2);default:0" json:"debit"`
+	Credit            float64    `gorm:"column:credit;type:decimal(12,2);default:0" json:"credit"`
+	Status            string     `gorm:"column:status;size:30;index" json:"status"`                                                  // Posted for charges; Paid/Pending/Verified/Rejected/Refunded for payments
+	Reference         *string    `gorm:"column:reference;size:100;uniqueIndex:idx_fee_ledger_reference,priority:2" json:"reference"` // gateway/bank id or legacy row, unique per entry type
+	ParentEntryID     *int64     `gorm:"column:parent_entry_id;index" json:"parent_entry_id"`                                        // refund/reversal → payment; penalty, waiver or targeted payment → charge
+	TransactionNumber *string    `gorm:"column:transaction_number;size:100" json:"transaction_number"`
+	TransactionDate   *time.Time `gorm:"column:transaction_date;index" json:"transaction_date"`
+	DueDate           *time.Time `gorm:"column:due_date" json:"due_date"`
+	Semester          *int       `gorm:"column:semester" json:"semester"`
+	InstallmentNo     *int       `gorm:"column:installment_no" json:"installment_no"`
+	PaymentMethod     *string    `gorm:"column:payment_method;size:30" json:"payment_method"`
+	Description       *string    `gorm:"column:description;size:255" json:"description"`
+	CreatedBy         *int64     `gorm:"column:created_by" json:"created_by"`
+	CreatedAt         time.Time  `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt         time.Time  `gorm:"column:updated_at" json:"updated_at"`
+}
+
+func (FeeLedgerEntry) TableName() string { return "fee_ledger" }
+
+// LateFeeRule configures the penalty on overdue charges of one fee type
+type LateFeeRule struct {
+	RuleID    int       `gorm:"column:rule_id;primaryKey;autoIncrement" json:"rule_id"`
+	FeeTypeID int       `gorm:"column:fee_type_id;uniqueIndex;not null" json:"fee_type_id"`
+	Method    string    `gorm:"column:method;size:20;not null" json:"method"`                     // flat, per_day or slab
+	Amount    float64   `gorm:"column:amount;type:decimal(12,2);default:0" json:"amount"`         // flat amount, or the daily rate for per_day
+	Slabs     *string   `gorm:"column:slabs;type:text" json:"slabs"`                              // slab: JSON [{"after_days":0,"amount":100},{"after_days":30,"amount":500}]
+	GraceDays int       `gorm:"column:grace_days;default:0" json:"grace_days"`                    // days after the due date before any penalty
+	MaxAmount float64   `gorm:"column:max_amount;type:decimal(12,2);default:0" json:"max_amount"` // cap per charge; 0 = uncapped
+	IsActive  bool      `gorm:"column:is_active;default:true" json:"is_active"`
+	UpdatedBy *int64    `gorm:"column:updated_by" json:"updated_by"`
+	CreatedAt time.Time `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt time.Time `gorm:"column:updated_at" json:"updated_at"`
+}
+
+func (LateFeeRule) TableName() string { return "late_fee_rules" }
+
+// InstallmentPlan splits a fee structure's amount into dated installments, e.g. 40/30/30
+type InstallmentPlan struct {
+	PlanID         int                   `gorm:"column:plan_id;primaryKey;autoIncrement" json:"plan_id"`
+	FeeStructureID int                   `gorm:"column:fee_structure_id;index;not null" json:"fee_structure_id"`
+	Name           string                `gorm:"column:name;size:100;not null" json:"name"`
+	IsActive       bool                  `gorm:"column:is_active;default:true" json:"is_active"`
+	CreatedBy      *int64                `gorm:"column:created_by" json:"created_by"`
+	CreatedAt      time.Time             `gorm:"column:created_at" json:"created_at"`
+	Items          []InstallmentPlanItem `gorm:"foreignKey:PlanID" json:"installments"`
+}
+
+func (InstallmentPlan) TableName() string { return "installment_plans" }
+
+type InstallmentPlanItem struct {
+	ItemID     int       `gorm:"column:item_id;primaryKey;autoIncrement" json:"item_id"`
+	PlanID     int       `gorm:"column:plan_id;index;not null" json:"plan_id"`
+	Sequence   int       `gorm:"column:sequence;not null" json:"sequence"`
+	Label      string    `gorm:"column:label;size:100" json:"label"`
+	Percentage float64   `gorm:"column:percentage;type:decimal(5,2);not null" json:"percentage"`
+	DueDate    time.Time `gorm:"column:due_date;not null" json:"due_date"`
+}
+
+func (InstallmentPlanItem) TableName() string { return "installment_plan_items" }
+
+// FeeReminder remembers when a student was last reminded about an overdue charge
+type FeeReminder struct {
+	ChargeID         int64     `gorm:"column:charge_id;primaryKey;autoIncrement:false" json:"charge_id"`
+	EnrollmentNumber int64     `gorm:"column:enrollment_number;index" json:"enrollment_number"`
+	RemindersSent    int       `gorm:"column:reminders_sent" json:"reminders_sent"`
+	LastSentAt       time.Time `gorm:"column:last_sent_at" json:"last_sent_at"`
+}
+
+func (FeeReminder) TableName() string { return "fee_reminders" }
+
+// ScholarshipScheme is a merit scholarship or category concession. Eligibility fields left empty
+// match every student.
+type ScholarshipScheme struct {
+	SchemeID   int       `gorm:"column:scheme_id;primaryKey;autoIncrement" json:"scheme_id"`
+	Name       string    `gorm:"column:name;size:150;not null" json:"name"`
+	Kind       string    `gorm:"column:kind;size:20;not null" json:"kind"`     // scholarship or concession
+	Method     string    `gorm:"column:method;size:20;not null" json:"method"` // percentage or fixed
+	Value      float64   `gorm:"column:value;type:decimal(12,2);not null" json:"value"`
+	MaxAmount  float64   `gorm:"column:max_amount;type:decimal(12,2);default:0" json:"max_amount"` // cap per grant and fee type; 0 = uncapped
+	FeeTypeIDs *string   `gorm:"column:fee_type_ids;size:255" json:"fee_type_ids"`                 // JSON array; NULL = every fee type
+	CourseName *string   `gorm:"column:course_name" json:"course_name"`
+	Batch      *string   `gorm:"column:batch" json:"batch"`
+	Session    *string   `gorm:"column:session" json:"session"`
+	MinCGPA    *float64  `gorm:"column:min_cgpa" json:"min_cgpa"`
+	IsActive   bool      `gorm:"column:is_active;default:true" json:"is_active"`
+	CreatedBy  *int64    `gorm:"column:created_by" json:"created_by"`
+	CreatedAt  time.Time `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt  time.Time `gorm:"column:updated_at" json:"updated_at"`
+}
+
+func (ScholarshipScheme) TableName() string { return "scholarship_schemes" }
+
+// ScholarshipGrant awards a scheme to a student; only approved grants reduce fees
+type ScholarshipGrant struct {
+	GrantID          int64      `gorm:"column:grant_id;primaryKey;autoIncrement" json:"grant_id"`
+	SchemeID         int        `gorm:"column:scheme_id;uniqueIndex:idx_scholarship_grant,priority:1;not null" json:"scheme_id"`
+	EnrollmentNumber int64      `gorm:"column:enrollment_number;uniqueIndex:idx_scholarship_grant,priority:2;not null" json:"enrollment_number"`
+	Status           string     `gorm:"column:status;size:20;index" json:"status"` // pending, approved, rejected, revoked
+	Remarks          *string    `gorm:"column:remarks;size:255" json:"remarks"`
+	RequestedBy      *int64     `gorm:"column:requested_by" json:"requested_by"`
+	ReviewedBy       *int64     `gorm:"column:reviewed_by" json:"reviewed_by"`
+	ReviewedAt       *time.Time `gorm:"column:reviewed_at" json:"reviewed_at"`
+	CreatedAt        time.Time  `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt        time.Time  `gorm:"column:updated_at" json:"updated_at"`
+}
+
+func (ScholarshipGrant) TableName() string { return "scholarship_grants" }
+
+// FeeReceipt is the numbered receipt issued for a settled payment. Numbers run per institute and
+// financial year; the verification code resolves at the public receipt check.
+type FeeReceipt struct {
+	ReceiptID        int64     `gorm:"column:receipt_id;primaryKey;autoIncrement" json:"receipt_id"`
+	ReceiptNumber    string    `gorm:"column:receipt_number;size:50;uniqueIndex;not null" json:"receipt_number"`
+	InstituteID      int       `gorm:"column:institute_id;uniqueIndex:idx_fee_receipt_seq,priority:1" json:"institute_id"` // 0 when the student has no institute
+	FinancialYear    string    `gorm:"column:financial_year;size:7;uniqueIndex:idx_fee_receipt_seq,priority:2" json:"financial_year"`
+	Sequence         int       `gorm:"column:sequence;uniqueIndex:idx_fee_receipt_seq,priority:3" json:"sequence"`
+	PaymentEntryID   int64     `gorm:"column:payment_entry_id;uniqueIndex;not null" json:"payment_entry_id"`
+	EnrollmentNumber int64     `gorm:"column:enrollment_number;index;not null" json:"enrollment_number"`
+	Amount           float64   `gorm:"column:amount;type:decimal(12,2);not null" json:"amount"`
+	VerificationCode string    `gorm:"column:verification_code;size:20;uniqueIndex;not null" json:"verification_code"`
+	IssuedAt         time.Time `gorm:"column:issued_at" json:"issued_at"`
+}
+
+func (FeeReceipt) TableName() string { return "fee_receipts" }
+
+// ReceiptSequence holds the last receipt number used per institute and financial year
+type ReceiptSequence struct {
+	InstituteID   int    `gorm:"column:institute_id;primaryKey;autoIncrement:false"`
+	FinancialYear string `gorm:"column:financial_year;size:7;primaryKey"`
+	LastNumber    int    `gorm:"column:last_number;not null;default:0"`
+}
+
+func (ReceiptSequence) TableName() string { return "receipt_sequences" }
+
+// BankStatementImport is one uploaded bank or gateway settlement file
+type BankStatementImport struct {
+	ImportID      int64      `gorm:"column:import_id;primaryKey;autoIncrement" json:"import_id"`
+	FileName      string     `gorm:"column:file_name;size:255" json:"file_name"`
+	FileHash      string     `gorm:"column:file_hash;size:64;uniqueIndex;not null" json:"-"` // sha256, rejects re-uploads
+	Source        string     `gorm:"column:source;size:20" json:"source"`                    // bank or gateway
+	StatementFrom *time.Time `gorm:"column:statement_from" json:"statement_from"`
+	StatementTo   *time.Time `gorm:"column:statement_to" json:"statement_to"`
+	TotalLines    int        `gorm:"column:total_lines" json:"total_lines"`
+	MatchedLines  int        `gorm:"column:matched_lines" json:"matched_lines"` // matched automatically on import
+	UploadedBy    *int64     `gorm:"column:uploaded_by" json:"uploaded_by"`
+	CreatedAt     time.Time  `gorm:"column:created_at" json:"created_at"`
+}
+
+func (BankStatementImport) TableName() string { return "bank_statement_imports" }
+
+// BankStatementLine is a credit on an imported statement and what it was matched to
+type BankStatementLine struct {
+	LineID           int64     `gorm:"column:line_id;primaryKey;autoIncrement" json:"line_id"`
+	ImportID         int64     `gorm:"column:import_id;index;not null" json:"import_id"`
+	LineNo           int       `gorm:"column:line_no" json:"line_no"`
+	TxnDate          time.Time `gorm:"column:txn_date;index" json:"txn_date"`
+	Reference        *string   `gorm:"column:reference;size:100;index" json:"reference"`
+	Amount           float64   `gorm:"column:amount;type:decimal(12,2);not null" json:"amount"`
+	Narration        *string   `gorm:"column:narration;size:255" json:"narration"`
+	Status           string    `gorm:"column:status;size:20;index" json:"status"` // matched, mismatch, manual, unmatched, ignored
+	PaymentEntryID   *int64    `gorm:"column:payment_entry_id;index" json:"payment_entry_id"`
+	ReconciliationID *int      `gorm:"column:reconciliation_id" json:"reconciliation_id"`
+	Remarks          *string   `gorm:"column:remarks;size:255" json:"remarks"`
+	CreatedAt        time.Time `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt        time.Time `gorm:"column:updated_at" json:"updated_at"`
+}
+
+func (BankStatementLine) TableName() string { return "bank_statement_lines" }
+
+// PaymentProof is the scanned slip, DD or transfer confirmation attached to an offline payment
+type PaymentProof struct {
+	ProofID        int64     `gorm:"column:proof_id;primaryKey;autoIncrement" json:"proof_id"`
+	PaymentEntryID int64     `gorm:"column:payment_entry_id;uniqueIndex;not null" json:"payment_entry_id"`
+	FileName       string    `gorm:"column:file_name;size:255" json:"file_name"`
+	StoredName     string    `gorm:"column:stored_name;size:100;not null" json:"-"` // file under UPLOAD_DIR/payment_proofs
+	ContentType    string    `gorm:"column:content_type;size:100" json:"content_type"`
+	Size           int64     `gorm:"column:size" json:"size"`
+	UploadedBy     *int64    `gorm:"column:uploaded_by" json:"uploaded_by"`
+	CreatedAt      time.Time `gorm:"column:created_at" json:"created_at"`
+}
+
+func (PaymentProof) TableName() string { return "payment_proofs" }
+
+// AssessmentScheme says how a subject's final marks (out of 100) are built: InternalShare marks come
+// from the weighted internal components, the rest from the external exam
+type AssessmentScheme struct {
+	SchemeID         int                   `gorm:"column:scheme_id;primaryKey;autoIncrement" json:"scheme_id"`
+	SubjectCode      string                `gorm:"column:subject_code;size:50;uniqueIndex;not null" json:"subject_code"`
+	InternalShare    float64               `gorm:"column:internal_share;type:decimal(5,2);not null" json:"internal_share"`         // e.g. 30 of 100
+	ExternalMaxMarks float64               `gorm:"column:external_max_marks;type:decimal(6,2);not null" json:"external_max_marks"` // what the external exam is marked out of
+	Components       []AssessmentComponent `gorm:"foreignKey:SchemeID" json:"components"`
+	UpdatedBy        *int64                `gorm:"column:updated_by" json:"updated_by"`
+	CreatedAt        time.Time             `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt        time.Time             `gorm:"column:updated_at" json:"updated_at"`
+}
+
+func (AssessmentScheme) TableName() string { return "assessment_schemes" }
+
+// AssessmentComponent is one internal mark type of a scheme; weights are relative to each other
+type AssessmentComponent struct {
+	ComponentID int     `gorm:"column:component_id;primaryKey;autoIncrement" json:"component_id"`
+	SchemeID    int     `gorm:"column:scheme_id;uniqueIndex:idx_assessment_component,priority:1;not null" json:"scheme_id"`
+	MarkType    string  `gorm:"column:mark_type;size:30;uniqueIndex:idx_assessment_component,priority:2;not null" json:"mark_type"` // MSE1, MSE2, Assignment, Practical
+	Weight      float64 `gorm:"column:weight;type:decimal(6,2);not null" json:"weight"`
+	MaxMarks    float64 `gorm:"column:max_marks;type:decimal(6,2);not null" json:"max_marks"`
+}
+
+func (AssessmentComponent) TableName() string { return "assessment_components" }
+
+// ExternalMark is a student's end-semester exam mark for a subject
+type ExternalMark struct {
+	ExternalMarkID   int64     `gorm:"column:external_mark_id;primaryKey;autoIncrement" json:"external_mark_id"`
+	EnrollmentNumber int64     `gorm:"column:enrollment_number;uniqueIndex:idx_external_mark,priority:1;not null" json:"enrollment_number"`
+	Semester         int       `gorm:"column:semester;uniqueIndex:idx_external_mark,priority:2;not null" json:"semester"`
+	SubjectCode      string    `gorm:"column:subject_code;size:50;uniqueIndex:idx_external_mark,priority:3;not null" json:"subject_code"`
+	MarksObtained    float64   `gorm:"column:marks_obtained;type:decimal(6,2)" json:"marks_obtained"`
+	Absent           bool      `gorm:"column:absent;default:false" json:"absent"`
+	EnteredBy        *int64    `gorm:"column:entered_by" json:"entered_by"`
+	CreatedAt        time.Time `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt        time.Time `gorm:"column:updated_at" json:"updated_at"`
+}
+
+func (ExternalMark) TableName() string { return "external_marks" }
+
+// ResultBatch is one institute's semester going through result processing. Processing stages the
+// final subject marks; publishing writes them and the semester results in a single transaction.
+type ResultBatch struct {
+	BatchID     int64      `gorm:"column:batch_id;primaryKey;autoIncrement" json:"batch_id"`
+	InstituteID int        `gorm:"column:institute_id;uniqueIndex:idx_result_batch_round,priority:1;not null" json:"institute_id"`
+	Semester    int        `gorm:"column:semester;uniqueIndex:idx_result_batch_round,priority:2;not null" json:"semester"`
+	Round       int        `gorm:"column:round;uniqueIndex:idx_result_batch_round,priority:3;not null;default:1" json:"round"` // follow-up batches for withheld students count up from 1
+	Status      string     `gorm:"column:status;size:20;not null" json:"status"`                                               // processed or published
+	Students    int        `gorm:"column:students" json:"students"`
+	Withheld    int        `gorm:"column:withheld" json:"withheld"`       // students left out for missing marks or schemes
+	Issues      *string    `gorm:"column:issues;type:text" json:"issues"` // JSON list of what is missing
+	ProcessedBy *int64     `gorm:"column:processed_by" json:"processed_by"`
+	ProcessedAt time.Time  `gorm:"column:processed_at" json:"processed_at"`
+	PublishedBy *int64     `gorm:"column:published_by" json:"published_by"`
+	PublishedAt *time.Time `gorm:"column:published_at" json:"published_at"`
+}
+
+func (ResultBatch) TableName() string { return "result_batches" }
+
+// ProcessedMark is a staged final subject mark awaiting publication
+type ProcessedMark struct {
+	ProcessedMarkID  int64   `gorm:"column:processed_mark_id;primaryKey;autoIncrement" json:"processed_mark_id"`
+	BatchID          int64   `gorm:"column:batch_id;uniqueIndex:idx_processed_mark,priority:1;not null" json:"batch_id"`
+	EnrollmentNumber int64   `gorm:"column:enrollment_number;uniqueIndex:idx_processed_mark,priority:2;not null" json:"enrollment_number"`
+	SubjectCode      string  `gorm:"column:subject_code;size:50;uniqueIndex:idx_processed_mark,priority:3;not null" json:"subject_code"`
+	SubjectName      string  `gorm:"column:subject_name" json:"subject_name"`
+	SubjectType      string  `gorm:"column:subject_type" json:"subject_type"`
+	InternalMarks    float64 `gorm:"column:internal_marks;type:decimal(6,2)" json:"internal_marks"`
+	ExternalMarks    float64 `gorm:"column:external_marks;type:decimal(6,2)" json:"external_marks"`
+	TotalMarks       float64 `gorm:"column:total_marks;type:decimal(6,2)" json:"total_marks"` // out of 100
+	Grade            string  `gorm:"column:grade;size:10" json:"grade"`
+	GradePoints      float64 `gorm:"column:grade_points;type:decimal(4,2)" json:"grade_points"`
+	Status           string  `gorm:"column:status;size:20" json:"status"` // Pass, Fail or Absent
+}
+
+func (ProcessedMark) TableName() string { return "processed_marks" }
+
+// RevaluationRequest is a student's challenge of a published subject mark. The original mark is
+// kept here when the revised one is applied.
+type RevaluationRequest struct {
+	RequestID        int64      `gorm:"column:request_id;primaryKey;autoIncrement" json:"request_id"`
+	EnrollmentNumber int64      `gorm:"column:enrollment_number;index:idx_revaluation_subject,priority:1;not null" json:"enrollment_number"`
+	Semester         int        `gorm:"column:semester;index:idx_revaluation_subject,priority:2;not null" json:"semester"`
+	SubjectCode      string     `gorm:"column:subject_code;size:50;index:idx_revaluation_subject,priority:3;not null" json:"subject_code"`
+	Kind             string     `gorm:"column:kind;size:20;not null" json:"kind"`      // revaluation or retotalling
+	Status           string     `gorm:"column:status;size:20;index" json:"status"`     // applied, assigned, evaluated, approved, rejected
+	FeeChargeID      *int64     `gorm:"column:fee_charge_id" json:"fee_charge_id"`     // fee_ledger charge the student pays
+	EvaluatorID      *int64     `gorm:"column:evaluator_id;index" json:"evaluator_id"` // faculty user_id
+	OriginalMarks    float64    `gorm:"column:original_marks;type:decimal(6,2)" json:"original_marks"`
+	OriginalGrade    *string    `gorm:"column:original_grade;size:10" json:"original_grade"`
+	RevisedMarks     *float64   `gorm:"column:revised_marks;type:decimal(6,2)" json:"revised_marks"`
+	RevisedGrade     *string    `gorm:"column:revised_grade;size:10" json:"revised_grade"`
+	EvaluatorRemarks *string    `gorm:"column:evaluator_remarks;size:255" json:"evaluator_remarks"`
+	EvaluatedAt      *time.Time `gorm:"column:evaluated_at" json:"evaluated_at"`
+	ReviewedBy       *int64     `gorm:"column:reviewed_by" json:"reviewed_by"`
+	ReviewedAt       *time.Time `gorm:"column:reviewed_at" json:"reviewed_at"`
+	ReviewRemarks    *string    `gorm:"column:review_remarks;size:255" json:"review_remarks"`
+	CreatedAt        time.Time  `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt        time.Time  `gorm:"column:updated_at" json:"updated_at"`
+}
+
+func (RevaluationRequest) TableName() string { return "revaluation_requests" }
+
+// ======================== EXAM SESSIONS ========================
+
+// ExamSession is an examination cycle (e.g. "Nov-Dec 2026") that students register for
+type ExamSession struct {
+	ExamSessionID      int        `gorm:"column:exam_session_id;primaryKey;autoIncrement" json:"exam_session_id"`
+	Name               string     `gorm:"column:name;size:100;uniqueIndex;not null" json:"name"`
+	Term               *string    `gorm:"column:term;size:50" json:"term"`                             // e.g. "Odd 2026-27"
+	ExamType           string     `gorm:"column:exam_type;size:20;default:'regular'" json:"exam_type"` // regular, supplementary, special
+	StartDate          *time.Time `gorm:"column:start_date;type:date" json:"start_date"`
+	EndDate            *time.Time `gorm:"column:end_date;type:date" json:"end_date"`
+	RegistrationOpens  *time.Time `gorm:"column:registration_opens;type:date" json:"registration_opens"`
+	RegistrationCloses *time.Time `gorm:"column:registration_closes;type:date" json:"registration_closes"` // last day, inclusive
+	AllowReappear      bool       `gorm:"column:allow_reappear;default:true" json:"allow_reappear"`
+	ReappearFee        float64    `gorm:"column:reappear_fee;type:decimal(10,2)" json:"reappear_fee"` // per subject
+	Status             string     `gorm:"column:status;size:20;default:'draft'" json:"status"`        // draft, open, closed
+	CreatedBy          *int64     `gorm:"column:created_by" json:"created_by"`
+	CreatedAt          time.Time  `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt          time.Time  `gorm:"column:updated_at" json:"updated_at"`
+}
+
+func (ExamSession) TableName() string { return "exam_sessions" }
+
+// DateSheetEntry schedules one subject's paper for a course in an exam session
+type DateSheetEntry struct {
+	EntryID       int64     `gorm:"column:entry_id;primaryKey;autoIncrement" json:"entry_id"`
+	ExamSessionID int       `gorm:"column:exam_session_id;uniqueIndex:idx_date_sheet_subject,priority:1;not null" json:"exam_session_id"`
+	CourseName    string    `gorm:"column:course_name;size:255;uniqueIndex:idx_date_sheet_subject,priority:2;not null" json:"course_name"`
+	SubjectCode   string    `gorm:"column:subject_code;size:50;uniqueIndex:idx_date_sheet_subject,priority:3;not null" json:"subject_code"`
+	SubjectName   string    `gorm:"column:subject_name;size:255" json:"subject_name"`
+	Semester      int       `gorm:"column:semester;not null" json:"semester"`
+	ExamDate      time.Time `gorm:"column:exam_date;type:date;not null" json:"exam_date"`
+	StartTime     string    `gorm:"column:start_time;size:5;not null" json:"start_time"` // HH:MM
+	EndTime       string    `gorm:"column:end_time;size:5;not null" json:"end_time"`
+	CreatedAt     time.Time `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt     time.Time `gorm:"column:updated_at" json:"updated_at"`
+}
+
+func (DateSheetEntry) TableName() string { return "exam_date_sheet" }
+
+// HallTicket admits a student to an exam session's papers
+type HallTicket struct {
+	TicketID         int64     `gorm:"column:ticket_id;primaryKey;autoIncrement" json:"ticket_id"`
+	ExamSessionID    int       `gorm:"column:exam_session_id;uniqueIndex:idx_hall_ticket_student,priority:1;not null" json:"exam_session_id"`
+	EnrollmentNumber int64     `gorm:"column:enrollment_number;uniqueIndex:idx_hall_ticket_student,priority:2;not null" json:"enrollment_number"`
+	TicketNumber     string    `gorm:"column:ticket_number;size:40;uniqueIndex;not null" json:"ticket_number"`
+	CourseName       string    `gorm:"column:course_name;size:255" json:"course_name"`
+	Semester         int       `gorm:"column:semester" json:"semester"` // regular semester sat; 0 for backlog papers only
+	VerificationCode string    `gorm:"column:verification_code;size:16;uniqueIndex;not null" json:"verification_code"`
+	IssuedBy         *int64    `gorm:"column:issued_by" json:"issued_by"` // nil when the student downloaded it themselves
+	IssuedAt         time.Time `gorm:"column:issued_at" json:"issued_at"`
+}
+
+func (HallTicket) TableName() string { return "hall_tickets" }
+
+// ExamRoom is a hall with benches laid out in rows
+type ExamRoom struct {
+	RoomID        int       `gorm:"column:room_id;primaryKey;autoIncrement" json:"room_id"`
+	Name          string    `gorm:"column:name;size:50;uniqueIndex;not null" json:"name"`
+	Building      *string   `gorm:"column:building;size:100" json:"building"`
+	Rows          int       `gorm:"column:bench_rows;not null" json:"rows"`
+	BenchesPerRow int       `gorm:"column:benches_per_row;not null" json:"benches_per_row"`
+	SeatsPerBench int       `gorm:"column:seats_per_bench;default:2" json:"seats_per_bench"`
+	IsActive      bool      `gorm:"column:is_active;default:true" json:"is_active"`
+	CreatedAt     time.Time `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt     time.Time `gorm:"column:updated_at" json:"updated_at"`
+}
+
+func (ExamRoom) TableName() string { return "exam_rooms" }
+
+// SeatAllocation places a student at a bench for one exam slot
+type SeatAllocation struct {
+	AllocationID     int64     `gorm:"column:allocation_id;primaryKey;autoIncrement" json:"allocation_id"`
+	ExamSessionID    int       `gorm:"column:exam_session_id;uniqueIndex:idx_seat_student,priority:1;uniqueIndex:idx_seat_position,priority:1;not null" json:"exam_session_id"`
+	ExamDate         time.Time `gorm:"column:exam_date;type:date;uniqueIndex:idx_seat_student,priority:2;uniqueIndex:idx_seat_position,priority:2;not null" json:"exam_date"`
+	StartTime        string    `gorm:"column:start_time;size:5;uniqueIndex:idx_seat_student,priority:3;uniqueIndex:idx_seat_position,priority:3;not null" json:"start_time"`
+	EnrollmentNumber int64     `gorm:"column:enrollment_number;uniqueIndex:idx_seat_student,priority:4;not null" json:"enrollment_number"`
+	RoomID           int       `gorm:"column:room_id;uniqueIndex:idx_seat_position,priority:4;not null" json:"room_id"`
+	BenchRow         int       `gorm:"column:bench_row;uniqueIndex:idx_seat_position,priority:5" json:"bench_row"`
+	BenchNo          int       `gorm:"column:bench_no;uniqueIndex:idx_seat_position,priority:6" json:"bench_no"`
+	Seat             int       `gorm:"column:seat;uniqueIndex:idx_seat_position,priority:7" json:"seat"`
+	SubjectCode      string    `gorm:"column:subject_code;size:50" json:"subject_code"`
+	CreatedAt        time.Time `gorm:"column:created_at" json:"created_at"`
+}
+
+func (SeatAllocation) TableName() string { return "seat_allocations" }
+
+// ======================== ACADEMIC RULES & ATTENDANCE ELIGIBILITY ========================
+
+// AcademicRuleSet is one version of the academic rules. Every change adds a version; the latest
+// applies and earlier ones are kept for reference. Rules is the free-text statement shown to users.
+type AcademicRuleSet struct {
+	ID                    int        `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	Version               int        `gorm:"column:version;default:1" json:"version"`
+	Rules                 string     `gorm:"column:rules;type:text" json:"rules"`
+	MinAttendancePercent  float64    `gorm:"column:min_attendance_percent;type:decimal(5,2);default:75" json:"min_attendance_percent"`
+	CondonationMinPercent float64    `gorm:"column:condonation_min_percent;type:decimal(5,2);default:65" json:"condonation_min_percent"` // from here up to the minimum, attendance can be condoned
+	InternalWeightage     float64    `gorm:"column:internal_weightage;type:decimal(5,2);default:30" json:"internal_weightage"`           // percent of a subject's marks from internals
+	MaxReappearSubjects   int        `gorm:"column:max_reappear_subjects;default:0" json:"max_reappear_subjects"`                        // per exam session, and the most backlogs for ATKT; 0 for no limit
+	EffectiveFrom         *time.Time `gorm:"column:effective_from;type:date" json:"effective_from"`
+	UpdatedAt             time.Time  `gorm:"column:updated_at" json:"updated_at"`
+	UpdatedBy             *int64     `gorm:"column:updated_by" json:"updated_by"`
+}
+
+func (AcademicRuleSet) TableName() string { return "academic_rules" }
+
+// AttendanceCondonation is a request to excuse a shortfall in a subject's attendance, decided by
+// the student's institute
+type AttendanceCondonation struct {
+	CondonationID     int64      `gorm:"column:condonation_id;primaryKey;autoIncrement" json:"condonation_id"`
+	EnrollmentNumber  int64      `gorm:"column:enrollment_number;uniqueIndex:idx_condonation_subject,priority:1;not null" json:"enrollment_number"`
+	Semester          int        `gorm:"column:semester;uniqueIndex:idx_condonation_subject,priority:2;not null" json:"semester"`
+	SubjectCode       string     `gorm:"column:subject_code;size:50;uniqueIndex:idx_condonation_subject,priority:3;not null" json:"subject_code"`
+	InstituteID       int        `gorm:"column:institute_id;index" json:"institute_id"`
+	CourseName        string     `gorm:"column:course_name;size:255" json:"course_name"`
+	AttendancePercent float64    `gorm:"column:attendance_percent;type:decimal(5,2)" json:"attendance_percent"` // when requested
+	RulesVersion      int        `gorm:"column:rules_version" json:"rules_version"`
+	Reason            string     `gorm:"column:reason;type:text" json:"reason"`
+	Status            string     `gorm:"column:status;size:20;default:'pending'" json:"status"` // pending, approved, rejected
+	RequestedBy       *int64     `gorm:"column:requested_by" json:"requested_by"`
+	ReviewedBy        *int64     `gorm:"column:reviewed_by" json:"reviewed_by"`
+	ReviewedAt        *time.Time `gorm:"column:reviewed_at" json:"reviewed_at"`
+	ReviewRemarks     *string    `gorm:"column:review_remarks;type:text" json:"review_remarks"`
+	CreatedAt         time.Time  `gorm:"column:created_at" json:"created_at"`
+}
+
+func (AttendanceCondonation) TableName() string { return "attendance_condonations" }
@@ -0,0 +1,68 @@
+package payments
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+)
+
+// Amounts are always in the smallest currency unit (paise for INR)
+
+// Order is a gateway order the student pays against
+type Order struct {
+	ID       string
+	Amount   int64
+	Currency string
+	Receipt  string
+	Status   string
+}
+
+// Payment is a single payment attempt as reported by the gateway
+type Payment struct {
+	ID             string
+	OrderID        string
+	Status         string // created, authorized, captured, failed, refunded
+	Amount         int64
+	AmountRefunded int64
+	Method         string
+}
+
+// Refund is a (partial) refund issued against a captured payment
+type Refund struct {
+	ID        string
+	PaymentID string
+	Amount    int64
+	Status    string
+}
+
+// PaymentGateway is what the fee module needs from a payment provider
+type PaymentGateway interface {
+	// Name identifies the provider in logs and stored records, e.g. "razorpay"
+	Name() string
+	// KeyID is the public key handed to the browser checkout
+	KeyID() string
+	CreateOrder(amount int64, currency, receipt string, notes map[string]string) (Order, error)
+	FetchPayment(paymentID string) (Payment, error)
+	FetchOrderPayments(orderID string) ([]Payment, error)
+	VerifyPaymentSignature(orderID, paymentID, signature string) bool
+	VerifyWebhookSignature(body []byte, signature string) bool
+	Refund(paymentID string, amount int64, notes map[string]string) (Refund, error)
+}
+
+// ErrNotFound is returned when the gateway has no such order or payment
+var ErrNotFound = errors.New("not found")
+
+// hmacHex is the HMAC-SHA256 hex digest Razorpay uses for checkout and webhook signatures
+func hmacHex(secret, message string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(message))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func verifyHMAC(secret, message, signature string) bool {
+	if secret == "" || signature == "" {
+		return false
+	}
+	return hmac.Equal([]byte(hmacHex(secret, message)), []byte(signature))
+}
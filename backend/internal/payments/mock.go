@@ -0,0 +1,134 @@
+package payments
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sync"
+)
+
+// ======================== LOCAL DEVELOPMENT ========================
+
+// MockGateway keeps orders and payments in memory and signs them like Razorpay does, so the
+// whole request → checkout → verify → webhook flow runs without credentials.
+// Checkout is simulated with Pay (exposed over HTTP by the API in mock mode).
+type MockGateway struct {
+	secret        string
+	webhookSecret string
+
+	mu       sync.Mutex
+	orders   map[string]*Order
+	payments map[string]*Payment
+	refunds  map[string][]Refund
+}
+
+func NewMockGateway(secret, webhookSecret string) *MockGateway {
+	if secret == "" {
+		secret = "mock_secret"
+	}
+	if webhookSecret == "" {
+		webhookSecret = secret
+	}
+	return &MockGateway{
+		secret:        secret,
+		webhookSecret: webhookSecret,
+		orders:        map[string]*Order{},
+		payments:      map[string]*Payment{},
+		refunds:       map[string][]Refund{},
+	}
+}
+
+func (g *MockGateway) Name() string  { return "mock" }
+func (g *MockGateway) KeyID() string { return "mock_key" }
+
+func (g *MockGateway) CreateOrder(amount int64, currency, receipt string, notes map[string]string) (Order, error) {
+	if amount <= 0 {
+		return Order{}, fmt.Errorf("amount must be positive")
+	}
+	order := Order{ID: mockID("order"), Amount: amount, Currency: currency, Receipt: receipt, Status: "created"}
+	g.mu.Lock()
+	g.orders[order.ID] = &order
+	g.mu.Unlock()
+	return order, nil
+}
+
+func (g *MockGateway) FetchPayment(paymentID string) (Payment, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	p, ok := g.payments[paymentID]
+	if !ok {
+		return Payment{}, ErrNotFound
+	}
+	return *p, nil
+}
+
+func (g *MockGateway) FetchOrderPayments(orderID string) ([]Payment, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if _, ok := g.orders[orderID]; !ok {
+		return nil, ErrNotFound
+	}
+	var out []Payment
+	for _, p := range g.payments {
+		if p.OrderID == orderID {
+			out = append(out, *p)
+		}
+	}
+	return out, nil
+}
+
+func (g *MockGateway) VerifyPaymentSignature(orderID, paymentID, signature string) bool {
+	return verifyHMAC(g.secret, orderID+"|"+paymentID, signature)
+}
+
+func (g *MockGateway) VerifyWebhookSignature(body []byte, signature string) bool {
+	return verifyHMAC(g.webhookSecret, string(body), signature)
+}
+
+func (g *MockGateway) Refund(paymentID string, amount int64, notes map[string]string) (Refund, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	p, ok := g.payments[paymentID]
+	if !ok {
+		return Refund{}, ErrNotFound
+	}
+	if p.Status != "captured" {
+		return Refund{}, fmt.Errorf("payment %s is %s", paymentID, p.Status)
+	}
+	if amount <= 0 || p.AmountRefunded+amount > p.Amount {
+		return Refund{}, fmt.Errorf("refund amount exceeds captured amount")
+	}
+	p.AmountRefunded += amount
+	if p.AmountRefunded == p.Amount {
+		p.Status = "refunded"
+	}
+	r := Refund{ID: mockID("rfnd"), PaymentID: paymentID, Amount: amount, Status: "processed"}
+	g.refunds[paymentID] = append(g.refunds[paymentID], r)
+	return r, nil
+}
+
+// Pay simulates the student completing (or failing) checkout for an order. It returns the payment
+// and the signature the browser would post to the verify endpoint.
+func (g *MockGateway) Pay(orderID string, succeed bool) (Payment, string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	order, ok := g.orders[orderID]
+	if !ok {
+		return Payment{}, "", ErrNotFound
+	}
+	p := Payment{ID: mockID("pay"), OrderID: orderID, Amount: order.Amount, Method: "mock", Status: "failed"}
+	if succeed {
+		p.Status = "captured"
+		order.Status = "paid"
+	} else {
+		order.Status = "attempted"
+	}
+	g.payments[p.ID] = &p
+	return p, hmacHex(g.secret, orderID+"|"+p.ID), nil
+}
+
+func mockID(prefix string) string {
+	b := make([]byte, 7)
+	rand.Read(b)
+	return prefix + "_mock" + hex.EncodeToString(b)
+}
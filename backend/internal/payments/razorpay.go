@@ -0,0 +1,129 @@
+package payments
+
+import (
+	"strings"
+
+	razorpay "github.com/razorpay/razorpay-go"
+)
+
+// ======================== RAZORPAY ========================
+
+// RazorpayGateway talks to the Razorpay REST API
+type RazorpayGateway struct {
+	client        *razorpay.Client
+	keyID         string
+	secret        string
+	webhookSecret string
+}
+
+func NewRazorpayGateway(keyID, secret, webhookSecret string) *RazorpayGateway {
+	return &RazorpayGateway{
+		client:        razorpay.NewClient(keyID, secret),
+		keyID:         keyID,
+		secret:        secret,
+		webhookSecret: webhookSecret,
+	}
+}
+
+func (g *RazorpayGateway) Name() string  { return "razorpay" }
+func (g *RazorpayGateway) KeyID() string { return g.keyID }
+
+func (g *RazorpayGateway) CreateOrder(amount int64, currency, receipt string, notes map[string]string) (Order, error) {
+	data := map[string]interface{}{"amount": amount, "currency": currency, "receipt": receipt}
+	if len(notes) > 0 {
+		data["notes"] = notes
+	}
+	resp, err := g.client.Order.Create(data, nil)
+	if err != nil {
+		return Order{}, err
+	}
+	return Order{
+		ID:       str(resp["id"]),
+		Amount:   num(resp["amount"]),
+		Currency: str(resp["currency"]),
+		Receipt:  str(resp["receipt"]),
+		Status:   str(resp["status"]),
+	}, nil
+}
+
+func (g *RazorpayGateway) FetchPayment(paymentID string) (Payment, error) {
+	resp, err := g.client.Payment.Fetch(paymentID, nil, nil)
+	if err != nil {
+		if strings.Contains(strings.ToLower(err.Error()), "does not exist") {
+			return Payment{}, ErrNotFound
+		}
+		return Payment{}, err
+	}
+	return paymentFromMap(resp), nil
+}
+
+func (g *RazorpayGateway) FetchOrderPayments(orderID string) ([]Payment, error) {
+	resp, err := g.client.Order.Payments(orderID, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	items, _ := resp["items"].([]interface{})
+	out := make([]Payment, 0, len(items))
+	for _, item := range items {
+		if m, ok := item.(map[string]interface{}); ok {
+			out = append(out, paymentFromMap(m))
+		}
+	}
+	return out, nil
+}
+
+// VerifyPaymentSignature checks the checkout callback signature: HMAC(order_id|payment_id, key secret)
+func (g *RazorpayGateway) VerifyPaymentSignature(orderID, paymentID, signature string) bool {
+	return verifyHMAC(g.secret, orderID+"|"+paymentID, signature)
+}
+
+// VerifyWebhookSignature checks X-Razorpay-Signature: HMAC(raw body, webhook secret)
+func (g *RazorpayGateway) VerifyWebhookSignature(body []byte, signature string) bool {
+	return verifyHMAC(g.webhookSecret, string(body), signature)
+}
+
+func (g *RazorpayGateway) Refund(paymentID string, amount int64, notes map[string]string) (Refund, error) {
+	data := map[string]interface{}{}
+	if len(notes) > 0 {
+		data["notes"] = notes
+	}
+	resp, err := g.client.Payment.Refund(paymentID, int(amount), data, nil)
+	if err != nil {
+		return Refund{}, err
+	}
+	return Refund{
+		ID:        str(resp["id"]),
+		PaymentID: str(resp["payment_id"]),
+		Amount:    num(resp["amount"]),
+		Status:    str(resp["status"]),
+	}, nil
+}
+
+func paymentFromMap(m map[string]interface{}) Payment {
+	return Payment{
+		ID:             str(m["id"]),
+		OrderID:        str(m["order_id"]),
+		Status:         str(m["status"]),
+		Amount:         num(m["amount"]),
+		AmountRefunded: num(m["amount_refunded"]),
+		Method:         str(m["method"]),
+	}
+}
+
+func str(v interface{}) string {
+	s, _ := v.(string)
+	return s
+}
+
+// num reads a JSON number, which the Razorpay client decodes as float64
+func num(v interface{}) int64 {
+	switch n := v.(type) {
+	case float64:
+		return int64(n)
+	case int64:
+		return n
+	case int:
+		return int64(n)
+	}
+	return 0
+}
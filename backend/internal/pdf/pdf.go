@@ -0,0 +1,194 @@
+// Package pdf writes simple A4 documents (text, lines, boxes and images in the standard Helvetica
+// fonts) without any external dependency. It is enough for receipts, hall tickets and similar
+// printouts.
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"image"
+	"strings"
+)
+
+// A4 page size in points
+const (
+	PageWidth  = 595.28
+	PageHeight = 841.89
+)
+
+// Document is a multi-page PDF. Coordinates are in points from the top-left corner of the page.
+type Document struct {
+	pages  []*bytes.Buffer
+	cur    *bytes.Buffer
+	images []pdfImage
+}
+
+// pdfImage is an embedded RGB image, Flate compressed
+type pdfImage struct {
+	width, height int
+	data          []byte
+}
+
+// New returns a document with one empty page
+func New() *Document {
+	d := &Document{}
+	d.AddPage()
+	return d
+}
+
+// AddPage starts a new page; subsequent drawing goes to it
+func (d *Document) AddPage() {
+	d.cur = &bytes.Buffer{}
+	d.pages = append(d.pages, d.cur)
+}
+
+// Text draws a single line of text with its baseline at y
+func (d *Document) Text(x, y, size float64, bold bool, s string) {
+	font := "F1"
+	if bold {
+		font = "F2"
+	}
+	fmt.Fprintf(d.cur, "BT /%s %.2f Tf %.2f %.2f Td (%s) Tj ET\n", font, size, x, PageHeight-y, escape(s))
+}
+
+// TextRight draws text ending at x
+func (d *Document) TextRight(x, y, size float64, bold bool, s string) {
+	d.Text(x-TextWidth(s, size, bold), y, size, bold, s)
+}
+
+// TextCenter draws text centred on x
+func (d *Document) TextCenter(x, y, size float64, bold bool, s string) {
+	d.Text(x-TextWidth(s, size, bold)/2, y, size, bold, s)
+}
+
+// Wrap draws text wrapped to width and returns the y below the last line
+func (d *Document) Wrap(x, y, width, size float64, bold bool, s string) float64 {
+	line := ""
+	for _, word := range strings.Fields(s) {
+		next := word
+		if line != "" {
+			next = line + " " + word
+		}
+		if line != "" && TextWidth(next, size, bold) > width {
+			d.Text(x, y, size, bold, line)
+			y += size * 1.3
+			next = word
+		}
+		line = next
+	}
+	if line != "" {
+		d.Text(x, y, size, bold, line)
+		y += size * 1.3
+	}
+	return y
+}
+
+// Line draws a straight line
+func (d *Document) Line(x1, y1, x2, y2, width float64) {
+	fmt.Fprintf(d.cur, "%.2f w %.2f %.2f m %.2f %.2f l S\n", width, x1, PageHeight-y1, x2, PageHeight-y2)
+}
+
+// Rect draws a rectangle outline with its top-left corner at x, y
+func (d *Document) Rect(x, y, w, h, width float64) {
+	fmt.Fprintf(d.cur, "%.2f w %.2f %.2f %.2f %.2f re S\n", width, x, PageHeight-y-h, w, h)
+}
+
+// FillRect draws a filled rectangle in a grey level between 0 (black) and 1 (white)
+func (d *Document) FillRect(x, y, w, h, gray float64) {
+	fmt.Fprintf(d.cur, "q %.2f g %.2f %.2f %.2f %.2f re f Q\n", gray, x, PageHeight-y-h, w, h)
+}
+
+// Image draws img scaled into the w x h box with its top-left corner at x, y. Transparency is
+// flattened onto white.
+func (d *Document) Image(x, y, w, h float64, img image.Image) {
+	b := img.Bounds()
+	raw := make([]byte, 0, b.Dx()*b.Dy()*3)
+	for py := b.Min.Y; py < b.Max.Y; py++ {
+		for px := b.Min.X; px < b.Max.X; px++ {
+			r, g, bl, a := img.At(px, py).RGBA()
+			// RGBA is alpha-premultiplied; add the white background for the uncovered part
+			white := 0xffff - a
+			raw = append(raw, byte((r+white)>>8), byte((g+white)>>8), byte((bl+white)>>8))
+		}
+	}
+	var buf bytes.Buffer
+	zw := zlib.NewWriter(&buf)
+	zw.Write(raw)
+	zw.Close()
+	d.images = append(d.images, pdfImage{width: b.Dx(), height: b.Dy(), data: buf.Bytes()})
+	fmt.Fprintf(d.cur, "q %.2f 0 0 %.2f %.2f %.2f cm /Im%d Do Q\n", w, h, x, PageHeight-y-h, len(d.images))
+}
+
+// Bytes renders the document
+func (d *Document) Bytes() []byte {
+	var out bytes.Buffer
+	var offsets []int
+	obj := func(body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	// 1 catalog, 2 page tree, 3-4 fonts, the images, then a page and its content stream per page
+	firstPage := 5 + len(d.images)
+	obj("<< /Type /Catalog /Pages 2 0 R >>")
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", firstPage+2*i)
+	}
+	obj(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+	obj("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	obj("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+	xobjects := ""
+	for i, img := range d.images {
+		obj(fmt.Sprintf("<< /Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /DeviceRGB "+
+			"/BitsPerComponent 8 /Filter /FlateDecode /Length %d >>\nstream\n%s\nendstream", img.width, img.height, len(img.data), img.data))
+		xobjects += fmt.Sprintf(" /Im%d %d 0 R", i+1, 5+i)
+	}
+	if xobjects != "" {
+		xobjects = " /XObject <<" + xobjects + " >>"
+	}
+	for i, page := range d.pages {
+		obj(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.2f %.2f] "+
+			"/Resources << /Font << /F1 3 0 R /F2 4 0 R >>%s >> /Contents %d 0 R >>", PageWidth, PageHeight, xobjects, firstPage+1+2*i))
+		obj(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", page.Len(), page.String()))
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+	return out.Bytes()
+}
+
+// escape makes s safe inside a PDF string literal. Characters outside Latin-1 become '?'.
+func escape(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r == '\t':
+			b.WriteByte(' ')
+		case r < 32 || r > 255:
+			b.WriteByte('?')
+		default:
+			b.WriteByte(byte(r))
+		}
+	}
+	return b.String()
+}
+
+// TextWidth approximates the width of s in points. Helvetica averages about half the font size
+// per character; bold runs slightly wider.
+func TextWidth(s string, size float64, bold bool) float64 {
+	factor := 0.5
+	if bold {
+		factor = 0.55
+	}
+	return float64(len([]rune(s))) * size * factor
+}
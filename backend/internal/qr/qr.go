@@ -0,0 +1,435 @@
+// Package qr encodes short byte strings (verification URLs and the like) as QR codes without any
+// external dependency. It supports byte mode at error correction level M, versions 1 to 10, which
+// holds up to 213 bytes.
+package qr
+
+import (
+	"errors"
+	"math"
+)
+
+// Code is an encoded symbol: Modules[y][x] is true for a dark module. It has no quiet zone; leave
+// four modules of white around it when drawing.
+type Code struct {
+	Size    int
+	Modules [][]bool
+}
+
+// version describes one symbol size at level M
+type version struct {
+	total     int      // codewords in the symbol
+	ecPer     int      // error correction codewords per block
+	blocks    [][2]int // groups of {block count, data codewords per block}
+	alignment []int    // alignment pattern centre coordinates
+	remainder int      // bits left over after the last codeword
+}
+
+var versions = []version{
+	{},
+	{26, 10, [][2]int{{1, 16}}, nil, 0},
+	{44, 16, [][2]int{{1, 28}}, []int{6, 18}, 7},
+	{70, 26, [][2]int{{1, 44}}, []int{6, 22}, 7},
+	{100, 18, [][2]int{{2, 32}}, []int{6, 26}, 7},
+	{134, 24, [][2]int{{2, 43}}, []int{6, 30}, 7},
+	{172, 16, [][2]int{{4, 27}}, []int{6, 34}, 7},
+	{196, 18, [][2]int{{4, 31}}, []int{6, 22, 38}, 0},
+	{242, 22, [][2]int{{2, 38}, {2, 39}}, []int{6, 24, 42}, 0},
+	{292, 22, [][2]int{{3, 36}, {2, 37}}, []int{6, 26, 46}, 0},
+	{346, 26, [][2]int{{4, 43}, {1, 44}}, []int{6, 28, 50}, 0},
+}
+
+func (v version) dataCodewords() int {
+	n := 0
+	for _, g := range v.blocks {
+		n += g[0] * g[1]
+	}
+	return n
+}
+
+// ErrTooLong is returned when the data does not fit in a version 10 symbol
+var ErrTooLong = errors.New("qr: data too long")
+
+// Encode returns the smallest symbol that holds data
+func Encode(data string) (*Code, error) {
+	payload := []byte(data)
+	ver := 0
+	for v := 1; v < len(versions); v++ {
+		countBits := 8
+		if v >= 10 {
+			countBits = 16
+		}
+		if 4+countBits+8*len(payload) <= 8*versions[v].dataCodewords() {
+			ver = v
+			break
+		}
+	}
+	if ver == 0 {
+		return nil, ErrTooLong
+	}
+	info := versions[ver]
+
+	// Mode indicator, character count, data, terminator, then pad to the capacity
+	var bits bitBuffer
+	bits.append(0x4, 4)
+	if ver >= 10 {
+		bits.append(len(payload), 16)
+	} else {
+		bits.append(len(payload), 8)
+	}
+	for _, b := range payload {
+		bits.append(int(b), 8)
+	}
+	capacity := 8 * info.dataCodewords()
+	bits.append(0, min(4, capacity-len(bits)))
+	bits.append(0, (8-len(bits)%8)%8)
+	for pad := 0xEC; len(bits) < capacity; pad ^= 0xEC ^ 0x11 {
+		bits.append(pad, 8)
+	}
+	codewords := interleave(info, bits.bytes())
+
+	size := 17 + 4*ver
+	m := newMatrix(size)
+	m.drawFunctionPatterns(ver, info)
+	m.drawCodewords(codewords)
+
+	best, bestPenalty := 0, math.MaxInt
+	for mask := 0; mask < 8; mask++ {
+		m.applyMask(mask)
+		m.drawFormatBits(mask)
+		if p := m.penalty(); p < bestPenalty {
+			best, bestPenalty = mask, p
+		}
+		m.applyMask(mask) // XOR again to undo
+	}
+	m.applyMask(best)
+	m.drawFormatBits(best)
+	return &Code{Size: size, Modules: m.dark}, nil
+}
+
+// ---------------------------------------------------------------------------------------------
+
+type bitBuffer []bool
+
+func (b *bitBuffer) append(v, n int) {
+	for i := n - 1; i >= 0; i-- {
+		*b = append(*b, (v>>i)&1 == 1)
+	}
+}
+
+func (b bitBuffer) bytes() []byte {
+	out := make([]byte, len(b)/8)
+	for i, bit := range b {
+		if bit {
+			out[i/8] |= 0x80 >> (i % 8)
+		}
+	}
+	return out
+}
+
+// interleave splits the data into blocks, adds Reed-Solomon codewords to each and interleaves
+// data codewords, then error correction codewords, block by block
+func interleave(v version, data []byte) []byte {
+	var blocks, ecc [][]byte
+	divisor := rsDivisor(v.ecPer)
+	offset := 0
+	for _, g := range v.blocks {
+		for i := 0; i < g[0]; i++ {
+			block := data[offset : offset+g[1]]
+			offset += g[1]
+			blocks = append(blocks, block)
+			ecc = append(ecc, rsRemainder(block, divisor))
+		}
+	}
+	out := make([]byte, 0, v.total)
+	for i := 0; ; i++ {
+		added := false
+		for _, b := range blocks {
+			if i < len(b) {
+				out = append(out, b[i])
+				added = true
+			}
+		}
+		if !added {
+			break
+		}
+	}
+	for i := 0; i < v.ecPer; i++ {
+		for _, e := range ecc {
+			out = append(out, e[i])
+		}
+	}
+	return out
+}
+
+// gfMul multiplies in GF(2^8) modulo x^8 + x^4 + x^3 + x^2 + 1
+func gfMul(x, y byte) byte {
+	var z int
+	for i := 7; i >= 0; i-- {
+		z = (z << 1) ^ ((z >> 7) * 0x11D)
+		z ^= int((y>>i)&1) * int(x)
+	}
+	return byte(z)
+}
+
+// rsDivisor is the generator polynomial of the given degree, highest term first and the leading
+// 1 omitted
+func rsDivisor(degree int) []byte {
+	result := make([]byte, degree)
+	result[degree-1] = 1
+	root := byte(1)
+	for i := 0; i < degree; i++ {
+		for j := range result {
+			result[j] = gfMul(result[j], root)
+			if j+1 < len(result) {
+				result[j] ^= result[j+1]
+			}
+		}
+		root = gfMul(root, 0x02)
+	}
+	return result
+}
+
+func rsRemainder(data, divisor []byte) []byte {
+	result := make([]byte, len(divisor))
+	for _, b := range data {
+		factor := b ^ result[0]
+		copy(result, result[1:])
+		result[len(result)-1] = 0
+		for i, coef := range divisor {
+			result[i] ^= gfMul(coef, factor)
+		}
+	}
+	return result
+}
+
+// ---------------------------------------------------------------------------------------------
+
+type matrix struct {
+	size     int
+	dark     [][]bool
+	function [][]bool // finder, timing, alignment, format and version modules; never masked
+}
+
+func newMatrix(size int) *matrix {
+	m := &matrix{size: size, dark: make([][]bool, size), function: make([][]bool, size)}
+	for i := range m.dark {
+		m.dark[i] = make([]bool, size)
+		m.function[i] = make([]bool, size)
+	}
+	return m
+}
+
+func (m *matrix) set(x, y int, dark bool) {
+	m.dark[y][x] = dark
+	m.function[y][x] = true
+}
+
+func (m *matrix) drawFunctionPatterns(ver int, v version) {
+	for i := 0; i < m.size; i++ {
+		m.set(6, i, i%2 == 0)
+		m.set(i, 6, i%2 == 0)
+	}
+	for _, c := range [][2]int{{3, 3}, {m.size - 4, 3}, {3, m.size - 4}} {
+		for dy := -4; dy <= 4; dy++ {
+			for dx := -4; dx <= 4; dx++ {
+				x, y := c[0]+dx, c[1]+dy
+				if x < 0 || y < 0 || x >= m.size || y >= m.size {
+					continue
+				}
+				d := max(abs(dx), abs(dy))
+				m.set(x, y, d != 2 && d != 4)
+			}
+		}
+	}
+	last := len(v.alignment) - 1
+	for i, cy := range v.alignment {
+		for j, cx := range v.alignment {
+			if (i == 0 && j == 0) || (i == 0 && j == last) || (i == last && j == 0) {
+				continue // overlaps a finder pattern
+			}
+			for dy := -2; dy <= 2; dy++ {
+				for dx := -2; dx <= 2; dx++ {
+					m.set(cx+dx, cy+dy, max(abs(dx), abs(dy)) != 1)
+				}
+			}
+		}
+	}
+	m.drawFormatBits(0) // reserve the area; the real bits are drawn after masking
+	if ver >= 7 {
+		rem := ver
+		for i := 0; i < 12; i++ {
+			rem = (rem << 1) ^ ((rem >> 11) * 0x1F25)
+		}
+		bits := ver<<12 | rem
+		for i := 0; i < 18; i++ {
+			dark := (bits>>i)&1 == 1
+			a, b := m.size-11+i%3, i/3
+			m.set(a, b, dark)
+			m.set(b, a, dark)
+		}
+	}
+}
+
+// drawFormatBits writes the level M indicator and mask number, BCH protected, in both copies
+func (m *matrix) drawFormatBits(mask int) {
+	data := 0<<3 | mask // level M is 00
+	rem := data
+	for i := 0; i < 10; i++ {
+		rem = (rem << 1) ^ ((rem >> 9) * 0x537)
+	}
+	bits := (data<<10 | rem) ^ 0x5412
+	bit := func(i int) bool { return (bits>>i)&1 == 1 }
+
+	for i := 0; i <= 5; i++ {
+		m.set(8, i, bit(i))
+	}
+	m.set(8, 7, bit(6))
+	m.set(8, 8, bit(7))
+	m.set(7, 8, bit(8))
+	for i := 9; i < 15; i++ {
+		m.set(14-i, 8, bit(i))
+	}
+	for i := 0; i < 8; i++ {
+		m.set(m.size-1-i, 8, bit(i))
+	}
+	for i := 8; i < 15; i++ {
+		m.set(8, m.size-15+i, bit(i))
+	}
+	m.set(8, m.size-8, true) // the dark module
+}
+
+// drawCodewords places the bits in the two-module-wide zigzag from the bottom-right corner
+func (m *matrix) drawCodewords(data []byte) {
+	i := 0
+	for right := m.size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5 // skip the vertical timing pattern
+		}
+		for vert := 0; vert < m.size; vert++ {
+			for j := 0; j < 2; j++ {
+				x := right - j
+				y := vert
+				if (right+1)&2 == 0 {
+					y = m.size - 1 - vert
+				}
+				if !m.function[y][x] && i < len(data)*8 {
+					m.dark[y][x] = (data[i>>3]>>(7-(i&7)))&1 == 1
+					i++
+				}
+				// remainder bits stay light
+			}
+		}
+	}
+}
+
+func (m *matrix) applyMask(mask int) {
+	for y := 0; y < m.size; y++ {
+		for x := 0; x < m.size; x++ {
+			if m.function[y][x] {
+				continue
+			}
+			var invert bool
+			switch mask {
+			case 0:
+				invert = (x+y)%2 == 0
+			case 1:
+				invert = y%2 == 0
+			case 2:
+				invert = x%3 == 0
+			case 3:
+				invert = (x+y)%3 == 0
+			case 4:
+				invert = (x/3+y/2)%2 == 0
+			case 5:
+				invert = x*y%2+x*y%3 == 0
+			case 6:
+				invert = (x*y%2+x*y%3)%2 == 0
+			case 7:
+				invert = ((x+y)%2+x*y%3)%2 == 0
+			}
+			if invert {
+				m.dark[y][x] = !m.dark[y][x]
+			}
+		}
+	}
+}
+
+// penalty scores a masked symbol by the standard's four rules; the lowest score wins
+func (m *matrix) penalty() int {
+	score := 0
+	at := func(x, y int, transpose bool) bool {
+		if transpose {
+			return m.dark[x][y]
+		}
+		return m.dark[y][x]
+	}
+	finder := []bool{true, false, true, true, true, false, true}
+	for _, transpose := range []bool{false, true} {
+		for y := 0; y < m.size; y++ {
+			run := 1
+			for x := 1; x < m.size; x++ {
+				if at(x, y, transpose) == at(x-1, y, transpose) {
+					run++
+					continue
+				}
+				if run >= 5 {
+					score += 3 + run - 5
+				}
+				run = 1
+			}
+			if run >= 5 {
+				score += 3 + run - 5
+			}
+			// 1:1:3:1:1 finder-like runs with four light modules on one side
+			for x := 0; x+7 <= m.size; x++ {
+				match := true
+				for k, want := range finder {
+					if at(x+k, y, transpose) != want {
+						match = false
+						break
+					}
+				}
+				if match && (lightRun(m, x-4, y, transpose) || lightRun(m, x+7, y, transpose)) {
+					score += 40
+				}
+			}
+		}
+	}
+	dark := 0
+	for y := 0; y < m.size; y++ {
+		for x := 0; x < m.size; x++ {
+			if m.dark[y][x] {
+				dark++
+			}
+			if x+1 < m.size && y+1 < m.size {
+				c := m.dark[y][x]
+				if c == m.dark[y][x+1] && c == m.dark[y+1][x] && c == m.dark[y+1][x+1] {
+					score += 3
+				}
+			}
+		}
+	}
+	total := m.size * m.size
+	score += abs(dark*20-total*10) / total * 10
+	return score
+}
+
+// lightRun reports whether the four modules from x are light; outside the symbol counts as light
+func lightRun(m *matrix, x, y int, transpose bool) bool {
+	for k := x; k < x+4; k++ {
+		if k < 0 || k >= m.size {
+			continue
+		}
+		if (transpose && m.dark[k][y]) || (!transpose && m.dark[y][k]) {
+			return false
+		}
+	}
+	return true
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
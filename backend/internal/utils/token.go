@@ -0,0 +1,118 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/kiranraoboinapally/student/backend/internal/config"
+)
+
+// Token types carried in the "typ" claim
+const (
+	TokenTypeAccess     = "access"
+	TokenTypeMFAPending = "mfa_pending" // only accepted by /api/auth/mfa/verify
+)
+
+// MFAPendingTTL is how long a user has to enter their second factor after the password
+const MFAPendingTTL = 5 * time.Minute
+
+// RandomToken returns n random bytes hex-encoded
+func RandomToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// HashToken returns the sha256 hex digest used to store opaque tokens server-side
+func HashToken(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
+
+// GenerateAccessToken issues a short-lived HS256 access token with a unique jti
+func GenerateAccessToken(userID int64, username string, roleID int, tokenVersion int) (string, string, time.Time, error) {
+	jti, err := RandomToken(16)
+	if err != nil {
+		return "", "", time.Time{}, err
+	}
+	expiresAt := time.Now().Add(time.Minute * time.Duration(config.JwtAccessMinutes))
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"user_id":  userID,
+		"username": username,
+		"role_id":  roleID,
+		"ver":      tokenVersion,
+		"jti":      jti,
+		"typ":      TokenTypeAccess,
+		"exp":      expiresAt.Unix(),
+	})
+
+	tokenStr, err := token.SignedString([]byte(config.JwtSecret))
+	if err != nil {
+		return "", "", time.Time{}, err
+	}
+	return tokenStr, jti, expiresAt, nil
+}
+
+// GenerateMFAPendingToken issues the short-lived token returned by Login when a second factor is still needed
+func GenerateMFAPendingToken(userID int64, tokenVersion int) (string, string, time.Time, error) {
+	jti, err := RandomToken(16)
+	if err != nil {
+		return "", "", time.Time{}, err
+	}
+	expiresAt := time.Now().Add(MFAPendingTTL)
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"user_id": userID,
+		"ver":     tokenVersion,
+		"jti":     jti,
+		"typ":     TokenTypeMFAPending,
+		"exp":     expiresAt.Unix(),
+	})
+
+	tokenStr, err := token.SignedString([]byte(config.JwtSecret))
+	if err != nil {
+		return "", "", time.Time{}, err
+	}
+	return tokenStr, jti, expiresAt, nil
+}
+
+// ParseToken verifies the signature and expiry of a token and returns its claims
+func ParseToken(tokenStr string) (jwt.MapClaims, error) {
+	token, err := jwt.Parse(tokenStr, func(t *jwt.Token) (interface{}, error) {
+		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, errors.New("unexpected signing method")
+		}
+		return []byte(config.JwtSecret), nil
+	})
+	if errors.Is(err, jwt.ErrTokenExpired) {
+		return nil, errors.New("token expired")
+	}
+	if err != nil || !token.Valid {
+		return nil, errors.New("invalid token")
+	}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, errors.New("invalid token claims")
+	}
+	return claims, nil
+}
+
+// ClaimInt64 reads a numeric claim regardless of how it was decoded
+func ClaimInt64(claims jwt.MapClaims, key string) (int64, bool) {
+	switch v := claims[key].(type) {
+	case float64:
+		return int64(v), true
+	case int64:
+		return v, true
+	case int:
+		return int64(v), true
+	}
+	return 0, false
+}
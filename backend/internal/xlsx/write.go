@@ -0,0 +1,197 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	contentTypesXML = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+		`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>` +
+		`</Types>`
+	rootRelsXML = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+		`</Relationships>`
+	workbookRelsXML = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+		`<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>` +
+		`</Relationships>`
+	// Style 0 is the default, style 1 is bold for the header row
+	stylesXML = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+		`<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>` +
+		`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>` +
+		`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+		`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+		`<cellXfs count="2"><xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>` +
+		`<xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/></cellXfs>` +
+		`</styleSheet>`
+)
+
+// Write produces a single-sheet workbook with a bold header row. Numbers (ints and floats) become
+// numeric cells, times are written as YYYY-MM-DD HH:MM:SS text, nil pointers as empty cells and
+// everything else as text.
+func Write(w io.Writer, sheetName string, header []string, rows [][]interface{}) error {
+	zw := zip.NewWriter(w)
+	parts := []struct{ name, body string }{
+		{"[Content_Types].xml", contentTypesXML},
+		{"_rels/.rels", rootRelsXML},
+		{"xl/workbook.xml", workbookXML(sheetName)},
+		{"xl/_rels/workbook.xml.rels", workbookRelsXML},
+		{"xl/styles.xml", stylesXML},
+	}
+	for _, p := range parts {
+		f, err := zw.Create(p.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(f, p.body); err != nil {
+			return err
+		}
+	}
+
+	f, err := zw.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	buf.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n")
+	buf.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	rowNum := 0
+	if len(header) > 0 {
+		rowNum++
+		cells := make([]interface{}, len(header))
+		for i, h := range header {
+			cells[i] = h
+		}
+		writeRow(&buf, rowNum, cells, 1)
+	}
+	for _, row := range rows {
+		rowNum++
+		writeRow(&buf, rowNum, row, 0)
+		// Flush periodically so large exports do not sit in memory twice
+		if buf.Len() > 1<<20 {
+			if _, err := f.Write(buf.Bytes()); err != nil {
+				return err
+			}
+			buf.Reset()
+		}
+	}
+	buf.WriteString(`</sheetData></worksheet>`)
+	if _, err := f.Write(buf.Bytes()); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+func workbookXML(sheetName string) string {
+	name := strings.NewReplacer("/", " ", "\\", " ", "?", " ", "*", " ", "[", " ", "]", " ", ":", " ").Replace(sheetName)
+	if name = strings.TrimSpace(name); name == "" {
+		name = "Sheet1"
+	}
+	if r := []rune(name); len(r) > 31 {
+		name = string(r[:31])
+	}
+	return `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+		`<sheets><sheet name="` + escape(name) + `" sheetId="1" r:id="rId1"/></sheets></workbook>`
+}
+
+func writeRow(buf *bytes.Buffer, rowNum int, cells []interface{}, style int) {
+	fmt.Fprintf(buf, `<row r="%d">`, rowNum)
+	for i, v := range cells {
+		ref := columnName(i) + strconv.Itoa(rowNum)
+		styleAttr := ""
+		if style != 0 {
+			styleAttr = fmt.Sprintf(` s="%d"`, style)
+		}
+		if num, ok := numericValue(v); ok {
+			fmt.Fprintf(buf, `<c r="%s"%s><v>%s</v></c>`, ref, styleAttr, num)
+			continue
+		}
+		text, ok := textValue(v)
+		if !ok {
+			continue
+		}
+		fmt.Fprintf(buf, `<c r="%s" t="inlineStr"%s><is><t xml:space="preserve">%s</t></is></c>`, ref, styleAttr, escape(text))
+	}
+	buf.WriteString(`</row>`)
+}
+
+// numericValue formats numbers (and non-nil pointers to them) for a <v> element
+func numericValue(v interface{}) (string, bool) {
+	switch n := v.(type) {
+	case int:
+		return strconv.Itoa(n), true
+	case int64:
+		return strconv.FormatInt(n, 10), true
+	case float64:
+		return strconv.FormatFloat(n, 'f', -1, 64), true
+	case *int:
+		if n != nil {
+			return strconv.Itoa(*n), true
+		}
+	case *int64:
+		if n != nil {
+			return strconv.FormatInt(*n, 10), true
+		}
+	case *float64:
+		if n != nil {
+			return strconv.FormatFloat(*n, 'f', -1, 64), true
+		}
+	}
+	return "", false
+}
+
+// textValue renders everything else as text; false means an empty cell
+func textValue(v interface{}) (string, bool) {
+	switch t := v.(type) {
+	case nil:
+		return "", false
+	case string:
+		return t, t != ""
+	case *string:
+		if t == nil || *t == "" {
+			return "", false
+		}
+		return *t, true
+	case time.Time:
+		return t.Format("2006-01-02 15:04:05"), true
+	case *time.Time:
+		if t == nil {
+			return "", false
+		}
+		return t.Format("2006-01-02 15:04:05"), true
+	case *int, *int64, *float64:
+		return "", false
+	}
+	return fmt.Sprint(v), true
+}
+
+// columnName converts a zero-based column to its letters, e.g. 27 -> "AB"
+func columnName(i int) string {
+	name := ""
+	for i++; i > 0; i = (i - 1) / 26 {
+		name = string(rune('A'+(i-1)%26)) + name
+	}
+	return name
+}
+
+func escape(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
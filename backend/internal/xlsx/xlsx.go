@@ -0,0 +1,188 @@
+// Package xlsx reads the first worksheet of an Office Open XML spreadsheet as rows of strings, and
+// writes simple single-sheet workbooks for report exports. Reading handles shared, inline and plain
+// cell values; formulas yield their cached result and styles are ignored, so dates arrive as Excel
+// serial numbers (see SerialToTime).
+package xlsx
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"errors"
+	"io"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrNoSheet is returned when the workbook has no worksheet
+var ErrNoSheet = errors.New("xlsx: workbook has no worksheet")
+
+type sharedStrings struct {
+	Items []struct {
+		T    string `xml:"t"`
+		Runs []struct {
+			T string `xml:"t"`
+		} `xml:"r"`
+	} `xml:"si"`
+}
+
+type worksheet struct {
+	Rows []struct {
+		Cells []struct {
+			Ref    string `xml:"r,attr"`
+			Type   string `xml:"t,attr"`
+			Value  string `xml:"v"`
+			Inline struct {
+				T    string `xml:"t"`
+				Runs []struct {
+					T string `xml:"t"`
+				} `xml:"r"`
+			} `xml:"is"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+type workbook struct {
+	Sheets []struct {
+		RelID string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+type relationships struct {
+	Rels []struct {
+		ID     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+// Read returns the cells of the first worksheet. Rows are as long as their last non-empty cell.
+func Read(r io.ReaderAt, size int64) ([][]string, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, err
+	}
+	files := map[string]*zip.File{}
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+
+	var shared []string
+	if f := files["xl/sharedStrings.xml"]; f != nil {
+		var ss sharedStrings
+		if err := decode(f, &ss); err != nil {
+			return nil, err
+		}
+		for _, si := range ss.Items {
+			text := si.T
+			for _, run := range si.Runs {
+				text += run.T
+			}
+			shared = append(shared, text)
+		}
+	}
+
+	sheet := firstSheet(files)
+	if sheet == nil {
+		return nil, ErrNoSheet
+	}
+	var ws worksheet
+	if err := decode(sheet, &ws); err != nil {
+		return nil, err
+	}
+
+	var rows [][]string
+	for _, row := range ws.Rows {
+		var out []string
+		for i, cell := range row.Cells {
+			col := i
+			if cell.Ref != "" {
+				col = columnIndex(cell.Ref)
+			}
+			var v string
+			switch cell.Type {
+			case "s":
+				if n, err := strconv.Atoi(cell.Value); err == nil && n >= 0 && n < len(shared) {
+					v = shared[n]
+				}
+			case "inlineStr":
+				v = cell.Inline.T
+				for _, run := range cell.Inline.Runs {
+					v += run.T
+				}
+			default:
+				v = cell.Value
+			}
+			for len(out) <= col {
+				out = append(out, "")
+			}
+			out[col] = v
+		}
+		rows = append(rows, out)
+	}
+	return rows, nil
+}
+
+// firstSheet resolves the first sheet listed in the workbook, falling back to the first
+// worksheet part by name
+func firstSheet(files map[string]*zip.File) *zip.File {
+	var wb workbook
+	var rels relationships
+	if f := files["xl/workbook.xml"]; f != nil && decode(f, &wb) == nil && len(wb.Sheets) > 0 {
+		if rf := files["xl/_rels/workbook.xml.rels"]; rf != nil && decode(rf, &rels) == nil {
+			for _, rel := range rels.Rels {
+				if rel.ID != wb.Sheets[0].RelID {
+					continue
+				}
+				target := rel.Target
+				if strings.HasPrefix(target, "/") {
+					target = strings.TrimPrefix(target, "/")
+				} else {
+					target = path.Join("xl", target)
+				}
+				if f := files[target]; f != nil {
+					return f
+				}
+			}
+		}
+	}
+	var names []string
+	for name := range files {
+		if strings.HasPrefix(name, "xl/worksheets/") && strings.HasSuffix(name, ".xml") {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	sort.Strings(names)
+	return files[names[0]]
+}
+
+func decode(f *zip.File, v interface{}) error {
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	return xml.NewDecoder(rc).Decode(v)
+}
+
+// columnIndex converts a cell reference such as "AB12" to its zero-based column
+func columnIndex(ref string) int {
+	n := 0
+	for _, ch := range ref {
+		if ch < 'A' || ch > 'Z' {
+			break
+		}
+		n = n*26 + int(ch-'A'+1)
+	}
+	return n - 1
+}
+
+// SerialToTime converts an Excel date serial (days since 1899-12-30, fraction = time of day)
+func SerialToTime(serial float64) time.Time {
+	base := time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+	return base.Add(time.Duration(serial * 24 * float64(time.Hour))).Round(time.Second)
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/kiranraoboinapally/student/backend/internal/api"
 	"github.com/kiranraoboinapally/student/backend/internal/config"
 	icontrollers "github.com/kiranraoboinapally/student/backend/internal/controllers"
+	"github.com/kiranraoboinapally/student/backend/internal/middleware"
 )
 
 func main() {
@@ -20,18 +21,32 @@ func main() {
 	config.Init()
 
 	// Initialize services
-	// Razorpay init (will panic if env vars missing in dev)
-	icontrollers.InitRazorpay()
+	// Payment gateway (PAYMENT_GATEWAY=razorpay|mock|disabled)
+	icontrollers.InitPaymentGateway()
 	// Websocket hub
 	icontrollers.InitNotifications()
+	// Mail driver + outbox worker
+	icontrollers.InitMailer()
+	// Expired refresh tokens / revoked jti cleanup
+	icontrollers.InitSessionCleanup()
+	// Expire / reconcile payment orders that were never verified
+	icontrollers.InitPaymentOrderSweep()
+	// Late fee assessment on overdue charges (LATE_FEE_INTERVAL_HOURS, 0 disables)
+	icontrollers.InitLateFeeJob()
+	// Daily overdue fee / installment reminders (FEE_REMINDER_INTERVAL_DAYS, 0 disables)
+	icontrollers.InitFeeReminderJob()
+	// Rate limiter store (in-process unless RATE_LIMIT_STORE=db)
+	middleware.InitRateLimiter()
+	// Permission catalogue + built-in role grants
+	middleware.InitPermissions()
 
 	r := gin.Default()
 
 	r.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"http://localhost:5173"},
 		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization", "X-Request-ID"},
+		ExposeHeaders:    []string{"Content-Length", "Content-Disposition", "X-Request-ID"},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
 	}))
@@ -0,0 +1,45 @@
+-- Migration: Auth sessions
+-- Description: Rotating refresh tokens, revoked access tokens and per-user token version
+
+-- ============================================
+-- 1. REFRESH TOKENS (only the sha256 hash is stored)
+-- ============================================
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    user_id BIGINT NOT NULL,
+    token_hash VARCHAR(64) NOT NULL UNIQUE,
+    expires_at TIMESTAMP NOT NULL,
+    revoked_at TIMESTAMP NULL,
+    replaced_by BIGINT NULL,
+    user_agent VARCHAR(255),
+    client_ip VARCHAR(64),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_refresh_tokens_user (user_id)
+);
+
+-- ============================================
+-- 2. REVOKED ACCESS TOKENS (denylist by jti until expiry)
+-- ============================================
+CREATE TABLE IF NOT EXISTS revoked_tokens (
+    jti VARCHAR(64) PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_revoked_tokens_expires (expires_at)
+);
+
+-- ============================================
+-- 3. ADD TOKEN_VERSION TO USERS TABLE
+-- ============================================
+SET @exist := (SELECT COUNT(*) FROM INFORMATION_SCHEMA.COLUMNS
+               WHERE TABLE_SCHEMA = DATABASE()
+               AND TABLE_NAME = 'users'
+               AND COLUMN_NAME = 'token_version');
+
+SET @query := IF(@exist = 0,
+    'ALTER TABLE users ADD COLUMN token_version INT NOT NULL DEFAULT 0',
+    'SELECT 1');
+
+PREPARE stmt FROM @query;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
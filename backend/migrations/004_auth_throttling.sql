@@ -0,0 +1,26 @@
+-- Migration: Brute-force protection
+-- Description: Failed-attempt tracking with exponential lockout, and shared rate-limit counters
+
+-- ============================================
+-- 1. AUTH THROTTLES (per username / client IP)
+-- ============================================
+CREATE TABLE IF NOT EXISTS auth_throttles (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    scope VARCHAR(32) NOT NULL,
+    subject VARCHAR(191) NOT NULL,
+    failed_count INT DEFAULT 0,
+    lockout_count INT DEFAULT 0,
+    last_failed_at TIMESTAMP NULL,
+    locked_until TIMESTAMP NULL,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    UNIQUE KEY idx_auth_throttle_subject (scope, subject)
+);
+
+-- ============================================
+-- 2. RATE LIMIT BUCKETS (used when RATE_LIMIT_STORE=db)
+-- ============================================
+CREATE TABLE IF NOT EXISTS rate_limit_buckets (
+    bucket_key VARCHAR(191) PRIMARY KEY,
+    window_start TIMESTAMP NOT NULL,
+    hits INT NOT NULL DEFAULT 0
+);
@@ -0,0 +1,17 @@
+-- Migration: Email outbox
+-- Description: Queued transactional emails (password reset, approvals, credentials) delivered with retries
+
+CREATE TABLE IF NOT EXISTS email_outbox (
+    email_id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    to_address VARCHAR(255) NOT NULL,
+    subject VARCHAR(255),
+    body TEXT,
+    status VARCHAR(20) DEFAULT 'pending',
+    attempts INT DEFAULT 0,
+    last_error TEXT,
+    next_attempt_at TIMESTAMP NULL,
+    sent_at TIMESTAMP NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_email_outbox_status (status),
+    INDEX idx_email_outbox_next_attempt (next_attempt_at)
+);
@@ -0,0 +1,46 @@
+-- Migration: Two-factor authentication
+-- Description: TOTP secrets on users, per-role MFA requirement, single-use recovery codes
+
+-- ============================================
+-- 1. RECOVERY CODES
+-- ============================================
+CREATE TABLE IF NOT EXISTS mfa_recovery_codes (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    user_id BIGINT NOT NULL,
+    code_hash VARCHAR(64) NOT NULL,
+    used_at TIMESTAMP NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_mfa_recovery_user (user_id)
+);
+
+-- ============================================
+-- 2. ADD MFA COLUMNS TO USERS TABLE
+-- ============================================
+SET @exist := (SELECT COUNT(*) FROM INFORMATION_SCHEMA.COLUMNS
+               WHERE TABLE_SCHEMA = DATABASE()
+               AND TABLE_NAME = 'users'
+               AND COLUMN_NAME = 'mfa_enabled');
+
+SET @query := IF(@exist = 0,
+    'ALTER TABLE users ADD COLUMN mfa_enabled BOOLEAN NOT NULL DEFAULT FALSE, ADD COLUMN mfa_secret VARCHAR(64) NULL, ADD COLUMN mfa_last_step BIGINT NOT NULL DEFAULT 0',
+    'SELECT 1');
+
+PREPARE stmt FROM @query;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+-- ============================================
+-- 3. ADD MFA_REQUIRED TO ROLES TABLE
+-- ============================================
+SET @exist := (SELECT COUNT(*) FROM INFORMATION_SCHEMA.COLUMNS
+               WHERE TABLE_SCHEMA = DATABASE()
+               AND TABLE_NAME = 'roles'
+               AND COLUMN_NAME = 'mfa_required');
+
+SET @query := IF(@exist = 0,
+    'ALTER TABLE roles ADD COLUMN mfa_required BOOLEAN NOT NULL DEFAULT FALSE',
+    'SELECT 1');
+
+PREPARE stmt FROM @query;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
@@ -0,0 +1,63 @@
+-- Migration: Fine-grained permissions
+-- Description: Permission catalogue and role grants checked by RequirePermission.
+-- The API seeds the same data on startup (middleware.InitPermissions); this file is for manual setups.
+
+-- ============================================
+-- 1. PERMISSIONS
+-- ============================================
+CREATE TABLE IF NOT EXISTS permissions (
+    permission_id INT PRIMARY KEY AUTO_INCREMENT,
+    code VARCHAR(64) NOT NULL UNIQUE,
+    description VARCHAR(255)
+);
+
+-- ============================================
+-- 2. ROLE <-> PERMISSION GRANTS
+-- ============================================
+CREATE TABLE IF NOT EXISTS role_permissions (
+    role_id INT NOT NULL,
+    permission_id INT NOT NULL,
+    PRIMARY KEY (role_id, permission_id)
+);
+
+-- ============================================
+-- 3. SEED CATALOGUE
+-- ============================================
+INSERT IGNORE INTO permissions (code, description) VALUES
+('dashboard.view', 'View university dashboard, statistics and pending approvals'),
+('notifications.view', 'Receive live admin notifications'),
+('faculty.approve', 'Approve or reject faculty accounts'),
+('faculty.manage', 'Create, edit and delete faculty'),
+('courses.approve', 'Approve or reject institute course-stream requests'),
+('marks.view', 'View internal marks across institutes'),
+('marks.lock', 'Lock submitted marks'),
+('marks.publish', 'Publish results'),
+('fees.view', 'View fee payment history'),
+('fees.verify', 'Verify fee payments'),
+('fees.manage', 'Manage fee types, structures and dues'),
+('students.view', 'View students'),
+('students.approve', 'Approve or reject student registrations'),
+('attendance.view', 'View attendance summaries'),
+('institutes.manage', 'Manage institutes and their users'),
+('academics.manage', 'Manage courses, subjects, departments, grading and academic rules'),
+('notices.manage', 'Manage notices'),
+('users.manage', 'Create users, assign roles and reset MFA'),
+('roles.manage', 'Manage roles, permissions and MFA policy'),
+('portal.faculty', 'Use the faculty portal'),
+('portal.institute', 'Use the institute admin portal'),
+('portal.student', 'Use the student portal');
+
+-- ============================================
+-- 4. BUILT-IN ROLE GRANTS (same behaviour as the old role checks)
+-- ============================================
+INSERT IGNORE INTO role_permissions (role_id, permission_id)
+SELECT 1, permission_id FROM permissions WHERE code NOT LIKE 'portal.%';
+
+INSERT IGNORE INTO role_permissions (role_id, permission_id)
+SELECT 2, permission_id FROM permissions WHERE code = 'portal.faculty';
+
+INSERT IGNORE INTO role_permissions (role_id, permission_id)
+SELECT 3, permission_id FROM permissions WHERE code = 'portal.institute';
+
+INSERT IGNORE INTO role_permissions (role_id, permission_id)
+SELECT 5, permission_id FROM permissions WHERE code = 'portal.student';
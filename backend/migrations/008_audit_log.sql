@@ -0,0 +1,32 @@
+-- Migration: Audit trail
+-- Description: Append-only log of every state-changing API request (actor, entity, before/after, IP, request id)
+
+CREATE TABLE IF NOT EXISTS audit_log (
+    audit_id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    actor_user_id BIGINT NULL,
+    actor_username VARCHAR(100),
+    role_id INT NULL,
+    institute_id INT NULL,
+    action VARCHAR(100),
+    entity_type VARCHAR(50),
+    entity_id VARCHAR(64),
+    before_data JSON NULL,
+    after_data JSON NULL,
+    changes JSON NULL,
+    method VARCHAR(10),
+    path VARCHAR(255),
+    status_code INT,
+    client_ip VARCHAR(64),
+    request_id VARCHAR(64),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_audit_actor (actor_user_id),
+    INDEX idx_audit_institute (institute_id),
+    INDEX idx_audit_action (action),
+    INDEX idx_audit_entity (entity_type, entity_id),
+    INDEX idx_audit_request (request_id),
+    INDEX idx_audit_created (created_at)
+);
+
+INSERT IGNORE INTO permissions (code, description) VALUES ('audit.view', 'View and export the audit log');
+INSERT IGNORE INTO role_permissions (role_id, permission_id)
+SELECT 1, permission_id FROM permissions WHERE code = 'audit.view';
@@ -0,0 +1,36 @@
+-- Migration: Razorpay webhook
+-- Description: One row per gateway payment id (idempotent recording) and a log of verified webhook deliveries
+
+CREATE TABLE IF NOT EXISTS gateway_payments (
+    payment_id VARCHAR(64) PRIMARY KEY,
+    order_id VARCHAR(64),
+    enrollment_number BIGINT,
+    fee_head VARCHAR(50),
+    fee_type VARCHAR(100),
+    amount DOUBLE,
+    refunded_amount DOUBLE DEFAULT 0,
+    status VARCHAR(20),
+    source VARCHAR(20),
+    fee_record_id BIGINT NULL,
+    error_reason VARCHAR(255) NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    INDEX idx_gateway_payments_order (order_id),
+    INDEX idx_gateway_payments_enrollment (enrollment_number),
+    INDEX idx_gateway_payments_status (status)
+);
+
+CREATE TABLE IF NOT EXISTS webhook_events (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    provider VARCHAR(20),
+    event_id VARCHAR(100) NOT NULL,
+    event VARCHAR(50),
+    entity_id VARCHAR(64),
+    status VARCHAR(20),
+    error TEXT NULL,
+    payload LONGTEXT,
+    received_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE INDEX idx_webhook_events_event_id (event_id),
+    INDEX idx_webhook_events_event (event),
+    INDEX idx_webhook_events_entity (entity_id)
+);
@@ -0,0 +1,20 @@
+-- Migration: Payment orders
+-- Description: Server-side record of every gateway order (enrollment, fee head, expected amount) used to verify payments
+
+CREATE TABLE IF NOT EXISTS payment_orders (
+    order_id VARCHAR(64) PRIMARY KEY,
+    enrollment_number BIGINT NOT NULL,
+    fee_head VARCHAR(50),
+    fee_type VARCHAR(100),
+    amount DOUBLE NOT NULL,
+    currency VARCHAR(3) DEFAULT 'INR',
+    status VARCHAR(20) DEFAULT 'created',
+    payment_id VARCHAR(64) NULL,
+    expires_at TIMESTAMP NOT NULL,
+    paid_at TIMESTAMP NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    INDEX idx_payment_orders_enrollment (enrollment_number),
+    INDEX idx_payment_orders_status (status),
+    INDEX idx_payment_orders_expires (expires_at)
+);
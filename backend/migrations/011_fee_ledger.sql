@@ -0,0 +1,107 @@
+-- Migration: Unified fee ledger
+-- Description: One ledger for charges, payments, adjustments, refunds and waivers keyed to master_fee_types,
+-- replacing registration_fees / examination_fees / miscellaneous_fees, fee_dues and the balances in expected_fee_collections.
+-- Safe to re-run: every backfilled row carries a unique legacy reference.
+
+CREATE TABLE IF NOT EXISTS fee_ledger (
+    entry_id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    enrollment_number BIGINT NOT NULL,
+    fee_type_id INT NOT NULL,
+    entry_type VARCHAR(20) NOT NULL,
+    debit DECIMAL(12,2) DEFAULT 0,
+    credit DECIMAL(12,2) DEFAULT 0,
+    status VARCHAR(30),
+    reference VARCHAR(100) NULL,
+    parent_entry_id BIGINT NULL,
+    transaction_number VARCHAR(100) NULL,
+    transaction_date DATETIME NULL,
+    due_date DATETIME NULL,
+    semester INT NULL,
+    payment_method VARCHAR(30) NULL,
+    description VARCHAR(255) NULL,
+    created_by BIGINT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    UNIQUE INDEX idx_fee_ledger_reference (entry_type, reference),
+    INDEX idx_fee_ledger_student (enrollment_number, fee_type_id),
+    INDEX idx_fee_ledger_status (status),
+    INDEX idx_fee_ledger_parent (parent_entry_id),
+    INDEX idx_fee_ledger_transaction_date (transaction_date)
+);
+
+-- Built-in fee types the legacy tables map onto
+INSERT IGNORE INTO master_fee_types (fee_type_name, description, is_active, created_by, created_at) VALUES
+    ('Registration', 'Registration fee', 1, 0, NOW()),
+    ('Examination', 'Examination fee', 1, 0, NOW()),
+    ('Miscellaneous', 'Miscellaneous fee', 1, 0, NOW());
+
+-- Payments
+INSERT IGNORE INTO fee_ledger (enrollment_number, fee_type_id, entry_type, debit, credit, status, reference,
+    transaction_number, transaction_date, semester, description, created_at, updated_at)
+SELECT r.enrollment_number, t.fee_type_id, 'payment', 0, COALESCE(r.fee_amount, 0),
+    COALESCE(NULLIF(r.payment_status, ''), 'Paid'),
+    CONCAT('legacy:registration_fees:', r.regn_fee_id),
+    r.transaction_number,
+    CASE WHEN r.transaction_date REGEXP '^[0-9]{4}-[0-9]{2}-[0-9]{2}' THEN STR_TO_DATE(LEFT(r.transaction_date, 10), '%Y-%m-%d') END,
+    r.semester, r.fee_type, NOW(), NOW()
+FROM registration_fees r
+JOIN master_fee_types t ON t.fee_type_name = 'Registration';
+
+INSERT IGNORE INTO fee_ledger (enrollment_number, fee_type_id, entry_type, debit, credit, status, reference,
+    transaction_number, transaction_date, description, created_at, updated_at)
+SELECT e.enrollment_number, t.fee_type_id, 'payment', 0, COALESCE(e.fee_amount, 0),
+    COALESCE(NULLIF(e.payment_status, ''), 'Paid'),
+    CONCAT('legacy:examination_fees:', e.exam_fee_id),
+    e.transaction_number,
+    CASE WHEN e.transaction_date REGEXP '^[0-9]{4}-[0-9]{2}-[0-9]{2}' THEN STR_TO_DATE(LEFT(e.transaction_date, 10), '%Y-%m-%d') END,
+    e.fee_type, NOW(), NOW()
+FROM examination_fees e
+JOIN master_fee_types t ON t.fee_type_name = 'Examination';
+
+INSERT IGNORE INTO fee_ledger (enrollment_number, fee_type_id, entry_type, debit, credit, status, reference,
+    transaction_number, transaction_date, semester, description, created_at, updated_at)
+SELECT m.enrollment_number, t.fee_type_id, 'payment', 0, COALESCE(m.fee_amount, 0),
+    COALESCE(NULLIF(m.payment_status, ''), 'Paid'),
+    CONCAT('legacy:miscellaneous_fees:', m.misc_fee_id),
+    m.transaction_number,
+    CASE WHEN m.transaction_date REGEXP '^[0-9]{4}-[0-9]{2}-[0-9]{2}' THEN STR_TO_DATE(LEFT(m.transaction_date, 10), '%Y-%m-%d') END,
+    m.semester, m.fee_type, NOW(), NOW()
+FROM miscellaneous_fees m
+JOIN master_fee_types t ON t.fee_type_name = 'Miscellaneous';
+
+-- Charges: the expected amounts become opening charges
+INSERT IGNORE INTO fee_ledger (enrollment_number, fee_type_id, entry_type, debit, credit, status, reference, description, created_at, updated_at)
+SELECT x.enrollment_number, t.fee_type_id, 'charge', x.expected_registration_fee, 0, 'Posted',
+    CONCAT('legacy:expected_fee_collections:', x.expected_fee_id, ':registration'), 'Opening balance', NOW(), NOW()
+FROM expected_fee_collections x
+JOIN master_fee_types t ON t.fee_type_name = 'Registration'
+WHERE x.expected_registration_fee > 0;
+
+INSERT IGNORE INTO fee_ledger (enrollment_number, fee_type_id, entry_type, debit, credit, status, reference, description, created_at, updated_at)
+SELECT x.enrollment_number, t.fee_type_id, 'charge', x.expected_exam_fee, 0, 'Posted',
+    CONCAT('legacy:expected_fee_collections:', x.expected_fee_id, ':examination'), 'Opening balance', NOW(), NOW()
+FROM expected_fee_collections x
+JOIN master_fee_types t ON t.fee_type_name = 'Examination'
+WHERE x.expected_exam_fee > 0;
+
+INSERT IGNORE INTO fee_ledger (enrollment_number, fee_type_id, entry_type, debit, credit, status, reference, description, created_at, updated_at)
+SELECT x.enrollment_number, t.fee_type_id, 'charge', x.expected_misc_fee, 0, 'Posted',
+    CONCAT('legacy:expected_fee_collections:', x.expected_fee_id, ':miscellaneous'), 'Opening balance', NOW(), NOW()
+FROM expected_fee_collections x
+JOIN master_fee_types t ON t.fee_type_name = 'Miscellaneous'
+WHERE x.expected_misc_fee > 0;
+
+-- Charges: fee dues created one by one. Legacy rows store the enrollment number in student_id and
+-- only the fee head name; their payments were recorded in the per-head tables above.
+INSERT IGNORE INTO fee_ledger (enrollment_number, fee_type_id, entry_type, debit, credit, status, reference, due_date, description, created_at, updated_at)
+SELECT d.student_id, t.fee_type_id, 'charge', d.original_amount, 0, 'Posted',
+    CONCAT('legacy:fee_dues:', d.fee_due_id), d.due_date, d.fee_head, COALESCE(d.created_at, NOW()), NOW()
+FROM fee_dues d
+JOIN master_fee_types t ON (d.fee_type_id > 0 AND t.fee_type_id = d.fee_type_id)
+    OR (COALESCE(d.fee_type_id, 0) = 0 AND t.fee_type_name IN (d.fee_head, TRIM(TRAILING ' Fee' FROM d.fee_head)))
+WHERE d.original_amount > 0;
+
+-- Gateway payments recorded before the ledger point at their backfilled entry so refunds can find it
+UPDATE gateway_payments g
+JOIN fee_ledger l ON l.entry_type = 'payment' AND l.transaction_number = g.payment_id
+SET g.fee_record_id = l.entry_id;
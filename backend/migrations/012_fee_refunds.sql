@@ -0,0 +1,15 @@
+-- Migration: Refunds and payment reversals
+-- Description: Rejected payments are now cancelled by a 'reversal' ledger entry instead of being
+-- hidden by their status, so balances and refunds follow one path.
+
+INSERT IGNORE INTO permissions (code, description) VALUES ('fees.refund', 'Refund fee payments');
+INSERT IGNORE INTO role_permissions (role_id, permission_id)
+SELECT 1, permission_id FROM permissions WHERE code = 'fees.refund';
+
+-- Payments rejected before this migration get their reversal so they keep netting to zero
+INSERT IGNORE INTO fee_ledger (enrollment_number, fee_type_id, entry_type, debit, credit, status, reference,
+    parent_entry_id, transaction_number, transaction_date, semester, payment_method, description, created_at, updated_at)
+SELECT p.enrollment_number, p.fee_type_id, 'reversal', p.credit, 0, 'Posted', CONCAT('reject:', p.entry_id),
+    p.entry_id, p.transaction_number, COALESCE(p.updated_at, NOW()), p.semester, p.payment_method, 'Payment rejected', NOW(), NOW()
+FROM fee_ledger p
+WHERE p.entry_type = 'payment' AND p.status = 'Rejected' AND p.credit > 0;
@@ -0,0 +1,21 @@
+-- Migration: Late fee engine
+-- Description: Late fee rules per fee type. Penalties are posted to fee_ledger as 'penalty' debits
+-- linked to the overdue charge (parent_entry_id); waivers are 'penalty' credits recording created_by.
+
+CREATE TABLE IF NOT EXISTS late_fee_rules (
+    rule_id INT PRIMARY KEY AUTO_INCREMENT,
+    fee_type_id INT NOT NULL UNIQUE,
+    method VARCHAR(20) NOT NULL,            -- flat, per_day, slab
+    amount DECIMAL(12,2) DEFAULT 0,         -- flat amount or daily rate
+    slabs TEXT NULL,                        -- JSON [{"after_days":0,"amount":100}, ...]
+    grace_days INT DEFAULT 0,
+    max_amount DECIMAL(12,2) DEFAULT 0,     -- 0 = uncapped
+    is_active TINYINT(1) DEFAULT 1,
+    updated_by BIGINT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+);
+
+INSERT IGNORE INTO permissions (code, description) VALUES ('fees.waive', 'Waive late fees');
+INSERT IGNORE INTO role_permissions (role_id, permission_id)
+SELECT 1, permission_id FROM permissions WHERE code = 'fees.waive';
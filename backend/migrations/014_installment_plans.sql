@@ -0,0 +1,59 @@
+-- Migration: Installment plans
+-- Description: Installment templates on fee structures. Each installment is posted to fee_ledger as its
+-- own dated charge (installment_no); online payments may target one via payment_orders.charge_id.
+
+CREATE TABLE IF NOT EXISTS installment_plans (
+    plan_id INT PRIMARY KEY AUTO_INCREMENT,
+    fee_structure_id INT NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    is_active TINYINT(1) DEFAULT 1,
+    created_by BIGINT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_installment_plans_structure (fee_structure_id)
+);
+
+CREATE TABLE IF NOT EXISTS installment_plan_items (
+    item_id INT PRIMARY KEY AUTO_INCREMENT,
+    plan_id INT NOT NULL,
+    sequence INT NOT NULL,
+    label VARCHAR(100) NULL,
+    percentage DECIMAL(5,2) NOT NULL,
+    due_date DATETIME NOT NULL,
+    INDEX idx_installment_plan_items_plan (plan_id)
+);
+
+CREATE TABLE IF NOT EXISTS fee_reminders (
+    charge_id BIGINT PRIMARY KEY,
+    enrollment_number BIGINT NOT NULL,
+    reminders_sent INT DEFAULT 0,
+    last_sent_at DATETIME NOT NULL,
+    INDEX idx_fee_reminders_enrollment (enrollment_number)
+);
+
+-- fee_ledger.installment_no
+SET @exist := (SELECT COUNT(*) FROM INFORMATION_SCHEMA.COLUMNS
+               WHERE TABLE_SCHEMA = DATABASE()
+               AND TABLE_NAME = 'fee_ledger'
+               AND COLUMN_NAME = 'installment_no');
+
+SET @query := IF(@exist = 0,
+    'ALTER TABLE fee_ledger ADD COLUMN installment_no INT NULL AFTER semester',
+    'SELECT 1');
+
+PREPARE stmt FROM @query;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+-- payment_orders.charge_id
+SET @exist := (SELECT COUNT(*) FROM INFORMATION_SCHEMA.COLUMNS
+               WHERE TABLE_SCHEMA = DATABASE()
+               AND TABLE_NAME = 'payment_orders'
+               AND COLUMN_NAME = 'charge_id');
+
+SET @query := IF(@exist = 0,
+    'ALTER TABLE payment_orders ADD COLUMN charge_id BIGINT NULL AFTER fee_type',
+    'SELECT 1');
+
+PREPARE stmt FROM @query;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
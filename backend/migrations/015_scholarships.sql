@@ -0,0 +1,43 @@
+-- Migration: Scholarships and concessions
+-- Description: Schemes (percentage or fixed, optionally limited to fee types and eligibility criteria)
+-- and grants awarding them to students. Approved grants post 'waiver' credits to fee_ledger with
+-- reference 'scholarship:<grant_id>:<charge_id>' whenever dues are generated.
+
+CREATE TABLE IF NOT EXISTS scholarship_schemes (
+    scheme_id INT PRIMARY KEY AUTO_INCREMENT,
+    name VARCHAR(150) NOT NULL,
+    kind VARCHAR(20) NOT NULL,              -- scholarship, concession
+    method VARCHAR(20) NOT NULL,            -- percentage, fixed
+    value DECIMAL(12,2) NOT NULL,
+    max_amount DECIMAL(12,2) DEFAULT 0,     -- per grant and fee type; 0 = uncapped
+    fee_type_ids VARCHAR(255) NULL,         -- JSON array; NULL = every fee type
+    course_name VARCHAR(255) NULL,
+    batch VARCHAR(255) NULL,
+    session VARCHAR(255) NULL,
+    min_cgpa DOUBLE NULL,                   -- checked against the latest semester_results row
+    is_active TINYINT(1) DEFAULT 1,
+    created_by BIGINT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS scholarship_grants (
+    grant_id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    scheme_id INT NOT NULL,
+    enrollment_number BIGINT NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending', -- pending, approved, rejected, revoked
+    remarks VARCHAR(255) NULL,
+    requested_by BIGINT NULL,
+    reviewed_by BIGINT NULL,
+    reviewed_at TIMESTAMP NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    UNIQUE KEY idx_scholarship_grant (scheme_id, enrollment_number),
+    INDEX idx_scholarship_grants_status (status)
+);
+
+INSERT IGNORE INTO permissions (code, description) VALUES
+    ('scholarships.manage', 'Define scholarship schemes and request grants'),
+    ('scholarships.approve', 'Approve, reject and revoke scholarship grants');
+INSERT IGNORE INTO role_permissions (role_id, permission_id)
+SELECT 1, permission_id FROM permissions WHERE code IN ('scholarships.manage', 'scholarships.approve');
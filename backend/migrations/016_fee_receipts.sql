@@ -0,0 +1,25 @@
+-- Migration: Fee receipts
+-- Description: Numbered receipts for settled payments (gateway payments on capture, offline payments
+-- when an admin verifies them). Numbers are sequential per institute and April-March financial year.
+
+CREATE TABLE IF NOT EXISTS receipt_sequences (
+    institute_id INT NOT NULL,              -- 0 for students without an institute
+    financial_year VARCHAR(7) NOT NULL,     -- e.g. 2026-27
+    last_number INT NOT NULL DEFAULT 0,
+    PRIMARY KEY (institute_id, financial_year)
+);
+
+CREATE TABLE IF NOT EXISTS fee_receipts (
+    receipt_id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    receipt_number VARCHAR(50) NOT NULL UNIQUE, -- <institute code>/<financial year>/<sequence>
+    institute_id INT NOT NULL DEFAULT 0,
+    financial_year VARCHAR(7) NOT NULL,
+    sequence INT NOT NULL,
+    payment_entry_id BIGINT NOT NULL UNIQUE,    -- fee_ledger payment entry
+    enrollment_number BIGINT NOT NULL,
+    amount DECIMAL(12,2) NOT NULL,
+    verification_code VARCHAR(20) NOT NULL UNIQUE,
+    issued_at DATETIME NOT NULL,
+    UNIQUE KEY idx_fee_receipt_seq (institute_id, financial_year, sequence),
+    INDEX idx_fee_receipts_enrollment_number (enrollment_number)
+);
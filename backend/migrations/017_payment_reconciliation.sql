@@ -0,0 +1,73 @@
+-- Migration: Bank statement reconciliation
+-- Description: Imported bank / gateway settlement statements and their credit lines. Matched lines
+-- write payment_reconciliation, whose payment_id now refers to the fee_ledger payment entry (the
+-- table was never written before, so no existing rows need converting).
+
+CREATE TABLE IF NOT EXISTS payment_reconciliation (
+    reconciliation_id INT PRIMARY KEY AUTO_INCREMENT,
+    payment_id BIGINT NOT NULL,
+    bank_statement_date DATETIME NULL,
+    bank_statement_reference VARCHAR(100) NULL,
+    reconciled_amount DECIMAL(12,2) NULL,
+    difference_amount DECIMAL(12,2) NULL,
+    reconciliation_status VARCHAR(20) NOT NULL,  -- matched, mismatch, manual
+    reconciled_by BIGINT NULL,
+    reconciled_at DATETIME NULL,
+    remarks VARCHAR(255) NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE payment_reconciliation MODIFY payment_id BIGINT NOT NULL;
+ALTER TABLE payment_reconciliation MODIFY reconciled_by BIGINT NULL;
+
+-- One reconciliation per payment
+SET @exist := (SELECT COUNT(*) FROM INFORMATION_SCHEMA.STATISTICS
+               WHERE TABLE_SCHEMA = DATABASE()
+               AND TABLE_NAME = 'payment_reconciliation'
+               AND INDEX_NAME = 'idx_payment_reconciliation_payment_id');
+
+SET @query := IF(@exist = 0,
+    'ALTER TABLE payment_reconciliation ADD UNIQUE INDEX idx_payment_reconciliation_payment_id (payment_id)',
+    'SELECT 1');
+
+PREPARE stmt FROM @query;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+CREATE TABLE IF NOT EXISTS bank_statement_imports (
+    import_id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    file_name VARCHAR(255) NULL,
+    file_hash VARCHAR(64) NOT NULL UNIQUE,  -- sha256 of the file; the same file cannot be imported twice
+    source VARCHAR(20) NULL,                -- bank, gateway
+    statement_from DATETIME NULL,
+    statement_to DATETIME NULL,
+    total_lines INT DEFAULT 0,
+    matched_lines INT DEFAULT 0,
+    uploaded_by BIGINT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS bank_statement_lines (
+    line_id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    import_id BIGINT NOT NULL,
+    line_no INT NULL,
+    txn_date DATETIME NOT NULL,
+    reference VARCHAR(100) NULL,
+    amount DECIMAL(12,2) NOT NULL,
+    narration VARCHAR(255) NULL,
+    status VARCHAR(20) NOT NULL,            -- matched, mismatch, manual, unmatched, ignored
+    payment_entry_id BIGINT NULL,
+    reconciliation_id INT NULL,
+    remarks VARCHAR(255) NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    INDEX idx_bank_statement_lines_import_id (import_id),
+    INDEX idx_bank_statement_lines_txn_date (txn_date),
+    INDEX idx_bank_statement_lines_reference (reference),
+    INDEX idx_bank_statement_lines_status (status),
+    INDEX idx_bank_statement_lines_payment_entry_id (payment_entry_id)
+);
+
+INSERT IGNORE INTO permissions (code, description) VALUES ('fees.reconcile', 'Import bank statements and reconcile payments');
+INSERT IGNORE INTO role_permissions (role_id, permission_id)
+SELECT 1, permission_id FROM permissions WHERE code = 'fees.reconcile';
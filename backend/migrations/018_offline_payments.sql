@@ -0,0 +1,14 @@
+-- Migration: Offline payments
+-- Description: Proof documents for cash / DD / cheque / bank transfer payments. The payment itself is
+-- a 'Pending' fee_ledger payment (created_by = submitter) until a different user verifies it.
+
+CREATE TABLE IF NOT EXISTS payment_proofs (
+    proof_id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    payment_entry_id BIGINT NOT NULL UNIQUE,
+    file_name VARCHAR(255) NULL,
+    stored_name VARCHAR(100) NOT NULL,       -- file under UPLOAD_DIR/payment_proofs
+    content_type VARCHAR(100) NULL,
+    size BIGINT NULL,
+    uploaded_by BIGINT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,8 @@
+-- Migration: Result computation
+-- Description: Permission for computing SGPA / CGPA / result status from student_marks, grade_mapping
+-- and subject credits. Results are written to the existing semester_results table.
+
+INSERT IGNORE INTO permissions (code, description) VALUES
+    ('results.compute', 'Compute SGPA, CGPA and semester result status');
+INSERT IGNORE INTO role_permissions (role_id, permission_id)
+SELECT 1, permission_id FROM permissions WHERE code = 'results.compute';
@@ -0,0 +1,67 @@
+-- Migration: Result processing
+-- Description: Assessment schemes (internal components with weights and max marks, internal share of
+-- the final 100), external exam marks, and result batches that stage final subject marks per
+-- institute and semester until they are published in one transaction.
+
+CREATE TABLE IF NOT EXISTS assessment_schemes (
+    scheme_id INT PRIMARY KEY AUTO_INCREMENT,
+    subject_code VARCHAR(50) NOT NULL UNIQUE,
+    internal_share DECIMAL(5,2) NOT NULL,          -- e.g. 30 of 100 marks
+    external_max_marks DECIMAL(6,2) NOT NULL,      -- what the external exam is marked out of
+    updated_by BIGINT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS assessment_components (
+    component_id INT PRIMARY KEY AUTO_INCREMENT,
+    scheme_id INT NOT NULL,
+    mark_type VARCHAR(30) NOT NULL,                -- MSE1, MSE2, Assignment, Practical
+    weight DECIMAL(6,2) NOT NULL,                  -- relative to the scheme's other components
+    max_marks DECIMAL(6,2) NOT NULL,
+    UNIQUE KEY idx_assessment_component (scheme_id, mark_type)
+);
+
+CREATE TABLE IF NOT EXISTS external_marks (
+    external_mark_id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    enrollment_number BIGINT NOT NULL,
+    semester INT NOT NULL,
+    subject_code VARCHAR(50) NOT NULL,
+    marks_obtained DECIMAL(6,2) NULL,
+    absent BOOLEAN DEFAULT FALSE,
+    entered_by BIGINT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    UNIQUE KEY idx_external_mark (enrollment_number, semester, subject_code)
+);
+
+CREATE TABLE IF NOT EXISTS result_batches (
+    batch_id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    institute_id INT NOT NULL,
+    semester INT NOT NULL,
+    status VARCHAR(20) NOT NULL,                   -- processed, published
+    students INT NULL,
+    withheld INT NULL,                             -- students left out for missing marks or schemes
+    issues TEXT NULL,                              -- JSON list of what is missing
+    processed_by BIGINT NULL,
+    processed_at DATETIME NULL,
+    published_by BIGINT NULL,
+    published_at DATETIME NULL,
+    UNIQUE KEY idx_result_batch (institute_id, semester)
+);
+
+CREATE TABLE IF NOT EXISTS processed_marks (
+    processed_mark_id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    batch_id BIGINT NOT NULL,
+    enrollment_number BIGINT NOT NULL,
+    subject_code VARCHAR(50) NOT NULL,
+    subject_name VARCHAR(255) NULL,
+    subject_type VARCHAR(50) NULL,
+    internal_marks DECIMAL(6,2) NULL,
+    external_marks DECIMAL(6,2) NULL,
+    total_marks DECIMAL(6,2) NULL,                 -- out of 100
+    grade VARCHAR(10) NULL,
+    grade_points DECIMAL(4,2) NULL,
+    status VARCHAR(20) NULL,                       -- Pass, Fail, Absent
+    UNIQUE KEY idx_processed_mark (batch_id, enrollment_number, subject_code)
+);
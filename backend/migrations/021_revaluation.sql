@@ -0,0 +1,34 @@
+-- Migration: Revaluation
+-- Description: Student revaluation / re-totalling requests on published marks. Each request carries
+-- its fee charge, the faculty evaluator (never the one who entered the original marks), the revised
+-- mark awaiting approval and the original mark for history.
+
+CREATE TABLE IF NOT EXISTS revaluation_requests (
+    request_id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    enrollment_number BIGINT NOT NULL,
+    semester INT NOT NULL,
+    subject_code VARCHAR(50) NOT NULL,
+    kind VARCHAR(20) NOT NULL,                     -- revaluation, retotalling
+    status VARCHAR(20) NULL,                       -- applied, assigned, evaluated, approved, rejected
+    fee_charge_id BIGINT NULL,                     -- fee_ledger charge the student pays
+    evaluator_id BIGINT NULL,                      -- faculty user_id
+    original_marks DECIMAL(6,2) NULL,
+    original_grade VARCHAR(10) NULL,
+    revised_marks DECIMAL(6,2) NULL,
+    revised_grade VARCHAR(10) NULL,
+    evaluator_remarks VARCHAR(255) NULL,
+    evaluated_at DATETIME NULL,
+    reviewed_by BIGINT NULL,
+    reviewed_at DATETIME NULL,
+    review_remarks VARCHAR(255) NULL,
+    created_at DATETIME NULL,
+    updated_at DATETIME NULL,
+    INDEX idx_revaluation_subject (enrollment_number, semester, subject_code),
+    INDEX idx_revaluation_requests_status (status),
+    INDEX idx_revaluation_requests_evaluator_id (evaluator_id)
+);
+
+INSERT IGNORE INTO permissions (code, description) VALUES
+    ('results.revaluation', 'Assign and approve revaluation requests');
+INSERT IGNORE INTO role_permissions (role_id, permission_id)
+SELECT 1, permission_id FROM permissions WHERE code = 'results.revaluation';
@@ -0,0 +1,58 @@
+-- Migration: Reappear registrations
+-- Description: Exam sessions, and per-subject backlog registrations on the legacy reappear_students
+-- table: the session, the failed subject, the fee charge, the lock and the reappear result.
+
+CREATE TABLE IF NOT EXISTS exam_sessions (
+    exam_session_id INT PRIMARY KEY AUTO_INCREMENT,
+    name VARCHAR(100) NOT NULL UNIQUE,
+    start_date DATE NULL,
+    end_date DATE NULL,
+    registration_opens DATE NULL,
+    registration_closes DATE NULL,                 -- last day, inclusive
+    allow_reappear BOOLEAN NOT NULL DEFAULT TRUE,
+    reappear_fee DECIMAL(10,2) NULL,               -- per subject
+    status VARCHAR(20) NULL DEFAULT 'draft',       -- draft, open, closed
+    created_by BIGINT NULL,
+    created_at DATETIME NULL,
+    updated_at DATETIME NULL
+);
+
+CREATE TABLE IF NOT EXISTS reappear_students (
+    reappear_id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    enrollment_no BIGINT NOT NULL,
+    year_sem VARCHAR(20) NULL,
+    amount DECIMAL(10,2) NULL,
+    txn_no VARCHAR(100) NULL
+);
+
+-- reappear_students registration and result columns
+SET @exist := (SELECT COUNT(*) FROM INFORMATION_SCHEMA.COLUMNS
+               WHERE TABLE_SCHEMA = DATABASE()
+               AND TABLE_NAME = 'reappear_students'
+               AND COLUMN_NAME = 'exam_session_id');
+
+SET @query := IF(@exist = 0,
+    'ALTER TABLE reappear_students
+        ADD COLUMN exam_session_id INT NULL,
+        ADD COLUMN semester INT NULL,
+        ADD COLUMN subject_code VARCHAR(50) NULL,
+        ADD COLUMN status VARCHAR(20) NULL,
+        ADD COLUMN fee_charge_id BIGINT NULL,
+        ADD COLUMN marks_obtained DECIMAL(6,2) NULL,
+        ADD COLUMN absent BOOLEAN NOT NULL DEFAULT FALSE,
+        ADD COLUMN result_at DATETIME NULL,
+        ADD COLUMN locked_at DATETIME NULL,
+        ADD COLUMN locked_by BIGINT NULL,
+        ADD COLUMN created_at DATETIME NULL,
+        ADD INDEX idx_reappear_session_subject (exam_session_id, subject_code),
+        ADD INDEX idx_reappear_enrollment (enrollment_no)',
+    'SELECT 1');
+
+PREPARE stmt FROM @query;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+INSERT IGNORE INTO permissions (code, description) VALUES
+    ('exams.manage', 'Manage exam sessions and reappear registrations');
+INSERT IGNORE INTO role_permissions (role_id, permission_id)
+SELECT 1, permission_id FROM permissions WHERE code = 'exams.manage';
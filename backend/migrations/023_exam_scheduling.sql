@@ -0,0 +1,75 @@
+-- Migration: Exam scheduling
+-- Description: Term and type on exam sessions, the date sheet, hall tickets, exam rooms and
+-- seating plans.
+
+-- exam_sessions term and type
+SET @exist := (SELECT COUNT(*) FROM INFORMATION_SCHEMA.COLUMNS
+               WHERE TABLE_SCHEMA = DATABASE()
+               AND TABLE_NAME = 'exam_sessions'
+               AND COLUMN_NAME = 'exam_type');
+
+SET @query := IF(@exist = 0,
+    'ALTER TABLE exam_sessions
+        ADD COLUMN term VARCHAR(50) NULL,
+        ADD COLUMN exam_type VARCHAR(20) NULL DEFAULT ''regular''',
+    'SELECT 1');
+
+PREPARE stmt FROM @query;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+CREATE TABLE IF NOT EXISTS exam_date_sheet (
+    entry_id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    exam_session_id INT NOT NULL,
+    course_name VARCHAR(255) NOT NULL,
+    subject_code VARCHAR(50) NOT NULL,
+    subject_name VARCHAR(255) NULL,
+    semester INT NOT NULL,
+    exam_date DATE NOT NULL,
+    start_time VARCHAR(5) NOT NULL,                -- HH:MM
+    end_time VARCHAR(5) NOT NULL,
+    created_at DATETIME NULL,
+    updated_at DATETIME NULL,
+    UNIQUE KEY idx_date_sheet_subject (exam_session_id, course_name, subject_code)
+);
+
+CREATE TABLE IF NOT EXISTS hall_tickets (
+    ticket_id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    exam_session_id INT NOT NULL,
+    enrollment_number BIGINT NOT NULL,
+    ticket_number VARCHAR(40) NOT NULL UNIQUE,
+    course_name VARCHAR(255) NULL,
+    semester INT NULL,                             -- 0 for backlog papers only
+    verification_code VARCHAR(16) NOT NULL UNIQUE,
+    issued_by BIGINT NULL,                         -- NULL when the student downloaded it
+    issued_at DATETIME NULL,
+    UNIQUE KEY idx_hall_ticket_student (exam_session_id, enrollment_number)
+);
+
+CREATE TABLE IF NOT EXISTS exam_rooms (
+    room_id INT PRIMARY KEY AUTO_INCREMENT,
+    name VARCHAR(50) NOT NULL UNIQUE,
+    building VARCHAR(100) NULL,
+    bench_rows INT NOT NULL,
+    benches_per_row INT NOT NULL,
+    seats_per_bench INT NULL DEFAULT 2,
+    is_active BOOLEAN NULL DEFAULT TRUE,
+    created_at DATETIME NULL,
+    updated_at DATETIME NULL
+);
+
+CREATE TABLE IF NOT EXISTS seat_allocations (
+    allocation_id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    exam_session_id INT NOT NULL,
+    exam_date DATE NOT NULL,
+    start_time VARCHAR(5) NOT NULL,
+    enrollment_number BIGINT NOT NULL,
+    room_id INT NOT NULL,
+    bench_row INT NULL,
+    bench_no INT NULL,
+    seat INT NULL,
+    subject_code VARCHAR(50) NULL,
+    created_at DATETIME NULL,
+    UNIQUE KEY idx_seat_student (exam_session_id, exam_date, start_time, enrollment_number),
+    UNIQUE KEY idx_seat_position (exam_session_id, exam_date, start_time, room_id, bench_row, bench_no, seat)
+);
@@ -0,0 +1,50 @@
+-- Migration: Structured academic rules and attendance eligibility
+-- Description: Versioned, structured columns on academic_rules (minimum attendance, condonation
+-- band, internal weightage, reappear limit), and attendance condonation requests.
+
+CREATE TABLE IF NOT EXISTS academic_rules (
+    id INT PRIMARY KEY AUTO_INCREMENT,
+    rules TEXT NOT NULL,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    updated_by INT DEFAULT NULL
+);
+
+-- academic_rules structured columns; existing rows become version 1 with the defaults
+SET @exist := (SELECT COUNT(*) FROM INFORMATION_SCHEMA.COLUMNS
+               WHERE TABLE_SCHEMA = DATABASE()
+               AND TABLE_NAME = 'academic_rules'
+               AND COLUMN_NAME = 'version');
+
+SET @query := IF(@exist = 0,
+    'ALTER TABLE academic_rules
+        ADD COLUMN version INT NULL DEFAULT 1,
+        ADD COLUMN min_attendance_percent DECIMAL(5,2) NULL DEFAULT 75,
+        ADD COLUMN condonation_min_percent DECIMAL(5,2) NULL DEFAULT 65,
+        ADD COLUMN internal_weightage DECIMAL(5,2) NULL DEFAULT 30,
+        ADD COLUMN max_reappear_subjects INT NULL DEFAULT 0,
+        ADD COLUMN effective_from DATE NULL',
+    'SELECT 1');
+
+PREPARE stmt FROM @query;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+CREATE TABLE IF NOT EXISTS attendance_condonations (
+    condonation_id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    enrollment_number BIGINT NOT NULL,
+    semester INT NOT NULL,
+    subject_code VARCHAR(50) NOT NULL,
+    institute_id INT NULL,
+    course_name VARCHAR(255) NULL,
+    attendance_percent DECIMAL(5,2) NULL,          -- when requested
+    rules_version INT NULL,
+    reason TEXT NULL,
+    status VARCHAR(20) NULL DEFAULT 'pending',     -- pending, approved, rejected
+    requested_by BIGINT NULL,
+    reviewed_by BIGINT NULL,
+    reviewed_at DATETIME NULL,
+    review_remarks TEXT NULL,
+    created_at DATETIME NULL,
+    UNIQUE KEY idx_condonation_subject (enrollment_number, semester, subject_code),
+    INDEX idx_attendance_condonations_institute_id (institute_id)
+);
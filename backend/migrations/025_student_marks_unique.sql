@@ -0,0 +1,23 @@
+-- Migration: Unique student marks per subject
+-- Description: One student_marks row per student, semester and subject, which result publication and
+-- marks upload upsert on. Duplicates left by earlier uploads are removed first, keeping the newest row.
+
+DELETE older FROM student_marks older
+JOIN student_marks newer
+    ON newer.enrollment_number = older.enrollment_number
+    AND newer.semester = older.semester
+    AND newer.subject_code = older.subject_code
+    AND newer.mark_id > older.mark_id;
+
+SET @exist := (SELECT COUNT(*) FROM INFORMATION_SCHEMA.STATISTICS
+               WHERE TABLE_SCHEMA = DATABASE()
+               AND TABLE_NAME = 'student_marks'
+               AND INDEX_NAME = 'idx_student_marks_subject');
+
+SET @query := IF(@exist = 0,
+    'ALTER TABLE student_marks ADD UNIQUE INDEX idx_student_marks_subject (enrollment_number, semester, subject_code)',
+    'SELECT 1');
+
+PREPARE stmt FROM @query;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
@@ -0,0 +1,42 @@
+-- Migration: Result batch rounds
+-- Description: Students withheld from a published result batch are published later in a follow-up
+-- batch, so an institute and semester can have several batches, numbered by round.
+
+SET @exist := (SELECT COUNT(*) FROM INFORMATION_SCHEMA.COLUMNS
+               WHERE TABLE_SCHEMA = DATABASE()
+               AND TABLE_NAME = 'result_batches'
+               AND COLUMN_NAME = 'round');
+
+SET @query := IF(@exist = 0,
+    'ALTER TABLE result_batches ADD COLUMN round INT NOT NULL DEFAULT 1 AFTER semester',
+    'SELECT 1');
+
+PREPARE stmt FROM @query;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+SET @exist := (SELECT COUNT(*) FROM INFORMATION_SCHEMA.STATISTICS
+               WHERE TABLE_SCHEMA = DATABASE()
+               AND TABLE_NAME = 'result_batches'
+               AND INDEX_NAME = 'idx_result_batch_round');
+
+SET @query := IF(@exist = 0,
+    'ALTER TABLE result_batches ADD UNIQUE INDEX idx_result_batch_round (institute_id, semester, round)',
+    'SELECT 1');
+
+PREPARE stmt FROM @query;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+SET @exist := (SELECT COUNT(*) FROM INFORMATION_SCHEMA.STATISTICS
+               WHERE TABLE_SCHEMA = DATABASE()
+               AND TABLE_NAME = 'result_batches'
+               AND INDEX_NAME = 'idx_result_batch');
+
+SET @query := IF(@exist > 0,
+    'ALTER TABLE result_batches DROP INDEX idx_result_batch',
+    'SELECT 1');
+
+PREPARE stmt FROM @query;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
@@ -14,12 +14,10 @@ export default function FeeManagement() {
     const [courses, setCourses] = useState<any[]>([]);
     const [selectedCourse, setSelectedCourse] = useState("");
 
-    // Fee form state
+    // Dues run form state; amounts come from the fee structures in force
     const [feeForm, setFeeForm] = useState({
-        fee_type: "all",
-        expected_exam_fee: "",
-        expected_registration_fee: "",
-        expected_misc_fee: ""
+        term: "",
+        due_date: ""
     });
 
     const [loading, setLoading] = useState(false);
@@ -70,23 +68,21 @@ export default function FeeManagement() {
             return;
         }
 
-        if (!feeForm.expected_exam_fee && !feeForm.expected_registration_fee && !feeForm.expected_misc_fee) {
-            alert("Please enter at least one fee amount");
+        if (!feeForm.term.trim()) {
+            alert("Please enter the term, e.g. 2026-ODD");
             return;
         }
 
         setLoading(true);
         try {
             const payload = {
+                term: feeForm.term.trim(),
                 institute_id: parseInt(selectedInstitute),
                 course_name: selectedCourse,
-                fee_type: feeForm.fee_type,
-                expected_exam_fee: parseFloat(feeForm.expected_exam_fee || "0"),
-                expected_registration_fee: parseFloat(feeForm.expected_registration_fee || "0"),
-                expected_misc_fee: parseFloat(feeForm.expected_misc_fee || "0")
+                due_date: feeForm.due_date || undefined
             };
 
-            const res = await authFetch("/api/admin/fees/create-for-active", {
+            const res = await authFetch("/api/admin/fees/dues-run", {
                 method: "POST",
                 headers: { "Content-Type": "application/json" },
                 body: JSON.stringify(payload)
@@ -94,14 +90,12 @@ export default function FeeManagement() {
 
             if (res.ok) {
                 const data = await res.json();
-                alert(`✅ Success! Fees created for ${data.students_count} active students`);
+                alert(`✅ Success! ${data.charged} dues posted (₹${data.amount}); ${data.already_charged} already charged for ${data.term}, ${data.unmatched.length} students without a fee structure`);
 
                 // Reset form and close modal
                 setFeeForm({
-                    fee_type: "all",
-                    expected_exam_fee: "",
-                    expected_registration_fee: "",
-                    expected_misc_fee: ""
+                    term: "",
+                    due_date: ""
                 });
                 setSelectedInstitute("");
                 setSelectedCourse("");
@@ -230,74 +224,45 @@ export default function FeeManagement() {
                                 )}
                             </div>
 
-                            {/* Step 3: Enter Fee Amounts */}
+                            {/* Step 3: Term and due date */}
                             <div>
                                 <label className="block text-sm font-semibold text-gray-700 mb-4">
-                                    Step 3: Enter Fee Amounts
+                                    Step 3: Enter Term
                                 </label>
 
-                                <div className="grid grid-cols-1 md:grid-cols-3 gap-4">
+                                <div className="grid grid-cols-1 md:grid-cols-2 gap-4">
                                     <div>
                                         <label className="block text-sm font-medium text-gray-600 mb-2">
-                                            Examination Fee (₹)
+                                            Term *
                                         </label>
                                         <input
-                                            type="number"
+                                            type="text"
                                             className="w-full p-3 border-2 border-gray-200 rounded-lg focus:ring-2 focus:ring-[#650C08] focus:border-transparent"
-                                            placeholder="e.g., 1500"
-                                            value={feeForm.expected_exam_fee}
-                                            onChange={(e) => setFeeForm({ ...feeForm, expected_exam_fee: e.target.value })}
-                                            min="0"
-                                            step="100"
+                                            placeholder="e.g., 2026-ODD"
+                                            value={feeForm.term}
+                                            onChange={(e) => setFeeForm({ ...feeForm, term: e.target.value })}
+                                            maxLength={40}
                                         />
                                     </div>
 
                                     <div>
                                         <label className="block text-sm font-medium text-gray-600 mb-2">
-                                            Registration Fee (₹)
+                                            Due Date
                                         </label>
                                         <input
-                                            type="number"
+                                            type="date"
                                             className="w-full p-3 border-2 border-gray-200 rounded-lg focus:ring-2 focus:ring-[#650C08] focus:border-transparent"
-                                            placeholder="e.g., 5000"
-                                            value={feeForm.expected_registration_fee}
-                                            onChange={(e) => setFeeForm({ ...feeForm, expected_registration_fee: e.target.value })}
-                                            min="0"
-                                            step="100"
+                                            value={feeForm.due_date}
+                                            onChange={(e) => setFeeForm({ ...feeForm, due_date: e.target.value })}
                                         />
                                     </div>
-
-                                    <div>
-                                        <label className="block text-sm font-medium text-gray-600 mb-2">
-                                            Miscellaneous Fee (₹)
-                                        </label>
-                                        <input
-                                            type="number"
-                                            className="w-full p-3 border-2 border-gray-200 rounded-lg focus:ring-2 focus:ring-[#650C08] focus:border-transparent"
-                                            placeholder="e.g., 500"
-                                            value={feeForm.expected_misc_fee}
-                                            onChange={(e) => setFeeForm({ ...feeForm, expected_misc_fee: e.target.value })}
-                                            min="0"
-                                            step="100"
-                                        />
-                                    </div>
-                                </div>
-
-                                <div className="mt-4 p-4 bg-blue-50 border border-blue-200 rounded-lg">
-                                    <p className="text-sm font-semibold text-blue-900">
-                                        Total Fee: ₹{(
-                                            (parseFloat(feeForm.expected_exam_fee) || 0) +
-                                            (parseFloat(feeForm.expected_registration_fee) || 0) +
-                                            (parseFloat(feeForm.expected_misc_fee) || 0)
-                                        ).toFixed(2)}
-                                    </p>
                                 </div>
                             </div>
 
                             {/* Info Note */}
                             <div className="bg-amber-50 border border-amber-200 rounded-lg p-4">
                                 <p className="text-sm text-amber-800">
-                                    <strong>ℹ️ Note:</strong> Fees will be created only for students with <code className="bg-amber-100 px-2 py-1 rounded">student_status = 'active'</code> in the master_students table.
+                                    <strong>ℹ️ Note:</strong> Amounts come from the fee structures in force. Fees will be created only for students with <code className="bg-amber-100 px-2 py-1 rounded">student_status = 'active'</code> in the master_students table, once per fee type per term.
                                 </p>
                             </div>
                         </div>
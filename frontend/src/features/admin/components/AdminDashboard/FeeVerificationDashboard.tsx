@@ -2,6 +2,8 @@ import { useMemo, useState, useEffect } from "react";
 import {
     CheckCircle,
     XCircle,
+    RotateCcw,
+    Paperclip,
     Filter,
     Search,
     AlertCircle,
@@ -126,10 +128,11 @@ export default function FeeVerificationDashboard({
     };
 
     const handleReject = async (id: number, source?: string) => {
-        if (!window.confirm("Reject this payment?")) return;
+        const remarks = window.prompt("Reject this payment? Enter a reason (sent to the student):");
+        if (remarks === null) return;
         setProcessingId(id);
         try {
-            await service.verifyPayment(id, source || 'registration', 'reject');
+            await service.verifyPayment(id, source || 'registration', 'reject', remarks);
             onRefresh();
         } catch (err) {
             console.error(err);
@@ -139,6 +142,28 @@ export default function FeeVerificationDashboard({
         }
     };
 
+    const handleRefund = async (id: number, paid: number) => {
+        const input = window.prompt(`Refund amount (max ₹${paid.toLocaleString("en-IN")}, blank for the full remaining amount):`, "");
+        if (input === null) return;
+        const amount = input.trim() === "" ? 0 : Number(input);
+        if (Number.isNaN(amount) || amount < 0) {
+            alert("Enter a valid amount");
+            return;
+        }
+        const reason = window.prompt("Reason for the refund (sent to the student):");
+        if (!reason) return;
+        setProcessingId(id);
+        try {
+            await service.refundPayment(id, amount, reason);
+            onRefresh();
+        } catch (err: any) {
+            console.error(err);
+            alert(err.message || "Failed to refund payment");
+        } finally {
+            setProcessingId(null);
+        }
+    };
+
     const uniqueInstitutes = useMemo(() => {
         const map = new Map<number, Institute>();
         institutes.forEach(inst => {
@@ -297,6 +322,7 @@ export default function FeeVerificationDashboard({
                                 );
                                 const normalized = normalizeStatus(payment.status);
                                 const showActions = normalized === "pending"; // Only show actions for pending
+                                const canRefund = ["paid", "verified", "partially refunded"].includes((payment.status || "").toLowerCase());
 
                                 return (
                                     <tr key={payment.payment_id} className="hover:bg-gray-50/50 transition-colors">
@@ -350,6 +376,15 @@ export default function FeeVerificationDashboard({
                                         <td className="px-6 py-4 text-right">
                                             {showActions && (
                                                 <div className="flex justify-end gap-2">
+                                                    {payment.proof_id && (
+                                                        <button
+                                                            onClick={() => service.openPaymentProof(payment.payment_id!).catch((e) => alert(e.message))}
+                                                            className="p-1.5 rounded-lg bg-blue-50 text-blue-600 hover:bg-blue-100 transition-colors"
+                                                            title="View Proof"
+                                                        >
+                                                            <Paperclip size={18} />
+                                                        </button>
+                                                    )}
                                                     <button
                                                         onClick={() => handleVerify(payment.payment_id!, payment.source)}
                                                         disabled={processingId === payment.payment_id}
@@ -368,6 +403,18 @@ export default function FeeVerificationDashboard({
                                                     </button>
                                                 </div>
                                             )}
+                                            {canRefund && (
+                                                <div className="flex justify-end">
+                                                    <button
+                                                        onClick={() => handleRefund(payment.payment_id!, Number(payment.fee_amount) || 0)}
+                                                        disabled={processingId === payment.payment_id}
+                                                        className="p-1.5 rounded-lg bg-gray-50 text-gray-600 hover:bg-gray-100 transition-colors"
+                                                        title="Refund Payment"
+                                                    >
+                                                        <RotateCcw size={18} />
+                                                    </button>
+                                                </div>
+                                            )}
                                         </td>
                                     </tr>
                                 );
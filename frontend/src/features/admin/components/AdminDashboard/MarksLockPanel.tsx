@@ -1,6 +1,7 @@
 import { useState, useEffect, useCallback } from 'react';
 import { useAuth, apiBase } from '../../../auth/AuthProvider';
 import { Lock, Send, RefreshCw } from 'lucide-react';
+import AdminService from '../../services/adminService';
 
 interface InternalMark {
     internal_mark_id: number;
@@ -110,37 +111,45 @@ export default function MarksLockPanel() {
         }
     };
 
+    // Results are published per institute and semester through result batches, so publishing the
+    // selection processes and publishes every institute/semester it touches
     const handlePublishResults = async () => {
         if (selectedMarkIds.length === 0) {
             alert('Please select marks to publish');
             return;
         }
 
-        if (!confirm('Are you sure you want to publish these results? Students will be able to view them.')) {
+        const groups = new Map<string, { institute_id: number; semester: number }>();
+        marks
+            .filter(m => selectedMarkIds.includes(m.internal_mark_id))
+            .forEach(m => groups.set(`${m.institute_id}:${m.semester}`, { institute_id: m.institute_id, semester: m.semester }));
+
+        if (!confirm(`Results are published for a whole institute and semester. This will process and publish ${groups.size} institute/semester result(s) covering the selected marks; students with missing marks are withheld. Continue?`)) {
             return;
         }
 
         setActionLoading(true);
-        try {
-            const res = await authFetch(`${apiBase}/admin/marks/publish`, {
-                method: 'POST',
-                headers: { 'Content-Type': 'application/json' },
-                body: JSON.stringify({ mark_ids: selectedMarkIds }),
-            });
-            if (res.ok) {
-                const data = await res.json();
-                alert(`Successfully published ${data.published_count} results`);
-                setSelectedMarkIds([]);
-                await loadMarks();
-            } else {
-                const err = await res.json();
-                alert(err.error || 'Failed to publish results');
+        const service = new AdminService(authFetch);
+        let published = 0;
+        let withheld = 0;
+        const failures: string[] = [];
+        for (const group of groups.values()) {
+            try {
+                const batch = await service.processResults(group);
+                const data = await service.publishResultBatch(batch.batch_id);
+                published += data.students || 0;
+                withheld += data.withheld || 0;
+            } catch (e) {
+                failures.push(`Institute ${group.institute_id}, semester ${group.semester}: ${e instanceof Error ? e.message : 'failed'}`);
             }
-        } catch (e) {
-            console.error(e);
-        } finally {
-            setActionLoading(false);
         }
+        setActionLoading(false);
+        setSelectedMarkIds([]);
+        await loadMarks();
+        let message = `Published results for ${published} students`;
+        if (withheld > 0) message += `; ${withheld} withheld for missing marks`;
+        if (failures.length > 0) message += `\n\nNot published:\n${failures.join('\n')}`;
+        alert(message);
     };
 
     const getStatusColor = (status: string) => {
@@ -2,6 +2,7 @@ import React, { useState, useMemo } from "react";
 import { useNavigate } from "react-router-dom";
 import { useAuth, apiBase } from "../../auth/AuthProvider";
 import { LogIn, Eye, EyeOff } from "lucide-react";
+import MfaVerifyStep, { type MfaChallenge } from "../../auth/components/MfaVerifyStep";
 
 export default function AdminLoginPage(): React.ReactNode {
   const { login } = useAuth();
@@ -12,6 +13,7 @@ export default function AdminLoginPage(): React.ReactNode {
   const [loading, setLoading] = useState(false);
   const [error, setError] = useState<string | null>(null);
   const [showPassword, setShowPassword] = useState(false);
+  const [mfaChallenge, setMfaChallenge] = useState<MfaChallenge | null>(null);
 
   const logoSrc = "/Logo.png";
   const theme = "#650C08";
@@ -30,6 +32,25 @@ export default function AdminLoginPage(): React.ReactNode {
       .replace(",", "");
   }, []);
 
+  function completeLogin(data: any) {
+    login(data.token, data.role_id, data.expires_in_hours, data.refresh_token);
+
+    if (data.force_password_change) {
+      navigate("/change-password");
+    } else {
+      // Redirect based on Role ID
+      if (data.role_id === 1) {
+        navigate("/admin/dashboard");
+      } else if (data.role_id === 2) {
+        navigate("/faculty/dashboard");
+      } else if (data.role_id === 3) {
+        navigate("/institute/dashboard");
+      } else {
+        navigate("/admin/dashboard"); // Default
+      }
+    }
+  }
+
   async function handleLogin(e: React.FormEvent) {
     e.preventDefault();
     setLoading(true);
@@ -45,28 +66,18 @@ export default function AdminLoginPage(): React.ReactNode {
 
       const data = await res.json().catch(() => ({}));
 
+      if (res.ok && data.mfa_required) {
+        setMfaChallenge(data);
+        return;
+      }
+
       if (!res.ok || !data.token) {
         setError("Invalid Username or Password");
         setLoading(false);
         return;
       }
 
-      login(data.token, data.role_id, data.expires_in_hours);
-
-      if (data.force_password_change) {
-        navigate("/change-password");
-      } else {
-        // Redirect based on Role ID
-        if (data.role_id === 1) {
-          navigate("/admin/dashboard");
-        } else if (data.role_id === 2) {
-          navigate("/faculty/dashboard");
-        } else if (data.role_id === 3) {
-          navigate("/institute/dashboard");
-        } else {
-          navigate("/admin/dashboard"); // Default
-        }
-      }
+      completeLogin(data);
     } catch {
       setError("Connection error. Please try again.");
     } finally {
@@ -110,6 +121,14 @@ export default function AdminLoginPage(): React.ReactNode {
           )}
 
           {/* Form */}
+          {mfaChallenge ? (
+            <MfaVerifyStep
+              challenge={mfaChallenge}
+              theme={theme}
+              onSuccess={completeLogin}
+              onCancel={() => setMfaChallenge(null)}
+            />
+          ) : (
           <form onSubmit={handleLogin} className="space-y-5">
             <div>
               <label className="block text-sm font-semibold mb-2" style={{ color: theme }}>
@@ -168,6 +187,7 @@ export default function AdminLoginPage(): React.ReactNode {
               {loading ? "Logging in..." : "Login"}
             </button>
           </form>
+          )}
 
           {/* Footer Links */}
           <div className="mt-6 text-center">
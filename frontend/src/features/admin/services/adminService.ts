@@ -129,6 +129,9 @@ export interface FeePayment {
   course_name?: string;
   semester?: number;
   program_pattern?: string;
+  payment_method?: string;
+  proof_id?: number | null;
+  recorded_by?: number | null;
 }
 
 export interface AdminStats {
@@ -293,7 +296,8 @@ class AdminService {
   async verifyPayment(
     paymentId: number,
     source: "registration" | "examination" | "miscellaneous",
-    action: "verify" | "reject" = "verify"
+    action: "verify" | "reject" = "verify",
+    remarks?: string
   ) {
     const res = await this.authFetch(`${apiBase}/admin/fees/verify`, {
       method: "POST",
@@ -302,6 +306,7 @@ class AdminService {
         payment_id: paymentId,
         source,
         action,
+        remarks,
       }),
     });
 
@@ -313,6 +318,197 @@ class AdminService {
     return res.json();
   }
 
+  // amount 0 refunds whatever is still refundable
+  async refundPayment(paymentId: number, amount: number, reason: string) {
+    const res = await this.authFetch(`${apiBase}/admin/fees/refund`, {
+      method: "POST",
+      headers: { "Content-Type": "application/json" },
+      body: JSON.stringify({ payment_id: paymentId, amount, reason }),
+    });
+
+    if (!res.ok) {
+      const err = await res.json().catch(() => ({}));
+      throw new Error(err.error || "Failed to refund payment");
+    }
+
+    return res.json();
+  }
+
+  // Generates dues from the fee structures in force; dry_run returns the preview without posting
+  async runFeeDues(params: {
+    term: string;
+    institute_id?: number;
+    course_name?: string;
+    as_of?: string;
+    due_date?: string;
+    semester?: number;
+    dry_run?: boolean;
+  }) {
+    const res = await this.authFetch(`${apiBase}/admin/fees/dues-run`, {
+      method: "POST",
+      headers: { "Content-Type": "application/json" },
+      body: JSON.stringify(params),
+    });
+    if (!res.ok) {
+      const err = await res.json().catch(() => ({}));
+      throw new Error(err.error || "Failed to run fee dues");
+    }
+    return res.json();
+  }
+
+  // Opens the proof attached to an offline payment in a new tab
+  async openPaymentProof(paymentId: number) {
+    const res = await this.authFetch(`${apiBase}/admin/fees/payments/${paymentId}/proof`);
+    if (!res.ok) throw new Error("No proof attached to this payment");
+    const url = URL.createObjectURL(await res.blob());
+    window.open(url, "_blank");
+    setTimeout(() => URL.revokeObjectURL(url), 60000);
+  }
+
+  // ======================= RECONCILIATION =======================
+  async importBankStatement(file: File, source: "bank" | "gateway" = "bank") {
+    const form = new FormData();
+    form.append("file", file);
+    form.append("source", source);
+    const res = await this.authFetch(`${apiBase}/admin/fees/reconciliation/import`, {
+      method: "POST",
+      body: form,
+    });
+    if (!res.ok) {
+      const err = await res.json().catch(() => ({}));
+      throw new Error(err.error || "Failed to import statement");
+    }
+    return res.json();
+  }
+
+  async getUnmatchedReconciliation(from?: string, to?: string) {
+    const query = new URLSearchParams();
+    if (from) query.set("from", from);
+    if (to) query.set("to", to);
+    const res = await this.authFetch(`${apiBase}/admin/fees/reconciliation/unmatched?${query.toString()}`);
+    if (!res.ok) throw new Error("Failed to fetch unmatched items");
+    return res.json();
+  }
+
+  async resolveStatementLine(lineId: number, action: "match" | "ignore" | "unmatch", paymentId?: number, remarks?: string) {
+    const res = await this.authFetch(`${apiBase}/admin/fees/reconciliation/lines/${lineId}/resolve`, {
+      method: "POST",
+      headers: { "Content-Type": "application/json" },
+      body: JSON.stringify({ action, payment_id: paymentId, remarks }),
+    });
+    if (!res.ok) {
+      const err = await res.json().catch(() => ({}));
+      throw new Error(err.error || "Failed to update statement line");
+    }
+    return res.json();
+  }
+
+  async getReconciliationSummary(from?: string, to?: string) {
+    const query = new URLSearchParams();
+    if (from) query.set("from", from);
+    if (to) query.set("to", to);
+    const res = await this.authFetch(`${apiBase}/admin/fees/reconciliation/summary?${query.toString()}`);
+    if (!res.ok) throw new Error("Failed to fetch reconciliation summary");
+    return res.json();
+  }
+
+  // ======================= FEE REPORTS =======================
+  // report: aging | defaulters | collections | projection. With format csv/xlsx the file is downloaded.
+  async getFeeReport(
+    report: "aging" | "defaulters" | "collections" | "projection",
+    params: Record<string, string | number | undefined> = {},
+    format: "json" | "csv" | "xlsx" = "json"
+  ) {
+    const query = new URLSearchParams();
+    Object.entries(params).forEach(([key, value]) => {
+      if (value !== undefined && value !== "") query.set(key, String(value));
+    });
+    query.set("format", format);
+    const res = await this.authFetch(`${apiBase}/admin/fees/reports/${report}?${query.toString()}`);
+    if (!res.ok) {
+      const err = await res.json().catch(() => ({}));
+      throw new Error(err.error || "Failed to fetch report");
+    }
+    if (format === "json") return res.json();
+
+    const url = URL.createObjectURL(await res.blob());
+    const link = document.createElement("a");
+    link.href = url;
+    link.download = `fee-${report}.${format}`;
+    link.click();
+    URL.revokeObjectURL(url);
+  }
+
+  // ======================= SCHOLARSHIPS =======================
+  async getScholarshipSchemes() {
+    const res = await this.authFetch(`${apiBase}/admin/scholarships/schemes`);
+    if (!res.ok) throw new Error("Failed to fetch scholarship schemes");
+    return res.json();
+  }
+
+  async createScholarshipScheme(scheme: {
+    name: string;
+    kind: "scholarship" | "concession";
+    method: "percentage" | "fixed";
+    value: number;
+    max_amount?: number;
+    fee_type_ids?: number[];
+    course_name?: string;
+    batch?: string;
+    session?: string;
+    min_cgpa?: number;
+  }) {
+    const res = await this.authFetch(`${apiBase}/admin/scholarships/schemes`, {
+      method: "POST",
+      headers: { "Content-Type": "application/json" },
+      body: JSON.stringify(scheme),
+    });
+    if (!res.ok) {
+      const err = await res.json().catch(() => ({}));
+      throw new Error(err.error || "Failed to create scheme");
+    }
+    return res.json();
+  }
+
+  async getScholarshipGrants(status?: string) {
+    const query = status ? `?status=${encodeURIComponent(status)}` : "";
+    const res = await this.authFetch(`${apiBase}/admin/scholarships/grants${query}`);
+    if (!res.ok) throw new Error("Failed to fetch scholarship grants");
+    return res.json();
+  }
+
+  async requestScholarshipGrants(schemeId: number, enrollmentNumbers: number[], remarks?: string) {
+    const res = await this.authFetch(`${apiBase}/admin/scholarships/grants`, {
+      method: "POST",
+      headers: { "Content-Type": "application/json" },
+      body: JSON.stringify({ scheme_id: schemeId, enrollment_numbers: enrollmentNumbers, remarks }),
+    });
+    if (!res.ok) {
+      const err = await res.json().catch(() => ({}));
+      throw new Error(err.error || "Failed to request grants");
+    }
+    return res.json();
+  }
+
+  async reviewScholarshipGrant(grantId: number, action: "approve" | "reject" | "revoke", remarks?: string) {
+    const res = await this.authFetch(`${apiBase}/admin/scholarships/grants/${grantId}/review`, {
+      method: "POST",
+      headers: { "Content-Type": "application/json" },
+      body: JSON.stringify({ action, remarks }),
+    });
+    if (!res.ok) {
+      const err = await res.json().catch(() => ({}));
+      throw new Error(err.error || `Failed to ${action} grant`);
+    }
+    return res.json();
+  }
+
+  async getScholarshipDisbursements() {
+    const res = await this.authFetch(`${apiBase}/admin/scholarships/disbursements`);
+    if (!res.ok) throw new Error("Failed to fetch disbursement report");
+    return res.json();
+  }
+
 
   // ======================= MARKS & ATTENDANCE =======================
   async uploadMarks(marks: Array<{
@@ -452,6 +648,346 @@ class AdminService {
     return res.json();
   }
 
+  // ======================= RESULT COMPUTATION =======================
+  // Computes SGPA / CGPA / Pass-ATKT-Fail for a semester; preview returns the results without saving
+  async computeResults(params: { institute_id: number; course_name: string; semester: number; preview?: boolean }) {
+    const res = await this.authFetch(`${apiBase}/admin/results/compute`, {
+      method: "POST",
+      headers: { "Content-Type": "application/json" },
+      body: JSON.stringify(params),
+    });
+    if (!res.ok) {
+      const err = await res.json().catch(() => ({}));
+      throw new Error(err.error || "Failed to compute results");
+    }
+    return res.json();
+  }
+
+  async getAssessmentSchemes() {
+    const res = await this.authFetch(`${apiBase}/admin/assessment-schemes`);
+    if (!res.ok) throw new Error("Failed to fetch assessment schemes");
+    return res.json();
+  }
+
+  async saveAssessmentScheme(
+    subjectCode: string,
+    scheme: {
+      internal_share?: number;
+      external_max_marks: number;
+      components: { mark_type: string; weight: number; max_marks: number }[];
+    }
+  ) {
+    const res = await this.authFetch(`${apiBase}/admin/assessment-schemes/${encodeURIComponent(subjectCode)}`, {
+      method: "PUT",
+      headers: { "Content-Type": "application/json" },
+      body: JSON.stringify(scheme),
+    });
+    if (!res.ok) {
+      const err = await res.json().catch(() => ({}));
+      throw new Error(err.error || "Failed to save assessment scheme");
+    }
+    return res.json();
+  }
+
+  async uploadExternalMarks(
+    marks: { enrollment_number: number; semester: number; subject_code: string; marks_obtained: number; absent?: boolean }[]
+  ) {
+    const res = await this.authFetch(`${apiBase}/admin/external-marks`, {
+      method: "POST",
+      headers: { "Content-Type": "application/json" },
+      body: JSON.stringify({ marks }),
+    });
+    if (!res.ok) {
+      const err = await res.json().catch(() => ({}));
+      throw new Error(err.error || "Failed to save external marks");
+    }
+    return res.json();
+  }
+
+  // Aggregates locked internals and external marks into a result batch; preview stages nothing
+  async processResults(params: { institute_id: number; semester: number; preview?: boolean }) {
+    const res = await this.authFetch(`${apiBase}/admin/results/process`, {
+      method: "POST",
+      headers: { "Content-Type": "application/json" },
+      body: JSON.stringify(params),
+    });
+    if (!res.ok) {
+      const err = await res.json().catch(() => ({}));
+      throw new Error(err.error || "Failed to process results");
+    }
+    return res.json();
+  }
+
+  async getResultBatches(instituteId?: number, semester?: number) {
+    const query = new URLSearchParams();
+    if (instituteId) query.set("institute_id", String(instituteId));
+    if (semester) query.set("semester", String(semester));
+    const res = await this.authFetch(`${apiBase}/admin/results/batches?${query.toString()}`);
+    if (!res.ok) throw new Error("Failed to fetch result batches");
+    return res.json();
+  }
+
+  async getResultBatch(batchId: number) {
+    const res = await this.authFetch(`${apiBase}/admin/results/batches/${batchId}`);
+    if (!res.ok) throw new Error("Failed to fetch result batch");
+    return res.json();
+  }
+
+  async publishResultBatch(batchId: number) {
+    const res = await this.authFetch(`${apiBase}/admin/results/batches/${batchId}/publish`, { method: "POST" });
+    if (!res.ok) {
+      const err = await res.json().catch(() => ({}));
+      throw new Error(err.error || "Failed to publish results");
+    }
+    return res.json();
+  }
+
+  async getRevaluationRequests(params: { status?: string; semester?: number; subject_code?: string; page?: number; limit?: number } = {}) {
+    const query = new URLSearchParams();
+    Object.entries(params).forEach(([key, value]) => {
+      if (value !== undefined && value !== "") query.set(key, String(value));
+    });
+    const res = await this.authFetch(`${apiBase}/admin/revaluations?${query.toString()}`);
+    if (!res.ok) throw new Error("Failed to fetch revaluation requests");
+    return res.json();
+  }
+
+  async assignRevaluation(requestId: number, facultyId: number) {
+    const res = await this.authFetch(`${apiBase}/admin/revaluations/${requestId}/assign`, {
+      method: "POST",
+      headers: { "Content-Type": "application/json" },
+      body: JSON.stringify({ faculty_id: facultyId }),
+    });
+    if (!res.ok) {
+      const err = await res.json().catch(() => ({}));
+      throw new Error(err.error || "Failed to assign evaluator");
+    }
+    return res.json();
+  }
+
+  async reviewRevaluation(requestId: number, action: "approve" | "reject", remarks?: string) {
+    const res = await this.authFetch(`${apiBase}/admin/revaluations/${requestId}/review`, {
+      method: "POST",
+      headers: { "Content-Type": "application/json" },
+      body: JSON.stringify({ action, remarks }),
+    });
+    if (!res.ok) {
+      const err = await res.json().catch(() => ({}));
+      throw new Error(err.error || "Failed to review revaluation");
+    }
+    return res.json();
+  }
+
+  // ======================= EXAM SESSIONS & REAPPEAR =======================
+  async getExamSessions(status?: string) {
+    const query = status ? `?status=${encodeURIComponent(status)}` : "";
+    const res = await this.authFetch(`${apiBase}/admin/exam-sessions${query}`);
+    if (!res.ok) throw new Error("Failed to fetch exam sessions");
+    return res.json();
+  }
+
+  async saveExamSession(session: {
+    name: string;
+    term?: string;
+    exam_type?: "regular" | "supplementary" | "special";
+    start_date?: string;
+    end_date?: string;
+    registration_opens?: string;
+    registration_closes?: string;
+    allow_reappear?: boolean;
+    reappear_fee?: number;
+    status?: "draft" | "open" | "closed";
+  }, sessionId?: number) {
+    const res = await this.authFetch(`${apiBase}/admin/exam-sessions${sessionId ? `/${sessionId}` : ""}`, {
+      method: sessionId ? "PUT" : "POST",
+      headers: { "Content-Type": "application/json" },
+      body: JSON.stringify(session),
+    });
+    if (!res.ok) {
+      const err = await res.json().catch(() => ({}));
+      throw new Error(err.error || "Failed to save exam session");
+    }
+    return res.json();
+  }
+
+  async getReappearRegistrations(sessionId: number, params: { subject_code?: string; status?: string; page?: number; limit?: number } = {}) {
+    const query = new URLSearchParams();
+    Object.entries(params).forEach(([key, value]) => {
+      if (value !== undefined && value !== "") query.set(key, String(value));
+    });
+    const res = await this.authFetch(`${apiBase}/admin/exam-sessions/${sessionId}/reappear?${query.toString()}`);
+    if (!res.ok) throw new Error("Failed to fetch reappear registrations");
+    return res.json();
+  }
+
+  async lockReappearRegistrations(sessionId: number, subjectCode?: string) {
+    const res = await this.authFetch(`${apiBase}/admin/exam-sessions/${sessionId}/reappear/lock`, {
+      method: "POST",
+      headers: { "Content-Type": "application/json" },
+      body: JSON.stringify({ subject_code: subjectCode }),
+    });
+    if (!res.ok) {
+      const err = await res.json().catch(() => ({}));
+      throw new Error(err.error || "Failed to lock registrations");
+    }
+    return res.json();
+  }
+
+  async recordReappearResults(sessionId: number, results: { enrollment_number: number; subject_code: string; marks_obtained: number; absent?: boolean }[]) {
+    const res = await this.authFetch(`${apiBase}/admin/exam-sessions/${sessionId}/reappear/results`, {
+      method: "POST",
+      headers: { "Content-Type": "application/json" },
+      body: JSON.stringify({ results }),
+    });
+    if (!res.ok) {
+      const err = await res.json().catch(() => ({}));
+      throw new Error(err.error || "Failed to save reappear results");
+    }
+    return res.json();
+  }
+
+  // ======================= DATE SHEETS, HALL TICKETS & SEATING =======================
+  async getDateSheet(sessionId: number, courseName?: string, semester?: number) {
+    const query = new URLSearchParams();
+    if (courseName) query.set("course_name", courseName);
+    if (semester) query.set("semester", String(semester));
+    const res = await this.authFetch(`${apiBase}/admin/exam-sessions/${sessionId}/date-sheet?${query.toString()}`);
+    if (!res.ok) throw new Error("Failed to fetch date sheet");
+    return res.json();
+  }
+
+  // Resolves with the clash report on success; a 409 carries the blocking clashes
+  async saveDateSheet(sessionId: number, entries: {
+    course_name: string;
+    semester: number;
+    subject_code: string;
+    exam_date: string;
+    start_time: string;
+    end_time: string;
+  }[], force = false) {
+    const res = await this.authFetch(`${apiBase}/admin/exam-sessions/${sessionId}/date-sheet`, {
+      method: "POST",
+      headers: { "Content-Type": "application/json" },
+      body: JSON.stringify({ entries, force }),
+    });
+    const data = await res.json().catch(() => ({}));
+    if (!res.ok) throw Object.assign(new Error(data.error || "Failed to save date sheet"), { clashes: data.clashes || [] });
+    return data;
+  }
+
+  async deleteDateSheetEntry(sessionId: number, entryId: number) {
+    const res = await this.authFetch(`${apiBase}/admin/exam-sessions/${sessionId}/date-sheet/${entryId}`, { method: "DELETE" });
+    if (!res.ok) {
+      const err = await res.json().catch(() => ({}));
+      throw new Error(err.error || "Failed to delete date sheet entry");
+    }
+    return res.json();
+  }
+
+  async issueHallTickets(sessionId: number, courseName: string, instituteId?: number) {
+    const res = await this.authFetch(`${apiBase}/admin/exam-sessions/${sessionId}/hall-tickets`, {
+      method: "POST",
+      headers: { "Content-Type": "application/json" },
+      body: JSON.stringify({ course_name: courseName, institute_id: instituteId }),
+    });
+    if (!res.ok) {
+      const err = await res.json().catch(() => ({}));
+      throw new Error(err.error || "Failed to issue hall tickets");
+    }
+    return res.json();
+  }
+
+  async getHallTickets(sessionId: number, courseName?: string) {
+    const query = courseName ? `?course_name=${encodeURIComponent(courseName)}` : "";
+    const res = await this.authFetch(`${apiBase}/admin/exam-sessions/${sessionId}/hall-tickets${query}`);
+    if (!res.ok) throw new Error("Failed to fetch hall tickets");
+    return res.json();
+  }
+
+  async downloadHallTicket(sessionId: number, enrollmentNumber: number) {
+    const res = await this.authFetch(`${apiBase}/admin/exam-sessions/${sessionId}/hall-tickets/${enrollmentNumber}`);
+    if (!res.ok) {
+      const err = await res.json().catch(() => ({}));
+      throw new Error(err.error || "Failed to download hall ticket");
+    }
+    const url = URL.createObjectURL(await res.blob());
+    const link = document.createElement("a");
+    link.href = url;
+    link.download = `hall-ticket-${sessionId}-${enrollmentNumber}.pdf`;
+    link.click();
+    URL.revokeObjectURL(url);
+  }
+
+  async uploadStudentPhoto(enrollmentNumber: number, photo: File) {
+    const form = new FormData();
+    form.append("photo", photo);
+    const res = await this.authFetch(`${apiBase}/admin/students/${enrollmentNumber}/photo`, {
+      method: "POST",
+      body: form,
+    });
+    if (!res.ok) {
+      const err = await res.json().catch(() => ({}));
+      throw new Error(err.error || "Failed to upload photo");
+    }
+    return res.json();
+  }
+
+  async getExamRooms() {
+    const res = await this.authFetch(`${apiBase}/admin/exam-rooms`);
+    if (!res.ok) throw new Error("Failed to fetch exam rooms");
+    return res.json();
+  }
+
+  async saveExamRoom(room: {
+    name: string;
+    building?: string;
+    rows: number;
+    benches_per_row: number;
+    seats_per_bench?: number;
+    is_active?: boolean;
+  }, roomId?: number) {
+    const res = await this.authFetch(`${apiBase}/admin/exam-rooms${roomId ? `/${roomId}` : ""}`, {
+      method: roomId ? "PUT" : "POST",
+      headers: { "Content-Type": "application/json" },
+      body: JSON.stringify(room),
+    });
+    if (!res.ok) {
+      const err = await res.json().catch(() => ({}));
+      throw new Error(err.error || "Failed to save exam room");
+    }
+    return res.json();
+  }
+
+  async generateSeatingPlan(sessionId: number, examDate: string, startTime: string, roomIds: number[]) {
+    const res = await this.authFetch(`${apiBase}/admin/exam-sessions/${sessionId}/seating`, {
+      method: "POST",
+      headers: { "Content-Type": "application/json" },
+      body: JSON.stringify({ exam_date: examDate, start_time: startTime, room_ids: roomIds }),
+    });
+    if (!res.ok) {
+      const err = await res.json().catch(() => ({}));
+      throw new Error(err.error || "Failed to generate seating plan");
+    }
+    return res.json();
+  }
+
+  async getSeatingPlan(sessionId: number, examDate: string, startTime: string, format: "json" | "csv" | "xlsx" = "json") {
+    const query = new URLSearchParams({ exam_date: examDate, start_time: startTime, format });
+    const res = await this.authFetch(`${apiBase}/admin/exam-sessions/${sessionId}/seating?${query.toString()}`);
+    if (!res.ok) {
+      const err = await res.json().catch(() => ({}));
+      throw new Error(err.error || "Failed to fetch seating plan");
+    }
+    if (format === "json") return res.json();
+
+    const url = URL.createObjectURL(await res.blob());
+    const link = document.createElement("a");
+    link.href = url;
+    link.download = `seating-plan-${examDate}-${startTime.replace(":", "")}.${format}`;
+    link.click();
+    URL.revokeObjectURL(url);
+  }
+
   // ======================= INSTITUTE CRUD =======================
   async createInstitute(data: Institute) {
     const res = await this.authFetch(`${apiBase}/admin/institutes`, {
@@ -498,6 +1034,61 @@ class AdminService {
     return res.json();
   }
 
+  // Saves a new version of the structured rules; fields left out keep their current value
+  async saveAcademicRuleSet(rules: {
+    rules?: string;
+    min_attendance_percent?: number;
+    condonation_min_percent?: number;
+    internal_weightage?: number;
+    max_reappear_subjects?: number;
+    effective_from?: string;
+  }) {
+    const res = await this.authFetch(`${apiBase}/admin/academic-rules`, {
+      method: "PUT",
+      headers: { "Content-Type": "application/json" },
+      body: JSON.stringify(rules),
+    });
+    if (!res.ok) {
+      const err = await res.json().catch(() => ({}));
+      throw new Error(err.error || "Failed to update academic rules");
+    }
+    return res.json();
+  }
+
+  async getAcademicRuleVersions() {
+    const res = await this.authFetch(`${apiBase}/admin/academic-rules/versions`);
+    if (!res.ok) throw new Error("Failed to fetch academic rule versions");
+    return res.json();
+  }
+
+  async getAttendanceEligibility(params: {
+    course_name: string;
+    semester: number;
+    institute_id?: number;
+    status?: "eligible" | "condonable" | "detained";
+    page?: number;
+    limit?: number;
+  }, format: "json" | "csv" | "xlsx" = "json") {
+    const query = new URLSearchParams();
+    Object.entries(params).forEach(([key, value]) => {
+      if (value !== undefined && value !== "") query.set(key, String(value));
+    });
+    query.set("format", format);
+    const res = await this.authFetch(`${apiBase}/admin/attendance-eligibility?${query.toString()}`);
+    if (!res.ok) {
+      const err = await res.json().catch(() => ({}));
+      throw new Error(err.error || "Failed to fetch eligibility list");
+    }
+    if (format === "json") return res.json();
+
+    const url = URL.createObjectURL(await res.blob());
+    const link = document.createElement("a");
+    link.href = url;
+    link.download = `attendance-eligibility-semester-${params.semester}.${format}`;
+    link.click();
+    URL.revokeObjectURL(url);
+  }
+
   // ======================= FEE MANAGEMENT =======================
   async bulkUpdateExpectedFees(data: {
     institute_id?: number;
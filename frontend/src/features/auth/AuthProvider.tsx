@@ -4,7 +4,7 @@ type AuthContextType = {
   token: string | null;
   expiresAt: number | null;
   roleId: number | null; // <-- added roleId
-  login: (token: string, roleId: number, expiresInHours?: number) => void;
+  login: (token: string, roleId: number, expiresInHours?: number, refreshToken?: string) => void;
   logout: () => void;
   authFetch: (input: RequestInfo, init?: RequestInit) => Promise<Response>;
 };
@@ -14,6 +14,7 @@ const AuthContext = createContext<AuthContextType | undefined>(undefined);
 const STORAGE_TOKEN_KEY = "app_token";
 const STORAGE_EXP_KEY = "app_token_exp";
 const STORAGE_ROLE_KEY = "app_role_id"; // <-- role storage
+const STORAGE_REFRESH_KEY = "app_refresh_token";
 
 const apiBase =
   (import.meta.env.VITE_API_BASE as string | undefined) ||
@@ -41,12 +42,23 @@ export const AuthProvider: React.FC<{ children: React.ReactNode }> = ({ children
   });
 
   const logout = React.useCallback(() => {
+    const currentToken = localStorage.getItem(STORAGE_TOKEN_KEY);
+    const refreshToken = localStorage.getItem(STORAGE_REFRESH_KEY);
+    if (currentToken) {
+      // Revoke server-side; local state is cleared regardless of the outcome
+      fetch(`${apiBase}/auth/logout`, {
+        method: "POST",
+        headers: { "Content-Type": "application/json", Authorization: `Bearer ${currentToken}` },
+        body: JSON.stringify({ refresh_token: refreshToken }),
+      }).catch(() => {});
+    }
     setToken(null);
     setExpiresAt(null);
     setRoleId(null);
     localStorage.removeItem(STORAGE_TOKEN_KEY);
     localStorage.removeItem(STORAGE_EXP_KEY);
     localStorage.removeItem(STORAGE_ROLE_KEY);
+    localStorage.removeItem(STORAGE_REFRESH_KEY);
   }, []);
 
   useEffect(() => {
@@ -55,11 +67,12 @@ export const AuthProvider: React.FC<{ children: React.ReactNode }> = ({ children
     }
   }, [expiresAt, logout]);
 
-  const login = React.useCallback((tok: string, rId: number, expiresInHours?: number) => {
+  const login = React.useCallback((tok: string, rId: number, expiresInHours?: number, refreshToken?: string) => {
     setToken(tok);
     setRoleId(rId);
     localStorage.setItem(STORAGE_TOKEN_KEY, tok);
     localStorage.setItem(STORAGE_ROLE_KEY, String(rId));
+    if (refreshToken) localStorage.setItem(STORAGE_REFRESH_KEY, refreshToken);
 
     let exp: number | null = null;
     if (expiresInHours && !isNaN(Number(expiresInHours))) {
@@ -69,12 +82,40 @@ export const AuthProvider: React.FC<{ children: React.ReactNode }> = ({ children
     }
   }, []);
 
+  // Exchanges the stored refresh token for a new access token (refresh tokens rotate on every use)
+  const refreshAccessToken = React.useCallback(async (): Promise<string | null> => {
+    const refreshToken = localStorage.getItem(STORAGE_REFRESH_KEY);
+    if (!refreshToken) return null;
+    try {
+      const res = await fetch(`${apiBase}/auth/refresh`, {
+        method: "POST",
+        headers: { "Content-Type": "application/json" },
+        body: JSON.stringify({ refresh_token: refreshToken }),
+      });
+      if (!res.ok) return null;
+      const data = await res.json();
+      setToken(data.token);
+      localStorage.setItem(STORAGE_TOKEN_KEY, data.token);
+      localStorage.setItem(STORAGE_REFRESH_KEY, data.refresh_token);
+      return data.token as string;
+    } catch {
+      return null;
+    }
+  }, []);
+
   const authFetch = React.useCallback(async (input: RequestInfo, init: RequestInit = {}): Promise<Response> => {
     const headers = new Headers(init.headers || {});
     if (token) headers.set("Authorization", `Bearer ${token}`);
     if (!headers.has("Accept")) headers.set("Accept", "application/json");
+    const res = await fetch(input, { ...init, headers });
+    if (res.status !== 401) return res;
+
+    // Access tokens are short-lived: refresh once and replay the request
+    const newToken = await refreshAccessToken();
+    if (!newToken) return res;
+    headers.set("Authorization", `Bearer ${newToken}`);
     return fetch(input, { ...init, headers });
-  }, [token]);
+  }, [token, refreshAccessToken]);
 
   return (
     <AuthContext.Provider value={{ token, expiresAt, roleId, login, logout, authFetch }}>
@@ -3,7 +3,7 @@ import { useNavigate } from "react-router-dom";
 import { useAuth, apiBase } from "../AuthProvider";
 
 export default function ChangePasswordPage(): React.ReactNode {
-  const { authFetch, roleId, token } = useAuth();
+  const { authFetch, roleId, token, login } = useAuth();
   const navigate = useNavigate();
 
   const [newPassword, setNewPassword] = useState("");
@@ -50,13 +50,18 @@ export default function ChangePasswordPage(): React.ReactNode {
         body: JSON.stringify({ new_password: newPassword }),
       });
 
+      const data = await res.json().catch(() => ({}));
       if (!res.ok) {
-        const data = await res.json().catch(() => ({}));
         setError(data.error || "Failed to change password");
         setLoading(false);
         return;
       }
 
+      // Changing the password revokes every session, including this one
+      if (data.token) {
+        login(data.token, data.role_id ?? roleId, data.expires_in_hours, data.refresh_token);
+      }
+
       setSuccess(true);
       setTimeout(() => navigate(getRedirectPath()), 1000);
     } catch {
@@ -7,7 +7,6 @@ export default function ForgotPasswordPage(): React.ReactNode {
   const [email, setEmail] = useState("");
   const [loading, setLoading] = useState(false);
   const [success, setSuccess] = useState(false);
-  const [token, setToken] = useState("");
   const [error, setError] = useState<string | null>(null);
 
   const rightPanelStyle: React.CSSProperties = {
@@ -41,9 +40,6 @@ export default function ForgotPasswordPage(): React.ReactNode {
       }
 
       setSuccess(true);
-      if (data.token) {
-        setToken(data.token);
-      }
     } catch {
       setError("Network error. Please try again.");
     } finally {
@@ -85,14 +81,6 @@ export default function ForgotPasswordPage(): React.ReactNode {
                 <h3 className="text-xl font-bold mb-4">Reset Link Sent!</h3>
                 <p className="mb-4">If your email exists in our system, a password reset link has been sent.</p>
 
-                {token && (
-                  <div className="bg-white/10 p-4 rounded-lg mb-4">
-                    <p className="text-sm mb-2">Your reset token:</p>
-                    <code className="bg-black/30 p-2 rounded text-sm break-all block">{token}</code>
-                    <p className="text-xs mt-2 opacity-75">Copy this token and use it to reset your password.</p>
-                  </div>
-                )}
-
                 <button
                   onClick={() => navigate("/reset-password")}
                   className="w-full bg-white text-[#650C08] font-bold text-lg py-4 rounded-lg shadow-xl hover:shadow-2xl transition mt-4"
@@ -0,0 +1,160 @@
+import React, { useState } from "react";
+import { apiBase } from "../AuthProvider";
+import { ShieldCheck } from "lucide-react";
+
+export interface MfaChallenge {
+  mfa_token: string;
+  mfa_enrollment_required?: boolean;
+  mfa_secret?: string;
+  otpauth_uri?: string;
+}
+
+interface Props {
+  challenge: MfaChallenge;
+  theme: string;
+  onSuccess: (data: any) => void;
+  onCancel: () => void;
+}
+
+// Second login step: TOTP (or recovery) code, with first-time enrollment when the role requires MFA
+export default function MfaVerifyStep({ challenge, theme, onSuccess, onCancel }: Props): React.ReactNode {
+  const [code, setCode] = useState("");
+  const [useRecovery, setUseRecovery] = useState(false);
+  const [loading, setLoading] = useState(false);
+  const [error, setError] = useState<string | null>(null);
+  const [session, setSession] = useState<any | null>(null);
+
+  async function handleVerify(e: React.FormEvent) {
+    e.preventDefault();
+    setLoading(true);
+    setError(null);
+
+    try {
+      const body = useRecovery
+        ? { mfa_token: challenge.mfa_token, recovery_code: code }
+        : { mfa_token: challenge.mfa_token, code };
+      const res = await fetch(`${apiBase}/auth/mfa/verify`, {
+        method: "POST",
+        headers: { "Content-Type": "application/json" },
+        body: JSON.stringify(body),
+      });
+      const data = await res.json().catch(() => ({}));
+
+      if (!res.ok || !data.token) {
+        setError(data.error || "Verification failed");
+        return;
+      }
+
+      // Freshly enrolled: show recovery codes once before continuing
+      if (data.recovery_codes?.length) {
+        setSession(data);
+      } else {
+        onSuccess(data);
+      }
+    } catch {
+      setError("Connection error. Please try again.");
+    } finally {
+      setLoading(false);
+    }
+  }
+
+  if (session) {
+    return (
+      <div className="space-y-4">
+        <h2 className="text-lg font-bold" style={{ color: theme }}>
+          Save your recovery codes
+        </h2>
+        <p className="text-sm text-gray-600">
+          Each code can be used once if you lose access to your authenticator app. They will not be shown again.
+        </p>
+        <div className="grid grid-cols-2 gap-2 font-mono text-sm bg-gray-100 p-4 rounded-lg">
+          {session.recovery_codes.map((rc: string) => (
+            <span key={rc}>{rc}</span>
+          ))}
+        </div>
+        <button
+          type="button"
+          onClick={() => onSuccess(session)}
+          className="w-full px-6 py-3 rounded-lg font-bold text-white"
+          style={{ backgroundColor: theme }}
+        >
+          I have saved them, continue
+        </button>
+      </div>
+    );
+  }
+
+  return (
+    <form onSubmit={handleVerify} className="space-y-5">
+      {challenge.mfa_enrollment_required && (
+        <div className="p-4 rounded-lg text-sm bg-gray-100 space-y-2">
+          <p className="font-semibold" style={{ color: theme }}>
+            Two-factor authentication is required for your account
+          </p>
+          <p className="text-gray-600">
+            Add this key to your authenticator app (Google Authenticator, Authy, etc.), then enter the 6-digit code.
+          </p>
+          <p className="font-mono break-all">{challenge.mfa_secret}</p>
+          {challenge.otpauth_uri && (
+            <a href={challenge.otpauth_uri} className="font-semibold hover:underline" style={{ color: theme }}>
+              Open in authenticator app
+            </a>
+          )}
+        </div>
+      )}
+
+      {error && (
+        <div className="p-4 rounded-lg text-sm font-medium text-white" style={{ backgroundColor: "#D32F2F" }}>
+          {error}
+        </div>
+      )}
+
+      <div>
+        <label className="block text-sm font-semibold mb-2" style={{ color: theme }}>
+          {useRecovery ? "Recovery code" : "Authentication code"}
+        </label>
+        <input
+          type="text"
+          inputMode={useRecovery ? "text" : "numeric"}
+          autoComplete="one-time-code"
+          value={code}
+          onChange={(e) => setCode(e.target.value)}
+          placeholder={useRecovery ? "xxxxx-xxxxx" : "123456"}
+          required
+          disabled={loading}
+          className="w-full px-4 py-3 border-2 rounded-lg focus:outline-none transition"
+          style={{ borderColor: code ? theme : "#e0e0e0", backgroundColor: "#f9f9f9" }}
+        />
+      </div>
+
+      <button
+        type="submit"
+        disabled={loading}
+        className="w-full px-6 py-3 rounded-lg font-bold text-white flex items-center justify-center gap-2 disabled:opacity-50"
+        style={{ backgroundColor: theme }}
+      >
+        <ShieldCheck className="w-5 h-5" />
+        {loading ? "Verifying..." : "Verify"}
+      </button>
+
+      <div className="flex justify-between text-sm">
+        {!challenge.mfa_enrollment_required && (
+          <button
+            type="button"
+            onClick={() => {
+              setUseRecovery(!useRecovery);
+              setCode("");
+            }}
+            className="hover:underline"
+            style={{ color: theme }}
+          >
+            {useRecovery ? "Use authenticator code" : "Use a recovery code"}
+          </button>
+        )}
+        <button type="button" onClick={onCancel} className="text-gray-600 hover:underline">
+          Back to login
+        </button>
+      </div>
+    </form>
+  );
+}
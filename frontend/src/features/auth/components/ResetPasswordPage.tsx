@@ -1,10 +1,12 @@
 import React, { useState } from "react";
-import { useNavigate } from "react-router-dom";
+import { useNavigate, useSearchParams } from "react-router-dom";
 import { apiBase } from "../AuthProvider";
 
 export default function ResetPasswordPage(): React.ReactNode {
   const navigate = useNavigate();
-  const [token, setToken] = useState("");
+  const [searchParams] = useSearchParams();
+  // The emailed reset link carries the token as ?token=...
+  const [token, setToken] = useState(() => searchParams.get("token") || "");
   const [newPassword, setNewPassword] = useState("");
   const [confirmPassword, setConfirmPassword] = useState("");
   const [loading, setLoading] = useState(false);
@@ -2,6 +2,7 @@ import React, { useState, useMemo } from "react";
 import { useNavigate } from "react-router-dom";
 import { useAuth, apiBase } from "../../auth/AuthProvider";
 import { LogIn, Eye, EyeOff, School } from "lucide-react";
+import MfaVerifyStep, { type MfaChallenge } from "../../auth/components/MfaVerifyStep";
 
 export default function InstituteLoginPage(): React.ReactNode {
     const { login } = useAuth();
@@ -12,6 +13,7 @@ export default function InstituteLoginPage(): React.ReactNode {
     const [loading, setLoading] = useState(false);
     const [error, setError] = useState<string | null>(null);
     const [showPassword, setShowPassword] = useState(false);
+    const [mfaChallenge, setMfaChallenge] = useState<MfaChallenge | null>(null);
 
     const logoSrc = "/Logo.png";
     const theme = "#650C08";
@@ -30,6 +32,18 @@ export default function InstituteLoginPage(): React.ReactNode {
             .replace(",", "");
     }, []);
 
+    function completeLogin(data: any) {
+        login(data.token, data.role_id, data.expires_in_hours, data.refresh_token);
+
+        if (data.force_password_change) {
+            navigate("/change-password");
+        } else if (data.role_id === 3) {
+            navigate("/institute/dashboard"); // Institute Admin
+        } else if (data.role_id === 2) {
+            navigate("/faculty/dashboard"); // Faculty
+        }
+    }
+
     async function handleLogin(e: React.FormEvent) {
         e.preventDefault();
         setLoading(true);
@@ -45,6 +59,16 @@ export default function InstituteLoginPage(): React.ReactNode {
 
             const data = await res.json().catch(() => ({}));
 
+            if (res.ok && data.mfa_required) {
+                // Allow only Institute Admin (3) and Faculty (2)
+                if (![2, 3].includes(data.role_id)) {
+                    setError("Access denied.");
+                    return;
+                }
+                setMfaChallenge(data);
+                return;
+            }
+
             if (!res.ok || !data.token) {
                 setError("Invalid Username or Password");
                 setLoading(false);
@@ -58,15 +82,7 @@ export default function InstituteLoginPage(): React.ReactNode {
                 return;
             }
 
-            login(data.token, data.role_id, data.expires_in_hours);
-
-            if (data.force_password_change) {
-                navigate("/change-password");
-            } else if (data.role_id === 3) {
-                navigate("/institute/dashboard"); // Institute Admin
-            } else if (data.role_id === 2) {
-                navigate("/faculty/dashboard"); // Faculty
-            }
+            completeLogin(data);
         } catch {
             setError("Connection error. Please try again.");
         } finally {
@@ -114,6 +130,14 @@ export default function InstituteLoginPage(): React.ReactNode {
                     )}
 
                     {/* Form */}
+                    {mfaChallenge ? (
+                        <MfaVerifyStep
+                            challenge={mfaChallenge}
+                            theme={theme}
+                            onSuccess={completeLogin}
+                            onCancel={() => setMfaChallenge(null)}
+                        />
+                    ) : (
                     <form onSubmit={handleLogin} className="space-y-5">
                         <div>
                             <label className="block text-sm font-semibold mb-2" style={{ color: theme }}>
@@ -162,6 +186,7 @@ export default function InstituteLoginPage(): React.ReactNode {
                             {loading ? "Logging in..." : "Login"}
                         </button>
                     </form>
+                    )}
                 </div>
             </div>
         </div>
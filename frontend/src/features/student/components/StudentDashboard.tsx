@@ -407,11 +407,6 @@ export default function StudentDashboard(): React.ReactElement {
 
   // Updated handlePayment to accept custom amount
   const handlePayment = useCallback(async (fee: FeeItem, amountToPay: number = fee.balance ?? 0) => {
-    if (typeof window.Razorpay === 'undefined') {
-      alert('Razorpay SDK not loaded. Please wait or refresh.');
-      return;
-    }
-
     const enrollmentNumber = getEnrollmentNumber(profile);
     const balance = fee.balance ?? 0;
 
@@ -434,6 +429,7 @@ export default function StudentDashboard(): React.ReactElement {
           amount: amountToPay,
           fee_head: fee.fee_head,
           fee_type: fee.fee_type,
+          charge_id: fee.charge_id,
         }),
       });
 
@@ -444,6 +440,52 @@ export default function StudentDashboard(): React.ReactElement {
 
       const orderData = await orderResponse.json();
 
+      const verifyPayment = async (response: any) => {
+        try {
+          const verificationResponse = await authFetch(`${apiBase}/student/fees/verify-payment`, {
+            method: 'POST',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify({
+              razorpay_order_id: response.razorpay_order_id,
+              razorpay_payment_id: response.razorpay_payment_id,
+              razorpay_signature: response.razorpay_signature,
+            }),
+          });
+
+          if (!verificationResponse.ok) {
+            const errorBody = await verificationResponse.json().catch(() => ({ error: 'Unknown server error' }));
+            throw new Error(errorBody.error || "Payment successful, but server verification failed.");
+          }
+
+          alert('Payment successful! Payment ID: ' + response.razorpay_payment_id);
+          loadFees();
+        } catch (error) {
+          console.error('Verification Error:', error);
+          alert('Payment recorded by Razorpay, but server update failed. Contact admin. Error: ' + (error as Error).message);
+        }
+      };
+
+      // Local development: the mock gateway completes checkout server-side
+      if (orderData.gateway === 'mock') {
+        const mockResponse = await authFetch(`${apiBase}/student/fees/mock-checkout/${orderData.order_id}`, {
+          method: 'POST',
+          headers: { 'Content-Type': 'application/json' },
+          body: JSON.stringify({ fail: !window.confirm(`Mock gateway: pay ₹${amountToPay}? (Cancel simulates a failed payment)`) }),
+        });
+        const mockData = await mockResponse.json();
+        if (!mockResponse.ok || mockData.status !== 'captured') {
+          alert('Payment failed. Reason: ' + (mockData.error || 'Transaction declined.'));
+          return;
+        }
+        await verifyPayment(mockData);
+        return;
+      }
+
+      if (typeof window.Razorpay === 'undefined') {
+        alert('Razorpay SDK not loaded. Please wait or refresh.');
+        return;
+      }
+
       const options: RazorpayOptions = {
         key: orderData.key_id,
         amount: amountToPay * 100,
@@ -451,35 +493,7 @@ export default function StudentDashboard(): React.ReactElement {
         name: orderData.name,
         description: orderData.description,
         order_id: orderData.order_id,
-        handler: async (response: any) => {
-          try {
-            const verificationResponse = await authFetch(`${apiBase}/student/fees/verify-payment`, {
-              method: 'POST',
-              headers: { 'Content-Type': 'application/json' },
-              body: JSON.stringify({
-                razorpay_order_id: response.razorpay_order_id,
-                razorpay_payment_id: response.razorpay_payment_id,
-                razorpay_signature: response.razorpay_signature,
-                fee_due_id: Number(fee.fee_due_id ?? 0),
-                amount: amountToPay,
-                fee_head: fee.fee_head,
-                fee_type: fee.fee_type,
-                enrollment: Number(enrollmentNumber),
-              }),
-            });
-
-            if (!verificationResponse.ok) {
-              const errorBody = await verificationResponse.json().catch(() => ({ error: 'Unknown server error' }));
-              throw new Error(errorBody.error || "Payment successful, but server verification failed.");
-            }
-
-            alert('Payment successful! Payment ID: ' + response.razorpay_payment_id);
-            loadFees();
-          } catch (error) {
-            console.error('Verification Error:', error);
-            alert('Payment recorded by Razorpay, but server update failed. Contact admin. Error: ' + (error as Error).message);
-          }
-        },
+        handler: verifyPayment,
         prefill: {
           name: orderData.prefill.name || profile?.act_student?.CandidateName || profile?.user?.full_name,
           email: orderData.prefill.email || profile?.act_student?.EmailID || profile?.user?.email,
@@ -508,9 +522,10 @@ export default function StudentDashboard(): React.ReactElement {
   }, [authFetch, profile, loadFees, theme]);
 
   // Modal handlers
-  const openPaymentModal = (fee: FeeItem) => {
+  // amount defaults to the whole balance; installments pass their own outstanding amount
+  const openPaymentModal = (fee: FeeItem, amount: number = fee.balance ?? 0) => {
     setSelectedFeeForPayment(fee);
-    setCustomAmount(fee.balance ?? 0);
+    setCustomAmount(amount);
     setPaymentModalOpen(true);
   };
 
@@ -649,6 +664,7 @@ export default function StudentDashboard(): React.ReactElement {
                         <th className="px-6 py-3 text-left text-xs font-medium text-gray-500 uppercase tracking-wider">Fee Head</th>
                         <th className="px-6 py-3 text-left text-xs font-medium text-gray-500 uppercase tracking-wider">Due Date</th>
                         <th className="px-6 py-3 text-right text-xs font-medium text-gray-500 uppercase tracking-wider">Original Amount (₹)</th>
+                        <th className="px-6 py-3 text-right text-xs font-medium text-gray-500 uppercase tracking-wider">Late Fee (₹)</th>
                         <th className="px-6 py-3 text-right text-xs font-medium text-gray-500 uppercase tracking-wider">Amount Paid (₹)</th>
                         <th className="px-6 py-3 text-right text-xs font-medium text-red-700 uppercase tracking-wider font-bold">Balance Due (₹)</th>
                         <th className="px-6 py-3 text-left text-xs font-medium text-gray-500 uppercase tracking-wider">Action</th>
@@ -656,11 +672,15 @@ export default function StudentDashboard(): React.ReactElement {
                     </thead>
                     <tbody className="bg-white divide-y divide-gray-200">
                       {feeDues.length > 0 ? (
-                        feeDues.map((fee, idx) => (
+                        feeDues.flatMap((fee, idx) => [
                           <tr key={idx} className="hover:bg-red-50">
                             <td className="px-6 py-4 whitespace-nowrap text-sm font-medium text-gray-900">{fee.fee_head}</td>
-                            <td className="px-6 py-4 whitespace-nowrap text-sm text-gray-500">{fee.due_date}</td>
-                            <td className="px-6 py-4 whitespace-nowrap text-sm text-right text-gray-700">{fmtMoney(fee.original_amount)}</td>
+                            <td className="px-6 py-4 whitespace-nowrap text-sm text-gray-500">
+                              {fee.due_date || '-'}
+                              {fee.status === 'Overdue' && <span className="ml-2 text-xs font-semibold text-red-600">Overdue</span>}
+                            </td>
+                            <td className="px-6 py-4 whitespace-nowrap text-sm text-right text-gray-700">{fmtMoney((fee.original_amount || 0) - (fee.late_fee || 0))}</td>
+                            <td className="px-6 py-4 whitespace-nowrap text-sm text-right text-gray-700">{fmtMoney(fee.late_fee)}</td>
                             <td className="px-6 py-4 whitespace-nowrap text-sm text-right text-gray-700">{fmtMoney(fee.amount_paid)}</td>
                             <td className="px-6 py-4 whitespace-nowrap text-sm font-bold text-right text-red-700">{fmtMoney(fee.balance)}</td>
                             <td className="px-6 py-4 whitespace-nowrap text-sm font-medium">
@@ -671,11 +691,30 @@ export default function StudentDashboard(): React.ReactElement {
                                 Pay Now
                               </button>
                             </td>
-                          </tr>
-                        ))
+                          </tr>,
+                          ...(fee.installments && fee.installments.length > 1
+                            ? fee.installments.filter((inst) => inst.outstanding > 0).map((inst) => (
+                              <tr key={`${idx}-${inst.charge_id}`} className="bg-gray-50 text-sm">
+                                <td className="px-6 py-2 pl-10 text-gray-600">{inst.description || `Installment ${inst.installment_no ?? ''}`}</td>
+                                <td className="px-6 py-2 text-gray-500">{inst.due_date ? new Date(inst.due_date).toLocaleDateString() : '-'}</td>
+                                <td className="px-6 py-2 text-right text-gray-600">{fmtMoney(inst.amount)}</td>
+                                <td className="px-6 py-2" colSpan={2}></td>
+                                <td className="px-6 py-2 text-right text-red-600">{fmtMoney(inst.outstanding)}</td>
+                                <td className="px-6 py-2">
+                                  <button
+                                    onClick={() => openPaymentModal({ ...fee, charge_id: inst.charge_id }, inst.outstanding)}
+                                    className="text-green-700 hover:text-green-900 text-xs font-semibold"
+                                  >
+                                    Pay installment
+                                  </button>
+                                </td>
+                              </tr>
+                            ))
+                            : []),
+                        ])
                       ) : (
                         <tr>
-                          <td colSpan={6} className="px-6 py-8 text-center text-md text-gray-600 bg-gray-50">
+                          <td colSpan={7} className="px-6 py-8 text-center text-md text-gray-600 bg-gray-50">
                             <div className="text-lg mb-1">No Pending Dues!</div>
                             <div className="text-sm">All your fees are up to date.</div>
                           </td>
@@ -695,6 +734,7 @@ export default function StudentDashboard(): React.ReactElement {
                         <th className="px-6 py-3 text-right text-xs font-medium text-gray-500 uppercase tracking-wider">Amount Paid (₹)</th>
                         <th className="px-6 py-3 text-left text-xs font-medium text-gray-500 uppercase tracking-wider">Transaction Date</th>
                         <th className="px-6 py-3 text-left text-xs font-medium text-gray-500 uppercase tracking-wider">Transaction No.</th>
+                        <th className="px-6 py-3 text-left text-xs font-medium text-gray-500 uppercase tracking-wider">Receipt</th>
                       </tr>
                     </thead>
                     <tbody className="bg-white divide-y divide-gray-200">
@@ -705,11 +745,24 @@ export default function StudentDashboard(): React.ReactElement {
                             <td className="px-6 py-4 whitespace-nowrap text-sm text-right text-green-700">{fmtMoney(fee.amount_paid)}</td>
                             <td className="px-6 py-4 whitespace-nowrap text-sm text-gray-500">{fee.transaction_date}</td>
                             <td className="px-6 py-4 whitespace-nowrap text-sm text-gray-500">{fee.transaction_number}</td>
+                            <td className="px-6 py-4 whitespace-nowrap text-sm">
+                              {fee.receipt_id ? (
+                                <button
+                                  onClick={() => service.downloadReceipt(fee.receipt_id!, fee.receipt_number).catch((e) => alert(e.message))}
+                                  className="text-blue-600 hover:underline"
+                                  title={fee.receipt_number}
+                                >
+                                  Download
+                                </button>
+                              ) : (
+                                <span className="text-gray-400">-</span>
+                              )}
+                            </td>
                           </tr>
                         ))
                       ) : (
                         <tr>
-                          <td colSpan={4} className="px-6 py-4 text-center text-sm text-gray-500">
+                          <td colSpan={5} className="px-6 py-4 text-center text-sm text-gray-500">
                             No payment history found.
                           </td>
                         </tr>
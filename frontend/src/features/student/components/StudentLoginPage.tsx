@@ -51,7 +51,7 @@ export default function StudentLoginPage(): React.ReactNode {
         return;
       }
 
-      login(data.token, data.role_id, data.expires_in_hours);
+      login(data.token, data.role_id, data.expires_in_hours, data.refresh_token);
 
       if (data.force_password_change) {
         navigate("/change-password");
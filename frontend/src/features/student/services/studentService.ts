@@ -24,6 +24,16 @@ export interface StudentProfile {
   };
 }
 
+// One charge / installment of a due, oldest first
+export interface Installment {
+  charge_id: number;
+  installment_no?: number | null;
+  description?: string | null;
+  amount: number;
+  due_date?: string | null;
+  outstanding: number;
+}
+
 // Fee Item Type
 export interface FeeItem {
   fee_due_id?: number;
@@ -32,12 +42,17 @@ export interface FeeItem {
   due_date?: string;
   original_amount?: number;
   amount_paid?: number;
+  late_fee?: number;
   status?: string;
   balance?: number;
+  charge_id?: number;
+  installments?: Installment[];
   transaction_number?: string;
   payment_status?: string;
   transaction_date?: string;
   created_at?: string;
+  receipt_id?: number;
+  receipt_number?: string;
 }
 
 // Attendance Item Type
@@ -143,7 +158,9 @@ class StudentService {
       due_date: d.due_date || d.DueDate,
       original_amount: d.original_amount || d.OriginalAmount,
       amount_paid: d.amount_paid || d.AmountPaid,
-      balance: (d.original_amount || d.OriginalAmount || 0) - (d.amount_paid || d.AmountPaid || 0),
+      late_fee: d.late_fee || 0,
+      installments: d.installments || [],
+      balance: d.total_payable ?? (d.original_amount || d.OriginalAmount || 0) - (d.amount_paid || d.AmountPaid || 0),
       status: d.status || d.Status,
       transaction_number: d.transaction_number || d.TransactionNumber,
     }));
@@ -159,11 +176,50 @@ class StudentService {
       transaction_number: p.transaction_number || p.TransactionNo,
       transaction_date: p.transaction_date || p.TransactionDate,
       created_at: p.created_at || p.CreatedAt,
+      receipt_id: p.receipt_id,
+      receipt_number: p.receipt_number,
     }));
 
     return { dues, history };
   }
 
+  // Reports a cash / DD / bank transfer payment; it counts once an admin verifies it
+  async submitOfflinePayment(payment: {
+    fee_head: string;
+    amount: number;
+    payment_mode: "cash" | "dd" | "cheque" | "neft" | "rtgs" | "imps" | "upi";
+    reference_number?: string;
+    payment_date: string;
+    charge_id?: number;
+    remarks?: string;
+    proof: File;
+  }): Promise<{ payment_id: number; status: string }> {
+    const form = new FormData();
+    Object.entries(payment).forEach(([key, value]) => {
+      if (value !== undefined && value !== null && value !== "") form.append(key, value instanceof File ? value : String(value));
+    });
+    const res = await this.authFetch(`${apiBase}/student/fees/pay`, { method: "POST", body: form });
+    if (!res.ok) {
+      const err = await res.json().catch(() => ({}));
+      throw new Error(err.error || "Failed to submit payment");
+    }
+    return res.json();
+  }
+
+  async downloadReceipt(receiptId: number, receiptNumber?: string): Promise<void> {
+    const res = await this.authFetch(`${apiBase}/student/fees/receipts/${receiptId}`);
+    if (!res.ok) throw new Error("Failed to download receipt");
+    const blob = await res.blob();
+    const url = URL.createObjectURL(blob);
+    const link = document.createElement("a");
+    link.href = url;
+    link.download = `receipt-${(receiptNumber || String(receiptId)).replace(/\//g, "-")}.pdf`;
+    document.body.appendChild(link);
+    link.click();
+    document.body.removeChild(link);
+    URL.revokeObjectURL(url);
+  }
+
   async getAllFees(): Promise<FeeItem[]> {
     const res = await this.authFetch(`${apiBase}/student/fees`);
     if (!res.ok) return [];
@@ -229,6 +285,103 @@ class StudentService {
     return Array.isArray(data) ? data : [];
   }
 
+  // ---------------- Revaluation ----------------
+  async getMyRevaluations() {
+    const res = await this.authFetch(`${apiBase}/student/revaluations`);
+    if (!res.ok) return [];
+    const data = await res.json();
+    return Array.isArray(data.requests) ? data.requests : [];
+  }
+
+  async applyRevaluation(semester: number, subjectCode: string, kind: "revaluation" | "retotalling" = "revaluation") {
+    const res = await this.authFetch(`${apiBase}/student/revaluations`, {
+      method: "POST",
+      headers: { "Content-Type": "application/json" },
+      body: JSON.stringify({ semester, subject_code: subjectCode, kind }),
+    });
+    if (!res.ok) {
+      const err = await res.json().catch(() => ({}));
+      throw new Error(err.error || "Failed to apply for revaluation");
+    }
+    return res.json();
+  }
+
+  // ---------------- Backlogs / Reappear ----------------
+  async getBacklogs() {
+    const res = await this.authFetch(`${apiBase}/student/backlogs`);
+    if (!res.ok) throw new Error("Failed to fetch backlogs");
+    return res.json();
+  }
+
+  async registerReappear(examSessionId: number, subjectCodes: string[]) {
+    const res = await this.authFetch(`${apiBase}/student/reappear`, {
+      method: "POST",
+      headers: { "Content-Type": "application/json" },
+      body: JSON.stringify({ exam_session_id: examSessionId, subject_codes: subjectCodes }),
+    });
+    if (!res.ok) {
+      const err = await res.json().catch(() => ({}));
+      throw new Error(err.error || "Failed to register for reappear exams");
+    }
+    return res.json();
+  }
+
+  // ---------------- Exams / Hall tickets ----------------
+  async getMyExams() {
+    const res = await this.authFetch(`${apiBase}/student/exams`);
+    if (!res.ok) throw new Error("Failed to fetch exams");
+    return res.json();
+  }
+
+  async downloadHallTicket(sessionId: number): Promise<void> {
+    const res = await this.authFetch(`${apiBase}/student/exams/${sessionId}/hall-ticket`);
+    if (!res.ok) {
+      const err = await res.json().catch(() => ({}));
+      const reasons = Array.isArray(err.reasons) && err.reasons.length ? `: ${err.reasons.join("; ")}` : "";
+      throw new Error((err.error || "Failed to download hall ticket") + reasons);
+    }
+    const blob = await res.blob();
+    const url = URL.createObjectURL(blob);
+    const link = document.createElement("a");
+    link.href = url;
+    link.download = `hall-ticket-${sessionId}.pdf`;
+    document.body.appendChild(link);
+    link.click();
+    document.body.removeChild(link);
+    URL.revokeObjectURL(url);
+  }
+
+  async uploadPhoto(photo: File) {
+    const form = new FormData();
+    form.append("photo", photo);
+    const res = await this.authFetch(`${apiBase}/student/photo`, { method: "POST", body: form });
+    if (!res.ok) {
+      const err = await res.json().catch(() => ({}));
+      throw new Error(err.error || "Failed to upload photo");
+    }
+    return res.json();
+  }
+
+  // ---------------- Attendance eligibility / Condonation ----------------
+  async getEligibility() {
+    const res = await this.authFetch(`${apiBase}/student/eligibility`);
+    if (!res.ok) throw new Error("Failed to fetch attendance eligibility");
+    return res.json();
+  }
+
+  async applyForCondonation(subjectCode: string, reason: string) {
+    const res = await this.authFetch(`${apiBase}/student/condonations`, {
+      method: "POST",
+      headers: { "Content-Type": "application/json" },
+      body: JSON.stringify({ subject_code: subjectCode, reason }),
+    });
+    if (!res.ok) {
+      const err = await res.json().catch(() => ({}));
+      throw new Error(err.error || "Failed to submit condonation request");
+    }
+    return res.json();
+  }
+
   // ---------------- Notices ----------------
   async getNotices(): Promise<NoticeItem[]> {
     const res = await this.authFetch(`${apiBase}/student/notices`);